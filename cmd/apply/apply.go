@@ -2,14 +2,19 @@ package apply
 
 import (
 	"context"
+	"errors"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
 	"github.com/selefra/selefra/cli_ui"
 	"github.com/selefra/selefra/config"
 	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/exit_code"
 	"github.com/selefra/selefra/pkg/message"
 	"github.com/selefra/selefra/pkg/modules/executors"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/modules/planner"
 	"github.com/selefra/selefra/pkg/utils"
 	"github.com/spf13/cobra"
+	"strings"
 	"sync/atomic"
 )
 
@@ -17,7 +22,7 @@ func NewApplyCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:              "apply",
 		Short:            "Analyze infrastructure",
-		Long:             "Analyze infrastructure",
+		Long:             "Analyze infrastructure\n\n" + exit_code.Description,
 		PersistentPreRun: global.DefaultWrappedInit(),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			output, _ := cmd.PersistentFlags().GetString("output")
@@ -25,6 +30,18 @@ func NewApplyCmd() *cobra.Command {
 			openaiApiKey, _ := cmd.PersistentFlags().GetString("openai_api_key")
 			openaiMode, _ := cmd.PersistentFlags().GetString("openai_mode")
 			openaiLimit, _ := cmd.PersistentFlags().GetUint64("openai_limit")
+			baseline, _ := cmd.PersistentFlags().GetString("baseline")
+			saveBaseline, _ := cmd.PersistentFlags().GetString("save-baseline")
+			failOnNew, _ := cmd.PersistentFlags().GetBool("fail-on-new")
+			reports, _ := cmd.PersistentFlags().GetStringArray("report")
+			failOn, _ := cmd.PersistentFlags().GetString("fail-on")
+			rules, _ := cmd.PersistentFlags().GetStringArray("rule")
+			tags, _ := cmd.PersistentFlags().GetStringArray("tag")
+			severities, _ := cmd.PersistentFlags().GetStringArray("severity")
+			providers, _ := cmd.PersistentFlags().GetStringArray("provider")
+			parallelism, _ := cmd.PersistentFlags().GetUint64("parallelism")
+			vars, _ := cmd.PersistentFlags().GetStringArray("var")
+			varFiles, _ := cmd.PersistentFlags().GetStringArray("var-file")
 			//projectWorkspace := "./test_data/test_query_module"
 			//downloadWorkspace := "./test_download"
 			instructions := make(map[string]interface{})
@@ -33,17 +50,70 @@ func NewApplyCmd() *cobra.Command {
 			instructions["openai_api_key"] = openaiApiKey
 			instructions["openai_mode"] = openaiMode
 			instructions["openai_limit"] = openaiLimit
+			instructions[planner.InstructionKeyRule] = rules
+			instructions[planner.InstructionKeyTag] = tags
+			instructions[planner.InstructionKeySeverity] = severities
+			instructions[planner.InstructionKeyProvider] = providers
 			projectWorkspace := "./"
 			downloadWorkspace, _ := config.GetDefaultDownloadCacheDirectory()
 
-			return Apply(cmd.Context(), instructions, projectWorkspace, downloadWorkspace)
+			if failOn != "" && module.SeverityLevel(failOn) < 0 {
+				return exit_code.New(exit_code.ExitCodeConfigurationError, "--fail-on %s is not a severity, it must be one of %s", failOn, strings.Join(module.Severities, ", "))
+			}
+			if err := planner.NewRuleSelector(instructions).Check(); err != nil {
+				return exit_code.Wrap(exit_code.ExitCodeConfigurationError, err)
+			}
+			if parallelism == 0 {
+				return exit_code.New(exit_code.ExitCodeConfigurationError, "--parallelism must be greater than 0")
+			}
+
+			variables, err := planner.LoadRootVariables(&planner.RootVariablesOptions{
+				Vars:     vars,
+				VarFiles: varFiles,
+				ReadEnv:  true,
+			})
+			if err != nil {
+				return exit_code.Wrap(exit_code.ExitCodeConfigurationError, err)
+			}
+
+			applyOptions := &ApplyOptions{
+				BaselineOptions: &executors.BaselineOptions{
+					Baseline:          baseline,
+					SaveBaselinePath:  saveBaseline,
+					FailOnNewFindings: failOnNew,
+				},
+				FailOnSeverity: failOn,
+				QueryWorkerNum: parallelism,
+				Variables:      variables,
+			}
+			for _, report := range reports {
+				reportOption, err := executors.ParseReportOption(report)
+				if err != nil {
+					return exit_code.Wrap(exit_code.ExitCodeConfigurationError, err)
+				}
+				applyOptions.Reports = append(applyOptions.Reports, reportOption)
+			}
+
+			return Apply(cmd.Context(), instructions, projectWorkspace, downloadWorkspace, applyOptions)
 		},
 	}
-	cmd.PersistentFlags().StringP("output", "p", "", "display content format")
+	cmd.PersistentFlags().StringP("output", "p", "", "display content format, json or sarif, sarif writes the findings to selefra.sarif in the output directory")
 	cmd.PersistentFlags().StringP("dir", "d", "", "define the output directory")
 	cmd.PersistentFlags().StringP("openai_api_key", "k", "", "your openai_api_key")
 	cmd.PersistentFlags().StringP("openai_mode", "m", "", "what mode to use for analysis\n")
 	cmd.PersistentFlags().Uint64P("openai_limit", "i", 10, "how many pieces were analyzed in total")
+	cmd.PersistentFlags().String("baseline", "", "compare with a baseline file or a run id saved in the database, \"latest\" for the last successful run, only new and resolved findings are reported")
+	cmd.PersistentFlags().String("save-baseline", "", "save the findings of this apply to a baseline file")
+	cmd.PersistentFlags().Bool("fail-on-new", false, "exit with a non-zero code if there are findings that are not in the baseline")
+	cmd.PersistentFlags().String("fail-on", "", "exit with code 5 if there are issues of this severity or higher: "+strings.Join(module.Severities, ", "))
+	cmd.PersistentFlags().StringArray("rule", nil, "only run the rules with this name or metadata id, glob patterns such as \"ebs_*\" are supported, can be repeated")
+	cmd.PersistentFlags().StringArray("tag", nil, "only run the rules with this tag in the metadata, can be repeated")
+	cmd.PersistentFlags().StringArray("severity", nil, "only run the rules of this severity, \">=High\" for High and Critical, can be repeated")
+	cmd.PersistentFlags().StringArray("provider", nil, "only run the rules of this provider, can be repeated, only the tables used by the selected rules are fetched")
+	cmd.PersistentFlags().Uint64("parallelism", executors.DefaultQueryWorkerNum, "how many rule queries are executed at the same time")
+	cmd.PersistentFlags().StringArray("var", nil, "set a variable of the root module in the form of key=value, wins over --var-file and SELEFRA_VAR_<key>, can be repeated")
+	cmd.PersistentFlags().StringArray("var-file", nil, "set the variables of the root module from a yaml file of key: value, wins over SELEFRA_VAR_<key>, can be repeated")
+	cmd.PersistentFlags().StringArray("report", nil, "write a report after the rules are executed, in the form of <format>=<path>, the format is junit or sarif, can be repeated")
 
 	cmd.SetHelpFunc(cmd.HelpFunc())
 	return cmd
@@ -51,8 +121,34 @@ func NewApplyCmd() *cobra.Command {
 
 // ------------------------------------------------- --------------------------------------------------------------------
 
+// ApplyOptions The options of apply that are not instructions for the rules
+type ApplyOptions struct {
+
+	// Compare the findings with a baseline, and save them as a baseline
+	BaselineOptions *executors.BaselineOptions
+
+	// The reports to write after the rules are executed
+	Reports []*executors.ReportOption
+
+	// Fail if there are issues of this severity or higher
+	FailOnSeverity string
+
+	// How many rule queries are executed at the same time, DefaultQueryWorkerNum if not set
+	QueryWorkerNum uint64
+
+	// The values of the variables of the root module
+	Variables []*planner.RootVariable
+}
+
 // Apply a project
-func Apply(ctx context.Context, instructions map[string]interface{}, projectWorkspace, downloadWorkspace string) error {
+func Apply(ctx context.Context, instructions map[string]interface{}, projectWorkspace, downloadWorkspace string, applyOptions *ApplyOptions) error {
+	if applyOptions == nil {
+		applyOptions = &ApplyOptions{}
+	}
+	queryWorkerNum := applyOptions.QueryWorkerNum
+	if queryWorkerNum == 0 {
+		queryWorkerNum = executors.DefaultQueryWorkerNum
+	}
 
 	hasError := atomic.Bool{}
 	messageChannel := message.NewChannel[*schema.Diagnostics](func(index int, message *schema.Diagnostics) {
@@ -62,7 +158,7 @@ func Apply(ctx context.Context, instructions map[string]interface{}, projectWork
 			}
 		}
 	})
-	d := executors.NewProjectLocalLifeCycleExecutor(&executors.ProjectLocalLifeCycleExecutorOptions{
+	executor := executors.NewProjectLocalLifeCycleExecutor(&executors.ProjectLocalLifeCycleExecutorOptions{
 		Instruction:          instructions,
 		ProjectWorkspace:     projectWorkspace,
 		DownloadWorkspace:    downloadWorkspace,
@@ -74,12 +170,23 @@ func Apply(ctx context.Context, instructions map[string]interface{}, projectWork
 			IsNeedLogin:       true,
 		},
 		//DSN:                                  env.GetDatabaseDsn(),
-		FetchWorkerNum: 1,
-		QueryWorkerNum: 1,
-	}).Execute(ctx)
+		FetchWorkerNum:  1,
+		QueryWorkerNum:  queryWorkerNum,
+		BaselineOptions: applyOptions.BaselineOptions,
+		Reports:         applyOptions.Reports,
+		FailOnSeverity:  applyOptions.FailOnSeverity,
+		Variables:       applyOptions.Variables,
+	})
+	d := executor.Execute(ctx)
 	messageChannel.ReceiverWait()
-	if err := cli_ui.PrintDiagnostics(d); err != nil {
+	if err := cli_ui.PrintDiagnostics(d); err != nil || executor.ExitCode() != exit_code.ExitCodeSuccess {
 		cli_ui.Errorln("Apply failed")
+		if err == nil {
+			err = errors.New("apply failed")
+		}
+		if executor.ExitCode() != exit_code.ExitCodeSuccess {
+			return exit_code.Wrap(executor.ExitCode(), err)
+		}
 		return err
 		//} else if hasError.Load() {
 		//	cli_ui.Errorln("Apply failed")
@@ -13,6 +13,6 @@ func TestApply(t *testing.T) {
 	downloadWorkspace := "./test_download"
 	Instructions := make(map[string]interface{})
 	Instructions["dir"] = "./ssss"
-	err := Apply(context.Background(), Instructions, projectWorkspace, downloadWorkspace)
+	err := Apply(context.Background(), Instructions, projectWorkspace, downloadWorkspace, nil)
 	assert.Nil(t, err)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/selefra/selefra/global"
 	"github.com/selefra/selefra/pkg/message"
 	"github.com/selefra/selefra/pkg/modules/executors"
+	"github.com/selefra/selefra/pkg/modules/planner"
 	"github.com/selefra/selefra/pkg/utils"
 	"github.com/spf13/cobra"
 	"sync/atomic"
@@ -30,6 +31,10 @@ func NewGPTCmd() *cobra.Command {
 			openaiMode, _ := cmd.PersistentFlags().GetString("openai_mode")
 			openaiLimit, _ := cmd.PersistentFlags().GetUint64("openai_limit")
 			output, _ := cmd.PersistentFlags().GetString("output")
+			rules, _ := cmd.PersistentFlags().GetStringArray("rule")
+			tags, _ := cmd.PersistentFlags().GetStringArray("tag")
+			severities, _ := cmd.PersistentFlags().GetStringArray("severity")
+			providers, _ := cmd.PersistentFlags().GetStringArray("provider")
 
 			//projectWorkspace := "./test_data/test_query_module"
 			//downloadWorkspace := "./test_download"
@@ -44,10 +49,17 @@ func NewGPTCmd() *cobra.Command {
 			instructions["openai_mode"] = openaiMode
 			instructions["openai_limit"] = openaiLimit
 			instructions["output"] = output
+			instructions[planner.InstructionKeyRule] = rules
+			instructions[planner.InstructionKeyTag] = tags
+			instructions[planner.InstructionKeySeverity] = severities
+			instructions[planner.InstructionKeyProvider] = providers
 
 			if instructions["query"] == nil || instructions["query"] == "" {
 				return errors.New("query is required")
 			}
+			if err := planner.NewRuleSelector(instructions).Check(); err != nil {
+				return err
+			}
 
 			return Gpt(cmd.Context(), instructions, projectWorkspace, downloadWorkspace)
 		},
@@ -58,6 +70,10 @@ func NewGPTCmd() *cobra.Command {
 	cmd.PersistentFlags().StringP("openai_api_key", "k", "", "your openai_api_key")
 	cmd.PersistentFlags().StringP("openai_mode", "m", "", "what mode to use for analysis")
 	cmd.PersistentFlags().Uint64P("openai_limit", "i", 10, "how many pieces were analyzed in total")
+	cmd.PersistentFlags().StringArray("rule", nil, "only run the rules with this name or metadata id, glob patterns such as \"ebs_*\" are supported, can be repeated")
+	cmd.PersistentFlags().StringArray("tag", nil, "only run the rules with this tag in the metadata, can be repeated")
+	cmd.PersistentFlags().StringArray("severity", nil, "only run the rules of this severity, \">=High\" for High and Critical, can be repeated")
+	cmd.PersistentFlags().StringArray("provider", nil, "only run the rules of this provider, can be repeated, only the tables used by the selected rules are fetched")
 
 	cmd.SetHelpFunc(cmd.HelpFunc())
 	return cmd
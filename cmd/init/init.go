@@ -26,6 +26,7 @@ func NewInitCmd() *cobra.Command {
 
 			relevance, _ := cmd.PersistentFlags().GetString("relevance")
 			force, _ := cmd.PersistentFlags().GetBool("force")
+			registrySetting, _ := cmd.PersistentFlags().GetString("registry")
 
 			downloadDirectory, err := config.GetDefaultDownloadCacheDirectory()
 			if err != nil {
@@ -46,11 +47,13 @@ func NewInitCmd() *cobra.Command {
 				ProjectWorkspace:  projectWorkspace,
 				DownloadWorkspace: downloadDirectory,
 				DSN:               dsn,
+				Registry:          registrySetting,
 			}).Run(cmd.Context())
 		},
 	}
 	cmd.PersistentFlags().BoolP("force", "f", false, "force overwriting the directory if it is not empty")
 	cmd.PersistentFlags().StringP("relevance", "r", "", "associate to selefra cloud project, use only after login")
+	cmd.PersistentFlags().String("registry", "", "where to list and install the providers from, such as github://owner/repo, a local directory, a http url or an oci:// url, it is saved as the registry of the selefra block")
 
 	cmd.SetHelpFunc(cmd.HelpFunc())
 	return cmd
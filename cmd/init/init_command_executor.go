@@ -12,6 +12,7 @@ import (
 	"github.com/selefra/selefra-utils/pkg/pointer"
 	"github.com/selefra/selefra/cli_ui"
 	"github.com/selefra/selefra/cmd/init/rule_example"
+	"github.com/selefra/selefra/cmd/lock"
 	"github.com/selefra/selefra/cmd/version"
 	"github.com/selefra/selefra/pkg/cloud_sdk"
 	"github.com/selefra/selefra/pkg/message"
@@ -51,6 +52,9 @@ type InitCommandExecutorOptions struct {
 
 	// The database link to use
 	DSN string
+
+	// Where the providers are listed and installed from, it is written to the selefra block, the official registry if empty
+	Registry string
 }
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -96,6 +100,14 @@ func (x *InitCommandExecutor) Run(ctx context.Context) error {
 
 	//x.initModulesYaml()
 
+	// Lock the providers just chosen, so that every run of the project uses the same ones
+	if len(providerSlice) > 0 {
+		if err := lock.Lock(ctx, x.options.ProjectWorkspace, x.options.DownloadWorkspace, true); err != nil {
+			cli_ui.Errorf("Lock the project failed, run selefra lock to try again\n")
+			return err
+		}
+	}
+
 	cli_ui.Infof("Selefra has been successfully initialized!\n")
 	cli_ui.Infof("Your new Selefra project \"%s\" was created!\n", selefraBlock.Name)
 	cli_ui.Infof("To perform an initial analysis, run selefra apply.\n")
@@ -236,6 +248,7 @@ func (x *InitCommandExecutor) initSelefraYaml(ctx context.Context, providerSlice
 	// cli version
 	selefraBlock.CliVersion = version.Version
 	selefraBlock.LogLevel = "info"
+	selefraBlock.Registry = x.options.Registry
 
 	if len(providerSlice) > 0 {
 		requiredProviderSlice := make([]*module.RequireProviderBlock, len(providerSlice))
@@ -448,11 +461,11 @@ func (x *InitCommandExecutor) getProjectName() (string, bool) {
 
 // Pull all providers from the remote repository
 func (x *InitCommandExecutor) requestProvidersList(ctx context.Context) ([]*registry.Provider, error) {
-	githubRegistry, err := registry.NewProviderGithubRegistry(registry.NewProviderGithubRegistryOptions(x.options.DownloadWorkspace))
+	providerRegistry, err := registry.NewProviderRegistry(x.options.DownloadWorkspace, x.options.Registry)
 	if err != nil {
 		return nil, err
 	}
-	providerSlice, err := githubRegistry.List(ctx)
+	providerSlice, err := providerRegistry.List(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -471,6 +484,7 @@ func (x *InitCommandExecutor) makeProviders(ctx context.Context, requiredProvide
 
 		providerInstallPlan := &planner.ProviderInstallPlan{
 			Provider: registry.NewProvider(requiredProvider.Name, requiredProvider.Version),
+			Registry: x.options.Registry,
 		}
 
 		// install providers
@@ -524,11 +538,23 @@ func (x *InitCommandExecutor) makeProviders(ctx context.Context, requiredProvide
 	return providersBlock, true
 }
 
-// run provider & get it's init configuration
-func (x *InitCommandExecutor) getProviderInitConfiguration(ctx context.Context, localProviderManager *local_providers_manager.LocalProvidersManager, plan *planner.ProviderInstallPlan) (string, bool) {
+// Start the installed provider, or the one SELEFRA_REATTACH_PROVIDERS overrides it with
+func (x *InitCommandExecutor) startProvider(ctx context.Context, localProviderManager *local_providers_manager.LocalProvidersManager, plan *planner.ProviderInstallPlan) (plugin.Plugin, bool) {
 
-	// start & get information
-	//cli_ui.Infof("Begin init provider %s \n", plan.String())
+	devOverride, err := plugin.GetDevOverride(plan.Name)
+	if err != nil {
+		cli_ui.Errorf("%s \n", err.Error())
+		return nil, false
+	}
+	if devOverride != nil {
+		cli_ui.Warningf("Provider %s is overridden by %s, the installed provider is not used \n", plan.String(), devOverride.String())
+		plug, err := devOverride.NewPlugin(plan.Version)
+		if err != nil {
+			cli_ui.Errorf("Start provider %s from %s failed: %s \n", plan.String(), devOverride.String(), err.Error())
+			return nil, false
+		}
+		return plug, true
+	}
 
 	// Find the local path of the provider
 	localProvider := &local_providers_manager.LocalProvider{
@@ -536,23 +562,36 @@ func (x *InitCommandExecutor) getProviderInitConfiguration(ctx context.Context,
 	}
 	installed, d := localProviderManager.IsProviderInstalled(ctx, localProvider)
 	if err := cli_ui.PrintDiagnostics(d); err != nil {
-		return "", false
+		return nil, false
 	}
 	if !installed {
 		cli_ui.Errorf("Provider %s not installed, can not exec init for it! \n", plan.String())
-		return "", false
+		return nil, false
 	}
 
 	// Find the local installation location of the provider
 	localProviderMeta, d := localProviderManager.Get(ctx, localProvider)
 	if err := cli_ui.PrintDiagnostics(d); err != nil {
-		return "", false
+		return nil, false
 	}
 
-	// Start provider
 	plug, err := plugin.NewManagedPlugin(localProviderMeta.ExecutableFilePath, plan.Name, plan.Version, "", nil)
 	if err != nil {
 		cli_ui.Errorf("Start provider %s at %s failed: %s \n", plan.String(), localProvider.ExecutableFilePath, err.Error())
+		return nil, false
+	}
+	return plug, true
+}
+
+// run provider & get it's init configuration
+func (x *InitCommandExecutor) getProviderInitConfiguration(ctx context.Context, localProviderManager *local_providers_manager.LocalProvidersManager, plan *planner.ProviderInstallPlan) (string, bool) {
+
+	// start & get information
+	//cli_ui.Infof("Begin init provider %s \n", plan.String())
+
+	// Start provider
+	plug, ok := x.startProvider(ctx, localProviderManager, plan)
+	if !ok {
 		return "", false
 	}
 	// Close the provider at the end of the method execution
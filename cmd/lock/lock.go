@@ -0,0 +1,76 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/cli_ui"
+	"github.com/selefra/selefra/config"
+	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/executors"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func NewLockCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Lock the versions and checksums of the providers and modules the project requires",
+		Long: "Lock the versions and checksums of the providers and modules the project requires\n\n" +
+			"The providers and modules are resolved, installed and written to " + lock_file.FileName + " in the project,\n" +
+			"apply, fetch and plan then use the locked versions and fail if a checksum does not match.\n" +
+			"The versions already locked are kept, use --upgrade to resolve them again.",
+		PersistentPreRun: global.DefaultWrappedInit(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			upgrade, _ := cmd.PersistentFlags().GetBool("upgrade")
+			projectWorkspace := "./"
+			downloadWorkspace, _ := config.GetDefaultDownloadCacheDirectory()
+			return Lock(cmd.Context(), projectWorkspace, downloadWorkspace, upgrade)
+		},
+	}
+	cmd.PersistentFlags().Bool("upgrade", false, "resolve the latest versions allowed and lock them again, the checksums locked before are not checked")
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
+
+// Lock the providers and modules of the project into the lock file
+func Lock(ctx context.Context, projectWorkspace, downloadWorkspace string, upgrade bool) error {
+	messageChannel := message.NewChannel[*schema.Diagnostics](func(index int, message *schema.Diagnostics) {
+		if utils.IsNotEmpty(message) {
+			_ = cli_ui.PrintDiagnostics(message)
+		}
+	})
+	executor := executors.NewProjectLockExecutor(&executors.ProjectLockExecutorOptions{
+		ProjectWorkspace:  projectWorkspace,
+		DownloadWorkspace: downloadWorkspace,
+		MessageChannel:    messageChannel,
+		Upgrade:           upgrade,
+	})
+	d := executor.Execute(ctx)
+	messageChannel.ReceiverWait()
+	if err := cli_ui.PrintDiagnostics(d); err != nil {
+		cli_ui.Errorln("Lock failed")
+		return err
+	}
+	lockFile := executor.LockFile()
+	if lockFile == nil {
+		cli_ui.Errorln("Lock failed")
+		return errors.New("lock failed")
+	}
+
+	cli_ui.Infof("\nLocked %d providers and %d modules in %s\n", len(lockFile.Providers), len(lockFile.Modules), lock_file.BuildLockFilePath(projectWorkspace))
+	for _, lockedProvider := range lockFile.Providers {
+		cli_ui.Infof("\t- provider %s@%s\n", lockedProvider.Name, lockedProvider.Version)
+	}
+	for _, lockedModule := range lockFile.Modules {
+		if lockedModule.Version != "" {
+			cli_ui.Infof("\t- module %s@%s\n", lockedModule.Uses, lockedModule.Version)
+		} else {
+			cli_ui.Infof("\t- module %s\n", lockedModule.Uses)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/cli_ui"
+	"github.com/selefra/selefra/config"
+	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/cli_env"
+	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/mirror"
+	"github.com/spf13/cobra"
+)
+
+func newCmdMirrorCreate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:              "create",
+		Short:            "Download providers, modules and the built-in PostgreSQL into a mirror directory, for example: selefra mirror create ./selefra-mirror --provider aws --postgresql linux",
+		Long:             "Download providers of every platform, modules and the built-in PostgreSQL into a mirror directory, for example: selefra mirror create ./selefra-mirror --provider aws@v0.0.1 --module rules-aws-misconfigure-s3 --postgresql linux. Copy the directory into the network without internet access and export SELEFRA_MIRROR=<mirror directory>, or set registry: <mirror directory> in the selefra block",
+		PersistentPreRun: global.DefaultWrappedInit(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("Must specify the mirror directory, for example: selefra mirror create ./selefra-mirror --provider aws")
+			}
+			downloadDirectory, err := config.GetDefaultDownloadCacheDirectory()
+			if err != nil {
+				return err
+			}
+			providers, _ := cmd.PersistentFlags().GetStringSlice("provider")
+			modules, _ := cmd.PersistentFlags().GetStringSlice("module")
+			postgresqlOS, _ := cmd.PersistentFlags().GetStringSlice("postgresql")
+			registrySetting, _ := cmd.PersistentFlags().GetString("registry")
+			return Create(cmd.Context(), &mirror.MirrorCreatorOptions{
+				MirrorDirectory:   args[0],
+				DownloadWorkspace: downloadDirectory,
+				Registry:          registrySetting,
+				Providers:         providers,
+				Modules:           modules,
+				PostgreSQLOS:      postgresqlOS,
+			})
+		},
+	}
+	cmd.PersistentFlags().StringSlice("provider", nil, "the provider to mirror such as aws or aws@v0.0.1, the latest version if the version is not given, can be given more than once")
+	cmd.PersistentFlags().StringSlice("module", nil, "the module of the registry to mirror such as rules-aws-misconfigure-s3 or rules-aws-misconfigure-s3@v0.0.1, can be given more than once")
+	cmd.PersistentFlags().StringSlice("postgresql", nil, "the operating system whose built-in PostgreSQL to mirror, such as linux, darwin or windows, can be given more than once")
+	cmd.PersistentFlags().String("registry", "", "where to mirror the providers from, such as github://owner/repo, a local directory or a http url, the official registry if not set")
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
+
+// Create Add the artifacts of the options to the mirror, the message channel of the options is created here
+func Create(ctx context.Context, options *mirror.MirrorCreatorOptions) (err error) {
+	if len(options.Providers) == 0 && len(options.Modules) == 0 && len(options.PostgreSQLOS) == 0 {
+		return errors.New("Must specify at least one of --provider, --module or --postgresql to mirror")
+	}
+	options.MessageChannel = message.NewChannel[*schema.Diagnostics](func(index int, message *schema.Diagnostics) {
+		e := cli_ui.PrintDiagnostics(message)
+		if err == nil {
+			err = e
+		}
+	})
+	isSuccess := mirror.NewMirrorCreator(options).Run(ctx)
+	options.MessageChannel.ReceiverWait()
+	if isSuccess {
+		cli_ui.Successf("Mirror %s is ready, export %s=%s to use it\n", options.MirrorDirectory, cli_env.SelefraMirror, options.MirrorDirectory)
+	}
+	return err
+}
@@ -0,0 +1,18 @@
+package mirror
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewMirrorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mirror [command]",
+		Short: "Top-level command to create a mirror for the networks without internet access",
+		Long:  "Top-level command to create a mirror for the networks without internet access, export SELEFRA_MIRROR=<mirror directory> to install the providers, the modules and the built-in PostgreSQL from it",
+	}
+
+	cmd.AddCommand(newCmdMirrorCreate())
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
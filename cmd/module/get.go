@@ -1,3 +1,52 @@
 package module
 
+import (
+	"context"
+	"errors"
+	"github.com/selefra/selefra/cli_ui"
+	"github.com/selefra/selefra/config"
+	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/modules/local_modules_manager"
+	"github.com/selefra/selefra/pkg/registry"
+	"github.com/spf13/cobra"
+)
 
+func newCmdModuleGet() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:              "get",
+		Short:            "Download modules one or more from the registry to the download cache, for example: selefra module get rules-aws-misconfigure-s3@v0.0.4",
+		Long:             "Download modules one or more from the registry to the download cache, for example: selefra module get rules-aws-misconfigure-s3@v0.0.4",
+		PersistentPreRun: global.DefaultWrappedInit(),
+		RunE: func(cmd *cobra.Command, names []string) error {
+			downloadWorkspace, err := config.GetDefaultDownloadCacheDirectory()
+			if err != nil {
+				return err
+			}
+			return Get(cmd.Context(), downloadWorkspace, names...)
+		},
+	}
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
+
+func Get(ctx context.Context, downloadWorkspace string, names ...string) error {
+	if len(names) == 0 {
+		return errors.New("Must specify at least one module to get, for example: rules-aws-misconfigure-s3@v0.0.4")
+	}
+	manager, err := local_modules_manager.NewLocalModuleManager(downloadWorkspace)
+	if err != nil {
+		return err
+	}
+	hasError := false
+	for _, name := range names {
+		_, d := manager.Get(ctx, registry.ParseModule(name), nil)
+		if err := cli_ui.PrintDiagnostics(d); err != nil {
+			hasError = true
+		}
+	}
+	if hasError {
+		return errors.New("get modules failed")
+	}
+	return nil
+}
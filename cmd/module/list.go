@@ -1 +1,55 @@
 package module
+
+import (
+	"github.com/selefra/selefra/cli_ui"
+	"github.com/selefra/selefra/config"
+	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/modules/local_modules_manager"
+	"github.com/spf13/cobra"
+)
+
+func newCmdModuleList() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:              "list",
+		Short:            "List currently downloaded modules",
+		Long:             "List currently downloaded modules",
+		PersistentPreRun: global.DefaultWrappedInit(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			downloadWorkspace, err := config.GetDefaultDownloadCacheDirectory()
+			if err != nil {
+				return err
+			}
+
+			return List(downloadWorkspace)
+		},
+	}
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
+
+func List(downloadWorkspace string) error {
+
+	manager, err := local_modules_manager.NewLocalModuleManager(downloadWorkspace)
+	if err != nil {
+		return err
+	}
+	localModules, diagnostics := manager.List()
+	if err := cli_ui.PrintDiagnostics(diagnostics); err != nil {
+		return err
+	}
+	if len(localModules) == 0 {
+		return nil
+	}
+
+	table := make([][]string, 0)
+	for _, localModule := range localModules {
+		table = append(table, []string{
+			localModule.Name, localModule.Version, string(localModule.LoaderType), localModule.Directory,
+		})
+	}
+	cli_ui.ShowTable([]string{"Name", "Version", "Loader", "Directory"}, table, nil, true)
+
+	return nil
+}
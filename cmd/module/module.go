@@ -0,0 +1,18 @@
+package module
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewModuleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "module [command]",
+		Short: "Top-level command to interact with the modules of the registry",
+		Long:  "Top-level command to interact with the modules of the registry",
+	}
+
+	cmd.AddCommand(newCmdModuleGet(), newCmdModuleList(), newCmdModuleSearch(), newCmdModuleUpdate(), newCmdModuleTidy(), newCmdModulePush())
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
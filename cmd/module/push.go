@@ -0,0 +1,49 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/selefra/selefra/cli_ui"
+	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/oci"
+	"github.com/spf13/cobra"
+)
+
+func newCmdModulePush() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:              "push",
+		Short:            "Publish a module directory to an OCI registry, for example: selefra module push ./rules-aws oci://registry.example.com/selefra/rules-aws:v0.0.1",
+		Long:             "Publish a module directory to an OCI registry, for example: selefra module push ./rules-aws oci://registry.example.com/selefra/rules-aws:v0.0.1, then it can be used by uses: oci://registry.example.com/selefra/rules-aws:v0.0.1. The registry is accessed with the credentials of docker login or SELEFRA_OCI_USERNAME and SELEFRA_OCI_PASSWORD",
+		PersistentPreRun: global.DefaultWrappedInit(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("Must specify the module directory and where to push it to, for example: selefra module push ./rules-aws oci://registry.example.com/selefra/rules-aws:v0.0.1")
+			}
+			store, err := oci.NewRemoteArtifactStore(oci.NewRemoteArtifactStoreOptionsFromEnv())
+			if err != nil {
+				return err
+			}
+			return Push(cmd.Context(), store, args[0], args[1])
+		},
+	}
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
+
+func Push(ctx context.Context, store oci.ArtifactStore, moduleDirectory, source string) error {
+	if !oci.IsArtifactSource(source) {
+		return fmt.Errorf("module can only be pushed to an oci source such as oci://registry.example.com/selefra/rules-aws:v0.0.1, but got %s", source)
+	}
+	reference, err := oci.ParseArtifactReference(source)
+	if err != nil {
+		return err
+	}
+	desc, err := oci.PushModule(ctx, store, reference.String(), moduleDirectory)
+	if err != nil {
+		return err
+	}
+	cli_ui.Successf("Push module %s to %s success, digest: %s\n", moduleDirectory, oci.SourcePrefix+reference.String(), desc.Digest.String())
+	return nil
+}
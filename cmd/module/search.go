@@ -1 +1,66 @@
 package module
+
+import (
+	"context"
+	"errors"
+	"github.com/selefra/selefra/cli_ui"
+	"github.com/selefra/selefra/config"
+	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/modules/local_modules_manager"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+func newCmdModuleSearch() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:              "search",
+		Short:            "Search the modules of the registry by keyword, for example: selefra module search s3",
+		Long:             "Search the modules of the registry by keyword, for example: selefra module search s3",
+		PersistentPreRun: global.DefaultWrappedInit(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("Must specify one keyword to search, for example: selefra module search s3")
+			}
+			downloadWorkspace, err := config.GetDefaultDownloadCacheDirectory()
+			if err != nil {
+				return err
+			}
+			return Search(cmd.Context(), downloadWorkspace, args[0])
+		},
+	}
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
+
+func Search(ctx context.Context, downloadWorkspace, keyword string) error {
+
+	manager, err := local_modules_manager.NewLocalModuleManager(downloadWorkspace)
+	if err != nil {
+		return err
+	}
+	modules, diagnostics := manager.Search(ctx, keyword)
+	if err := cli_ui.PrintDiagnostics(diagnostics); err != nil {
+		return err
+	}
+	if len(modules) == 0 {
+		cli_ui.Infof("No module matches %s\n", keyword)
+		return nil
+	}
+
+	table := make([][]string, 0)
+	for _, module := range modules {
+		// Which versions of the module are downloaded already
+		localModules, _ := manager.ListModuleVersions(module.Name)
+		downloadedVersions := make([]string, 0, len(localModules))
+		for _, localModule := range localModules {
+			downloadedVersions = append(downloadedVersions, localModule.Version)
+		}
+		table = append(table, []string{
+			module.Name, module.Version, strings.Join(downloadedVersions, ", "),
+		})
+	}
+	cli_ui.ShowTable([]string{"Name", "Latest Version", "Downloaded"}, table, nil, true)
+
+	return nil
+}
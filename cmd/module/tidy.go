@@ -1,3 +1,45 @@
 package module
 
+import (
+	"context"
+	"github.com/selefra/selefra/cli_ui"
+	"github.com/selefra/selefra/config"
+	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/modules/local_modules_manager"
+	"github.com/spf13/cobra"
+)
 
+func newCmdModuleTidy() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tidy",
+		Short: "Remove the superseded versions of the modules used by the project in the current directory",
+		Long: "Remove the downloaded versions of the registry modules used by the project in the current directory that it no longer references,\n" +
+			"a module is used if it is in the uses of the project or of a module the project uses. The downloaded modules are shared by\n" +
+			"all projects, so the modules this project does not use are kept, --all removes them too, they might be used by other projects",
+		PersistentPreRun: global.DefaultWrappedInit(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dryRun, _ := cmd.PersistentFlags().GetBool("dry-run")
+			all, _ := cmd.PersistentFlags().GetBool("all")
+			projectWorkspace := "./"
+			downloadWorkspace, err := config.GetDefaultDownloadCacheDirectory()
+			if err != nil {
+				return err
+			}
+			return Tidy(cmd.Context(), projectWorkspace, downloadWorkspace, all, dryRun)
+		},
+	}
+	cmd.PersistentFlags().Bool("dry-run", false, "only show the modules that would be removed")
+	cmd.PersistentFlags().Bool("all", false, "also remove the downloaded modules the project does not use at all, other projects that use them have to download them again")
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
+
+func Tidy(ctx context.Context, projectWorkspace, downloadWorkspace string, all, dryRun bool) error {
+	manager, err := local_modules_manager.NewLocalModuleManager(downloadWorkspace)
+	if err != nil {
+		return err
+	}
+	_, d := manager.Tidy(ctx, projectWorkspace, all, dryRun)
+	return cli_ui.PrintDiagnostics(d)
+}
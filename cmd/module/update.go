@@ -0,0 +1,38 @@
+package module
+
+import (
+	"context"
+	"github.com/selefra/selefra/cli_ui"
+	"github.com/selefra/selefra/config"
+	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/modules/local_modules_manager"
+	"github.com/spf13/cobra"
+)
+
+func newCmdModuleUpdate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:              "update",
+		Short:            "Download the latest version of the downloaded modules, all of them if no module is given, for example: selefra module update rules-aws-misconfigure-s3",
+		Long:             "Download the latest version of the downloaded modules, all of them if no module is given, for example: selefra module update rules-aws-misconfigure-s3",
+		PersistentPreRun: global.DefaultWrappedInit(),
+		RunE: func(cmd *cobra.Command, names []string) error {
+			downloadWorkspace, err := config.GetDefaultDownloadCacheDirectory()
+			if err != nil {
+				return err
+			}
+			return Update(cmd.Context(), downloadWorkspace, names...)
+		},
+	}
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
+
+func Update(ctx context.Context, downloadWorkspace string, names ...string) error {
+	manager, err := local_modules_manager.NewLocalModuleManager(downloadWorkspace)
+	if err != nil {
+		return err
+	}
+	d := manager.Update(ctx, nil, names...)
+	return cli_ui.PrintDiagnostics(d)
+}
@@ -0,0 +1,162 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/cli_ui"
+	"github.com/selefra/selefra/config"
+	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/exit_code"
+	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/executors"
+	"github.com/selefra/selefra/pkg/modules/planner"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+)
+
+func NewPlanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Show what apply would fetch and query, without fetching data or executing rules",
+		Long: "Show what apply would fetch and query, without fetching data or executing rules\n\n" +
+			"For every rule, the rendered query, the providers and tables it is bound to, the variables in its scope\n" +
+			"and the schemas it would be executed on are shown, --explain-query adds the query plan of postgresql\n\n" +
+			exit_code.Description,
+		PersistentPreRun: global.DefaultWrappedInit(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.PersistentFlags().GetString("format")
+			out, _ := cmd.PersistentFlags().GetString("out")
+			explainQuery, _ := cmd.PersistentFlags().GetBool("explain-query")
+			rules, _ := cmd.PersistentFlags().GetStringArray("rule")
+			tags, _ := cmd.PersistentFlags().GetStringArray("tag")
+			severities, _ := cmd.PersistentFlags().GetStringArray("severity")
+			providers, _ := cmd.PersistentFlags().GetStringArray("provider")
+			vars, _ := cmd.PersistentFlags().GetStringArray("var")
+			varFiles, _ := cmd.PersistentFlags().GetStringArray("var-file")
+
+			instructions := make(map[string]interface{})
+			instructions[planner.InstructionKeyRule] = rules
+			instructions[planner.InstructionKeyTag] = tags
+			instructions[planner.InstructionKeySeverity] = severities
+			instructions[planner.InstructionKeyProvider] = providers
+			projectWorkspace := "./"
+			downloadWorkspace, _ := config.GetDefaultDownloadCacheDirectory()
+
+			explainFormat, err := executors.ParseExplainFormat(format)
+			if err != nil {
+				return exit_code.Wrap(exit_code.ExitCodeConfigurationError, err)
+			}
+			if err := planner.NewRuleSelector(instructions).Check(); err != nil {
+				return exit_code.Wrap(exit_code.ExitCodeConfigurationError, err)
+			}
+
+			variables, err := planner.LoadRootVariables(&planner.RootVariablesOptions{
+				Vars:     vars,
+				VarFiles: varFiles,
+				ReadEnv:  true,
+			})
+			if err != nil {
+				return exit_code.Wrap(exit_code.ExitCodeConfigurationError, err)
+			}
+
+			return Plan(cmd.Context(), instructions, projectWorkspace, downloadWorkspace, &PlanOptions{
+				Format:       explainFormat,
+				OutputPath:   out,
+				ExplainQuery: explainQuery,
+				Variables:    variables,
+			})
+		},
+	}
+	cmd.PersistentFlags().String("format", string(executors.ExplainFormatTree), "how the plans are shown, tree or json")
+	cmd.PersistentFlags().StringP("out", "o", "", "write the plans to this file instead of the console")
+	cmd.PersistentFlags().Bool("explain-query", false, "run EXPLAIN in postgresql for every query and show the query plan, the tables must have been fetched before")
+	cmd.PersistentFlags().StringArray("rule", nil, "only plan the rules with this name or metadata id, glob patterns such as \"ebs_*\" are supported, can be repeated")
+	cmd.PersistentFlags().StringArray("tag", nil, "only plan the rules with this tag in the metadata, can be repeated")
+	cmd.PersistentFlags().StringArray("severity", nil, "only plan the rules of this severity, \">=High\" for High and Critical, can be repeated")
+	cmd.PersistentFlags().StringArray("provider", nil, "only plan the rules of this provider, can be repeated")
+	cmd.PersistentFlags().StringArray("var", nil, "set a variable of the root module in the form of key=value, wins over --var-file and SELEFRA_VAR_<key>, can be repeated")
+	cmd.PersistentFlags().StringArray("var-file", nil, "set the variables of the root module from a yaml file of key: value, wins over SELEFRA_VAR_<key>, can be repeated")
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// PlanOptions How the plans are shown
+type PlanOptions struct {
+	Format executors.ExplainFormat
+
+	// If not empty, the plans are written to this file, otherwise they are printed to the console
+	OutputPath string
+
+	// Run EXPLAIN in postgresql for every query
+	ExplainQuery bool
+
+	// The values of the variables of the root module
+	Variables []*planner.RootVariable
+}
+
+// Plan a project and show the plans
+func Plan(ctx context.Context, instructions map[string]interface{}, projectWorkspace, downloadWorkspace string, planOptions *PlanOptions) error {
+	if planOptions == nil {
+		planOptions = &PlanOptions{}
+	}
+
+	messageChannel := message.NewChannel[*schema.Diagnostics](func(index int, message *schema.Diagnostics) {
+		if utils.IsNotEmpty(message) {
+			_ = cli_ui.PrintDiagnostics(message)
+		}
+	})
+	executor := executors.NewProjectLocalLifeCycleExecutor(&executors.ProjectLocalLifeCycleExecutorOptions{
+		Instruction:          instructions,
+		ProjectWorkspace:     projectWorkspace,
+		DownloadWorkspace:    downloadWorkspace,
+		MessageChannel:       messageChannel,
+		ProjectLifeCycleStep: executors.ProjectLifeCycleStepQuery,
+		FetchStep:            executors.FetchStepGetInformation,
+		FetchWorkerNum:       1,
+		QueryWorkerNum:       1,
+		Explain: &executors.ExplainOptions{
+			ExplainQuery: planOptions.ExplainQuery,
+		},
+		Variables: planOptions.Variables,
+	})
+	d := executor.Execute(ctx)
+	messageChannel.ReceiverWait()
+	if err := cli_ui.PrintDiagnostics(d); err != nil || executor.ExitCode() != exit_code.ExitCodeSuccess || executor.ExplainReport() == nil {
+		cli_ui.Errorln("Plan failed")
+		if err == nil {
+			err = errors.New("plan failed")
+		}
+		if executor.ExitCode() != exit_code.ExitCodeSuccess {
+			return exit_code.Wrap(executor.ExitCode(), err)
+		}
+		return err
+	}
+
+	rendered, err := executor.ExplainReport().Render(planOptions.Format)
+	if err != nil {
+		return err
+	}
+	if planOptions.OutputPath == "" {
+		fmt.Print(rendered)
+		return nil
+	}
+	if dir := filepath.Dir(planOptions.OutputPath); dir != "" {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(planOptions.OutputPath, []byte(rendered), 0644); err != nil {
+		return err
+	}
+	cli_ui.Infof("Plans are written to %s\n", planOptions.OutputPath)
+	return nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -24,22 +24,25 @@ func newCmdProviderInstall() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			return Install(ctx, downloadDirectory, args...)
+			registrySetting, _ := cmd.PersistentFlags().GetString("registry")
+			return Install(ctx, downloadDirectory, registrySetting, args...)
 		},
 	}
+	cmd.PersistentFlags().String("registry", "", "where to install the providers from, such as github://owner/repo, a local directory, a http url or an oci:// url, the official registry if not set")
 
 	cmd.SetHelpFunc(cmd.HelpFunc())
 	return cmd
 }
 
-func Install(ctx context.Context, downloadWorkspace string, requiredProviders ...string) (err error) {
+// Install The providers are installed from the registry of the given setting, the official registry if it is empty
+func Install(ctx context.Context, downloadWorkspace, registrySetting string, requiredProviders ...string) (err error) {
 
 	if len(requiredProviders) == 0 {
 		cli_ui.Errorf("Please specify one or more providers to install, for example: selefra provider install aws \n")
 		return nil
 	}
 
-	manager, err := local_providers_manager.NewLocalProvidersManager(downloadWorkspace)
+	manager, err := local_providers_manager.NewLocalProvidersManager(downloadWorkspace, registrySetting)
 	if err != nil {
 		return err
 	}
@@ -11,8 +11,8 @@ func NewProviderCmd() *cobra.Command {
 		Long:  "Top-level command to interact with providers",
 	}
 
-	//cmd.AddCommand(newCmdProviderUpdate(), newCmdProviderRemove(), newCmdProviderRemove(), newCmdProviderList(), newCmdProviderInstall())
-	cmd.AddCommand(newCmdProviderRemove(), newCmdProviderList(), newCmdProviderInstall())
+	//cmd.AddCommand(newCmdProviderUpdate(), newCmdProviderSync(), newCmdProviderRemove(), newCmdProviderRemove(), newCmdProviderList(), newCmdProviderInstall())
+	cmd.AddCommand(newCmdProviderUpdate(), newCmdProviderSync(), newCmdProviderRemove(), newCmdProviderList(), newCmdProviderInstall(), newCmdProviderPush())
 
 	cmd.SetHelpFunc(cmd.HelpFunc())
 	return cmd
@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-version"
+	"github.com/selefra/selefra/cli_ui"
+	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/oci"
+	"github.com/spf13/cobra"
+	"path"
+	"strings"
+)
+
+// The platforms providers are released for
+var providerPlatforms = []string{"linux_amd64", "linux_arm64", "darwin_amd64", "darwin_arm64", "windows_amd64", "windows_arm64"}
+
+func newCmdProviderPush() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:              "push",
+		Short:            "Publish a provider version to an OCI registry, for example: selefra provider push oci://registry.example.com/selefra/providers/aws:v0.0.1 --executable linux_amd64=./selefra-provider-aws",
+		Long:             "Publish a provider version to an OCI registry, for example: selefra provider push oci://registry.example.com/selefra/providers/aws:v0.0.1 --executable linux_amd64=./selefra-provider-aws, the provider is aws and the registry of providers is oci://registry.example.com/selefra/providers. The registry is accessed with the credentials of docker login or SELEFRA_OCI_USERNAME and SELEFRA_OCI_PASSWORD",
+		PersistentPreRun: global.DefaultWrappedInit(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("Must specify where to push the provider to, for example: oci://registry.example.com/selefra/providers/aws:v0.0.1")
+			}
+			executables, _ := cmd.PersistentFlags().GetStringSlice("executable")
+			packageName, _ := cmd.PersistentFlags().GetString("package-name")
+			store, err := oci.NewRemoteArtifactStore(oci.NewRemoteArtifactStoreOptionsFromEnv())
+			if err != nil {
+				return err
+			}
+			return Push(cmd.Context(), store, args[0], packageName, executables...)
+		},
+	}
+	cmd.PersistentFlags().StringSlice("executable", nil, "the executable of a platform such as linux_amd64=./selefra-provider-aws, can be given once for every platform")
+	cmd.PersistentFlags().String("package-name", "", "the name of the executable, selefra-provider-<provider name> if not set")
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
+
+// Push The executables are in the form of platform=path
+func Push(ctx context.Context, store oci.ArtifactStore, source, packageName string, executables ...string) error {
+	if !oci.IsArtifactSource(source) {
+		return fmt.Errorf("provider can only be pushed to an oci source such as oci://registry.example.com/selefra/providers/aws:v0.0.1, but got %s", source)
+	}
+	reference, err := oci.ParseArtifactReference(source)
+	if err != nil {
+		return err
+	}
+	// The tag is the version of the provider
+	if _, err := version.NewVersion(reference.Reference); err != nil || !strings.HasPrefix(reference.Reference, "v") {
+		return fmt.Errorf("provider %s must be tagged with its version such as v0.0.1", source)
+	}
+
+	artifact := &oci.ProviderArtifact{
+		Name:        path.Base(reference.Repository),
+		PackageName: packageName,
+		Executables: make(map[string]string),
+	}
+	if artifact.PackageName == "" {
+		artifact.PackageName = "selefra-provider-" + artifact.Name
+	}
+	for _, executable := range executables {
+		platform, executablePath, ok := strings.Cut(executable, "=")
+		if !ok || executablePath == "" {
+			return fmt.Errorf("executable %s must be in the form of platform=path, such as linux_amd64=./selefra-provider-aws", executable)
+		}
+		if !isProviderPlatform(platform) {
+			return fmt.Errorf("executable %s platform %s is not supported, the platform can be %s", executable, platform, strings.Join(providerPlatforms, ", "))
+		}
+		artifact.Executables[platform] = executablePath
+	}
+	if len(artifact.Executables) == 0 {
+		return errors.New("Must specify at least one executable to push, for example: --executable linux_amd64=./selefra-provider-aws")
+	}
+
+	desc, err := oci.PushProvider(ctx, store, reference.String(), artifact)
+	if err != nil {
+		return err
+	}
+	cli_ui.Successf("Push provider %s %s to %s success, digest: %s\n", artifact.Name, reference.Reference, oci.SourcePrefix+reference.String(), desc.Digest.String())
+	return nil
+}
+
+func isProviderPlatform(platform string) bool {
+	for _, p := range providerPlatforms {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
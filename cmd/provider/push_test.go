@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"context"
+	"github.com/selefra/selefra/pkg/oci"
+	"github.com/selefra/selefra/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPush(t *testing.T) {
+	store, err := oci.NewLayoutArtifactStore(t.TempDir())
+	assert.Nil(t, err)
+	executablePath := filepath.Join(t.TempDir(), "selefra-provider-mock")
+	assert.Nil(t, os.WriteFile(executablePath, []byte("mock"), 0755))
+
+	ctx := context.Background()
+	err = Push(ctx, store, "oci://localhost:5000/selefra/providers/mock:v0.0.1", "", "linux_amd64="+executablePath, "darwin_arm64="+executablePath)
+	assert.Nil(t, err)
+
+	options := registry.NewProviderOCIRegistryOptions("oci://localhost:5000/selefra/providers")
+	options.ArtifactStore = store
+	providerRegistry, err := registry.NewProviderOCIRegistry(options)
+	assert.Nil(t, err)
+	supplement, err := providerRegistry.GetSupplement(ctx, registry.NewProvider("mock", "v0.0.1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "selefra-provider-mock", supplement.PackageName)
+	assert.NotEmpty(t, supplement.Checksums.LinuxAmd64)
+	assert.NotEmpty(t, supplement.Checksums.DarwinArm64)
+
+	// Not a version
+	assert.NotNil(t, Push(ctx, store, "oci://localhost:5000/selefra/providers/mock:latest", "", "linux_amd64="+executablePath))
+	// Unknown platform
+	assert.NotNil(t, Push(ctx, store, "oci://localhost:5000/selefra/providers/mock:v0.0.2", "", "plan9_amd64="+executablePath))
+	// Not an oci source
+	assert.NotNil(t, Push(ctx, store, "localhost:5000/selefra/providers/mock:v0.0.2", "", "linux_amd64="+executablePath))
+}
@@ -1,25 +1,18 @@
 package provider
 
-//import (
-//	"context"
-//	"github.com/selefra/selefra/config"
-//	"github.com/selefra/selefra/global"
-//	"github.com/stretchr/testify/require"
-//	"testing"
-//)
-//
-//func Test_effectiveDecls(t *testing.T) {
-//	ctx := context.Background()
-//	global.Init("", global.WithWorkspace("../../tests/workspace/offline"))
-//	rootConfig, err := config.GetConfig()
-//	if err != nil {
-//		t.Fatal(err)
-//	}
-//
-//	decls, _ := effectiveDecls(ctx, rootConfig.Selefra.ProviderDecls)
-//
-//	require.Equal(t, 1, len(decls))
-//
-//	require.Equal(t, "aws", decls[0].Name)
-//	require.Equal(t, "v0.0.9", decls[0].Version)
-//}
+import (
+	"github.com/selefra/selefra/pkg/modules/planner"
+	"github.com/selefra/selefra/pkg/providers/local_providers_manager"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBuildSyncTable(t *testing.T) {
+	plans := planner.ProvidersInstallPlan{planner.NewProviderInstallPlan("aws", "v0.0.2")}
+	unusedProviders := []*local_providers_manager.LocalProvider{local_providers_manager.NewLocalProvider("aws", "v0.0.1")}
+	assert.Equal(t, [][]string{
+		{"aws", "v0.0.2", "used"},
+		{"aws", "v0.0.1", "unused"},
+	}, buildSyncTable(plans, unusedProviders, false))
+	assert.Equal(t, "pruned", buildSyncTable(plans, unusedProviders, true)[1][2])
+}
@@ -1,77 +1,98 @@
 package provider
 
-//import (
-//	"context"
-//	"github.com/selefra/selefra/cli_ui"
-//	"github.com/selefra/selefra/cmd/fetch"
-//	"github.com/selefra/selefra/cmd/tools"
-//	"github.com/selefra/selefra/config"
-//	"github.com/selefra/selefra/global"
-//	"github.com/selefra/selefra/pkg/registry"
-//	"github.com/selefra/selefra/pkg/utils"
-//	"github.com/spf13/cobra"
-//)
-//
-//func newCmdProviderUpdate() *cobra.Command {
-//	cmd := &cobra.Command{
-//		Use:              "update",
-//		Short:            "Upgrade one or more plugins",
-//		Long:             "Upgrade one or more plugins",
-//		PersistentPreRun: global.DefaultWrappedInit(),
-//		RunE: func(cmd *cobra.Command, args []string) error {
-//			return update(cmd.Context(), args)
-//		},
-//	}
-//
-//	cmd.SetHelpFunc(cmd.HelpFunc())
-//	return cmd
-//}
-//
-//func update(ctx context.Context, args []string) error {
-//	err := config.IsSelefra()
-//	if err != nil {
-//		cli_ui.Errorln(err.Error())
-//		return err
-//	}
-//	argsMap := make(map[string]bool)
-//	for i := range args {
-//		argsMap[args[i]] = true
-//	}
-//	rootConfig, err := config.GetConfig()
-//	if err != nil {
-//		return err
-//	}
-//	namespace, _, err := utils.Home()
-//	if err != nil {
-//		return err
-//	}
-//	provider := registry.NewProviderRegistry(namespace)
-//	for _, decl := range rootConfig.Selefra.ProviderDecls {
-//		prov := registry.ProviderBinary{
-//			Provider: registry.Provider{
-//				Name:    decl.Name,
-//				Version: decl.Version,
-//				Source:  "",
-//			},
-//			Filepath: decl.Path,
-//		}
-//		if len(args) != 0 && !argsMap[decl.Name] {
-//			break
-//		}
-//
-//		pp, err := provider.CheckUpdate(ctx, prov)
-//		if err != nil {
-//			return err
-//		}
-//		decl.Path = pp.Filepath
-//		decl.Version = pp.Version
-//
-//		for _, prvd := range tools.ProvidersByID(rootConfig, decl.Name) {
-//			err = fetch.Fetch(ctx, decl, prvd)
-//			if err != nil {
-//				return err
-//			}
-//		}
-//	}
-//	return nil
-//}
+import (
+	"context"
+	"errors"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/cli_ui"
+	"github.com/selefra/selefra/config"
+	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/executors"
+	"github.com/selefra/selefra/pkg/modules/planner"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func newCmdProviderUpdate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update [provider...]",
+		Short: "Update the providers of the project to the latest versions its modules allow, for example: selefra provider update aws",
+		Long: "Update the providers of the project to the latest versions its modules allow, for example: selefra provider update aws\n\n" +
+			"All the providers the project requires are updated if none is given. The version constraints of every module are respected,\n" +
+			"the new versions are installed beside the old ones and the lock file is updated if the project is locked.\n" +
+			"Use --rewrite-version to also rewrite the version the project requires to the new one.",
+		PersistentPreRun: global.DefaultWrappedInit(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dryRun, _ := cmd.PersistentFlags().GetBool("dry-run")
+			rewriteVersion, _ := cmd.PersistentFlags().GetBool("rewrite-version")
+			projectWorkspace := "./"
+			downloadWorkspace, err := config.GetDefaultDownloadCacheDirectory()
+			if err != nil {
+				return err
+			}
+			return Update(cmd.Context(), &executors.ProviderUpdateExecutorOptions{
+				ProjectWorkspace:       projectWorkspace,
+				DownloadWorkspace:      downloadWorkspace,
+				ProviderNames:          args,
+				DryRun:                 dryRun,
+				RewriteRequiredVersion: rewriteVersion,
+			})
+		},
+	}
+	cmd.PersistentFlags().Bool("dry-run", false, "only list what would be updated, nothing is installed or written")
+	cmd.PersistentFlags().Bool("rewrite-version", false, "rewrite the version the project requires to the version updated to")
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
+
+// Update the providers of the project, the message channel of the options is created here
+func Update(ctx context.Context, options *executors.ProviderUpdateExecutorOptions) error {
+	options.MessageChannel = message.NewChannel[*schema.Diagnostics](func(index int, message *schema.Diagnostics) {
+		if utils.IsNotEmpty(message) {
+			_ = cli_ui.PrintDiagnostics(message)
+		}
+	})
+	executor := executors.NewProviderUpdateExecutor(options)
+	d := executor.Execute(ctx)
+	options.MessageChannel.ReceiverWait()
+	if err := cli_ui.PrintDiagnostics(d); err != nil {
+		cli_ui.Errorln("Update providers failed")
+		return err
+	}
+	plans := executor.Plans()
+	if plans == nil {
+		cli_ui.Errorln("Update providers failed")
+		return errors.New("update providers failed")
+	}
+
+	cli_ui.ShowTable([]string{"Provider", "Current", "Latest", "Update To", "Note"}, buildUpdateTable(plans), nil, true)
+	updateCount := len(plans.ToInstallPlan())
+	switch {
+	case updateCount == 0:
+		cli_ui.Infof("All providers are up to date\n")
+	case options.DryRun:
+		cli_ui.Infof("%d providers would be updated, run without --dry-run to update them\n", updateCount)
+	default:
+		cli_ui.Successf("%d providers updated\n", updateCount)
+	}
+	return nil
+}
+
+// One row per provider, the version to update to is empty if the provider stays as it is
+func buildUpdateTable(plans planner.ProvidersUpdatePlan) [][]string {
+	table := make([][]string, 0)
+	for _, plan := range plans {
+		currentVersion := plan.CurrentVersion
+		if currentVersion == "" {
+			currentVersion = "not installed"
+		}
+		updateVersion := ""
+		if plan.HasUpdate() {
+			updateVersion = plan.UpdateVersion
+		}
+		table = append(table, []string{plan.Name, currentVersion, plan.LatestVersion, updateVersion, plan.Note})
+	}
+	return table
+}
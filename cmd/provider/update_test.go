@@ -1,17 +1,20 @@
 package provider
 
-//import (
-//	"context"
-//	"github.com/selefra/selefra/global"
-//	"testing"
-//)
-//
-//func TestUpdate(t *testing.T) {
-//	global.Init("TestUpdate", global.WithWorkspace("../../tests/workspace/offline"))
-//	ctx := context.Background()
-//	arg := []string{"aws"}
-//	err := update(ctx, arg)
-//	if err != nil {
-//		t.Error(err)
-//	}
-//}
+import (
+	"github.com/selefra/selefra/pkg/modules/planner"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBuildUpdateTable(t *testing.T) {
+	table := buildUpdateTable(planner.ProvidersUpdatePlan{
+		{Name: "aws", CurrentVersion: "v0.0.1", LatestVersion: "v0.0.3", UpdateVersion: "v0.0.2", Note: "v0.0.3 is not allowed, the project requires < v0.0.3"},
+		{Name: "gcp", CurrentVersion: "v0.0.5", LatestVersion: "v0.0.5", UpdateVersion: "v0.0.5"},
+		{Name: "k8s", LatestVersion: "v0.0.1", UpdateVersion: "v0.0.1"},
+	})
+	assert.Equal(t, [][]string{
+		{"aws", "v0.0.1", "v0.0.3", "v0.0.2", "v0.0.3 is not allowed, the project requires < v0.0.3"},
+		{"gcp", "v0.0.5", "v0.0.5", "", ""},
+		{"k8s", "not installed", "v0.0.1", "v0.0.1", ""},
+	}, table)
+}
@@ -1,26 +1,35 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"github.com/selefra/selefra/cli_ui"
 	"github.com/selefra/selefra/cmd/apply"
 	"github.com/selefra/selefra/cmd/fetch"
 	"github.com/selefra/selefra/cmd/gpt"
 	initCmd "github.com/selefra/selefra/cmd/init"
+	"github.com/selefra/selefra/cmd/lock"
 	"github.com/selefra/selefra/cmd/login"
 	"github.com/selefra/selefra/cmd/logout"
+	"github.com/selefra/selefra/cmd/mirror"
+	"github.com/selefra/selefra/cmd/module"
+	"github.com/selefra/selefra/cmd/plan"
 	"github.com/selefra/selefra/cmd/provider"
 	"github.com/selefra/selefra/cmd/query"
+	"github.com/selefra/selefra/cmd/rule"
 	"github.com/selefra/selefra/cmd/test"
 	"github.com/selefra/selefra/cmd/version"
 	"github.com/selefra/selefra/global"
 	"github.com/selefra/selefra/pkg/cli_env"
+	"github.com/selefra/selefra/pkg/exit_code"
 	"github.com/selefra/selefra/pkg/telemetry"
 	"github.com/selefra/selefra/pkg/utils"
 	"github.com/spf13/cobra"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 )
 
 var group = make(map[string][]*cobra.Command)
@@ -74,10 +83,20 @@ func Execute() {
 		utils.Close()
 	}()
 
-	if err := rootCmd.Execute(); err != nil {
+	// Ctrl-C cancels the context of the command, so the queries in flight are cancelled and the command returns,
+	// a second Ctrl-C kills the process as usual
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		stop()
 		log.Printf("Error occurred in Execute: %+v", err)
-		os.Exit(1)
+		os.Exit(exit_code.GetExitCode(err))
 	}
+	stop()
 }
 
 func init() {
@@ -88,6 +107,7 @@ func init() {
 		initCmd.NewInitCmd(),
 		test.NewTestCmd(),
 		apply.NewApplyCmd(),
+		plan.NewPlanCmd(),
 		login.NewLoginCmd(),
 		logout.NewLogoutCmd(),
 		gpt.NewGPTCmd(),
@@ -95,8 +115,12 @@ func init() {
 
 	group["other"] = []*cobra.Command{
 		fetch.NewFetchCmd(),
+		lock.NewLockCmd(),
+		mirror.NewMirrorCmd(),
+		module.NewModuleCmd(),
 		provider.NewProviderCmd(),
 		query.NewQueryCmd(),
+		rule.NewRuleCmd(),
 		version.NewVersionCmd(),
 	}
 
@@ -0,0 +1,18 @@
+package rule
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewRuleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rule [command]",
+		Short: "Top-level command to develop rules",
+		Long:  "Top-level command to develop rules",
+	}
+
+	cmd.AddCommand(newCmdRuleTest())
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
@@ -0,0 +1,71 @@
+package rule
+
+import (
+	"context"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/cli_ui"
+	"github.com/selefra/selefra/config"
+	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/exit_code"
+	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/executors"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func newCmdRuleTest() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test [rule name...]",
+		Short: "Test the rules with the fixtures in the tests directory of the modules",
+		Long: `Test the rules with the fixtures in the tests directory of the modules.
+
+A fixture file declares the rows of the tables a rule queries, and the findings the rule is expected to report.
+The rows are loaded into a scratch schema of the built-in PostgreSQL, or of SELEFRA_DATABASE_DSN if it is set,
+so no cloud credentials or fetch are needed:
+
+  tests:
+    - name: unencrypted volume is reported
+      rule: ebs_volume_unencrypted
+      tables:
+        aws_ec2_ebs_volumes:
+          - volume_id: vol-1
+            encrypted: false
+      expect:
+        outputs:
+          - "volume vol-1 is not encrypted"
+`,
+		PersistentPreRun: global.DefaultWrappedInit(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectWorkspace := "./"
+			downloadWorkspace, _ := config.GetDefaultDownloadCacheDirectory()
+			return Test(cmd.Context(), projectWorkspace, downloadWorkspace, args)
+		},
+	}
+
+	cmd.SetHelpFunc(cmd.HelpFunc())
+	return cmd
+}
+
+// Test the rules of the project with their fixtures, only the fixtures of the given rules if any
+func Test(ctx context.Context, projectWorkspace, downloadWorkspace string, rules []string) error {
+	messageChannel := message.NewChannel[*schema.Diagnostics](func(index int, message *schema.Diagnostics) {
+		if utils.IsNotEmpty(message) {
+			_ = cli_ui.PrintDiagnostics(message)
+		}
+	})
+	executor := executors.NewRuleTestExecutor(&executors.RuleTestExecutorOptions{
+		ProjectWorkspace:  projectWorkspace,
+		DownloadWorkspace: downloadWorkspace,
+		Rules:             rules,
+		MessageChannel:    messageChannel,
+	})
+	d := executor.Execute(ctx)
+	messageChannel.ReceiverWait()
+	if err := cli_ui.PrintDiagnostics(d); err != nil {
+		return exit_code.Wrap(exit_code.ExitCodeConfigurationError, err)
+	}
+	if failedCount := executor.FailedCount(); failedCount != 0 {
+		return exit_code.New(exit_code.ExitCodeError, "%d of %d rule fixtures failed", failedCount, len(executor.GetResults()))
+	}
+	return nil
+}
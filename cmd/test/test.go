@@ -10,6 +10,7 @@ import (
 	"github.com/selefra/selefra/global"
 	"github.com/selefra/selefra/pkg/cli_env"
 	"github.com/selefra/selefra/pkg/cloud_sdk"
+	"github.com/selefra/selefra/pkg/exit_code"
 	"github.com/selefra/selefra/pkg/message"
 	"github.com/selefra/selefra/pkg/modules/executors"
 	"github.com/selefra/selefra/pkg/modules/module_loader"
@@ -20,12 +21,11 @@ import (
 	"sync/atomic"
 )
 
-// TODO 2023-2-20 15:32:56 Returns a non-zero value if the test fails
 func NewTestCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:              "test",
 		Short:            "Check whether the configuration is valid",
-		Long:             "Check whether the configuration is valid",
+		Long:             "Check whether the configuration is valid\n\n" + exit_code.Description,
 		PersistentPreRun: global.DefaultWrappedInit(),
 		RunE: func(cmd *cobra.Command, args []string) error {
 
@@ -57,9 +57,9 @@ func Test(ctx context.Context, projectWorkspace, downloadWorkspace string) error
 	})
 	dsn, err := getDsn(ctx, projectWorkspace, downloadWorkspace)
 	if err != nil {
-		return err
+		return exit_code.Wrap(exit_code.ExitCodeConfigurationError, err)
 	}
-	d := executors.NewProjectLocalLifeCycleExecutor(&executors.ProjectLocalLifeCycleExecutorOptions{
+	executor := executors.NewProjectLocalLifeCycleExecutor(&executors.ProjectLocalLifeCycleExecutorOptions{
 		ProjectWorkspace:                     projectWorkspace,
 		DownloadWorkspace:                    downloadWorkspace,
 		MessageChannel:                       messageChannel,
@@ -69,7 +69,8 @@ func Test(ctx context.Context, projectWorkspace, downloadWorkspace string) error
 		DSN:                                  dsn,
 		FetchWorkerNum:                       1,
 		QueryWorkerNum:                       1,
-	}).Execute(context.Background())
+	})
+	d := executor.Execute(context.Background())
 	messageChannel.ReceiverWait()
 
 	cli_ui.Infoln("\t- Client verification completed")
@@ -84,8 +85,12 @@ func Test(ctx context.Context, projectWorkspace, downloadWorkspace string) error
 		cli_ui.Infoln("Apply done")
 	}
 
-	if hasError.Load() {
-		return errors.New("Need help? Known on Slack or open a Github Issue: https://github.com/selefra/selefra#community")
+	if hasError.Load() || utils.HasError(d) || executor.ExitCode() != exit_code.ExitCodeSuccess {
+		code := executor.ExitCode()
+		if code == exit_code.ExitCodeSuccess {
+			code = exit_code.ExitCodeConfigurationError
+		}
+		return exit_code.New(code, "Need help? Known on Slack or open a Github Issue: https://github.com/selefra/selefra#community")
 	}
 	return nil
 }
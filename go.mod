@@ -15,6 +15,8 @@ require (
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/natefinch/lumberjack v2.0.0+incompatible
 	github.com/olekukonko/tablewriter v0.0.5
+	github.com/opencontainers/go-digest v1.0.0
+	github.com/opencontainers/image-spec v1.1.0-rc2
 	github.com/selefra/selefra-provider-sdk v0.0.23-0.20230818075347-cef95b1e16a5
 	github.com/selefra/selefra-utils v0.0.4
 	github.com/songzhibin97/gkit v1.2.7
@@ -78,7 +80,6 @@ require (
 	github.com/jackc/pgproto3/v2 v2.3.1 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20200714003250-2b9c44734f2b // indirect
 	github.com/jackc/pgtype v1.12.0 // indirect
-	github.com/jackc/pgx/v4 v4.17.2 // indirect
 	github.com/jackc/puddle v1.3.0 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
@@ -101,8 +102,6 @@ require (
 	github.com/morikuni/aec v1.0.0 // indirect
 	github.com/nsf/termbox-go v1.1.1 // indirect
 	github.com/oklog/run v1.1.0 // indirect
-	github.com/opencontainers/go-digest v1.0.0 // indirect
-	github.com/opencontainers/image-spec v1.1.0-rc2 // indirect
 	github.com/pelletier/go-toml v1.9.5 // indirect
 	github.com/pelletier/go-toml/v2 v2.0.6 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
@@ -146,6 +145,7 @@ require (
 require (
 	github.com/golang-infrastructure/go-trie v0.0.0-20230204150600-10750ecebaec
 	github.com/hashicorp/go-version v1.6.0
+	github.com/jackc/pgx/v4 v4.17.2
 	github.com/rudderlabs/analytics-go/v4 v4.1.0
 	github.com/sashabaranov/go-openai v1.5.7
 	golang.org/x/sys v0.10.0
@@ -80,3 +80,71 @@ func GetSelefraTelemetryToken() string {
 }
 
 // ------------------------------------------------ ---------------------------------------------------------------------
+
+const (
+	SelefraOCIUsername  = "SELEFRA_OCI_USERNAME"
+	SelefraOCIPassword  = "SELEFRA_OCI_PASSWORD"
+	SelefraOCIPlainHttp = "SELEFRA_OCI_PLAIN_HTTP"
+	SelefraOCIInsecure  = "SELEFRA_OCI_INSECURE"
+)
+
+// GetOCIUsername The username to log in to the OCI registry, the docker credentials are used if empty
+func GetOCIUsername() string {
+	return os.Getenv(SelefraOCIUsername)
+}
+
+func GetOCIPassword() string {
+	return os.Getenv(SelefraOCIPassword)
+}
+
+// IsOCIPlainHttp Whether to access the OCI registry over http, for example an internal registry without a certificate
+func IsOCIPlainHttp() bool {
+	flag := strings.ToLower(os.Getenv(SelefraOCIPlainHttp))
+	return flag == "true" || flag == "enable"
+}
+
+// IsOCIInsecure Whether to skip the verification of the OCI registry certificate
+func IsOCIInsecure() bool {
+	flag := strings.ToLower(os.Getenv(SelefraOCIInsecure))
+	return flag == "true" || flag == "enable"
+}
+
+// ------------------------------------------------ ---------------------------------------------------------------------
+
+// SelefraVariablePrefix The environment variable SELEFRA_VAR_<key> sets the variable <key> of the root module
+const SelefraVariablePrefix = "SELEFRA_VAR_"
+
+// GetVariables The variables of the root module set by the environment, the key is the part after SelefraVariablePrefix
+func GetVariables() map[string]string {
+	variables := make(map[string]string)
+	for _, env := range os.Environ() {
+		name, value, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(name, SelefraVariablePrefix) || len(name) == len(SelefraVariablePrefix) {
+			continue
+		}
+		variables[name[len(SelefraVariablePrefix):]] = value
+	}
+	return variables
+}
+
+// ------------------------------------------------ ---------------------------------------------------------------------
+
+// SelefraMirror The directory of a mirror created by selefra mirror create, when it is set the providers, the registry
+// modules and the built-in PostgreSQL are installed from it instead of the internet
+const SelefraMirror = "SELEFRA_MIRROR"
+
+func GetMirrorDirectory() string {
+	return os.Getenv(SelefraMirror)
+}
+
+// ------------------------------------------------ ---------------------------------------------------------------------
+
+// SelefraReattachProviders A JSON object that maps provider names to the reattach config of a provider process already running,
+// such as one under a debugger, or to the path of a local provider executable, see plugin.DevOverride
+const SelefraReattachProviders = "SELEFRA_REATTACH_PROVIDERS"
+
+func GetReattachProviders() string {
+	return os.Getenv(SelefraReattachProviders)
+}
+
+// ------------------------------------------------ ---------------------------------------------------------------------
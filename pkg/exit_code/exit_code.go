@@ -0,0 +1,93 @@
+package exit_code
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// The exit codes of selefra, pipelines can tell why a command failed from them.
+// Any other error exits with ExitCodeError
+const (
+
+	// ExitCodeSuccess Everything is fine
+	ExitCodeSuccess = 0
+
+	// ExitCodeError An error that does not fall into the categories below
+	ExitCodeError = 1
+
+	// ExitCodeConfigurationError The project could not be loaded or validated, the database is not available,
+	// or the providers could not be installed
+	ExitCodeConfigurationError = 2
+
+	// ExitCodeFetchError Failed to fetch the data of the providers
+	ExitCodeFetchError = 3
+
+	// ExitCodeQueryError Some rules could not be executed or timed out, so the result is incomplete
+	ExitCodeQueryError = 4
+
+	// ExitCodePolicyViolation The rules found issues at or above the severity passed by --fail-on,
+	// or new issues compared with the baseline when --fail-on-new is set
+	ExitCodePolicyViolation = 5
+)
+
+// Description The exit codes as a text to show in the help of the commands
+const Description = `Exit codes:
+  0  success
+  1  unknown error
+  2  configuration error: the project, the database or the providers
+  3  fetch error
+  4  query error: some rules failed to execute or timed out
+  5  policy violation: issues at or above --fail-on, or new issues with --fail-on-new`
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// Error An error that the process should exit with a specific code for
+type Error struct {
+	Code int
+	Err  error
+}
+
+var _ error = &Error{}
+
+// New Create an error with the exit code
+func New(code int, format string, args ...any) *Error {
+	return &Error{
+		Code: code,
+		Err:  fmt.Errorf(format, args...),
+	}
+}
+
+// Wrap Attach the exit code to an error, nil stays nil
+func Wrap(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		Code: code,
+		Err:  err,
+	}
+}
+
+func (x *Error) Error() string {
+	return x.Err.Error()
+}
+
+func (x *Error) Unwrap() error {
+	return x.Err
+}
+
+// GetExitCode The code the process should exit with for the error
+func GetExitCode(err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+	var exitError *Error
+	if errors.As(err, &exitError) {
+		return exitError.Code
+	}
+	return ExitCodeError
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,16 @@
+package exit_code
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetExitCode(t *testing.T) {
+	assert.Equal(t, ExitCodeSuccess, GetExitCode(nil))
+	assert.Equal(t, ExitCodeError, GetExitCode(errors.New("unknown")))
+	assert.Equal(t, ExitCodePolicyViolation, GetExitCode(New(ExitCodePolicyViolation, "%d issues", 3)))
+	assert.Equal(t, ExitCodeFetchError, GetExitCode(fmt.Errorf("apply: %w", Wrap(ExitCodeFetchError, errors.New("fetch failed")))))
+	assert.Nil(t, Wrap(ExitCodeFetchError, nil))
+}
@@ -88,6 +88,35 @@ func DownloadToDirectory(ctx context.Context, saveDirectory, targetUrl string, p
 	return client.Get()
 }
 
+// DownloadToFile Download the url to the file as it is, a local file is copied instead of linked so the file stays when the
+// source is gone. Add archive=false to the url to keep an archive packed
+func DownloadToFile(ctx context.Context, saveFile, targetUrl string, progressListener getter.ProgressTracker, options ...getter.ClientOption) error {
+	pwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	fileGetters := make(map[string]getter.Getter, len(getters))
+	for name, g := range getters {
+		fileGetters[name] = g
+	}
+	fileGetters["file"] = &getter.FileGetter{Copy: true}
+	client := getter.Client{
+		Src:           targetUrl,
+		Dst:           saveFile,
+		Pwd:           pwd,
+		Mode:          getter.ClientModeFile,
+		Detectors:     detectors,
+		Decompressors: decompressors,
+		Getters:       fileGetters,
+		Ctx:           ctx,
+		// Extra options provided by caller to overwrite default behavior
+		Options:          options,
+		ProgressListener: progressListener,
+	}
+
+	return client.Get()
+}
+
 //func ModuleGet(ctx context.Context, installPath, url string, options ...getter.ClientOption) error {
 //	pwd, _ := os.Getwd()
 //	client := getter.Client{
@@ -0,0 +1,150 @@
+package mirror
+
+import (
+	"context"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/oci"
+	"github.com/selefra/selefra/pkg/registry"
+	"github.com/selefra/selefra/pkg/utils"
+	"time"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// MirrorCreatorOptions What to put into the mirror
+type MirrorCreatorOptions struct {
+
+	// The directory of the mirror, the artifacts are added to it if it is already a mirror
+	MirrorDirectory string
+
+	// The cache directory of the registries on GitHub
+	DownloadWorkspace string
+
+	// The providers are mirrored from the provider registry of this setting, the official registry if it is empty
+	Registry string
+
+	// The providers such as aws or aws@v0.0.1, the latest version if the version is not given
+	Providers []string
+
+	// The modules of the registry such as rules-aws-misconfigure-s3 or rules-aws-misconfigure-s3@v0.0.1
+	Modules []string
+
+	// The operating systems whose built-in PostgreSQL is mirrored, such as linux
+	PostgreSQLOS []string
+
+	MessageChannel *message.Channel[*schema.Diagnostics]
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// MirrorCreator Copy the providers of every platform, the modules and the built-in PostgreSQL into a directory that can be
+// carried into a network without internet access, see registry.MirrorMetadata for the layout of the directory
+type MirrorCreator struct {
+	options *MirrorCreatorOptions
+}
+
+func NewMirrorCreator(options *MirrorCreatorOptions) *MirrorCreator {
+	return &MirrorCreator{
+		options: options,
+	}
+}
+
+// Run Everything that is mirrored successfully is recorded in mirror.yaml, even if some of the others fail
+func (x *MirrorCreator) Run(ctx context.Context) bool {
+
+	defer func() {
+		x.options.MessageChannel.SenderWaitAndClose()
+	}()
+
+	if err := utils.EnsureDirectoryExists(x.options.MirrorDirectory); err != nil {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("create mirror directory %s error: %s", x.options.MirrorDirectory, err.Error()))
+		return false
+	}
+	metadata := &registry.MirrorMetadata{}
+	if registry.IsMirrorDirectory(x.options.MirrorDirectory) {
+		var err error
+		metadata, err = registry.ReadMirrorMetadata(x.options.MirrorDirectory)
+		if err != nil {
+			x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg(err.Error()))
+			return false
+		}
+	}
+
+	isSuccess := x.mirrorProviders(ctx, metadata)
+	isSuccess = x.mirrorModules(ctx, metadata) && isSuccess
+	isSuccess = x.mirrorPostgreSQL(ctx, metadata) && isSuccess
+
+	metadata.UpdateTime = time.Now()
+	if err := metadata.Save(x.options.MirrorDirectory); err != nil {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("save mirror metadata to %s error: %s", x.options.MirrorDirectory, err.Error()))
+		return false
+	}
+	return isSuccess
+}
+
+func (x *MirrorCreator) mirrorProviders(ctx context.Context, metadata *registry.MirrorMetadata) bool {
+	if len(x.options.Providers) == 0 {
+		return true
+	}
+	providerRegistry, err := registry.NewProviderRegistry(x.options.DownloadWorkspace, x.options.Registry)
+	if err != nil {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("create provider registry error: %s", err.Error()))
+		return false
+	}
+	isSuccess := true
+	for _, providerNameAndVersion := range x.options.Providers {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("Mirror provider %s ...", providerNameAndVersion))
+		artifact, err := registry.MirrorProvider(ctx, providerRegistry, registry.ParseProvider(providerNameAndVersion), x.options.MirrorDirectory)
+		if err != nil {
+			x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("mirror provider %s failed: %s", providerNameAndVersion, err.Error()))
+			isSuccess = false
+			continue
+		}
+		metadata.SetProvider(artifact)
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("Mirror provider %s@%s success, %d packages", artifact.Name, artifact.Version, len(artifact.Files)))
+	}
+	return isSuccess
+}
+
+func (x *MirrorCreator) mirrorModules(ctx context.Context, metadata *registry.MirrorMetadata) bool {
+	if len(x.options.Modules) == 0 {
+		return true
+	}
+	moduleRegistry, err := registry.NewModuleGitHubRegistry(registry.NewModuleGithubRegistryOptions(x.options.DownloadWorkspace))
+	if err != nil {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("create module registry error: %s", err.Error()))
+		return false
+	}
+	isSuccess := true
+	for _, moduleNameAndVersion := range x.options.Modules {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("Mirror module %s ...", moduleNameAndVersion))
+		artifact, err := registry.MirrorModule(ctx, moduleRegistry, registry.ParseModule(moduleNameAndVersion), x.options.MirrorDirectory)
+		if err != nil {
+			x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("mirror module %s failed: %s", moduleNameAndVersion, err.Error()))
+			isSuccess = false
+			continue
+		}
+		metadata.SetModule(artifact)
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("Mirror module %s@%s success", artifact.Name, artifact.Version))
+	}
+	return isSuccess
+}
+
+func (x *MirrorCreator) mirrorPostgreSQL(ctx context.Context, metadata *registry.MirrorMetadata) bool {
+	isSuccess := true
+	for _, goos := range x.options.PostgreSQLOS {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("Mirror postgresql image %s ...", oci.PostgreSQLImageReference(goos)))
+		postgresql, err := oci.MirrorPostgreSQLImage(ctx, x.options.MirrorDirectory, goos)
+		if err != nil {
+			x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("mirror postgresql of %s failed: %s", goos, err.Error()))
+			isSuccess = false
+			continue
+		}
+		metadata.SetPostgreSQL(postgresql)
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("Mirror postgresql image %s success, digest: %s", postgresql.Reference, postgresql.Digest))
+	}
+	return isSuccess
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
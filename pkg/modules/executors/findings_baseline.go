@@ -0,0 +1,259 @@
+package executors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra-provider-sdk/storage/database_storage/postgresql_storage"
+	"github.com/selefra/selefra-provider-sdk/storage_factory"
+	"github.com/selefra/selefra/pkg/storage/pgstorage"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// BaselineOptions Compare the findings of an apply with the findings of a previous apply
+type BaselineOptions struct {
+
+	// The baseline to compare with, it is either the path of a baseline file, the id of a run saved in the database,
+	// or BaselineLatest for the last successful run. Empty means no comparison
+	Baseline string
+
+	// If not empty, the findings of this apply are saved to this path as a baseline file
+	SaveBaselinePath string
+
+	// Fail the apply if there is a finding that is not in the baseline
+	FailOnNewFindings bool
+}
+
+// BaselineLatest Use the last successful run in the database as the baseline
+const BaselineLatest = "latest"
+
+// BaselineFileVersion The version of the baseline file format
+const BaselineFileVersion = 1
+
+// BaselineFile The content of a baseline file
+type BaselineFile struct {
+	Version   int                  `json:"version"`
+	RunID     string               `json:"run_id,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+	Findings  []*pgstorage.Finding `json:"findings"`
+}
+
+// SaveBaselineFile Save the findings as a baseline file that can be passed to apply --baseline
+func SaveBaselineFile(path string, runID string, findings []*pgstorage.Finding) error {
+	if findings == nil {
+		findings = make([]*pgstorage.Finding, 0)
+	}
+	// The rules are executed in parallel, sort the findings so the same findings always make the same file
+	findings = append([]*pgstorage.Finding(nil), findings...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].RuleName != findings[j].RuleName {
+			return findings[i].RuleName < findings[j].RuleName
+		}
+		return findings[i].Fingerprint < findings[j].Fingerprint
+	})
+	marshal, err := json.MarshalIndent(&BaselineFile{
+		Version:   BaselineFileVersion,
+		RunID:     runID,
+		CreatedAt: time.Now(),
+		Findings:  findings,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, marshal, 0644)
+}
+
+// ReadBaselineFile Read the findings from a baseline file
+func ReadBaselineFile(path string) (*BaselineFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	baselineFile := &BaselineFile{}
+	if err := json.Unmarshal(content, baselineFile); err != nil {
+		return nil, fmt.Errorf("baseline file %s is not valid: %s", path, err.Error())
+	}
+	if baselineFile.Version > BaselineFileVersion {
+		return nil, fmt.Errorf("baseline file %s version %d is newer than this selefra supports (%d), please upgrade selefra", path, baselineFile.Version, BaselineFileVersion)
+	}
+	return baselineFile, nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// FindingBaselineStatus How a finding compares with the baseline
+type FindingBaselineStatus string
+
+const (
+
+	// FindingBaselineStatusNone There is no baseline to compare with
+	FindingBaselineStatusNone FindingBaselineStatus = ""
+
+	// FindingBaselineStatusNew The finding is not in the baseline
+	FindingBaselineStatusNew FindingBaselineStatus = "new"
+
+	// FindingBaselineStatusUnchanged The finding is also in the baseline
+	FindingBaselineStatusUnchanged FindingBaselineStatus = "unchanged"
+
+	// FindingBaselineStatusResolved The finding is in the baseline but not found any more
+	FindingBaselineStatusResolved FindingBaselineStatus = "resolved"
+)
+
+// FindingsBaseline Classify the findings of an apply against the findings of a baseline, findings are matched by fingerprint.
+// It is safe to classify from several query workers at the same time
+type FindingsBaseline struct {
+
+	// Where the baseline comes from, shown to the user
+	Ref string
+
+	findingMap map[string]*pgstorage.Finding
+
+	lock           sync.Mutex
+	seenSet        map[string]struct{}
+	newCount       int
+	unchangedCount int
+}
+
+// NewFindingsBaseline Create a baseline from the findings of a previous apply
+func NewFindingsBaseline(ref string, findings []*pgstorage.Finding) *FindingsBaseline {
+	findingMap := make(map[string]*pgstorage.Finding, len(findings))
+	for _, finding := range findings {
+		findingMap[finding.Fingerprint] = finding
+	}
+	return &FindingsBaseline{
+		Ref:        ref,
+		findingMap: findingMap,
+		seenSet:    make(map[string]struct{}),
+	}
+}
+
+// LoadFindingsBaseline Load the baseline from a baseline file, or from a run saved in the database
+func LoadFindingsBaseline(ctx context.Context, ref string, dsn string) (*FindingsBaseline, *schema.Diagnostics) {
+	diagnostics := schema.NewDiagnostics()
+
+	if _, err := os.Stat(ref); err == nil {
+		baselineFile, err := ReadBaselineFile(ref)
+		if err != nil {
+			return nil, diagnostics.AddErrorMsg("read baseline file %s error: %s", ref, err.Error())
+		}
+		return NewFindingsBaseline(ref, baselineFile.Findings), diagnostics
+	}
+
+	options := postgresql_storage.NewPostgresqlStorageOptions(dsn)
+	options.SearchPath = "public"
+	publicStorage, d := storage_factory.NewStorage(ctx, storage_factory.StorageTypePostgresql, options)
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return nil, diagnostics
+	}
+	defer func() {
+		_ = publicStorage.Close()
+	}()
+	if diagnostics.AddDiagnostics(pgstorage.InitFindingsSchema(ctx, publicStorage)).HasError() {
+		return nil, diagnostics
+	}
+
+	runID := ref
+	if ref == BaselineLatest {
+		latestRunID, d := pgstorage.GetLatestRunID(ctx, publicStorage)
+		if diagnostics.AddDiagnostics(d).HasError() {
+			return nil, diagnostics
+		}
+		if latestRunID == "" {
+			return nil, diagnostics.AddErrorMsg("baseline %s not found, there is no successful run in the database yet", ref)
+		}
+		runID = latestRunID
+	} else {
+		exists, d := pgstorage.ExistsRun(ctx, publicStorage, runID)
+		if diagnostics.AddDiagnostics(d).HasError() {
+			return nil, diagnostics
+		}
+		if !exists {
+			return nil, diagnostics.AddErrorMsg("baseline %s is neither a baseline file nor a run saved in the database", ref)
+		}
+	}
+
+	findings, d := pgstorage.ListFindings(ctx, publicStorage, runID)
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return nil, diagnostics
+	}
+	return NewFindingsBaseline("run "+runID, findings), diagnostics
+}
+
+// Classify Whether the finding with the fingerprint is new or unchanged, every finding should be classified only once
+func (x *FindingsBaseline) Classify(fingerprint string) FindingBaselineStatus {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	if _, exists := x.findingMap[fingerprint]; !exists {
+		x.newCount++
+		return FindingBaselineStatusNew
+	}
+	x.seenSet[fingerprint] = struct{}{}
+	x.unchangedCount++
+	return FindingBaselineStatusUnchanged
+}
+
+// NewCount How many findings are not in the baseline
+func (x *FindingsBaseline) NewCount() int {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	return x.newCount
+}
+
+// UnchangedCount How many findings are also in the baseline
+func (x *FindingsBaseline) UnchangedCount() int {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	return x.unchangedCount
+}
+
+// Resolved The findings of the baseline that were not found again, ordered by rule and resource
+func (x *FindingsBaseline) Resolved() []*pgstorage.Finding {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	resolved := make([]*pgstorage.Finding, 0)
+	for fingerprint, finding := range x.findingMap {
+		if _, seen := x.seenSet[fingerprint]; !seen {
+			resolved = append(resolved, finding)
+		}
+	}
+	sort.Slice(resolved, func(i, j int) bool {
+		if resolved[i].RuleName != resolved[j].RuleName {
+			return resolved[i].RuleName < resolved[j].RuleName
+		}
+		if resolved[i].ResourceID != resolved[j].ResourceID {
+			return resolved[i].ResourceID < resolved[j].ResourceID
+		}
+		return resolved[i].Output < resolved[j].Output
+	})
+	return resolved
+}
+
+// Summary Show the comparison to the user, resolved findings are listed because they are not printed anywhere else
+func (x *FindingsBaseline) Summary() *schema.Diagnostics {
+	resolved := x.Resolved()
+	diagnostics := schema.NewDiagnostics().AddInfo("Baseline %s: %d new, %d unchanged, %d resolved.\n", x.Ref, x.NewCount(), x.UnchangedCount(), len(resolved))
+	if len(resolved) != 0 {
+		message := "Resolved:\n"
+		for _, finding := range resolved {
+			message += fmt.Sprintf("\t[%s] %s: %s %s\n", finding.Severity, finding.RuleName, finding.Output, finding.ResourceID)
+		}
+		diagnostics.AddInfo(message)
+	}
+	return diagnostics
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,32 @@
+package executors
+
+import (
+	"github.com/selefra/selefra/pkg/storage/pgstorage"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindingsBaseline_Classify(t *testing.T) {
+	findings := []*pgstorage.Finding{
+		{Fingerprint: "a", RuleName: "rule_a"},
+		{Fingerprint: "b", RuleName: "rule_b"},
+	}
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	assert.Nil(t, SaveBaselineFile(path, "run-1", findings))
+	baselineFile, err := ReadBaselineFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "run-1", baselineFile.RunID)
+	assert.Len(t, baselineFile.Findings, 2)
+
+	baseline := NewFindingsBaseline(path, baselineFile.Findings)
+	assert.Equal(t, FindingBaselineStatusUnchanged, baseline.Classify("a"))
+	assert.Equal(t, FindingBaselineStatusNew, baseline.Classify("c"))
+	assert.Equal(t, 1, baseline.NewCount())
+	assert.Equal(t, 1, baseline.UnchangedCount())
+
+	resolved := baseline.Resolved()
+	assert.Len(t, resolved, 1)
+	assert.Equal(t, "rule_b", resolved[0].RuleName)
+}
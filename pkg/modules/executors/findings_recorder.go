@@ -0,0 +1,130 @@
+package executors
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra-provider-sdk/storage"
+	"github.com/selefra/selefra-provider-sdk/storage/database_storage/postgresql_storage"
+	"github.com/selefra/selefra-provider-sdk/storage_factory"
+	"github.com/selefra/selefra-utils/pkg/id_util"
+	"github.com/selefra/selefra/pkg/grpc/pb/issue"
+	"github.com/selefra/selefra/pkg/storage/pgstorage"
+	"github.com/selefra/selefra/pkg/utils"
+	"os"
+	"time"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// FindingsRecorder Save the findings of an apply to the selefra_runs and selefra_findings tables of the project database,
+// so that the history of runs can be queried later
+type FindingsRecorder struct {
+	storage storage.Storage
+
+	run *pgstorage.RunInformation
+}
+
+// NewFindingsRecorder Connect to the public schema of the dsn and make sure the findings tables are up to date
+func NewFindingsRecorder(ctx context.Context, dsn string, projectName string) (*FindingsRecorder, *schema.Diagnostics) {
+	options := postgresql_storage.NewPostgresqlStorageOptions(dsn)
+	options.SearchPath = "public"
+	publicStorage, diagnostics := storage_factory.NewStorage(ctx, storage_factory.StorageTypePostgresql, options)
+	if utils.HasError(diagnostics) {
+		return nil, diagnostics
+	}
+	if d := pgstorage.InitFindingsSchema(ctx, publicStorage); utils.HasError(d) {
+		_ = publicStorage.Close()
+		return nil, diagnostics.AddDiagnostics(d)
+	}
+	hostname, _ := os.Hostname()
+	return &FindingsRecorder{
+		storage: publicStorage,
+		run: &pgstorage.RunInformation{
+			RunID:       id_util.RandomId(),
+			ProjectName: projectName,
+			Hostname:    hostname,
+			Status:      pgstorage.RunStatusRunning,
+			StartedAt:   time.Now(),
+		},
+	}, diagnostics
+}
+
+// RunID The id of the run the findings are saved to
+func (x *FindingsRecorder) RunID() string {
+	return x.run.RunID
+}
+
+// Start Save the run before any finding refers to it
+func (x *FindingsRecorder) Start(ctx context.Context) *schema.Diagnostics {
+	return pgstorage.SaveRun(ctx, x.storage, x.run)
+}
+
+// Record Save the query result if it is a finding, passed resources are not saved
+func (x *FindingsRecorder) Record(ctx context.Context, result *RuleQueryResult) *schema.Diagnostics {
+	// Findings suppressed by waivers are accepted risks, they are not part of the history
+	if result.Status == issue.UploadIssueStream_Rule_SUCCESS || result.Waiver != nil {
+		return nil
+	}
+	finding := NewFindingFromRuleQueryResult(result)
+	finding.RunID = x.run.RunID
+	d := pgstorage.SaveFinding(ctx, x.storage, finding)
+	if !utils.HasError(d) {
+		x.run.FindingsCount++
+	}
+	return d
+}
+
+// Finish Mark the run as finished and release the connection
+func (x *FindingsRecorder) Finish(ctx context.Context, success bool) *schema.Diagnostics {
+	defer func() {
+		_ = x.storage.Close()
+	}()
+	x.run.FinishedAt = time.Now()
+	if success {
+		x.run.Status = pgstorage.RunStatusSuccess
+	} else {
+		x.run.Status = pgstorage.RunStatusFailed
+	}
+	return pgstorage.SaveRun(ctx, x.storage, x.run)
+}
+
+// NewFindingFromRuleQueryResult Convert the query result of a rule to a finding, the run id is left empty
+func NewFindingFromRuleQueryResult(result *RuleQueryResult) *pgstorage.Finding {
+	finding := &pgstorage.Finding{
+		RuleName:       result.RuleBlock.Name,
+		Output:         result.RuleBlock.Output,
+		ProviderSchema: result.Schema,
+		CreatedAt:      time.Now(),
+	}
+	if result.RuleBlock.MetadataBlock != nil {
+		finding.RuleID = result.RuleBlock.MetadataBlock.Id
+		finding.Severity = result.RuleBlock.MetadataBlock.Severity
+		finding.Title = result.RuleBlock.MetadataBlock.Title
+	}
+	if result.Module != nil {
+		finding.ModuleName = result.Module.BuildFullName()
+	}
+	if result.Provider != nil {
+		finding.ProviderName = result.Provider.Name
+		finding.ProviderVersion = result.Provider.Version
+	}
+	identityLabels := make(map[string]string)
+	for _, label := range pgstorage.FindingIdentityLabels {
+		if value, ok := result.RuleBlock.Labels[label]; ok {
+			identityLabels[label] = utils.Strava(value)
+		}
+	}
+	finding.ResourceID = identityLabels["resource_id"]
+	if labels, err := json.Marshal(result.RuleBlock.Labels); err == nil {
+		finding.Labels = string(labels)
+	}
+	ruleID := finding.RuleID
+	if ruleID == "" {
+		ruleID = finding.RuleName
+	}
+	finding.Fingerprint = pgstorage.BuildFindingFingerprint(ruleID, identityLabels, finding.Output)
+	return finding
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,393 @@
+package executors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/modules/planner"
+	"github.com/selefra/selefra/pkg/utils"
+	"sort"
+	"strings"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ExplainFormat How the explain report is rendered
+type ExplainFormat string
+
+const (
+	ExplainFormatTree ExplainFormat = "tree"
+	ExplainFormatJSON ExplainFormat = "json"
+)
+
+// ParseExplainFormat Parse the format of the explain report, the empty string is the tree format
+func ParseExplainFormat(format string) (ExplainFormat, error) {
+	switch ExplainFormat(strings.ToLower(format)) {
+	case "", ExplainFormatTree:
+		return ExplainFormatTree, nil
+	case ExplainFormatJSON:
+		return ExplainFormatJSON, nil
+	default:
+		return "", fmt.Errorf("explain format %s is not supported, it must be %s or %s", format, ExplainFormatTree, ExplainFormatJSON)
+	}
+}
+
+// ExplainOptions If set, the project is planned but the rules are not executed, the plans are collected into an ExplainReport
+type ExplainOptions struct {
+
+	// Run EXPLAIN in postgresql for every query, so the query plan of the database is part of the report
+	ExplainQuery bool
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ExplainReport What would be fetched and which queries would be executed on which schemas
+type ExplainReport struct {
+
+	// The variables of the root module given from the command line, the environment and the var files
+	Variables []*VariableExplain `json:"variables,omitempty"`
+
+	FetchPlans []*FetchPlanExplain `json:"fetch_plans"`
+	RulePlans  []*RulePlanExplain  `json:"rule_plans"`
+}
+
+// VariableExplain A planner.RootVariable that is applied, the value of a sensitive variable is masked
+type VariableExplain struct {
+	Key    string                 `json:"key"`
+	Value  any                    `json:"value"`
+	Source planner.VariableSource `json:"source"`
+	From   string                 `json:"from"`
+}
+
+// FetchPlanExplain A ProviderFetchPlan
+type FetchPlanExplain struct {
+	Provider      string `json:"provider"`
+	Version       string `json:"version"`
+	Configuration string `json:"configuration"`
+	Schema        string `json:"schema"`
+
+	// Empty if all tables of the provider are fetched
+	SelectedTables []string `json:"selected_tables,omitempty"`
+}
+
+// RulePlanExplain A RulePlan and the queries it is expanded into
+type RulePlanExplain struct {
+	Module   string `json:"module"`
+	Rule     string `json:"rule"`
+	Id       string `json:"id,omitempty"`
+	Severity string `json:"severity,omitempty"`
+
+	BindingProviders []string `json:"binding_providers"`
+	BindingTables    []string `json:"binding_tables"`
+
+	// The variables in the scope of the rule, the query is rendered with them
+	Variables map[string]any `json:"variables"`
+
+	// The query after the template is rendered, before it is qualified with the schemas of the providers
+	Query string `json:"query"`
+
+	// Why the rule would not be executed, such as being filtered by a modules block
+	Skipped string `json:"skipped,omitempty"`
+
+	// One per combination of the provider contexts the rule is bound to
+	Executions []*RuleExecutionExplain `json:"executions"`
+}
+
+// RuleExecutionExplain One execution of a rule on a combination of provider contexts
+type RuleExecutionExplain struct {
+	Targets []*ExplainTarget `json:"targets"`
+
+	// The query that is executed on the targets
+	Query string `json:"query"`
+
+	// The output of EXPLAIN, only if ExplainOptions.ExplainQuery is set
+	QueryPlan      []string `json:"query_plan,omitempty"`
+	QueryPlanError string   `json:"query_plan_error,omitempty"`
+}
+
+// ExplainTarget The schema a provider of the rule is queried in
+type ExplainTarget struct {
+	Provider      string `json:"provider"`
+	Configuration string `json:"configuration,omitempty"`
+	Schema        string `json:"schema"`
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ModuleExplainerOptions The plans to explain
+type ModuleExplainerOptions struct {
+
+	// The query plan of the root module
+	Plan *planner.ModulePlan
+
+	// The fetch plans of the providers
+	ProviderFetchPlans planner.ProvidersFetchPlan
+
+	// The same as ModuleQueryExecutorOptions.ProviderExpandMap
+	ProviderExpandMap map[string][]*planner.ProviderContext
+
+	ExplainQuery bool
+
+	// The same as planner.ModulePlannerOptions.Variables
+	Variables []*planner.RootVariable
+}
+
+// MakeExplainReport Explain the plans the same way ModuleQueryExecutor would execute them, without executing the rules
+func MakeExplainReport(ctx context.Context, options *ModuleExplainerOptions) (*ExplainReport, *schema.Diagnostics) {
+	diagnostics := schema.NewDiagnostics()
+	report := &ExplainReport{
+		FetchPlans: make([]*FetchPlanExplain, 0, len(options.ProviderFetchPlans)),
+		RulePlans:  make([]*RulePlanExplain, 0),
+	}
+
+	for _, variable := range options.Variables {
+		variableExplain := &VariableExplain{
+			Key:    variable.Key,
+			Source: variable.Source,
+			From:   variable.From,
+		}
+		// The value applied is the one converted to the declared type
+		if value, exists := options.Plan.ModuleScope.GetVariable(variable.Key); exists {
+			variableExplain.Value = value
+		}
+		if isSensitiveVariable(options.Plan, variable.Key) {
+			variableExplain.Value = planner.SensitiveValueMask
+		}
+		report.Variables = append(report.Variables, variableExplain)
+	}
+
+	for _, fetchPlan := range options.ProviderFetchPlans {
+		report.FetchPlans = append(report.FetchPlans, &FetchPlanExplain{
+			Provider:       fetchPlan.Name,
+			Version:        fetchPlan.Version,
+			Configuration:  fetchPlan.ProviderConfigurationName,
+			Schema:         fetchPlan.FetchToDatabaseSchema,
+			SelectedTables: fetchPlan.SelectedTables,
+		})
+	}
+
+	if options.Plan == nil {
+		return report, diagnostics
+	}
+	queryExecutor := NewModuleQueryExecutor(&ModuleQueryExecutorOptions{
+		Plan:              options.Plan,
+		ProviderExpandMap: options.ProviderExpandMap,
+	})
+	for _, rulePlan := range queryExecutor.makeRulePlanSlice(ctx, options.Plan) {
+		ruleExplain := newRulePlanExplain(rulePlan)
+		report.RulePlans = append(report.RulePlans, ruleExplain)
+		if ruleExplain.Skipped != "" {
+			continue
+		}
+		for _, providerContexts := range queryExecutor.expandProviderContexts(rulePlan) {
+			execution := newRuleExecutionExplain(rulePlan, providerContexts)
+			if options.ExplainQuery && isSql(rulePlan.Query) {
+				execution.QueryPlan, execution.QueryPlanError = explainQuery(ctx, execution.Query, providerContexts[0])
+			}
+			// The query is explained with the real values, but they are not shown
+			execution.Query = rulePlan.BuildMaskedQuery(providerContexts)
+			ruleExplain.Executions = append(ruleExplain.Executions, execution)
+		}
+		if len(ruleExplain.Executions) == 0 {
+			diagnostics.AddWarn("rule %s is bound to %s, but there is no fetch plan of them to query", rulePlan.String(), strings.Join(rulePlan.BindingProviders, ", "))
+		}
+	}
+	return report, diagnostics
+}
+
+// Whether the variable is declared sensitive by any module, the variables of the root module are inherited by the submodules
+func isSensitiveVariable(modulePlan *planner.ModulePlan, variableName string) bool {
+	if modulePlan.ModuleScope != nil && modulePlan.ModuleScope.IsSensitive(variableName) {
+		return true
+	}
+	for _, subModulePlan := range modulePlan.SubModulesPlan {
+		if isSensitiveVariable(subModulePlan, variableName) {
+			return true
+		}
+	}
+	return false
+}
+
+func newRulePlanExplain(rulePlan *planner.RulePlan) *RulePlanExplain {
+	ruleExplain := &RulePlanExplain{
+		Rule:             rulePlan.Name,
+		BindingProviders: rulePlan.BindingProviders,
+		BindingTables:    rulePlan.BindingTables,
+		Variables:        make(map[string]any),
+		Query:            rulePlan.BuildMaskedQuery(nil),
+		Executions:       make([]*RuleExecutionExplain, 0),
+	}
+	if rulePlan.MetadataBlock != nil {
+		ruleExplain.Id = rulePlan.MetadataBlock.Id
+		ruleExplain.Severity = rulePlan.MetadataBlock.Severity
+	}
+	if rulePlan.RuleScope != nil {
+		ruleExplain.Variables = rulePlan.RuleScope.MaskedVariables()
+	}
+	if rulePlan.Module != nil {
+		ruleExplain.Module = rulePlan.Module.BuildFullName()
+		if moduleBlock := rulePlan.Module.RuleExcludedBy(rulePlan.RuleBlock); moduleBlock != nil {
+			ruleExplain.Skipped = fmt.Sprintf("rule %s is filtered by module %s", rulePlan.Name, moduleBlock.Name)
+		}
+	}
+	return ruleExplain
+}
+
+func newRuleExecutionExplain(rulePlan *planner.RulePlan, providerContexts []*planner.ProviderContext) *RuleExecutionExplain {
+	execution := &RuleExecutionExplain{
+		Targets: make([]*ExplainTarget, 0, len(providerContexts)),
+		Query:   rulePlan.BuildQuery(providerContexts),
+	}
+	for _, providerContext := range providerContexts {
+		target := &ExplainTarget{
+			Provider: providerContext.ProviderName,
+			Schema:   providerContext.Schema,
+		}
+		if providerContext.ProviderConfiguration != nil {
+			target.Configuration = providerContext.ProviderConfiguration.Name
+		}
+		execution.Targets = append(execution.Targets, target)
+	}
+	return execution
+}
+
+// Run EXPLAIN on the storage the query would be executed on, an error of the database is returned as the error message
+func explainQuery(ctx context.Context, query string, providerContext *planner.ProviderContext) ([]string, string) {
+	resultSet, d := providerContext.Storage.Query(ctx, "EXPLAIN "+query)
+	if utils.HasError(d) {
+		return nil, d.ToString()
+	}
+	defer resultSet.Close()
+	rows, d := resultSet.ReadRows(-1)
+	if utils.HasError(d) {
+		return nil, d.ToString()
+	}
+	queryPlan := make([]string, 0, rows.RowCount())
+	for _, row := range rows.GetMatrix() {
+		if len(row) != 0 {
+			queryPlan = append(queryPlan, fmt.Sprintf("%v", row[0]))
+		}
+	}
+	return queryPlan, ""
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// Render the report in the format
+func (x *ExplainReport) Render(format ExplainFormat) (string, error) {
+	switch format {
+	case ExplainFormatJSON:
+		marshal, err := json.MarshalIndent(x, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(marshal) + "\n", nil
+	case ExplainFormatTree, "":
+		return x.renderTree(), nil
+	default:
+		return "", fmt.Errorf("explain format %s is not supported", format)
+	}
+}
+
+func (x *ExplainReport) renderTree() string {
+	builder := &explainTreeBuilder{}
+
+	if len(x.Variables) != 0 {
+		builder.line(0, "Variables:")
+		for _, variable := range x.Variables {
+			builder.line(1, "%s = %v (%s %s)", variable.Key, variable.Value, variable.Source, variable.From)
+		}
+	}
+
+	builder.line(0, "Provider fetch plans:")
+	if len(x.FetchPlans) == 0 {
+		builder.line(1, "(none)")
+	}
+	for _, fetchPlan := range x.FetchPlans {
+		builder.line(1, "%s@%s", fetchPlan.Provider, fetchPlan.Version)
+		builder.line(2, "configuration: %s", fetchPlan.Configuration)
+		builder.line(2, "schema: %s", fetchPlan.Schema)
+		if len(fetchPlan.SelectedTables) == 0 {
+			builder.line(2, "tables: all")
+		} else {
+			builder.line(2, "tables: %s", strings.Join(fetchPlan.SelectedTables, ", "))
+		}
+	}
+
+	builder.line(0, "Rule plans:")
+	if len(x.RulePlans) == 0 {
+		builder.line(1, "(none)")
+	}
+	for _, rulePlan := range x.RulePlans {
+		title := rulePlan.Rule
+		if rulePlan.Id != "" {
+			title += ":" + rulePlan.Id
+		}
+		if rulePlan.Severity != "" {
+			title += " [" + rulePlan.Severity + "]"
+		}
+		builder.line(1, "%s", title)
+		builder.line(2, "module: %s", rulePlan.Module)
+		builder.line(2, "providers: %s", strings.Join(rulePlan.BindingProviders, ", "))
+		builder.line(2, "tables: %s", strings.Join(rulePlan.BindingTables, ", "))
+		if len(rulePlan.Variables) != 0 {
+			builder.line(2, "variables:")
+			variableNames := make([]string, 0, len(rulePlan.Variables))
+			for variableName := range rulePlan.Variables {
+				variableNames = append(variableNames, variableName)
+			}
+			sort.Strings(variableNames)
+			for _, variableName := range variableNames {
+				builder.line(3, "%s = %v", variableName, rulePlan.Variables[variableName])
+			}
+		}
+		if rulePlan.Skipped != "" {
+			builder.line(2, "skipped: %s", rulePlan.Skipped)
+			continue
+		}
+		if len(rulePlan.Executions) == 0 {
+			builder.line(2, "executions: (none)")
+			builder.line(2, "query:")
+			builder.lines(3, rulePlan.Query)
+		}
+		for index, execution := range rulePlan.Executions {
+			targets := make([]string, 0, len(execution.Targets))
+			for _, target := range execution.Targets {
+				targets = append(targets, target.Provider+"="+target.Schema)
+			}
+			builder.line(2, "execution %d: %s", index+1, strings.Join(targets, ", "))
+			builder.line(3, "query:")
+			builder.lines(4, execution.Query)
+			if len(execution.QueryPlan) != 0 {
+				builder.line(3, "query plan:")
+				builder.lines(4, strings.Join(execution.QueryPlan, "\n"))
+			}
+			if execution.QueryPlanError != "" {
+				builder.line(3, "query plan error:")
+				builder.lines(4, execution.QueryPlanError)
+			}
+		}
+	}
+	return builder.String()
+}
+
+// explainTreeBuilder Write the tree of the report, two spaces for each level
+type explainTreeBuilder struct {
+	strings.Builder
+}
+
+func (x *explainTreeBuilder) line(level int, format string, args ...any) {
+	x.WriteString(strings.Repeat("  ", level))
+	x.WriteString(fmt.Sprintf(format, args...))
+	x.WriteString("\n")
+}
+
+// Write a multi-line text, such as a query, every line at the level
+func (x *explainTreeBuilder) lines(level int, text string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		x.line(level, "%s", line)
+	}
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
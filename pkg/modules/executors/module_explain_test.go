@@ -0,0 +1,106 @@
+package executors
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/modules/planner"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMakeExplainReport(t *testing.T) {
+	rootModule := &module.Module{ModuleLocalDirectory: "./rules"}
+	modulePlan := &planner.ModulePlan{Module: rootModule, ModuleScope: planner.NewScope()}
+	modulePlan.ModuleScope.SetVariable("min_size", 100)
+	modulePlan.ModuleScope.SetVariable("password", "s3cr3t")
+	modulePlan.ModuleScope.SetSensitive("password")
+	rulePlan, d := planner.NewRulePlanner(&planner.RulePlannerOptions{
+		ModulePlan:  modulePlan,
+		Module:      rootModule,
+		ModuleScope: modulePlan.ModuleScope,
+		RuleBlock: &module.RuleBlock{
+			Name:          "volume_of_instance",
+			Query:         "SELECT * FROM aws_ec2_ebs_volumes v JOIN gcp_compute_instances i ON v.id = i.id WHERE v.size > {{.min_size}} AND i.password <> '{{.password}}'",
+			MetadataBlock: &module.RuleMetadataBlock{Id: "R-001", Severity: "High"},
+		},
+		TableToProviderMap: map[string]string{
+			"aws_ec2_ebs_volumes":   "aws",
+			"gcp_compute_instances": "gcp",
+		},
+	}).MakePlan(context.Background())
+	assert.False(t, d.HasError())
+	modulePlan.RulesPlan = append(modulePlan.RulesPlan, rulePlan)
+
+	fetchPlans := planner.ProvidersFetchPlan{
+		{ProviderInstallPlan: planner.NewProviderInstallPlan("aws", "v0.1.0"), FetchToDatabaseSchema: "aws_a", ProviderConfigurationName: "aws_a"},
+		{ProviderInstallPlan: planner.NewProviderInstallPlan("aws", "v0.1.0"), FetchToDatabaseSchema: "aws_b", ProviderConfigurationName: "aws_b"},
+		{ProviderInstallPlan: planner.NewProviderInstallPlan("gcp", "v0.2.0"), FetchToDatabaseSchema: "gcp_a", ProviderConfigurationName: "gcp_a", SelectedTables: []string{"gcp_compute_instances"}},
+	}
+	providerExpandMap := make(map[string][]*planner.ProviderContext)
+	for _, fetchPlan := range fetchPlans {
+		providerExpandMap[fetchPlan.Name] = append(providerExpandMap[fetchPlan.Name], &planner.ProviderContext{
+			ProviderName:          fetchPlan.Name,
+			ProviderVersion:       fetchPlan.Version,
+			Schema:                fetchPlan.FetchToDatabaseSchema,
+			ProviderConfiguration: &module.ProviderBlock{Name: fetchPlan.ProviderConfigurationName},
+		})
+	}
+
+	report, d := MakeExplainReport(context.Background(), &ModuleExplainerOptions{
+		Plan:               modulePlan,
+		ProviderFetchPlans: fetchPlans,
+		ProviderExpandMap:  providerExpandMap,
+		Variables: []*planner.RootVariable{
+			{Key: "min_size", Value: "100", Source: planner.VariableSourceVar, From: "--var"},
+			{Key: "password", Value: "s3cr3t", Source: planner.VariableSourceEnv, From: "SELEFRA_VAR_password"},
+		},
+	})
+	assert.False(t, d.HasError())
+	assert.Len(t, report.Variables, 2)
+	assert.Equal(t, 100, report.Variables[0].Value)
+	assert.Equal(t, planner.SensitiveValueMask, report.Variables[1].Value)
+	assert.Len(t, report.FetchPlans, 3)
+	assert.Equal(t, []string{"gcp_compute_instances"}, report.FetchPlans[2].SelectedTables)
+	assert.Len(t, report.RulePlans, 1)
+
+	ruleExplain := report.RulePlans[0]
+	assert.Equal(t, "R-001", ruleExplain.Id)
+	assert.Equal(t, []string{"aws", "gcp"}, ruleExplain.BindingProviders)
+	assert.Equal(t, 100, ruleExplain.Variables["min_size"])
+	assert.Contains(t, ruleExplain.Query, "v.size > 100")
+	// Every aws schema is joined with every gcp schema
+	assert.Len(t, ruleExplain.Executions, 2)
+	assert.Equal(t, "aws_b", ruleExplain.Executions[1].Targets[0].Schema)
+	assert.Equal(t, "gcp_a", ruleExplain.Executions[1].Targets[1].Configuration)
+	assert.Contains(t, ruleExplain.Executions[1].Query, `"aws_b".aws_ec2_ebs_volumes`)
+	assert.Contains(t, ruleExplain.Executions[1].Query, `"gcp_a".gcp_compute_instances`)
+	assert.Contains(t, ruleExplain.Executions[1].Query, "i.password <> '(sensitive)'")
+	assert.NotContains(t, ruleExplain.Query, "s3cr3t")
+
+	tree, err := report.Render(ExplainFormatTree)
+	assert.Nil(t, err)
+	assert.Contains(t, tree, "volume_of_instance:R-001 [High]")
+	assert.Contains(t, tree, "    execution 2: aws=aws_b, gcp=gcp_a\n")
+	assert.Contains(t, tree, "      min_size = 100\n")
+	assert.Contains(t, tree, "  min_size = 100 (var --var)\n")
+	assert.Contains(t, tree, "      password = (sensitive)\n")
+	assert.NotContains(t, tree, "s3cr3t")
+
+	content, err := report.Render(ExplainFormatJSON)
+	assert.Nil(t, err)
+	decoded := &ExplainReport{}
+	assert.Nil(t, json.Unmarshal([]byte(content), decoded))
+	assert.Equal(t, ruleExplain.Executions[0].Query, decoded.RulePlans[0].Executions[0].Query)
+}
+
+func TestParseExplainFormat(t *testing.T) {
+	format, err := ParseExplainFormat("")
+	assert.Nil(t, err)
+	assert.Equal(t, ExplainFormatTree, format)
+	format, err = ParseExplainFormat("JSON")
+	assert.Nil(t, err)
+	assert.Equal(t, ExplainFormatJSON, format)
+	_, err = ParseExplainFormat("yaml")
+	assert.NotNil(t, err)
+}
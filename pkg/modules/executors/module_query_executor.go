@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/hashicorp/go-getter"
 	"github.com/selefra/selefra-provider-sdk/grpc/shard"
@@ -14,15 +15,19 @@ import (
 	"github.com/selefra/selefra/pkg/modules/module"
 	"github.com/selefra/selefra/pkg/modules/planner"
 	"github.com/selefra/selefra/pkg/registry"
+	"github.com/selefra/selefra/pkg/storage/pgstorage"
 	"github.com/selefra/selefra/pkg/utils"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"text/template"
+	"time"
 )
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -51,10 +56,69 @@ type RuleQueryResult struct {
 	// Which database is being queried
 	Schema string
 
+	// The contexts of all providers the rule query is bound to, one per binding provider, the first one is also used for the fields above
+	BindingProviderContexts []*planner.ProviderContext
+
 	// Find the row of data in issue
 	Row *schema.Row
 
 	Status issue.UploadIssueStream_Rule_Status
+
+	// Identifies the same finding across applies, not set for passed results
+	Fingerprint string
+
+	// How the finding compares with the baseline, not set for passed results or when there is no baseline
+	BaselineStatus FindingBaselineStatus
+
+	// The waiver that suppresses the finding, a suppressed finding is not counted, nil if the finding is reported
+	Waiver *module.WaiverBlock
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// RulePlanStatus How the execution of a rule plan ended
+type RulePlanStatus string
+
+const (
+
+	// RulePlanStatusPassed The rule found nothing
+	RulePlanStatusPassed RulePlanStatus = "passed"
+
+	// RulePlanStatusFailed The rule found something
+	RulePlanStatusFailed RulePlanStatus = "failed"
+
+	// RulePlanStatusError The rule could not be executed, some of its queries failed
+	RulePlanStatusError RulePlanStatus = "error"
+
+	// RulePlanStatusTimeout The query of the rule ran longer than its timeout on some storage, its findings may be incomplete
+	RulePlanStatusTimeout RulePlanStatus = "timeout"
+
+	// RulePlanStatusSkipped The rule was not executed because of the filter of its module, or because the run was cancelled
+	RulePlanStatusSkipped RulePlanStatus = "skipped"
+)
+
+// RulePlanResult Indicates the result of a rule plan as a whole, one per rule plan, while RuleQueryResult is one per row
+type RulePlanResult struct {
+
+	// The rule plan that was executed
+	RulePlan *planner.RulePlan
+
+	Status RulePlanStatus
+
+	// How many findings the rule reported
+	FindingsCount int
+
+	// The findings suppressed by waivers, they are not counted in FindingsCount
+	Suppressed []*RuleQueryResult
+
+	// The rendered outputs of the findings
+	Output string
+
+	// Why the rule failed to execute or was skipped
+	Messages []string
+
+	// How long the rule took
+	Duration time.Duration
 }
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -74,26 +138,46 @@ type ModuleQueryExecutorOptions struct {
 	// The rules detected during query execution are put into this channel
 	RuleQueryResultChannel *message.Channel[*RuleQueryResult]
 
+	// Optional, the result of every rule plan is put into this channel once the rule is executed or skipped
+	RulePlanResultChannel *message.Channel[*RulePlanResult]
+
 	// Tracking installation progress
 	ProgressTracker getter.ProgressTracker
 
 	// Used to communicate with the provider
 	ProviderInformationMap map[string]*shard.GetProviderInformationResponse
 
-	// Each Provider may have multiple Fetch tasks. As long as the policy is bound to the Provider, the policy must be executed for all Storage of the Provider
+	// Each Provider may have multiple Fetch tasks. As long as the policy is bound to the Provider, the policy must be executed for all Storage of the Provider,
+	// a policy bound to several providers is executed for every combination of their Storage
 	ProviderExpandMap map[string][]*planner.ProviderContext
 
 	// The number of concurrent queries used
 	WorkerNum uint64
+
+	// If set, the findings are compared with the baseline and only new findings are reported
+	Baseline *FindingsBaseline
 }
 
 // ------------------------------------------------- --------------------------------------------------------------------
 
 const ModuleQueryExecutorName = "module-query-executor"
 
+// DefaultQueryWorkerNum How many rule queries are executed at the same time by default
+const DefaultQueryWorkerNum uint64 = 8
+
 type ModuleQueryExecutor struct {
 	options *ModuleQueryExecutorOptions
 
+	// The number of issues of each severity, summed up from all workers
+	severityCountLock sync.Mutex
+	severityCountMap  map[string]int
+
+	// The number of rules that failed to execute
+	errorRuleCount atomic.Int64
+
+	// The number of rules whose query ran longer than their timeout
+	timeoutRuleCount atomic.Int64
+
 	//ruleMetricCounter *RuleMetricCounter
 	//ruleMetricChannel chan *RuleMetric
 }
@@ -102,7 +186,8 @@ var _ Executor = &ModuleQueryExecutor{}
 
 func NewModuleQueryExecutor(options *ModuleQueryExecutorOptions) *ModuleQueryExecutor {
 	return &ModuleQueryExecutor{
-		options: options,
+		options:          options,
+		severityCountMap: make(map[string]int),
 		//ruleMetricCounter: NewRuleMetricCounter(),
 		//ruleMetricChannel: make(chan *RuleMetric, 100),
 	}
@@ -112,6 +197,37 @@ func (x *ModuleQueryExecutor) Name() string {
 	return ModuleQueryExecutorName
 }
 
+// GetSeverityCountMap The number of issues of each severity found by the rules, only new issues are counted when compared with a baseline
+func (x *ModuleQueryExecutor) GetSeverityCountMap() map[string]int {
+	x.severityCountLock.Lock()
+	defer x.severityCountLock.Unlock()
+
+	severityCountMap := make(map[string]int, len(x.severityCountMap))
+	for severity, count := range x.severityCountMap {
+		severityCountMap[severity] = count
+	}
+	return severityCountMap
+}
+
+// GetErrorRuleCount The number of rules that failed to execute
+func (x *ModuleQueryExecutor) GetErrorRuleCount() int {
+	return int(x.errorRuleCount.Load())
+}
+
+// GetTimeoutRuleCount The number of rules whose query ran longer than their timeout
+func (x *ModuleQueryExecutor) GetTimeoutRuleCount() int {
+	return int(x.timeoutRuleCount.Load())
+}
+
+func (x *ModuleQueryExecutor) addSeverityCount(severityCountMap map[string]int) {
+	x.severityCountLock.Lock()
+	defer x.severityCountLock.Unlock()
+
+	for severity, count := range severityCountMap {
+		x.severityCountMap[severity] += count
+	}
+}
+
 // ------------------------------------------------- --------------------------------------------------------------------
 
 //func (x *ModuleQueryExecutor) StartMetricWorker() {
@@ -132,6 +248,9 @@ func (x *ModuleQueryExecutor) Execute(ctx context.Context) *schema.Diagnostics {
 	defer func() {
 		x.options.MessageChannel.SenderWaitAndClose()
 		x.options.RuleQueryResultChannel.SenderWaitAndClose()
+		if x.options.RulePlanResultChannel != nil {
+			x.options.RulePlanResultChannel.SenderWaitAndClose()
+		}
 	}()
 
 	rulePlanSlice := x.makeRulePlanSlice(ctx, x.options.Plan)
@@ -139,48 +258,159 @@ func (x *ModuleQueryExecutor) Execute(ctx context.Context) *schema.Diagnostics {
 		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("module %s no rule need query", x.options.Plan.BuildFullName()))
 		return nil
 	}
-	channel := x.toRulePlanChannel(rulePlanSlice)
-	x.RunQueryWorker(ctx, channel)
+	rulePlanSlice = x.filterRulePlanSlice(rulePlanSlice)
+	x.sendLoadedMessage(rulePlanSlice)
+	x.RunQueryWorker(ctx, x.toRuleQueryTaskChannel(rulePlanSlice))
+	x.sendSummaryMessage()
+
+	// The rules not executed yet are skipped when the run is cancelled, such as by Ctrl-C
+	if ctx.Err() != nil {
+		return schema.NewDiagnostics().AddErrorMsg("query is cancelled: %s", ctx.Err().Error())
+	}
+
+	// Resolved findings are known only after all rules are executed
+	if x.options.Baseline != nil {
+		x.options.MessageChannel.Send(x.options.Baseline.Summary())
+	}
 
 	//close(x.ruleMetricChannel)
 
 	return nil
 }
 
-func (x *ModuleQueryExecutor) RunQueryWorker(ctx context.Context, channel chan *planner.RulePlan) {
+// RunQueryWorker The tasks are spread over WorkerNum workers, returns when all of them are executed
+func (x *ModuleQueryExecutor) RunQueryWorker(ctx context.Context, channel chan *ruleQueryTask) {
+	workerNum := x.options.WorkerNum
+	if workerNum == 0 {
+		workerNum = 1
+	}
 	wg := sync.WaitGroup{}
-	for i := uint64(0); i < x.options.WorkerNum; i++ {
+	for i := uint64(0); i < workerNum; i++ {
 		wg.Add(1)
 		NewModuleQueryExecutorWorker(x, channel, &wg).Run(ctx)
 	}
 	wg.Wait()
 }
 
-func (x *ModuleQueryExecutor) toRulePlanChannel(rulePlanSlice []*planner.RulePlan) chan *planner.RulePlan {
-	rulePlanChannel := make(chan *planner.RulePlan, len(rulePlanSlice))
+// The filters of the modules blocks are applied all the way down the module tree, the filtered rules are reported as skipped
+func (x *ModuleQueryExecutor) filterRulePlanSlice(rulePlanSlice []*planner.RulePlan) []*planner.RulePlan {
+	filteredRulePlanSlice := make([]*planner.RulePlan, 0, len(rulePlanSlice))
 	for _, rulePlan := range rulePlanSlice {
-		var filters []module.Filter
-		if rulePlan.Module != nil && rulePlan.Module.ParentModule != nil {
-			for _, pm := range rulePlan.Module.ParentModule.ModulesBlock {
-				filters = append(filters, pm.Filter...)
+		if rulePlan.Module != nil {
+			if moduleBlock := rulePlan.Module.RuleExcludedBy(rulePlan.RuleBlock); moduleBlock != nil {
+				x.sendRulePlanResult(&RulePlanResult{
+					RulePlan: rulePlan,
+					Status:   RulePlanStatusSkipped,
+					Messages: []string{fmt.Sprintf("rule %s is filtered by module %s", rulePlan.RuleBlock.Name, moduleBlock.Name)},
+				})
+				continue
 			}
 		}
+		filteredRulePlanSlice = append(filteredRulePlanSlice, rulePlan)
+	}
+	return filteredRulePlanSlice
+}
 
-		filterFlag := false
+// Every rule is executed once for each combination of the provider storages it is bound to, all these executions of all rules are
+// put into the task queue, so a rule bound to many storages does not keep a single worker busy
+func (x *ModuleQueryExecutor) toRuleQueryTaskChannel(rulePlanSlice []*planner.RulePlan) chan *ruleQueryTask {
+	reporter := newRuleExecutionReporter(x, len(rulePlanSlice))
+	tasks := make([]*ruleQueryTask, 0, len(rulePlanSlice))
+	for index, rulePlan := range rulePlanSlice {
+		providerContextsSlice := x.expandProviderContexts(rulePlan)
+		execution := newRuleExecution(index, rulePlan, len(providerContextsSlice), reporter)
+		for taskIndex, providerContexts := range providerContextsSlice {
+			tasks = append(tasks, &ruleQueryTask{
+				execution:        execution,
+				index:            taskIndex,
+				providerContexts: providerContexts,
+			})
+		}
+		// A rule that is not bound to any storage has nothing to query, it passes
+		if len(providerContextsSlice) == 0 {
+			reporter.done(execution)
+		}
+	}
 
-		for _, filter := range filters {
-			if filter.Name == rulePlan.RuleBlock.Name {
-				filterFlag = true
-				break
+	ruleQueryTaskChannel := make(chan *ruleQueryTask, len(tasks))
+	for _, task := range tasks {
+		ruleQueryTaskChannel <- task
+	}
+	close(ruleQueryTaskChannel)
+	return ruleQueryTaskChannel
+}
+
+// Which provider contexts the rule should be executed on, each element is one execution
+func (x *ModuleQueryExecutor) expandProviderContexts(rulePlan *planner.RulePlan) [][]*planner.ProviderContext {
+	providerExpandMap := x.options.ProviderExpandMap
+
+	// The gpt rule is not bound to a table, so it is analyzed on every storage
+	if !isSql(rulePlan.Query) {
+		providerNameSlice := make([]string, 0, len(providerExpandMap))
+		for providerName := range providerExpandMap {
+			providerNameSlice = append(providerNameSlice, providerName)
+		}
+		sort.Strings(providerNameSlice)
+		providerContextsSlice := make([][]*planner.ProviderContext, 0)
+		for _, providerName := range providerNameSlice {
+			for _, providerContext := range providerExpandMap[providerName] {
+				providerContextsSlice = append(providerContextsSlice, []*planner.ProviderContext{providerContext})
 			}
 		}
-		if filterFlag {
-			continue
+		return providerContextsSlice
+	}
+
+	return rulePlan.ExpandProviderContexts(providerExpandMap)
+}
+
+func (x *ModuleQueryExecutor) sendRulePlanResult(result *RulePlanResult) {
+	if x.options.RulePlanResultChannel != nil {
+		x.options.RulePlanResultChannel.Send(result)
+	}
+}
+
+func (x *ModuleQueryExecutor) sendMessage(diagnostics *schema.Diagnostics) {
+	if utils.IsNotEmpty(diagnostics) {
+		x.options.MessageChannel.Send(diagnostics)
+	}
+}
+
+// The rules to execute, printed once before the workers start
+func (x *ModuleQueryExecutor) sendLoadedMessage(rulePlanSlice []*planner.RulePlan) {
+	x.sendMessage(schema.NewDiagnostics().AddInfo("Selefra will load and apply selefra policy with sql and prompt...\n"))
+	x.sendMessage(schema.NewDiagnostics().AddInfo("Loading and initializing Selefra policy...\n"))
+	secRuleMap := make(map[string]int)
+	for _, rulePlan := range rulePlanSlice {
+		if rulePlan.MetadataBlock != nil {
+			secRuleMap[rulePlan.MetadataBlock.Severity]++
 		}
-		rulePlanChannel <- rulePlan
+		x.sendMessage(schema.NewDiagnostics().AddInfo("\t- \"%s\" Rule Completed", rulePlan.RuleBlock.Name))
 	}
-	close(rulePlanChannel)
-	return rulePlanChannel
+	x.sendMessage(schema.NewDiagnostics().AddInfo("\nLoaded: %d policies to loaded, %s.\n", len(rulePlanSlice), formatSeverityCount(secRuleMap)))
+}
+
+// The issues found by all workers, printed once after all rules are executed
+func (x *ModuleQueryExecutor) sendSummaryMessage() {
+	secMap := x.GetSeverityCountMap()
+	total := 0
+	for _, count := range secMap {
+		total += count
+	}
+	x.sendMessage(schema.NewDiagnostics().AddInfo("Summary: Total %d Issues, %s.\n", total, formatSeverityCount(secMap)))
+	if timeoutRuleCount := x.GetTimeoutRuleCount(); timeoutRuleCount > 0 {
+		x.sendMessage(schema.NewDiagnostics().AddWarn("%d rules timed out, their findings may be incomplete", timeoutRuleCount))
+	}
+}
+
+// Such as "1 Critical , 2 High , 0 Medium , 0 Low , 0 Informational", colored by severity
+func formatSeverityCount(severityCountMap map[string]int) string {
+	return strings.Join([]string{
+		cli_ui.MagentaColor(fmt.Sprintf("%d Critical", severityCountMap["Critical"])),
+		cli_ui.RedColor(fmt.Sprintf("%d High", severityCountMap["High"])),
+		cli_ui.YellowColor(fmt.Sprintf("%d Medium", severityCountMap["Medium"])),
+		cli_ui.BlueColor(fmt.Sprintf("%d Low", severityCountMap["Low"])),
+		cli_ui.GreenColor(fmt.Sprintf("%d Informational", severityCountMap["Informational"])),
+	}, " , ")
 }
 
 // All the rule execution plans of the module and submodules are levelled and then placed in a task queue
@@ -203,83 +433,107 @@ func (x *ModuleQueryExecutor) makeRulePlanSlice(ctx context.Context, modulePlan
 
 // ------------------------------------------------- --------------------------------------------------------------------
 
-type ModuleQueryExecutorWorker struct {
-	ruleChannel chan *planner.RulePlan
-	wg          *sync.WaitGroup
+// ruleQueryTask One execution of a rule on a combination of provider storages, it is the unit of work of the query workers
+type ruleQueryTask struct {
+	execution *ruleExecution
 
-	moduleQueryExecutor *ModuleQueryExecutor
+	// The position of the task among the executions of the rule
+	index int
+
+	providerContexts []*planner.ProviderContext
 }
 
-func NewModuleQueryExecutorWorker(moduleQueryExecutor *ModuleQueryExecutor, rulePlanChannel chan *planner.RulePlan, wg *sync.WaitGroup) *ModuleQueryExecutorWorker {
-	return &ModuleQueryExecutorWorker{
-		ruleChannel:         rulePlanChannel,
-		wg:                  wg,
-		moduleQueryExecutor: moduleQueryExecutor,
+// ruleQueryTaskResult What an execution of a rule found
+type ruleQueryTaskResult struct {
+	output     string
+	num        int
+	suppressed []*RuleQueryResult
+	err        error
+}
+
+// ruleExecution Collects the results of all executions of a rule, the rule is reported when the last of them is done
+type ruleExecution struct {
+
+	// The position of the rule in the plan, the rules are reported in this order
+	index int
+
+	rulePlan  *planner.RulePlan
+	startTime time.Time
+
+	lock        sync.Mutex
+	results     []*ruleQueryTaskResult
+	pendingTask int
+
+	reporter *ruleExecutionReporter
+}
+
+func newRuleExecution(index int, rulePlan *planner.RulePlan, taskCount int, reporter *ruleExecutionReporter) *ruleExecution {
+	return &ruleExecution{
+		index:       index,
+		rulePlan:    rulePlan,
+		results:     make([]*ruleQueryTaskResult, taskCount),
+		pendingTask: taskCount,
+		reporter:    reporter,
 	}
 }
 
-func (x *ModuleQueryExecutorWorker) Run(ctx context.Context) {
-	go func() {
-		defer func() {
-			x.wg.Done()
-		}()
-		x.sendMessage(schema.NewDiagnostics().AddInfo("Selefra will load and apply selefra policy with sql and prompt...\n"))
-		x.sendMessage(schema.NewDiagnostics().AddInfo("Loading and initializing Selefra policy...\n"))
-		var num int
-		var secRuleMap = make(map[string]int)
-		secRuleMap["Critical"] = 0
-		secRuleMap["High"] = 0
-		secRuleMap["Medium"] = 0
-		secRuleMap["Low"] = 0
-		secRuleMap["Informational"] = 0
-		var secMap = make(map[string]int)
-		secMap["Critical"] = 0
-		secMap["High"] = 0
-		secMap["Medium"] = 0
-		secMap["Low"] = 0
-		secMap["Informational"] = 0
-		var plans []*planner.RulePlan
-		for plan := range x.ruleChannel {
-			plans = append(plans, plan)
-			num++
-			if plan.MetadataBlock != nil {
-				secRuleMap[plan.MetadataBlock.Severity]++
-			}
-			x.sendMessage(schema.NewDiagnostics().AddInfo("\t- \"%s\" Rule Completed", plan.RuleBlock.Name))
-		}
-		Critical := cli_ui.MagentaColor(fmt.Sprintf("%d Critical", secRuleMap["Critical"]))
-		High := cli_ui.RedColor(fmt.Sprintf("%d High", secRuleMap["High"]))
-		Medium := cli_ui.YellowColor(fmt.Sprintf("%d Medium", secRuleMap["Medium"]))
-		Low := cli_ui.BlueColor(fmt.Sprintf("%d Low", secRuleMap["Low"]))
-		Informational := cli_ui.GreenColor(fmt.Sprintf("%d Informational", secRuleMap["Informational"]))
+// The rule starts when the first of its tasks is taken by a worker
+func (x *ruleExecution) start() {
+	x.lock.Lock()
+	defer x.lock.Unlock()
 
-		x.sendMessage(schema.NewDiagnostics().AddInfo("\nLoaded: %d policies to loaded, %s , %s , %s , %s , %s.\n", num, Critical, High, Medium, Low, Informational))
+	if x.startTime.IsZero() {
+		x.startTime = time.Now()
+	}
+}
 
-		for i := range plans {
-			x.execRulePlan(ctx, plans[i], secMap)
-		}
+// Save the result of a task, the rule is handed to the reporter once all its tasks are done
+func (x *ruleExecution) submit(task *ruleQueryTask, result *ruleQueryTaskResult) {
+	x.lock.Lock()
+	x.results[task.index] = result
+	x.pendingTask--
+	isDone := x.pendingTask == 0
+	x.lock.Unlock()
 
-		totel := 0
-		for s := range secMap {
-			totel += secMap[s]
-		}
-		secCritical := cli_ui.MagentaColor(fmt.Sprintf("%d Critical", secMap["Critical"]))
-		secHigh := cli_ui.RedColor(fmt.Sprintf("%d High", secMap["High"]))
-		secMedium := cli_ui.YellowColor(fmt.Sprintf("%d Medium", secMap["Medium"]))
-		secLow := cli_ui.BlueColor(fmt.Sprintf("%d Low", secMap["Low"]))
-		secInformational := cli_ui.GreenColor(fmt.Sprintf("%d Informational", secMap["Informational"]))
+	if isDone {
+		x.reporter.done(x)
+	}
+}
 
-		x.sendMessage(schema.NewDiagnostics().AddInfo("Summary: Total %d Issues, %s , %s , %s , %s , %s.\n", totel, secCritical, secHigh, secMedium, secLow, secInformational))
-	}()
+// ruleExecutionReporter The rules finish in any order, but they are reported in the order of the plan,
+// so the console output, the output files and the rule plan results are the same no matter how many workers there are
+type ruleExecutionReporter struct {
+	moduleQueryExecutor *ModuleQueryExecutor
+
+	lock sync.Mutex
+
+	// The finished rules that wait for the rules before them
+	doneExecutions []*ruleExecution
+	nextIndex      int
 }
 
-func (x *ModuleQueryExecutorWorker) sendMessage(diagnostics *schema.Diagnostics) {
-	if utils.IsNotEmpty(diagnostics) {
-		x.moduleQueryExecutor.options.MessageChannel.Send(diagnostics)
+func newRuleExecutionReporter(moduleQueryExecutor *ModuleQueryExecutor, ruleCount int) *ruleExecutionReporter {
+	return &ruleExecutionReporter{
+		moduleQueryExecutor: moduleQueryExecutor,
+		doneExecutions:      make([]*ruleExecution, ruleCount),
 	}
 }
 
-func (x *ModuleQueryExecutorWorker) execRulePlan(ctx context.Context, rulePlan *planner.RulePlan, secMap map[string]int) {
+func (x *ruleExecutionReporter) done(execution *ruleExecution) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	x.doneExecutions[execution.index] = execution
+	for x.nextIndex < len(x.doneExecutions) && x.doneExecutions[x.nextIndex] != nil {
+		x.report(x.doneExecutions[x.nextIndex])
+		x.doneExecutions[x.nextIndex] = nil
+		x.nextIndex++
+	}
+}
+
+// Write the output file of the rule, and send its result, log and issue count
+func (x *ruleExecutionReporter) report(execution *ruleExecution) {
+	rulePlan := execution.rulePlan
 	Severity := fmt.Sprintf("[%s] ", rulePlan.MetadataBlock.Severity)
 
 	Title := rulePlan.MetadataBlock.Title
@@ -319,30 +573,110 @@ func (x *ModuleQueryExecutorWorker) execRulePlan(ctx context.Context, rulePlan *
 	str += fmt.Sprintf("\nDescription: %s\n", rulePlan.MetadataBlock.Description)
 	str += fmt.Sprintf("Results:\n")
 	var num int
-	storagesMap := x.moduleQueryExecutor.options.ProviderExpandMap
-	for _, storages := range storagesMap {
-		for _, storage := range storages {
-			output, snum := x.execStorageQuery(ctx, rulePlan, storage)
-			if f != nil {
-				f.WriteString(output)
-			}
-			num += snum
-			str += output
-			// TODO Stage log
+	result := &RulePlanResult{
+		RulePlan: rulePlan,
+		Status:   RulePlanStatusPassed,
+	}
+	var errorMessages, timeoutMessages, cancelledMessages []string
+	for _, taskResult := range execution.results {
+		result.Suppressed = append(result.Suppressed, taskResult.suppressed...)
+		switch {
+		case taskResult.err == nil:
+		case errors.Is(taskResult.err, context.Canceled):
+			cancelledMessages = append(cancelledMessages, taskResult.err.Error())
+		case errors.Is(taskResult.err, context.DeadlineExceeded):
+			timeoutMessages = append(timeoutMessages, taskResult.err.Error())
+		default:
+			errorMessages = append(errorMessages, taskResult.err.Error())
+		}
+		if f != nil {
+			f.WriteString(taskResult.output)
 		}
+		num += taskResult.num
+		str += taskResult.output
+		result.Output += taskResult.output
 	}
 
-	// TODO log
+	result.FindingsCount = num
+	if !execution.startTime.IsZero() {
+		result.Duration = time.Since(execution.startTime)
+	}
+	result.Messages = append(append(append(result.Messages, errorMessages...), timeoutMessages...), cancelledMessages...)
+	switch {
+	case len(errorMessages) != 0:
+		result.Status = RulePlanStatusError
+		x.moduleQueryExecutor.errorRuleCount.Add(1)
+	case len(timeoutMessages) != 0:
+		result.Status = RulePlanStatusTimeout
+		x.moduleQueryExecutor.timeoutRuleCount.Add(1)
+	case len(cancelledMessages) != 0:
+		result.Status = RulePlanStatusSkipped
+	case num > 0:
+		result.Status = RulePlanStatusFailed
+	}
+	x.moduleQueryExecutor.sendRulePlanResult(result)
 
-	defer func() {
-		if num > 0 {
-			logStr := fmt.Sprintf(str, num)
-			secMap[rulePlan.RuleBlock.MetadataBlock.Severity] += num
-			x.sendMessage(schema.NewDiagnostics().AddInfo(logStr))
+	if len(result.Suppressed) != 0 {
+		x.moduleQueryExecutor.sendMessage(schema.NewDiagnostics().AddInfo("Rule %s: %d findings are suppressed by waivers", rulePlan.RuleBlock.Name, len(result.Suppressed)))
+	}
+
+	if num > 0 {
+		x.moduleQueryExecutor.addSeverityCount(map[string]int{rulePlan.RuleBlock.MetadataBlock.Severity: num})
+		x.moduleQueryExecutor.sendMessage(schema.NewDiagnostics().AddInfo(fmt.Sprintf(str, num)))
+	}
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+type ModuleQueryExecutorWorker struct {
+	taskChannel chan *ruleQueryTask
+	wg          *sync.WaitGroup
+
+	moduleQueryExecutor *ModuleQueryExecutor
+}
+
+func NewModuleQueryExecutorWorker(moduleQueryExecutor *ModuleQueryExecutor, taskChannel chan *ruleQueryTask, wg *sync.WaitGroup) *ModuleQueryExecutorWorker {
+	return &ModuleQueryExecutorWorker{
+		taskChannel:         taskChannel,
+		wg:                  wg,
+		moduleQueryExecutor: moduleQueryExecutor,
+	}
+}
+
+// Run Take the tasks from the queue until it is empty, the workers share the queue so the tasks are executed in parallel
+func (x *ModuleQueryExecutorWorker) Run(ctx context.Context) {
+	go func() {
+		defer func() {
+			x.wg.Done()
+		}()
+		for task := range x.taskChannel {
+			x.execRuleQueryTask(ctx, task)
 		}
 	}()
 }
 
+func (x *ModuleQueryExecutorWorker) sendMessage(diagnostics *schema.Diagnostics) {
+	x.moduleQueryExecutor.sendMessage(diagnostics)
+}
+
+func (x *ModuleQueryExecutorWorker) execRuleQueryTask(ctx context.Context, task *ruleQueryTask) {
+	// Once the run is cancelled the tasks left in the queue are drained without querying
+	if ctx.Err() != nil {
+		task.execution.submit(task, &ruleQueryTaskResult{
+			err: fmt.Errorf("rule %s is cancelled: %w", task.execution.rulePlan.String(), ctx.Err()),
+		})
+		return
+	}
+	task.execution.start()
+	output, num, suppressed, err := x.execStorageQuery(ctx, task.execution.rulePlan, task.providerContexts)
+	task.execution.submit(task, &ruleQueryTaskResult{
+		output:     output,
+		num:        num,
+		suppressed: suppressed,
+		err:        err,
+	})
+}
+
 func isSql(query string) bool {
 	query = strings.ToLower(query)
 	if strings.Contains(query, "select") {
@@ -384,15 +718,29 @@ func (x *ModuleQueryExecutorWorker) FmtOutputStr(rule *module.RuleBlock, provide
 	return logStr
 }
 
-func (x *ModuleQueryExecutorWorker) execStorageQuery(ctx context.Context, rulePlan *planner.RulePlan, providerContext *planner.ProviderContext) (outputStr string, num int) {
+// The query is executed on the storage of the first provider context, the tables of the other providers are addressed by their schema
+func (x *ModuleQueryExecutorWorker) execStorageQuery(ctx context.Context, rulePlan *planner.RulePlan, providerContexts []*planner.ProviderContext) (outputStr string, num int, suppressed []*RuleQueryResult, err error) {
+	providerContext := providerContexts[0]
 	// Query whether it is gpt through query statement
 	resultStr := ""
 	if isSql(rulePlan.Query) {
-		resultSet, diagnostics := providerContext.Storage.Query(ctx, rulePlan.Query)
+		var timeout time.Duration
+		if rulePlan.Module != nil {
+			timeout = rulePlan.Module.RuleTimeout(rulePlan.RuleBlock)
+		}
+		queryCtx := ctx
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			queryCtx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		startTime := time.Now()
+
+		resultSet, diagnostics := pgstorage.QueryWithStatementTimeout(queryCtx, providerContext.Storage, rulePlan.BuildQuery(providerContexts), timeout)
 		if utils.HasError(diagnostics) {
-			x.sendMessage(schema.NewDiagnostics().AddErrorMsg("rule %s exec error: %s", rulePlan.String(), diagnostics.ToString()))
-			return "", 0
+			return "", 0, nil, x.queryError(ctx, queryCtx, rulePlan, timeout, startTime, diagnostics)
 		}
+		defer resultSet.Close()
 
 		// TODO Print log prompt
 		//x.moduleQueryExecutor.options.MessageChannel <- schema.NewDiagnostics().AddInfo("")
@@ -410,20 +758,31 @@ func (x *ModuleQueryExecutorWorker) execStorageQuery(ctx context.Context, rulePl
 			rows, d := resultSet.ReadRows(100)
 			if rows != nil {
 				for _, row := range rows.SplitRowByRow() {
-					result := x.processRuleRow(ctx, rulePlan, providerContext, row, issue.UploadIssueStream_Rule_FAILED)
+					result := x.processRuleRow(ctx, rulePlan, providerContexts, row, issue.UploadIssueStream_Rule_FAILED)
 					if result == nil {
 						continue
 					}
-					num++
+					// Every failing resource is kept out of the passed ones below, even if it is not reported
 					resource_id, ok := result.RuleBlock.Labels["resource_id"].(string)
 					if ok {
 						resource_ids = append(resource_ids, resource_id)
 					}
+					// Accepted risks are listed as suppressed, not reported
+					if result.Waiver != nil {
+						suppressed = append(suppressed, result)
+						continue
+					}
+					// Only new findings are reported when compared with a baseline
+					if result.BaselineStatus == FindingBaselineStatusUnchanged {
+						continue
+					}
+					num++
 					resultStr += x.FmtOutputStr(result.RuleBlock, providerContext)
 				}
 			}
+			// The rows read before the query failed are reported, but the rule is not complete
 			if utils.HasError(d) {
-				x.sendMessage(d)
+				return resultStr, num, suppressed, x.queryError(ctx, queryCtx, rulePlan, timeout, startTime, d)
 			}
 			if rows == nil || rows.RowCount() == 0 {
 				break
@@ -431,24 +790,25 @@ func (x *ModuleQueryExecutorWorker) execStorageQuery(ctx context.Context, rulePl
 		}
 
 		if strings.TrimSpace(rulePlan.RuleBlock.MetadataBlock.MainTable) == "" && (resource_id_key == "" || resource_id_key == "no available") {
-			return
+			return resultStr, num, suppressed, nil
 		}
 		for i := range resource_ids {
 			resource_ids[i] = fmt.Sprintf("'%s'", resource_ids[i])
 		}
-		safeQueryTemp := fmt.Sprintf("SELECT * FROM %s WHERE \"%s\" NOT IN (%s)", rulePlan.RuleBlock.MetadataBlock.MainTable, resource_id_key, strings.Join(resource_ids, ","))
+		mainTable := rulePlan.QualifyTable(rulePlan.RuleBlock.MetadataBlock.MainTable, providerContexts)
+		safeQueryTemp := fmt.Sprintf("SELECT * FROM %s WHERE \"%s\" NOT IN (%s)", mainTable, resource_id_key, strings.Join(resource_ids, ","))
 
-		safeSet, diagnostics := providerContext.Storage.Query(ctx, safeQueryTemp)
+		safeSet, diagnostics := pgstorage.QueryWithStatementTimeout(queryCtx, providerContext.Storage, safeQueryTemp, timeout)
 		if utils.HasError(diagnostics) {
-			x.sendMessage(schema.NewDiagnostics().AddErrorMsg("rule %s exec error: %s", rulePlan.String(), diagnostics.ToString()))
-			return "", 0
+			return "", 0, nil, x.queryError(ctx, queryCtx, rulePlan, timeout, startTime, diagnostics)
 		}
+		defer safeSet.Close()
 
 		for {
 			rows, d := safeSet.ReadRows(100)
 			if rows != nil {
 				for _, row := range rows.SplitRowByRow() {
-					_ = x.processRuleRow(ctx, rulePlan, providerContext, row, issue.UploadIssueStream_Rule_SUCCESS)
+					_ = x.processRuleRow(ctx, rulePlan, providerContexts, row, issue.UploadIssueStream_Rule_SUCCESS)
 				}
 			}
 			if utils.HasError(d) {
@@ -466,7 +826,7 @@ func (x *ModuleQueryExecutorWorker) execStorageQuery(ctx context.Context, rulePl
 		typeRes, err := utils.OpenApiClient(ctx, openaiApiKey, openaiMode, "type", rulePlan.Query)
 		if err != nil {
 			fmt.Println(err.Error())
-			return "", 0
+			return "", 0, nil, err
 		}
 		tar := strings.Split(typeRes, " & ")
 		ty := tar[0]
@@ -484,7 +844,7 @@ AND table_schema = '%s';`
 		resultSet, diagnostics := providerContext.Storage.Query(ctx, schameSql)
 		if utils.HasError(diagnostics) {
 			x.sendMessage(schema.NewDiagnostics().AddErrorMsg("rule %s exec error: %s", rulePlan.String(), diagnostics.ToString()))
-			return "", 0
+			return "", 0, nil, fmt.Errorf("rule %s exec error: %s", rulePlan.String(), diagnostics.ToString())
 		}
 		for {
 			rows, d := resultSet.ReadRows(-1)
@@ -513,13 +873,13 @@ AND table_schema = '%s';`
 		tables, err := x.filterTables(ctx, tableNames, ty, openaiApiKey, openaiMode, rulePlan)
 		if err != nil {
 			fmt.Println(err)
-			return "", 0
+			return "", 0, nil, err
 		}
 		tables = utils.RemoveRepeatedElement(tables)
 		columnMap, err := x.filterColumns(ctx, tables, providerContext, ty, openaiApiKey, openaiMode, rulePlan)
 		if err != nil {
 			fmt.Println(err)
-			return "", 0
+			return "", 0, nil, err
 		}
 		for k := range columnMap {
 			if len(columnMap[k]) == 0 {
@@ -529,7 +889,7 @@ AND table_schema = '%s';`
 		rows, err := x.getRows(ctx, columnMap, providerContext, openaiLimit, rulePlan)
 		if err != nil {
 			fmt.Println(err)
-			return "", 0
+			return "", 0, nil, err
 		}
 		limit := int(openaiLimit)
 		if len(rows) < int(openaiLimit) {
@@ -538,14 +898,31 @@ AND table_schema = '%s';`
 		resultStr, num, err = x.getIssue(ctx, rows[:limit], openaiApiKey, openaiMode, ty, provider, tableName, *rulePlan, providerContext)
 		if err != nil {
 			fmt.Println(err)
-			return "", 0
+			return "", 0, nil, err
 		}
 	}
-	return resultStr, num
+	return resultStr, num, suppressed, nil
+}
+
+// Why the query of the rule failed: the run is cancelled, the query ran longer than the timeout of the rule, or the query itself is wrong.
+// The timeout is raised either by the deadline of the context or by the statement_timeout of postgresql, whichever comes first
+func (x *ModuleQueryExecutorWorker) queryError(ctx, queryCtx context.Context, rulePlan *planner.RulePlan, timeout time.Duration, startTime time.Time, diagnostics *schema.Diagnostics) error {
+	switch {
+	case ctx.Err() != nil:
+		return fmt.Errorf("rule %s is cancelled: %w", rulePlan.String(), ctx.Err())
+	case timeout > 0 && (queryCtx.Err() != nil || time.Since(startTime) >= timeout):
+		err := fmt.Errorf("rule %s timed out after %s: %w", rulePlan.String(), timeout, context.DeadlineExceeded)
+		x.sendMessage(schema.NewDiagnostics().AddWarn(err.Error()))
+		return err
+	default:
+		x.sendMessage(schema.NewDiagnostics().AddErrorMsg("rule %s exec error: %s", rulePlan.String(), diagnostics.ToString()))
+		return fmt.Errorf("rule %s exec error: %s", rulePlan.String(), diagnostics.ToString())
+	}
 }
 
 // Process the row queried by the rule
-func (x *ModuleQueryExecutorWorker) processRuleRow(ctx context.Context, rulePlan *planner.RulePlan, storage *planner.ProviderContext, row *schema.Row, Status issue.UploadIssueStream_Rule_Status) *RuleQueryResult {
+func (x *ModuleQueryExecutorWorker) processRuleRow(ctx context.Context, rulePlan *planner.RulePlan, providerContexts []*planner.ProviderContext, row *schema.Row, Status issue.UploadIssueStream_Rule_Status) *RuleQueryResult {
+	storage := providerContexts[0]
 	rowScope := planner.ExtendScope(rulePlan.RuleScope)
 
 	// Inject the queried rows into the scope
@@ -562,15 +939,22 @@ func (x *ModuleQueryExecutorWorker) processRuleRow(ctx context.Context, rulePlan
 	}
 
 	result := &RuleQueryResult{
-		Instructions:          x.moduleQueryExecutor.options.Plan.Instruction,
-		Module:                rulePlan.Module,
-		RulePlan:              rulePlan,
-		RuleBlock:             ruleBlockResult,
-		Provider:              registry.NewProvider(storage.ProviderName, storage.ProviderVersion),
-		ProviderConfiguration: storage.ProviderConfiguration,
-		Schema:                storage.Schema,
-		Row:                   row,
-		Status:                Status,
+		Instructions:            x.moduleQueryExecutor.options.Plan.Instruction,
+		Module:                  rulePlan.Module,
+		RulePlan:                rulePlan,
+		RuleBlock:               ruleBlockResult,
+		Provider:                registry.NewProvider(storage.ProviderName, storage.ProviderVersion),
+		ProviderConfiguration:   storage.ProviderConfiguration,
+		Schema:                  storage.Schema,
+		BindingProviderContexts: providerContexts,
+		Row:                     row,
+		Status:                  Status,
+	}
+	if Status != issue.UploadIssueStream_Rule_SUCCESS {
+		result.Waiver = rulePlan.Module.FindWaiver(ruleBlockResult, time.Now())
+		if result.Waiver == nil {
+			x.compareWithBaseline(result)
+		}
 	}
 	x.moduleQueryExecutor.options.RuleQueryResultChannel.Send(result)
 	return result
@@ -578,6 +962,14 @@ func (x *ModuleQueryExecutorWorker) processRuleRow(ctx context.Context, rulePlan
 
 }
 
+// Fingerprint the finding and classify it against the baseline if there is one
+func (x *ModuleQueryExecutorWorker) compareWithBaseline(result *RuleQueryResult) {
+	result.Fingerprint = NewFindingFromRuleQueryResult(result).Fingerprint
+	if x.moduleQueryExecutor.options.Baseline != nil {
+		result.BaselineStatus = x.moduleQueryExecutor.options.Baseline.Classify(result.Fingerprint)
+	}
+}
+
 func (x *ModuleQueryExecutorWorker) renderRule(ctx context.Context, rulePlan *planner.RulePlan, rowScope *planner.Scope) (*module.RuleBlock, *schema.Diagnostics) {
 
 	diagnostics := schema.NewDiagnostics()
@@ -980,17 +1372,19 @@ func (x *ModuleQueryExecutorWorker) getIssue(ctx context.Context, rows []*schema
 			}
 
 			result := &RuleQueryResult{
-				Instructions:          x.moduleQueryExecutor.options.Plan.Instruction,
-				Module:                rulePlan.Module,
-				RulePlan:              &rulePlan,
-				RuleBlock:             ruleBlockResult,
-				Provider:              registry.NewProvider(providerContext.ProviderName, providerContext.ProviderVersion),
-				ProviderConfiguration: providerContext.ProviderConfiguration,
-				Schema:                providerContext.Schema,
-				Row:                   row,
+				Instructions:            x.moduleQueryExecutor.options.Plan.Instruction,
+				Module:                  rulePlan.Module,
+				RulePlan:                &rulePlan,
+				RuleBlock:               ruleBlockResult,
+				Provider:                registry.NewProvider(providerContext.ProviderName, providerContext.ProviderVersion),
+				ProviderConfiguration:   providerContext.ProviderConfiguration,
+				Schema:                  providerContext.Schema,
+				BindingProviderContexts: []*planner.ProviderContext{providerContext},
+				Row:                     row,
 			}
+			x.compareWithBaseline(result)
 
-			if result != nil {
+			if result.BaselineStatus != FindingBaselineStatusUnchanged {
 				num++
 				resultStr += x.FmtOutputStr(result.RuleBlock, providerContext)
 			}
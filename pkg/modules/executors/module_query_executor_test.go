@@ -4,6 +4,8 @@ import (
 	"context"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
 	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/modules/planner"
 	"github.com/selefra/selefra/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -45,3 +47,50 @@ func TestModuleQueryExecutor_Execute(t *testing.T) {
 	assert.False(t, utils.HasError(d))
 
 }
+
+func TestRuleExecutionReporter(t *testing.T) {
+	var rulePlanResults []*RulePlanResult
+	rulePlanResultChannel := message.NewChannel[*RulePlanResult](func(index int, message *RulePlanResult) {
+		rulePlanResults = append(rulePlanResults, message)
+	})
+	messageChannel := message.NewChannel[*schema.Diagnostics](func(index int, message *schema.Diagnostics) {})
+	executor := NewModuleQueryExecutor(&ModuleQueryExecutorOptions{
+		Plan:                  &planner.ModulePlan{},
+		MessageChannel:        messageChannel,
+		RulePlanResultChannel: rulePlanResultChannel,
+	})
+
+	newRulePlan := func(name, severity string) *planner.RulePlan {
+		ruleBlock := &module.RuleBlock{
+			Name:          name,
+			MetadataBlock: &module.RuleMetadataBlock{Title: name, Severity: severity},
+		}
+		return &planner.RulePlan{RuleBlock: ruleBlock}
+	}
+	reporter := newRuleExecutionReporter(executor, 3)
+	executions := []*ruleExecution{
+		newRuleExecution(0, newRulePlan("rule_a", "High"), 2, reporter),
+		newRuleExecution(1, newRulePlan("rule_b", "Low"), 1, reporter),
+		newRuleExecution(2, newRulePlan("rule_c", "High"), 1, reporter),
+	}
+
+	// The rules finish in reverse order, and the tasks of the first rule finish in reverse order too
+	executions[2].submit(&ruleQueryTask{execution: executions[2]}, &ruleQueryTaskResult{output: "c\n", num: 1})
+	executions[1].submit(&ruleQueryTask{execution: executions[1]}, &ruleQueryTaskResult{})
+	assert.Empty(t, executor.GetSeverityCountMap())
+	executions[0].submit(&ruleQueryTask{execution: executions[0], index: 1}, &ruleQueryTaskResult{output: "a2\n", num: 1})
+	executions[0].submit(&ruleQueryTask{execution: executions[0], index: 0}, &ruleQueryTaskResult{output: "a1\n", num: 1})
+
+	messageChannel.SenderWaitAndClose()
+	rulePlanResultChannel.SenderWaitAndClose()
+	rulePlanResultChannel.ReceiverWait()
+	messageChannel.ReceiverWait()
+
+	assert.Len(t, rulePlanResults, 3)
+	assert.Equal(t, "rule_a", rulePlanResults[0].RulePlan.Name)
+	assert.Equal(t, "a1\na2\n", rulePlanResults[0].Output)
+	assert.Equal(t, RulePlanStatusFailed, rulePlanResults[0].Status)
+	assert.Equal(t, RulePlanStatusPassed, rulePlanResults[1].Status)
+	assert.Equal(t, "rule_c", rulePlanResults[2].RulePlan.Name)
+	assert.Equal(t, map[string]int{"High": 3}, executor.GetSeverityCountMap())
+}
@@ -6,9 +6,12 @@ import (
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
 	"github.com/selefra/selefra-provider-sdk/storage/database_storage/postgresql_storage"
 	"github.com/selefra/selefra-provider-sdk/storage_factory"
+	"github.com/selefra/selefra/pkg/exit_code"
+	"github.com/selefra/selefra/pkg/grpc/pb/issue"
 	"github.com/selefra/selefra/pkg/grpc/pb/log"
 	"github.com/selefra/selefra/pkg/logger"
 	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
 	"github.com/selefra/selefra/pkg/modules/module"
 	"github.com/selefra/selefra/pkg/modules/module_loader"
 	"github.com/selefra/selefra/pkg/modules/planner"
@@ -16,6 +19,7 @@ import (
 	"github.com/selefra/selefra/pkg/storage/pgstorage"
 	"github.com/selefra/selefra/pkg/utils"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -79,6 +83,22 @@ type ProjectLocalLifeCycleExecutorOptions struct {
 
 	// The number of concurrent queries executed
 	QueryWorkerNum uint64
+
+	// Compare the findings with a baseline, and save them as a baseline
+	BaselineOptions *BaselineOptions
+
+	// The reports to write after the rules are executed
+	Reports []*ReportOption
+
+	// If not empty, the apply fails with exit_code.ExitCodePolicyViolation when there are issues of this severity or higher
+	FailOnSeverity string
+
+	// If set, the providers are started only to get their information, nothing is fetched and no rule is executed,
+	// the plans are collected into an ExplainReport instead
+	Explain *ExplainOptions
+
+	// The values of the variables of the root module given from the command line, the environment and the var files
+	Variables []*planner.RootVariable
 }
 
 // ------------------------------------------------ ---------------------------------------------------------------------
@@ -95,8 +115,25 @@ type ProjectLocalLifeCycleExecutor struct {
 	// project module path
 	rootModule *module.Module
 
+	// The lock file of the project, nil if the project has not been locked
+	lockFile *lock_file.LockFile
+
 	// for sync to cloud, If you log in, it has a real effect. If you do not log in, it has no real effect
 	cloudExecutor *ProjectCloudLifeCycleExecutor
+
+	// The baseline the findings were compared with, nil if there is no baseline
+	findingsBaseline *FindingsBaseline
+
+	// The number of issues of each severity and the number of rules that failed to execute, set after the query step
+	severityCountMap map[string]int
+	errorRuleCount   int
+	timeoutRuleCount int
+
+	// The plans explained, set after the query step if ExplainOptions is set
+	explainReport *ExplainReport
+
+	// Why the execution failed, see package exit_code
+	exitCode int
 }
 
 var _ Executor = &ProjectLocalLifeCycleExecutor{}
@@ -113,6 +150,16 @@ func (x *ProjectLocalLifeCycleExecutor) Name() string {
 	return ProjectLifeCycleExecutorName
 }
 
+// ExitCode The code the process should exit with after Execute, exit_code.ExitCodeSuccess if nothing failed
+func (x *ProjectLocalLifeCycleExecutor) ExitCode() int {
+	return x.exitCode
+}
+
+// ExplainReport The plans of the project, nil if it is not executed with ExplainOptions
+func (x *ProjectLocalLifeCycleExecutor) ExplainReport() *ExplainReport {
+	return x.explainReport
+}
+
 // Execute Actually execute the project
 func (x *ProjectLocalLifeCycleExecutor) Execute(ctx context.Context) *schema.Diagnostics {
 	defer func() {
@@ -129,6 +176,7 @@ func (x *ProjectLocalLifeCycleExecutor) Execute(ctx context.Context) *schema.Dia
 
 	// load module & check
 	if !x.loadModule(ctx) {
+		x.exitCode = exit_code.ExitCodeConfigurationError
 		return nil
 	}
 
@@ -145,6 +193,7 @@ func (x *ProjectLocalLifeCycleExecutor) Execute(ctx context.Context) *schema.Dia
 
 	// fix dsn
 	if !x.fixDsn(ctx) {
+		x.exitCode = exit_code.ExitCodeConfigurationError
 		return nil
 	}
 
@@ -155,6 +204,7 @@ func (x *ProjectLocalLifeCycleExecutor) Execute(ctx context.Context) *schema.Dia
 	validatorContext := module.NewValidatorContext()
 	d := x.rootModule.Check(x.rootModule, validatorContext)
 	if x.cloudExecutor.UploadLog(ctx, d) {
+		x.exitCode = exit_code.ExitCodeConfigurationError
 		return nil
 	}
 
@@ -164,6 +214,7 @@ func (x *ProjectLocalLifeCycleExecutor) Execute(ctx context.Context) *schema.Dia
 	}
 	providersInstallPlan, providerLocalManager, b := x.install(ctx)
 	if !b {
+		x.exitCode = exit_code.ExitCodeConfigurationError
 		x.cloudExecutor.ReportTaskStatus(log.StageType_STAGE_TYPE_INITIALIZING, log.Status_STATUS_FAILED)
 		return nil
 	}
@@ -190,11 +241,13 @@ func (x *ProjectLocalLifeCycleExecutor) Execute(ctx context.Context) *schema.Dia
 	}
 	fetchExecutor, fetchPlans, b := x.fetch(ctx, providersInstallPlan, providerLocalManager)
 	if !b {
+		x.exitCode = exit_code.ExitCodeFetchError
 		x.cloudExecutor.ReportTaskStatus(log.StageType_STAGE_TYPE_PULL_INFRASTRUCTURE, log.Status_STATUS_FAILED)
 		return nil
 	}
 	// A value of 0 indicates that none of the providers has been successfully pulled, so there is no need to start subsequent pull tasks
 	if len(fetchExecutor.GetProviderInformationMap()) == 0 {
+		x.exitCode = exit_code.ExitCodeFetchError
 		x.cloudExecutor.UploadLog(ctx, schema.NewDiagnostics().AddErrorMsg("Fetch failed, can not get provider information"))
 		x.cloudExecutor.ReportTaskStatus(log.StageType_STAGE_TYPE_PULL_INFRASTRUCTURE, log.Status_STATUS_FAILED)
 		return nil
@@ -202,10 +255,22 @@ func (x *ProjectLocalLifeCycleExecutor) Execute(ctx context.Context) *schema.Dia
 	x.cloudExecutor.ReportTaskStatus(log.StageType_STAGE_TYPE_PULL_INFRASTRUCTURE, log.Status_STATUS_SUCCESS)
 	x.cloudExecutor.ChangeLogStage(log.StageType_STAGE_TYPE_INFRASTRUCTURE_ANALYSIS)
 
+	// Explain the plans instead of executing the rules
+	if x.options.Explain != nil {
+		if x.options.ProjectLifeCycleStep > ProjectLifeCycleStepQuery {
+			return nil
+		}
+		if !x.explain(ctx, fetchExecutor, fetchPlans) {
+			x.exitCode = exit_code.ExitCodeQueryError
+		}
+		return nil
+	}
+
 	pubOpt := postgresql_storage.NewPostgresqlStorageOptions(x.options.DSN)
 	pubOpt.SearchPath = "public"
 	pubStorage, d := storage_factory.NewStorage(ctx, storage_factory.StorageTypePostgresql, pubOpt)
 	if d != nil && d.HasError() {
+		x.exitCode = exit_code.ExitCodeConfigurationError
 		x.options.MessageChannel.Send(d)
 		return nil
 	}
@@ -213,6 +278,7 @@ func (x *ProjectLocalLifeCycleExecutor) Execute(ctx context.Context) *schema.Dia
 		lowSchema := strings.ToLower(fetchPlans[i].FetchToDatabaseSchema)
 		dia := pubStorage.SetKey(ctx, fetchPlans[i].ProviderConfigurationBlock.Name, lowSchema)
 		if dia != nil && dia.HasError() {
+			x.exitCode = exit_code.ExitCodeConfigurationError
 			x.options.MessageChannel.Send(dia)
 			return nil
 		}
@@ -223,14 +289,49 @@ func (x *ProjectLocalLifeCycleExecutor) Execute(ctx context.Context) *schema.Dia
 		return nil
 	}
 	if !x.query(ctx, fetchExecutor, fetchPlans) {
+		if x.exitCode == exit_code.ExitCodeSuccess {
+			x.exitCode = exit_code.ExitCodeQueryError
+		}
 		x.cloudExecutor.ReportTaskStatus(log.StageType_STAGE_TYPE_INFRASTRUCTURE_ANALYSIS, log.Status_STATUS_FAILED)
 		return nil
 	}
 	x.cloudExecutor.ReportTaskStatus(log.StageType_STAGE_TYPE_INFRASTRUCTURE_ANALYSIS, log.Status_STATUS_SUCCESS)
 
+	// A policy violation is reported before the query errors, the issues found are certain even if some rules failed
+	if x.options.FailOnSeverity != "" {
+		if count := x.countIssuesAtOrAbove(x.options.FailOnSeverity); count > 0 {
+			x.exitCode = exit_code.ExitCodePolicyViolation
+			return schema.NewDiagnostics().AddErrorMsg("%d issues at or above severity %s", count, x.options.FailOnSeverity)
+		}
+	}
+	if x.options.BaselineOptions != nil && x.options.BaselineOptions.FailOnNewFindings && x.findingsBaseline != nil && x.findingsBaseline.NewCount() > 0 {
+		x.exitCode = exit_code.ExitCodePolicyViolation
+		return schema.NewDiagnostics().AddErrorMsg("%d new findings compared with baseline %s", x.findingsBaseline.NewCount(), x.findingsBaseline.Ref)
+	}
+	if x.errorRuleCount > 0 {
+		x.exitCode = exit_code.ExitCodeQueryError
+		return schema.NewDiagnostics().AddErrorMsg("%d rules failed to execute", x.errorRuleCount)
+	}
+	if x.timeoutRuleCount > 0 {
+		x.exitCode = exit_code.ExitCodeQueryError
+		return schema.NewDiagnostics().AddErrorMsg("%d rules timed out", x.timeoutRuleCount)
+	}
+
 	return nil
 }
 
+// The number of issues whose severity is the same as or higher than the severity
+func (x *ProjectLocalLifeCycleExecutor) countIssuesAtOrAbove(severity string) int {
+	level := module.SeverityLevel(severity)
+	count := 0
+	for s, c := range x.severityCountMap {
+		if l := module.SeverityLevel(s); l >= 0 && l >= level {
+			count += c
+		}
+	}
+	return count
+}
+
 func (x *ProjectLocalLifeCycleExecutor) fixDsn(ctx context.Context) bool {
 
 	// 1. first take from local module
@@ -279,6 +380,15 @@ func (x *ProjectLocalLifeCycleExecutor) fixDsn(ctx context.Context) bool {
 
 // Load the module to be apply
 func (x *ProjectLocalLifeCycleExecutor) loadModule(ctx context.Context) bool {
+
+	// If the project is locked, the same modules and providers are used every time
+	lockFile, err := lock_file.Read(x.options.ProjectWorkspace)
+	if err != nil {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("read lock file %s failed: %s", lock_file.BuildLockFilePath(x.options.ProjectWorkspace), err.Error()))
+		return false
+	}
+	x.lockFile = lockFile
+
 	moduleLoaderOptions := &module_loader.LocalDirectoryModuleLoaderOptions{
 		Instruction: x.options.Instruction,
 		ModuleLoaderOptions: &module_loader.ModuleLoaderOptions{
@@ -289,6 +399,7 @@ func (x *ProjectLocalLifeCycleExecutor) loadModule(ctx context.Context) bool {
 			ProgressTracker:  nil,
 			MessageChannel:   x.options.MessageChannel.MakeChildChannel(),
 			DependenciesTree: []string{x.options.ProjectWorkspace},
+			LockFile:         x.lockFile,
 		},
 		ModuleDirectory: x.options.ProjectWorkspace,
 	}
@@ -316,7 +427,7 @@ func (x *ProjectLocalLifeCycleExecutor) loadModule(ctx context.Context) bool {
 func (x *ProjectLocalLifeCycleExecutor) install(ctx context.Context) (planner.ProvidersInstallPlan, *local_providers_manager.LocalProvidersManager, bool) {
 
 	// Make an installation plan
-	providersInstallPlan, diagnostics := planner.MakeProviderInstallPlan(ctx, x.rootModule)
+	providersInstallPlan, diagnostics := planner.MakeProviderInstallPlan(ctx, x.rootModule, x.lockFile)
 	if x.cloudExecutor.UploadLog(ctx, diagnostics) {
 		return nil, nil, false
 	}
@@ -347,6 +458,13 @@ func (x *ProjectLocalLifeCycleExecutor) install(ctx context.Context) (planner.Pr
 	if x.cloudExecutor.UploadLog(ctx, d) {
 		return nil, nil, false
 	}
+
+	// Make sure the providers installed are the ones locked
+	if x.lockFile != nil {
+		if x.cloudExecutor.UploadLog(ctx, lockProviders(ctx, x.lockFile, false, providersInstallPlan, executor.GetLocalProviderManager(), "")) {
+			return nil, nil, false
+		}
+	}
 	return providersInstallPlan, executor.GetLocalProviderManager(), true
 }
 
@@ -361,6 +479,8 @@ func (x *ProjectLocalLifeCycleExecutor) fetch(ctx context.Context, providersInst
 		Module:                       x.rootModule,
 		ProviderVersionVoteWinnerMap: providersInstallPlan.ToMap(),
 		MessageChannel:               x.options.MessageChannel.MakeChildChannel(),
+		// Only the tables used by the rules selected from the command line need to be pulled
+		SelectedTables: x.listSelectedTables(ctx),
 	}).MakePlan(ctx)
 	if x.cloudExecutor.UploadLog(ctx, d) {
 		return nil, nil, false
@@ -371,6 +491,11 @@ func (x *ProjectLocalLifeCycleExecutor) fetch(ctx context.Context, providersInst
 			_ = x.cloudExecutor.UploadLog(ctx, message)
 		}
 	})
+	// When explaining, the providers are only asked for their tables, the data in the database is left as it is
+	fetchStep := x.options.FetchStep
+	if x.options.Explain != nil && fetchStep < FetchStepGetInformation {
+		fetchStep = FetchStepGetInformation
+	}
 	fetchExecutor := NewProviderFetchExecutor(&ProviderFetchExecutorOptions{
 		LocalProviderManager: localProviderManager,
 		Plans:                providerFetchPlans,
@@ -378,7 +503,7 @@ func (x *ProjectLocalLifeCycleExecutor) fetch(ctx context.Context, providersInst
 		WorkerNum:            x.options.FetchWorkerNum,
 		Workspace:            x.options.ProjectWorkspace,
 		DSN:                  x.options.DSN,
-		FetchStepTo:          x.options.FetchStep,
+		FetchStepTo:          fetchStep,
 	})
 	d = fetchExecutor.Execute(context.Background())
 	fetchMessageChannel.ReceiverWait()
@@ -389,23 +514,105 @@ func (x *ProjectLocalLifeCycleExecutor) fetch(ctx context.Context, providersInst
 	return fetchExecutor, providerFetchPlans, true
 }
 
+// The tables the rules selected from the command line may query, the providers have not told their tables yet, so the rules
+// selected by --provider through the tables they query are not known, all the tables of the rules the other selectors allow are
+// counted then
+func (x *ProjectLocalLifeCycleExecutor) listSelectedTables(ctx context.Context) []string {
+	return planner.NewModulePlanner(&planner.ModulePlannerOptions{
+		Instruction: x.options.Instruction,
+		Module:      x.rootModule,
+		Variables:   x.options.Variables,
+	}).ListSelectedTables(ctx)
+}
+
 // ------------------------------------------------- --------------------------------------------------------------------
 
+// Plan the rules the same way the query step does, and explain the plans instead of executing them
+func (x *ProjectLocalLifeCycleExecutor) explain(ctx context.Context, fetchExecutor *ProviderFetchExecutor, providerFetchPlans planner.ProvidersFetchPlan) bool {
+	plan, d := planner.MakeModuleQueryPlan(ctx, &planner.ModulePlannerOptions{
+		Instruction:        x.options.Instruction,
+		Module:             x.rootModule,
+		TableToProviderMap: fetchExecutor.GetTableToProviderMap(),
+		Variables:          x.options.Variables,
+	})
+	if x.cloudExecutor.UploadLog(ctx, d) {
+		return false
+	}
+	contextMap, d := providerFetchPlans.BuildProviderContextMap(ctx, x.options.DSN)
+	if x.cloudExecutor.UploadLog(ctx, d) {
+		return false
+	}
+	report, d := MakeExplainReport(ctx, &ModuleExplainerOptions{
+		Plan:               plan,
+		ProviderFetchPlans: providerFetchPlans,
+		ProviderExpandMap:  contextMap,
+		ExplainQuery:       x.options.Explain.ExplainQuery,
+		Variables:          x.options.Variables,
+	})
+	if x.cloudExecutor.UploadLog(ctx, d) {
+		return false
+	}
+	x.explainReport = report
+	return true
+}
+
 // Start querying the policy and output the query results to the console and upload them to the cloud
 func (x *ProjectLocalLifeCycleExecutor) query(ctx context.Context, fetchExecutor *ProviderFetchExecutor, providerFetchPlans planner.ProvidersFetchPlan) bool {
 	plan, d := planner.MakeModuleQueryPlan(ctx, &planner.ModulePlannerOptions{
 		Instruction:        x.options.Instruction,
 		Module:             x.rootModule,
 		TableToProviderMap: fetchExecutor.GetTableToProviderMap(),
+		Variables:          x.options.Variables,
 	})
 	if x.cloudExecutor.UploadLog(ctx, d) {
 		return false
 	}
+	// The baseline must be loaded before this run is saved, otherwise the latest run is this run
+	if x.options.BaselineOptions != nil && x.options.BaselineOptions.Baseline != "" {
+		findingsBaseline, d := LoadFindingsBaseline(ctx, x.options.BaselineOptions.Baseline, x.options.DSN)
+		if x.cloudExecutor.UploadLog(ctx, d) {
+			x.exitCode = exit_code.ExitCodeConfigurationError
+			return false
+		}
+		x.findingsBaseline = findingsBaseline
+	}
 	queryMessageChannel := message.NewChannel[*schema.Diagnostics](func(index int, message *schema.Diagnostics) {
 		_ = x.cloudExecutor.UploadLog(ctx, message)
 	})
+	findingsRecorder := x.startFindingsRecorder(ctx)
+	findings := make([]*pgstorage.Finding, 0)
+	reportOptions := x.reportOptions()
+	var sarifReport *SarifReport
+	var junitReport *JUnitReport
+	var rulePlanResultChannel *message.Channel[*RulePlanResult]
+	for _, reportOption := range reportOptions {
+		switch reportOption.Format {
+		case ReportFormatSarif:
+			if sarifReport == nil {
+				sarifReport = NewSarifReport()
+			}
+		case ReportFormatJUnit:
+			if junitReport == nil {
+				junitReport = NewJUnitReport()
+				rulePlanResultChannel = message.NewChannel[*RulePlanResult](func(index int, message *RulePlanResult) {
+					junitReport.Add(message)
+				})
+			}
+		}
+	}
 	resultQueryResultChannel := message.NewChannel[*RuleQueryResult](func(index int, message *RuleQueryResult) {
 		x.cloudExecutor.UploadIssue(ctx, message)
+		if sarifReport != nil {
+			sarifReport.Add(message)
+		}
+		if findingsRecorder != nil {
+			if d := findingsRecorder.Record(ctx, message); utils.HasError(d) {
+				_ = x.cloudExecutor.UploadLog(ctx, x.findingsWarning(d))
+			}
+		}
+		if message.Status != issue.UploadIssueStream_Rule_SUCCESS && message.Waiver == nil {
+			findings = append(findings, NewFindingFromRuleQueryResult(message))
+		}
 	})
 	contextMap, d := providerFetchPlans.BuildProviderContextMap(ctx, x.options.DSN)
 	if x.cloudExecutor.UploadLog(ctx, d) {
@@ -416,16 +623,103 @@ func (x *ProjectLocalLifeCycleExecutor) query(ctx context.Context, fetchExecutor
 		DownloadWorkspace:      x.options.DownloadWorkspace,
 		MessageChannel:         queryMessageChannel,
 		RuleQueryResultChannel: resultQueryResultChannel,
+		RulePlanResultChannel:  rulePlanResultChannel,
 		ProviderInformationMap: fetchExecutor.GetProviderInformationMap(),
 		ProviderExpandMap:      contextMap,
 		WorkerNum:              x.options.QueryWorkerNum,
+		Baseline:               x.findingsBaseline,
 		// TODO
 		ProgressTracker: nil,
 	})
 	d = queryExecutor.Execute(ctx)
+	x.severityCountMap = queryExecutor.GetSeverityCountMap()
+	x.errorRuleCount = queryExecutor.GetErrorRuleCount()
+	x.timeoutRuleCount = queryExecutor.GetTimeoutRuleCount()
 	resultQueryResultChannel.ReceiverWait()
+	if rulePlanResultChannel != nil {
+		rulePlanResultChannel.ReceiverWait()
+	}
 	queryMessageChannel.ReceiverWait()
-	return !x.cloudExecutor.UploadLog(ctx, d)
+	success := !x.cloudExecutor.UploadLog(ctx, d)
+	runID := ""
+	if findingsRecorder != nil {
+		runID = findingsRecorder.RunID()
+		if d := findingsRecorder.Finish(ctx, success); utils.HasError(d) {
+			_ = x.cloudExecutor.UploadLog(ctx, x.findingsWarning(d))
+		}
+	}
+	if success && x.options.BaselineOptions != nil && x.options.BaselineOptions.SaveBaselinePath != "" {
+		if err := SaveBaselineFile(x.options.BaselineOptions.SaveBaselinePath, runID, findings); err != nil {
+			return !x.cloudExecutor.UploadLog(ctx, schema.NewDiagnostics().AddErrorMsg("save baseline file %s error: %s", x.options.BaselineOptions.SaveBaselinePath, err.Error()))
+		}
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("Save %d findings as baseline to %s", len(findings), x.options.BaselineOptions.SaveBaselinePath))
+	}
+	for _, reportOption := range reportOptions {
+		var err error
+		switch reportOption.Format {
+		case ReportFormatSarif:
+			err = sarifReport.Write(reportOption.Path)
+		case ReportFormatJUnit:
+			err = junitReport.Write(reportOption.Path)
+		}
+		if err != nil {
+			return !x.cloudExecutor.UploadLog(ctx, schema.NewDiagnostics().AddErrorMsg("write %s report %s error: %s", reportOption.Format, reportOption.Path, err.Error()))
+		}
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("Save %s report to %s", reportOption.Format, reportOption.Path))
+	}
+	return success
+}
+
+// The reports passed by apply --report, apply --output sarif is the same as a sarif report in the --dir directory
+func (x *ProjectLocalLifeCycleExecutor) reportOptions() []*ReportOption {
+	reportOptions := make([]*ReportOption, 0, len(x.options.Reports)+1)
+	reportOptions = append(reportOptions, x.options.Reports...)
+	if x.outputFormat() == OutputFormatSarif {
+		reportOptions = append(reportOptions, &ReportOption{
+			Format: ReportFormatSarif,
+			Path:   filepath.Join(x.outputDirectory(), SarifReportFileName),
+		})
+	}
+	return reportOptions
+}
+
+// The format of the output passed by apply --output, lower case
+func (x *ProjectLocalLifeCycleExecutor) outputFormat() string {
+	if output, ok := x.options.Instruction["output"].(string); ok {
+		return strings.ToLower(output)
+	}
+	return ""
+}
+
+// The directory passed by apply --dir, reports are written to it
+func (x *ProjectLocalLifeCycleExecutor) outputDirectory() string {
+	if dir, ok := x.options.Instruction["dir"].(string); ok && dir != "" {
+		return dir
+	}
+	return "."
+}
+
+// Findings are saved to the database as a history, failing to save them does not fail the apply
+func (x *ProjectLocalLifeCycleExecutor) startFindingsRecorder(ctx context.Context) *FindingsRecorder {
+	projectName := ""
+	if x.rootModule.SelefraBlock != nil {
+		projectName = x.rootModule.SelefraBlock.Name
+	}
+	findingsRecorder, d := NewFindingsRecorder(ctx, x.options.DSN, projectName)
+	if utils.HasError(d) {
+		_ = x.cloudExecutor.UploadLog(ctx, x.findingsWarning(d))
+		return nil
+	}
+	if d := findingsRecorder.Start(ctx); utils.HasError(d) {
+		_ = x.cloudExecutor.UploadLog(ctx, x.findingsWarning(d))
+		_ = findingsRecorder.Finish(ctx, false)
+		return nil
+	}
+	return findingsRecorder
+}
+
+func (x *ProjectLocalLifeCycleExecutor) findingsWarning(d *schema.Diagnostics) *schema.Diagnostics {
+	return schema.NewDiagnostics().AddWarn("save findings to %s failed: %s", pgstorage.FindingsTableName, d.ToString())
 }
 
 func (x *ProjectLocalLifeCycleExecutor) initCloudClient(ctx context.Context) bool {
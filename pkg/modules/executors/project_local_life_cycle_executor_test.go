@@ -0,0 +1,49 @@
+package executors
+
+import (
+	"context"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/modules/planner"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestProjectLocalLifeCycleExecutor_listSelectedTables(t *testing.T) {
+	rootModule := module.NewModule()
+	rootModule.RulesBlock = module.RulesBlock{
+		// The metadata names the other provider of the join
+		{
+			Name:          "bucket_of_instance",
+			Query:         "SELECT * FROM aws_s3_buckets b JOIN gcp_compute_instances i ON b.name = i.name",
+			MetadataBlock: &module.RuleMetadataBlock{Provider: "gcp", Severity: "High"},
+		},
+		// No provider in the metadata at all
+		{
+			Name:  "user_without_mfa",
+			Query: "SELECT * FROM aws_iam_users WHERE mfa_active = false",
+		},
+		{
+			Name:          "pod_privileged",
+			Query:         "SELECT * FROM k8s_core_pods WHERE privileged",
+			MetadataBlock: &module.RuleMetadataBlock{Provider: "k8s", Severity: "Low"},
+		},
+	}
+	listSelectedTables := func(instruction map[string]interface{}) []string {
+		executor := &ProjectLocalLifeCycleExecutor{
+			options:    &ProjectLocalLifeCycleExecutorOptions{Instruction: instruction},
+			rootModule: rootModule,
+		}
+		return executor.listSelectedTables(context.Background())
+	}
+
+	assert.Nil(t, listSelectedTables(nil))
+	// The rules selected by aws through the tables they query are not known before the fetch, their tables must be fetched
+	assert.Equal(t, []string{"aws_iam_users", "aws_s3_buckets", "gcp_compute_instances", "k8s_core_pods"}, listSelectedTables(map[string]interface{}{
+		planner.InstructionKeyProvider: []string{"aws"},
+	}))
+	// The other selectors still narrow the tables
+	assert.Equal(t, []string{"aws_s3_buckets", "gcp_compute_instances"}, listSelectedTables(map[string]interface{}{
+		planner.InstructionKeyProvider: []string{"aws"},
+		planner.InstructionKeySeverity: []string{"High"},
+	}))
+}
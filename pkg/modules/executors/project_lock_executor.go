@@ -0,0 +1,191 @@
+package executors
+
+import (
+	"context"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
+	"github.com/selefra/selefra/pkg/modules/module_loader"
+	"github.com/selefra/selefra/pkg/modules/planner"
+	"github.com/selefra/selefra/pkg/plugin"
+	"github.com/selefra/selefra/pkg/providers/local_providers_manager"
+	"github.com/selefra/selefra/pkg/registry"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ProjectLockExecutorOptions Options when locking the providers and modules of a project
+type ProjectLockExecutorOptions struct {
+
+	// project path
+	ProjectWorkspace string
+
+	// download things put where
+	DownloadWorkspace string
+
+	// The channel through which messages are received externally
+	MessageChannel *message.Channel[*schema.Diagnostics]
+
+	// Resolve the providers and modules again instead of using the locked ones, the checksums are not checked
+	Upgrade bool
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+const ProjectLockExecutorName = "project-lock-executor"
+
+// ProjectLockExecutor Resolve the providers and modules the project requires and write them into the lock file
+type ProjectLockExecutor struct {
+	options *ProjectLockExecutorOptions
+
+	lockFile *lock_file.LockFile
+}
+
+var _ Executor = &ProjectLockExecutor{}
+
+func NewProjectLockExecutor(options *ProjectLockExecutorOptions) *ProjectLockExecutor {
+	return &ProjectLockExecutor{
+		options: options,
+	}
+}
+
+func (x *ProjectLockExecutor) Name() string {
+	return ProjectLockExecutorName
+}
+
+// LockFile The lock file written, nil if the execution failed
+func (x *ProjectLockExecutor) LockFile() *lock_file.LockFile {
+	return x.lockFile
+}
+
+func (x *ProjectLockExecutor) Execute(ctx context.Context) *schema.Diagnostics {
+
+	defer func() {
+		x.options.MessageChannel.SenderWaitAndClose()
+	}()
+
+	diagnostics := schema.NewDiagnostics()
+
+	// step 01. The versions locked before are kept unless upgrade
+	lockFile, err := lock_file.Read(x.options.ProjectWorkspace)
+	if err != nil {
+		return diagnostics.AddErrorMsg("read lock file %s failed: %s", lock_file.BuildLockFilePath(x.options.ProjectWorkspace), err.Error())
+	}
+	if lockFile == nil {
+		lockFile = lock_file.NewLockFile()
+	}
+
+	// step 02. Load the modules, the remote modules are locked while loading
+	moduleLoaderOptions := &module_loader.LocalDirectoryModuleLoaderOptions{
+		ModuleLoaderOptions: &module_loader.ModuleLoaderOptions{
+			Source:            x.options.ProjectWorkspace,
+			DownloadDirectory: x.options.DownloadWorkspace,
+			MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
+			DependenciesTree:  []string{x.options.ProjectWorkspace},
+			LockFile:          lockFile,
+			UpgradeLock:       x.options.Upgrade,
+		},
+		ModuleDirectory: x.options.ProjectWorkspace,
+	}
+	loader, err := module_loader.NewLocalDirectoryModuleLoader(moduleLoaderOptions)
+	if err != nil {
+		moduleLoaderOptions.MessageChannel.SenderWaitAndClose()
+		return diagnostics.AddErrorMsg("create local directory module loader from %s error: %s", x.options.ProjectWorkspace, err.Error())
+	}
+	rootModule, ok := loader.Load(ctx)
+	if !ok {
+		return diagnostics.AddErrorMsg("local directory module loader load %s failed", x.options.ProjectWorkspace)
+	}
+
+	// step 03. Resolve the provider versions and install them
+	plannerLockFile := lockFile
+	if x.options.Upgrade {
+		plannerLockFile = nil
+	}
+	providersInstallPlan, d := planner.MakeProviderInstallPlan(ctx, rootModule, plannerLockFile)
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+	installExecutor, d := NewProviderInstallExecutor(&ProviderInstallExecutorOptions{
+		Plans:             providersInstallPlan,
+		DownloadWorkspace: x.options.DownloadWorkspace,
+		MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
+	})
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+	if diagnostics.AddDiagnostics(installExecutor.Execute(ctx)).HasError() {
+		return diagnostics
+	}
+
+	// step 04. Lock the installed providers
+	if diagnostics.AddDiagnostics(lockProviders(ctx, lockFile, x.options.Upgrade, providersInstallPlan, installExecutor.GetLocalProviderManager(), x.options.DownloadWorkspace)).HasError() {
+		return diagnostics
+	}
+
+	// step 05. What is not used anymore is removed from the lock file
+	lockFile.Prune()
+	if err := lockFile.Save(x.options.ProjectWorkspace); err != nil {
+		return diagnostics.AddErrorMsg("write lock file %s failed: %s", lock_file.BuildLockFilePath(x.options.ProjectWorkspace), err.Error())
+	}
+	x.lockFile = lockFile
+	return diagnostics
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// Check the installed providers against the lock file and lock them, the source of a provider not locked yet is looked up
+// in the registry its plan selects, if the download workspace is empty, the source of the provider is only kept from the lock file
+func lockProviders(ctx context.Context, lockFile *lock_file.LockFile, upgrade bool, providersInstallPlan planner.ProvidersInstallPlan,
+	localProviderManager *local_providers_manager.LocalProvidersManager, downloadWorkspace string) *schema.Diagnostics {
+
+	diagnostics := schema.NewDiagnostics()
+	for _, plan := range providersInstallPlan {
+		// The provider under development is not installed, there is nothing to lock
+		if devOverride, err := plugin.GetDevOverride(plan.Name); err != nil {
+			return diagnostics.AddErrorMsg(err.Error())
+		} else if devOverride != nil {
+			diagnostics.AddWarn("Provider %s is overridden by %s, it is not locked", plan.String(), devOverride.String())
+			continue
+		}
+		localProvider, d := localProviderManager.Get(ctx, local_providers_manager.NewLocalProvider(plan.Name, plan.Version))
+		if diagnostics.AddDiagnostics(d).HasError() {
+			return diagnostics
+		}
+		checksum, err := lock_file.FileChecksum(localProvider.ExecutableFilePath)
+		if err != nil {
+			return diagnostics.AddErrorMsg("compute checksum of provider %s failed: %s", plan.String(), err.Error())
+		}
+		if !upgrade {
+			if err := lockFile.VerifyProvider(plan.Name, plan.Version, checksum); err != nil {
+				return diagnostics.AddErrorMsg("%s, run selefra lock --upgrade if the provider is changed on purpose", err.Error())
+			}
+		}
+
+		source := ""
+		if lockedProvider := lockFile.GetProvider(plan.Name); lockedProvider != nil && lockedProvider.Version == plan.Version {
+			source = lockedProvider.Source
+		}
+		if source == "" && downloadWorkspace != "" {
+			providerRegistry, err := registry.NewProviderRegistry(downloadWorkspace, plan.Registry)
+			if err != nil {
+				return diagnostics.AddErrorMsg("create provider registry %s for provider %s failed: %s", plan.Registry, plan.String(), err.Error())
+			}
+			supplement, err := providerRegistry.GetSupplement(ctx, plan.Provider)
+			if err != nil {
+				return diagnostics.AddErrorMsg("get provider %s supplement failed: %s", plan.String(), err.Error())
+			}
+			source = supplement.Source
+		}
+
+		lockFile.LockProvider(&lock_file.LockedProvider{
+			Name:     plan.Name,
+			Version:  plan.Version,
+			Source:   source,
+			Checksum: checksum,
+		})
+	}
+	return diagnostics
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -23,6 +23,8 @@ import (
 	"github.com/selefra/selefra/pkg/utils"
 	"io"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -209,12 +211,23 @@ func (x *ProviderFetchExecutorWorker) Run() {
 	}()
 }
 
-// Execute a provider fetch task plan
-func (x *ProviderFetchExecutorWorker) executePlan(ctx context.Context, plan *planner.ProviderFetchPlan) {
+// Start the installed provider, or the one SELEFRA_REATTACH_PROVIDERS overrides it with
+func (x *ProviderFetchExecutorWorker) startProvider(ctx context.Context, plan *planner.ProviderFetchPlan) (plugin.Plugin, *schema.Diagnostics) {
 
 	diagnostics := schema.NewDiagnostics()
 
-	x.sendMessage(x.addProviderNameForMessage(plan, schema.NewDiagnostics().AddInfo("Begin fetch provider %s", plan.String())))
+	devOverride, err := plugin.GetDevOverride(plan.Name)
+	if err != nil {
+		return nil, diagnostics.AddErrorMsg(err.Error())
+	}
+	if devOverride != nil {
+		x.sendMessage(x.addProviderNameForMessage(plan, schema.NewDiagnostics().AddWarn("Provider %s is overridden by %s, the installed provider is not used", plan.String(), devOverride.String())))
+		plug, err := devOverride.NewPlugin(plan.Version)
+		if err != nil {
+			return nil, diagnostics.AddErrorMsg("Start provider %s from %s failed: %s", plan.String(), devOverride.String(), err.Error())
+		}
+		return plug, diagnostics
+	}
 
 	// Find the local path of the provider
 	localProvider := &local_providers_manager.LocalProvider{
@@ -222,31 +235,41 @@ func (x *ProviderFetchExecutorWorker) executePlan(ctx context.Context, plan *pla
 	}
 	installed, d := x.executor.options.LocalProviderManager.IsProviderInstalled(ctx, localProvider)
 	if diagnostics.AddDiagnostics(d).HasError() {
-		x.sendMessage(x.addProviderNameForMessage(plan, diagnostics))
-		return
+		return nil, diagnostics
 	}
 	if !installed {
-		x.sendMessage(x.addProviderNameForMessage(plan, diagnostics.AddErrorMsg("Provider %s not installed, can not exec fetch for it", plan.String())))
-		return
+		return nil, diagnostics.AddErrorMsg("Provider %s not installed, can not exec fetch for it", plan.String())
 	}
 
 	// Find the local installation location of the provider
 	localProviderMeta, d := x.executor.options.LocalProviderManager.Get(ctx, localProvider)
 	if diagnostics.AddDiagnostics(d).HasError() {
-		x.sendMessage(x.addProviderNameForMessage(plan, diagnostics))
-		return
+		return nil, diagnostics
 	}
 
-	// Start provider
 	plug, err := plugin.NewManagedPlugin(localProviderMeta.ExecutableFilePath, plan.Name, plan.Version, "", nil)
 	if err != nil {
-		x.sendMessage(x.addProviderNameForMessage(plan, schema.NewDiagnostics().AddErrorMsg("Start provider %s at %s failed: %s", plan.String(), localProviderMeta.ExecutableFilePath, err.Error())))
+		return nil, diagnostics.AddErrorMsg("Start provider %s at %s failed: %s", plan.String(), localProviderMeta.ExecutableFilePath, err.Error())
+	}
+	return plug, diagnostics
+}
+
+// Execute a provider fetch task plan
+func (x *ProviderFetchExecutorWorker) executePlan(ctx context.Context, plan *planner.ProviderFetchPlan) {
+
+	diagnostics := schema.NewDiagnostics()
+
+	x.sendMessage(x.addProviderNameForMessage(plan, schema.NewDiagnostics().AddInfo("Begin fetch provider %s", plan.String())))
+
+	// Start provider
+	plug, d := x.startProvider(ctx, plan)
+	if diagnostics.AddDiagnostics(d).HasError() {
+		x.sendMessage(x.addProviderNameForMessage(plan, diagnostics))
 		return
 	}
 	// Close the provider at the end of the method execution
 	defer func() {
 		plug.Close()
-		//x.sendMessage(schema.NewDiagnostics().AddInfo("Stop provider %s at %s ", plan.String(), localProviderMeta.ExecutableFilePath))
 	}()
 
 	x.sendMessage(x.addProviderNameForMessage(plan, schema.NewDiagnostics().AddInfo("Start provider %s success", plan.String())))
@@ -361,29 +384,37 @@ func (x *ProviderFetchExecutorWorker) executePlan(ctx context.Context, plan *pla
 	cli_ui.Infof("%s %s, pull infrastructure data:\n", plan.ProviderConfigurationBlock.Provider, plan.Provider.String())
 	// Check whether the cache can be removed
 	cache, needFetchTableSet := x.tryHitCache(ctx, databaseStorage, plan, information)
+	if plan.SelectedTables != nil && len(needFetchTableSet) == 0 {
+		x.sendMessage(x.addProviderNameForMessage(plan, schema.NewDiagnostics().AddInfo("Provider %s has no table used by the selected rules, skip pull", plan.String())))
+		cli_ui.Infof("No table of %s Provider is used by the selected rules, skip pull.\n\n", plan.String())
+		return
+	}
 	if cache {
 		x.sendMessage(x.addProviderNameForMessage(plan, schema.NewDiagnostics().AddInfo("Provider %s pull data hit cache", plan.String())))
 		cli_ui.Infof("Hit Selefra %s Provider cache! The default data cache time is %s.\n\n", plan.String(), plan.ProviderConfigurationBlock.Cache)
 		return
 	}
 
-	// Delete the table before provider
-	dropRes, err := pluginProvider.DropTableAll(ctx, &shard.ProviderDropTableAllRequest{})
-	if err != nil {
-		x.sendMessage(x.addProviderNameForMessage(plan, schema.NewDiagnostics().AddErrorMsg("Provider %s, schema %s, drop all table failed: %s", plan.String(), plan.FetchToDatabaseSchema, err.Error())))
-		return
-	}
-	x.sendMessage(x.addProviderNameForMessage(plan, dropRes.Diagnostics))
-	if utils.HasError(dropRes.Diagnostics) {
-		return
+	// Delete the table before provider, when only the tables of the selected rules are pulled, the other tables keep their data
+	// for the other rules and queries, the tables pulled are emptied one by one before the pull instead
+	if plan.SelectedTables == nil {
+		dropRes, err := pluginProvider.DropTableAll(ctx, &shard.ProviderDropTableAllRequest{})
+		if err != nil {
+			x.sendMessage(x.addProviderNameForMessage(plan, schema.NewDiagnostics().AddErrorMsg("Provider %s, schema %s, drop all table failed: %s", plan.String(), plan.FetchToDatabaseSchema, err.Error())))
+			return
+		}
+		x.sendMessage(x.addProviderNameForMessage(plan, dropRes.Diagnostics))
+		if utils.HasError(dropRes.Diagnostics) {
+			return
+		}
+		x.sendMessage(x.addProviderNameForMessage(plan, schema.NewDiagnostics().AddInfo("Provider %s drop database schema clean success", plan.String())))
 	}
-	x.sendMessage(x.addProviderNameForMessage(plan, schema.NewDiagnostics().AddInfo("Provider %s drop database schema clean success", plan.String())))
 
 	if x.executor.options.FetchStepTo > FetchStepCreateAllTable {
 		return
 	}
 
-	// create all tables
+	// create all tables, the tables that exist are kept
 	createRes, err := pluginProvider.CreateAllTables(ctx, &shard.ProviderCreateAllTablesRequest{})
 	if err != nil {
 		cli_ui.Errorln(err.Error())
@@ -401,6 +432,15 @@ func (x *ProviderFetchExecutorWorker) executePlan(ctx context.Context, plan *pla
 	if x.executor.options.FetchStepTo > FetchStepFetch {
 		return
 	}
+	if plan.SelectedTables != nil {
+		truncateSql := buildTruncateTablesSql(plan.FetchToDatabaseSchema, x.listPullTables(information, needFetchTableSet))
+		d := databaseStorage.Exec(ctx, truncateSql)
+		x.sendMessage(x.addProviderNameForMessage(plan, d))
+		if utils.HasError(d) {
+			return
+		}
+		x.sendMessage(x.addProviderNameForMessage(plan, schema.NewDiagnostics().AddInfo("Provider %s clean the tables of the selected rules success", plan.String())))
+	}
 	x.sendMessage(x.addProviderNameForMessage(plan, schema.NewDiagnostics().AddInfo("Provider %s begin fetch...", plan.String())))
 
 	// being pull data
@@ -536,6 +576,18 @@ func (x *ProviderFetchExecutorWorker) tryHitCache(ctx context.Context, databaseS
 			needFetchTableNameSet[table.TableName] = struct{}{}
 		}
 	}
+	// If rules are selected from the command line, only the tables they use are pulled
+	if plan.SelectedTables != nil {
+		selectedRootTableSet := make(map[string]struct{})
+		for _, tableName := range plan.SelectedTables {
+			if rootTableName, exists := tooRootTableMap[tableName]; exists {
+				if _, exists := needFetchTableNameSet[rootTableName]; exists {
+					selectedRootTableSet[rootTableName] = struct{}{}
+				}
+			}
+		}
+		needFetchTableNameSet = selectedRootTableSet
+	}
 
 	//  If caching is not enabled, return directly
 	if !x.isEnableFetchCache(ctx, databaseStorage, plan) {
@@ -626,6 +678,25 @@ func (x *ProviderFetchExecutorWorker) flatTable(table *schema.Table) []string {
 	return tableNameSlice
 }
 
+// The tables written by the pull of the root tables, that is the root tables and their sub tables
+func (x *ProviderFetchExecutorWorker) listPullTables(providerInformation *shard.GetProviderInformationResponse, rootTableNameSet map[string]struct{}) []string {
+	tableNameSlice := make([]string, 0)
+	for rootTableName := range rootTableNameSet {
+		tableNameSlice = append(tableNameSlice, x.flatTable(providerInformation.Tables[rootTableName])...)
+	}
+	sort.Strings(tableNameSlice)
+	return tableNameSlice
+}
+
+// Empty the tables in one statement, so that the sub tables referencing their parent tables are emptied together
+func buildTruncateTablesSql(databaseSchema string, tableNameSlice []string) string {
+	qualifiedTableNameSlice := make([]string, 0, len(tableNameSlice))
+	for _, tableName := range tableNameSlice {
+		qualifiedTableNameSlice = append(qualifiedTableNameSlice, planner.QuoteIdentifier(databaseSchema)+"."+planner.QuoteIdentifier(tableName))
+	}
+	return "TRUNCATE TABLE " + strings.Join(qualifiedTableNameSlice, ", ")
+}
+
 func (x *ProviderFetchExecutorWorker) refreshPullTableTime(ctx context.Context, databaseStorage storage.Storage, plan *planner.ProviderFetchPlan, needFetchTableNameSet map[string]struct{}) *schema.Diagnostics {
 	diagnostics := schema.NewDiagnostics()
 	pullId := id_util.RandomId()
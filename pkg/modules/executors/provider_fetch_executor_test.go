@@ -3,6 +3,7 @@ package executors
 import (
 	"context"
 	"github.com/selefra/selefra-provider-sdk/env"
+	"github.com/selefra/selefra-provider-sdk/grpc/shard"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
 	"github.com/selefra/selefra/pkg/message"
 	"github.com/selefra/selefra/pkg/utils"
@@ -65,3 +66,20 @@ func TestProviderFetchExecutorWorker_computeNeedFetchTables(t *testing.T) {
 	assert.False(t, utils.HasError(d))
 
 }
+
+func TestProviderFetchExecutorWorker_listPullTables(t *testing.T) {
+	information := &shard.GetProviderInformationResponse{
+		Tables: map[string]*schema.Table{
+			"aws_s3_buckets": {
+				TableName: "aws_s3_buckets",
+				SubTables: []*schema.Table{{TableName: "aws_s3_bucket_cors_rules"}},
+			},
+			"aws_iam_users": {TableName: "aws_iam_users"},
+		},
+	}
+	worker := &ProviderFetchExecutorWorker{}
+	tables := worker.listPullTables(information, map[string]struct{}{"aws_s3_buckets": {}})
+	// Only the selected root table and its sub tables are emptied, the data of aws_iam_users is kept
+	assert.Equal(t, []string{"aws_s3_bucket_cors_rules", "aws_s3_buckets"}, tables)
+	assert.Equal(t, `TRUNCATE TABLE "aws_a"."aws_s3_bucket_cors_rules", "aws_a"."aws_s3_buckets"`, buildTruncateTablesSql("aws_a", tables))
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
 	"github.com/selefra/selefra/pkg/message"
 	"github.com/selefra/selefra/pkg/modules/planner"
+	"github.com/selefra/selefra/pkg/plugin"
 	"github.com/selefra/selefra/pkg/providers/local_providers_manager"
 	"github.com/selefra/selefra/pkg/utils"
 )
@@ -36,6 +37,9 @@ type ProviderInstallExecutor struct {
 	options *ProviderInstallExecutorOptions
 
 	localProviderManager *local_providers_manager.LocalProvidersManager
+
+	// The providers that select another registry are installed by the managers of their registries, <registry, manager>
+	registryProviderManagerMap map[string]*local_providers_manager.LocalProvidersManager
 }
 
 var _ Executor = &ProviderInstallExecutor{}
@@ -49,8 +53,9 @@ func NewProviderInstallExecutor(options *ProviderInstallExecutorOptions) (*Provi
 	}
 
 	return &ProviderInstallExecutor{
-		options:              options,
-		localProviderManager: manager,
+		options:                    options,
+		localProviderManager:       manager,
+		registryProviderManagerMap: make(map[string]*local_providers_manager.LocalProvidersManager),
 	}, diagnostics
 }
 
@@ -59,6 +64,22 @@ func (x *ProviderInstallExecutor) GetLocalProviderManager() *local_providers_man
 	return x.localProviderManager
 }
 
+// The manager that installs the provider of the plan from the registry the plan selects
+func (x *ProviderInstallExecutor) getRegistryProviderManager(plan *planner.ProviderInstallPlan) (*local_providers_manager.LocalProvidersManager, *schema.Diagnostics) {
+	if plan.Registry == "" {
+		return x.localProviderManager, nil
+	}
+	if manager, exists := x.registryProviderManagerMap[plan.Registry]; exists {
+		return manager, nil
+	}
+	manager, err := local_providers_manager.NewLocalProvidersManager(x.options.DownloadWorkspace, plan.Registry)
+	if err != nil {
+		return nil, schema.NewDiagnostics().AddErrorMsg("create provider registry %s for provider %s error: %s", plan.Registry, plan.Name, err.Error())
+	}
+	x.registryProviderManagerMap[plan.Registry] = manager
+	return manager, nil
+}
+
 func (x *ProviderInstallExecutor) Name() string {
 	return ProviderInstallExecutorName
 }
@@ -82,10 +103,23 @@ func (x *ProviderInstallExecutor) Execute(ctx context.Context) *schema.Diagnosti
 }
 
 func (x *ProviderInstallExecutor) executePlan(ctx context.Context, plan *planner.ProviderInstallPlan) *schema.Diagnostics {
+	// The provider under development is started from where SELEFRA_REATTACH_PROVIDERS says, it does not have to be released
+	devOverride, err := plugin.GetDevOverride(plan.Name)
+	if err != nil {
+		return schema.NewDiagnostics().AddErrorMsg(err.Error())
+	}
+	if devOverride != nil {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("\t- %s is overridden by %s, skip install", plan.String(), devOverride.String()))
+		return nil
+	}
 	requiredProvider := &local_providers_manager.LocalProvider{
 		Provider: plan.Provider,
 	}
-	installed, diagnostics := x.localProviderManager.IsProviderInstalled(ctx, requiredProvider)
+	manager, diagnostics := x.getRegistryProviderManager(plan)
+	if utils.HasError(diagnostics) {
+		return diagnostics
+	}
+	installed, diagnostics := manager.IsProviderInstalled(ctx, requiredProvider)
 	if utils.HasError(diagnostics) {
 		return diagnostics
 	}
@@ -96,7 +130,7 @@ func (x *ProviderInstallExecutor) executePlan(ctx context.Context, plan *planner
 
 	//x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("\t- %s downloading...", plan.String()))
 
-	x.localProviderManager.InstallProvider(ctx, &local_providers_manager.InstallProvidersOptions{
+	manager.InstallProvider(ctx, &local_providers_manager.InstallProvidersOptions{
 		RequiredProvider: requiredProvider,
 		MessageChannel:   x.options.MessageChannel.MakeChildChannel(),
 		ProgressTracker:  x.options.ProgressTracker,
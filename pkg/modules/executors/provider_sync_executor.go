@@ -0,0 +1,218 @@
+package executors
+
+import (
+	"context"
+	"github.com/selefra/selefra-provider-sdk/env"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra-provider-sdk/storage/database_storage/postgresql_storage"
+	"github.com/selefra/selefra-provider-sdk/storage_factory"
+	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/modules/module_loader"
+	"github.com/selefra/selefra/pkg/modules/planner"
+	"github.com/selefra/selefra/pkg/providers/local_providers_manager"
+	"github.com/selefra/selefra/pkg/selefra_workspace"
+	"github.com/selefra/selefra/pkg/storage/pgstorage"
+	"github.com/selefra/selefra/pkg/utils"
+	"os"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ProviderSyncExecutorOptions Options when synchronizing the installed providers to a project
+type ProviderSyncExecutorOptions struct {
+
+	// project path
+	ProjectWorkspace string
+
+	// download things put where
+	DownloadWorkspace string
+
+	// Remove the installed provider versions the project does not use, and release the database schemas they hold
+	Prune bool
+
+	// The database the schema owner records of the pruned providers are released in, if it is empty, it is taken from the
+	// connection of the project, SELEFRA_DATABASE_DSN or the built-in PostgreSQL
+	DSN string
+
+	// The channel through which messages are received externally
+	MessageChannel *message.Channel[*schema.Diagnostics]
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+const ProviderSyncExecutorName = "provider-sync-executor"
+
+// ProviderSyncExecutor Bring the installed providers into the exact state the project expects, the versions the version vote
+// selects are installed, and the other installed versions are reported as unused or pruned
+type ProviderSyncExecutor struct {
+	options *ProviderSyncExecutorOptions
+
+	plans           planner.ProvidersInstallPlan
+	unusedProviders []*local_providers_manager.LocalProvider
+}
+
+var _ Executor = &ProviderSyncExecutor{}
+
+func NewProviderSyncExecutor(options *ProviderSyncExecutorOptions) *ProviderSyncExecutor {
+	return &ProviderSyncExecutor{
+		options: options,
+	}
+}
+
+func (x *ProviderSyncExecutor) Name() string {
+	return ProviderSyncExecutorName
+}
+
+// Plans The provider versions the project uses, nil if the execution failed before they are resolved
+func (x *ProviderSyncExecutor) Plans() planner.ProvidersInstallPlan {
+	return x.plans
+}
+
+// UnusedProviders The installed provider versions the project does not use, they are already removed if prune
+func (x *ProviderSyncExecutor) UnusedProviders() []*local_providers_manager.LocalProvider {
+	return x.unusedProviders
+}
+
+func (x *ProviderSyncExecutor) Execute(ctx context.Context) *schema.Diagnostics {
+
+	defer func() {
+		x.options.MessageChannel.SenderWaitAndClose()
+	}()
+
+	diagnostics := schema.NewDiagnostics()
+
+	// step 01. The versions locked are used if the project is locked
+	lockFile, err := lock_file.Read(x.options.ProjectWorkspace)
+	if err != nil {
+		return diagnostics.AddErrorMsg("read lock file %s failed: %s", lock_file.BuildLockFilePath(x.options.ProjectWorkspace), err.Error())
+	}
+
+	// step 02. Load the module tree
+	moduleLoaderOptions := &module_loader.LocalDirectoryModuleLoaderOptions{
+		ModuleLoaderOptions: &module_loader.ModuleLoaderOptions{
+			Source:            x.options.ProjectWorkspace,
+			DownloadDirectory: x.options.DownloadWorkspace,
+			MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
+			DependenciesTree:  []string{x.options.ProjectWorkspace},
+			LockFile:          lockFile,
+		},
+		ModuleDirectory: x.options.ProjectWorkspace,
+	}
+	loader, err := module_loader.NewLocalDirectoryModuleLoader(moduleLoaderOptions)
+	if err != nil {
+		moduleLoaderOptions.MessageChannel.SenderWaitAndClose()
+		return diagnostics.AddErrorMsg("create local directory module loader from %s error: %s", x.options.ProjectWorkspace, err.Error())
+	}
+	rootModule, ok := loader.Load(ctx)
+	if !ok {
+		return diagnostics.AddErrorMsg("local directory module loader load %s failed", x.options.ProjectWorkspace)
+	}
+
+	// step 03. Vote for the versions and install the missing winners
+	providersInstallPlan, d := planner.MakeProviderInstallPlan(ctx, rootModule, lockFile)
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+	installExecutor, d := NewProviderInstallExecutor(&ProviderInstallExecutorOptions{
+		Plans:             providersInstallPlan,
+		DownloadWorkspace: x.options.DownloadWorkspace,
+		MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
+	})
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+	if diagnostics.AddDiagnostics(installExecutor.Execute(ctx)).HasError() {
+		return diagnostics
+	}
+	x.plans = providersInstallPlan
+
+	// step 04. Find the installed versions not used, and remove them if prune
+	usedProviders := make([]*local_providers_manager.LocalProvider, 0)
+	for _, plan := range providersInstallPlan {
+		usedProviders = append(usedProviders, &local_providers_manager.LocalProvider{Provider: plan.Provider})
+	}
+	unusedProviders, d := installExecutor.GetLocalProviderManager().Sync(ctx, &local_providers_manager.SyncProvidersOptions{
+		UsedProviders: usedProviders,
+		Prune:         x.options.Prune,
+	})
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+	x.unusedProviders = unusedProviders
+	if !x.options.Prune || len(unusedProviders) == 0 {
+		return diagnostics
+	}
+
+	// step 05. The database schemas of the pruned versions are released
+	return diagnostics.AddDiagnostics(x.releaseSchemaOwners(ctx, rootModule, unusedProviders))
+}
+
+// Remove the schema owner records this device holds for the providers, so the schemas can be used by others
+func (x *ProviderSyncExecutor) releaseSchemaOwners(ctx context.Context, rootModule *module.Module, providers []*local_providers_manager.LocalProvider) *schema.Diagnostics {
+
+	diagnostics := schema.NewDiagnostics()
+
+	dsn := x.fixDSN(rootModule)
+	if dsn == "" {
+		return diagnostics.AddErrorMsg("can not connect to the database, the schema owner records of the pruned providers are not released")
+	}
+	deviceID, d := selefra_workspace.GetDeviceID()
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+
+	// The schema is only opened if it exists, opening a storage would create it
+	publicOptions := postgresql_storage.NewPostgresqlStorageOptions(dsn)
+	publicOptions.SearchPath = "public"
+	publicStorage, d := storage_factory.NewStorage(ctx, storage_factory.StorageTypePostgresql, publicOptions)
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+	namespaces, d := publicStorage.NamespaceList(ctx)
+	publicStorage.Close()
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+
+	for _, provider := range providers {
+		schemaName := pgstorage.GetSchemaKey(provider.Name, provider.Version, nil)
+		for _, databaseSchema := range []string{schemaName, schemaName + "_" + deviceID} {
+			if !utils.HasOne(namespaces, databaseSchema) {
+				continue
+			}
+			options := postgresql_storage.NewPostgresqlStorageOptions(dsn)
+			options.SearchPath = databaseSchema
+			databaseStorage, d := storage_factory.NewStorage(ctx, storage_factory.StorageTypePostgresql, options)
+			if diagnostics.AddDiagnostics(d).HasError() {
+				return diagnostics
+			}
+			released, d := pgstorage.ReleaseSchemaOwner(ctx, databaseStorage, deviceID)
+			databaseStorage.Close()
+			if diagnostics.AddDiagnostics(d).HasError() {
+				return diagnostics
+			}
+			if released {
+				x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("Provider %s, schema %s, schema owner record released", provider.String(), databaseSchema))
+			}
+		}
+	}
+	return diagnostics
+}
+
+// The same database as apply and fetch use, except that the cloud is not asked for it
+func (x *ProviderSyncExecutor) fixDSN(rootModule *module.Module) string {
+	if x.options.DSN != "" {
+		return x.options.DSN
+	}
+	if rootModule.SelefraBlock != nil && rootModule.SelefraBlock.ConnectionBlock != nil {
+		return rootModule.SelefraBlock.ConnectionBlock.BuildDSN()
+	}
+	if os.Getenv(env.DatabaseDsn) != "" {
+		return os.Getenv(env.DatabaseDsn)
+	}
+	return pgstorage.DefaultPostgreSQL(x.options.DownloadWorkspace, x.options.MessageChannel.MakeChildChannel())
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,263 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/modules/module_loader"
+	"github.com/selefra/selefra/pkg/modules/planner"
+	"github.com/selefra/selefra/pkg/providers/local_providers_manager"
+	"github.com/selefra/selefra/pkg/utils"
+	"os"
+	"strings"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ProviderUpdateExecutorOptions Options when updating the providers of a project
+type ProviderUpdateExecutorOptions struct {
+
+	// project path
+	ProjectWorkspace string
+
+	// download things put where
+	DownloadWorkspace string
+
+	// The providers to update, all the providers the project requires if empty
+	ProviderNames []string
+
+	// Only plan the update, nothing is installed or written
+	DryRun bool
+
+	// Rewrite the version the project requires to the version updated to, the constraint of the project is not respected then
+	RewriteRequiredVersion bool
+
+	// The channel through which messages are received externally
+	MessageChannel *message.Channel[*schema.Diagnostics]
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+const ProviderUpdateExecutorName = "provider-update-executor"
+
+// ProviderUpdateExecutor Update the providers of a project to the latest versions every module allows, the new versions are
+// installed beside the old ones, so a provider can be rolled back by its version constraint
+type ProviderUpdateExecutor struct {
+	options *ProviderUpdateExecutorOptions
+
+	plans planner.ProvidersUpdatePlan
+}
+
+var _ Executor = &ProviderUpdateExecutor{}
+
+func NewProviderUpdateExecutor(options *ProviderUpdateExecutorOptions) *ProviderUpdateExecutor {
+	return &ProviderUpdateExecutor{
+		options: options,
+	}
+}
+
+func (x *ProviderUpdateExecutor) Name() string {
+	return ProviderUpdateExecutorName
+}
+
+// Plans What is updated, or would be updated in a dry run, nil if the planning failed
+func (x *ProviderUpdateExecutor) Plans() planner.ProvidersUpdatePlan {
+	return x.plans
+}
+
+func (x *ProviderUpdateExecutor) Execute(ctx context.Context) *schema.Diagnostics {
+
+	defer func() {
+		x.options.MessageChannel.SenderWaitAndClose()
+	}()
+
+	diagnostics := schema.NewDiagnostics()
+
+	// step 01. The providers are updated from the versions locked, or the versions installed if the project is not locked
+	lockFile, err := lock_file.Read(x.options.ProjectWorkspace)
+	if err != nil {
+		return diagnostics.AddErrorMsg("read lock file %s failed: %s", lock_file.BuildLockFilePath(x.options.ProjectWorkspace), err.Error())
+	}
+
+	// step 02. Load the modules, their constraints are respected
+	moduleLoaderOptions := &module_loader.LocalDirectoryModuleLoaderOptions{
+		ModuleLoaderOptions: &module_loader.ModuleLoaderOptions{
+			Source:            x.options.ProjectWorkspace,
+			DownloadDirectory: x.options.DownloadWorkspace,
+			MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
+			DependenciesTree:  []string{x.options.ProjectWorkspace},
+			LockFile:          lockFile,
+		},
+		ModuleDirectory: x.options.ProjectWorkspace,
+	}
+	loader, err := module_loader.NewLocalDirectoryModuleLoader(moduleLoaderOptions)
+	if err != nil {
+		moduleLoaderOptions.MessageChannel.SenderWaitAndClose()
+		return diagnostics.AddErrorMsg("create local directory module loader from %s error: %s", x.options.ProjectWorkspace, err.Error())
+	}
+	rootModule, ok := loader.Load(ctx)
+	if !ok {
+		return diagnostics.AddErrorMsg("local directory module loader load %s failed", x.options.ProjectWorkspace)
+	}
+
+	// step 03. Plan the update
+	localProviderManager, err := local_providers_manager.NewLocalProvidersManager(x.options.DownloadWorkspace)
+	if err != nil {
+		return diagnostics.AddErrorMsg("create local provider manager failed: %s", err.Error())
+	}
+	currentVersions, d := x.currentVersions(rootModule, lockFile, localProviderManager)
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+	plans, d := planner.MakeProviderUpdatePlan(ctx, &planner.ProviderUpdatePlannerOptions{
+		Module:                rootModule,
+		ProviderNames:         x.options.ProviderNames,
+		CurrentVersions:       currentVersions,
+		IgnoreRootConstraints: x.options.RewriteRequiredVersion,
+	})
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+	x.plans = plans
+	if x.options.DryRun {
+		return diagnostics
+	}
+
+	// step 04. Install the new versions beside the old ones
+	installPlan := plans.ToInstallPlan()
+	if len(installPlan) == 0 {
+		return diagnostics
+	}
+	installExecutor, d := NewProviderInstallExecutor(&ProviderInstallExecutorOptions{
+		Plans:             installPlan,
+		DownloadWorkspace: x.options.DownloadWorkspace,
+		MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
+	})
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+	if diagnostics.AddDiagnostics(installExecutor.Execute(ctx)).HasError() {
+		return diagnostics
+	}
+
+	// step 05. The project requires the new versions from now on
+	if x.options.RewriteRequiredVersion {
+		for _, plan := range plans {
+			if !plan.HasUpdate() {
+				continue
+			}
+			if diagnostics.AddDiagnostics(rewriteRequiredProviderVersion(plan.RootRequiredProviderBlock, plan.UpdateVersion)).HasError() {
+				return diagnostics
+			}
+		}
+	}
+
+	// step 06. Lock the new versions if the project is locked, the providers not updated stay as they are
+	if lockFile != nil {
+		if diagnostics.AddDiagnostics(lockProviders(ctx, lockFile, true, installPlan, installExecutor.GetLocalProviderManager(), x.options.DownloadWorkspace)).HasError() {
+			return diagnostics
+		}
+		if err := lockFile.Save(x.options.ProjectWorkspace); err != nil {
+			return diagnostics.AddErrorMsg("write lock file %s failed: %s", lock_file.BuildLockFilePath(x.options.ProjectWorkspace), err.Error())
+		}
+	}
+
+	return diagnostics
+}
+
+// The versions the providers are updated from
+func (x *ProviderUpdateExecutor) currentVersions(rootModule *module.Module, lockFile *lock_file.LockFile, localProviderManager *local_providers_manager.LocalProvidersManager) (map[string]string, *schema.Diagnostics) {
+	diagnostics := schema.NewDiagnostics()
+	currentVersions := make(map[string]string)
+	rootModule.Traversal(context.Background(), func(ctx context.Context, traversalContext *module.TraversalContext) bool {
+		if traversalContext.Module.SelefraBlock == nil {
+			return true
+		}
+		for _, requiredProviderBlock := range traversalContext.Module.SelefraBlock.RequireProvidersBlock {
+			providerName := requiredProviderBlock.Source
+			if _, exists := currentVersions[providerName]; exists {
+				continue
+			}
+			if lockFile != nil {
+				if lockedProvider := lockFile.GetProvider(providerName); lockedProvider != nil {
+					currentVersions[providerName] = lockedProvider.Version
+					continue
+				}
+			}
+			localProvider, d := localProviderManager.LatestInstalledVersion(providerName)
+			if diagnostics.AddDiagnostics(d).HasError() {
+				return false
+			}
+			if localProvider != nil {
+				currentVersions[providerName] = localProvider.Version
+			} else {
+				currentVersions[providerName] = ""
+			}
+		}
+		return true
+	})
+	return currentVersions, diagnostics
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// Rewrite the version of the required provider block in its yaml file in place, so the comments and the layout of the file
+// are kept, nothing is written if the block does not declare a version, which already means the latest version
+func rewriteRequiredProviderVersion(requiredProviderBlock *module.RequireProviderBlock, newVersion string) *schema.Diagnostics {
+	diagnostics := schema.NewDiagnostics()
+	if requiredProviderBlock == nil || requiredProviderBlock.Version == "" {
+		return diagnostics
+	}
+	location := requiredProviderBlock.GetNodeLocation("version" + module.NodeLocationSelfValue)
+	if location == nil || location.Begin == nil || location.End == nil {
+		return diagnostics.AddErrorMsg("can not find where the version of provider %s is required, please change it to %s by hand", requiredProviderBlock.Source, newVersion)
+	}
+
+	fileBytes, err := os.ReadFile(location.Path)
+	if err != nil {
+		return diagnostics.AddErrorMsg("read file %s error: %s", location.Path, err.Error())
+	}
+	lines := strings.Split(string(fileBytes), "\n")
+	if location.Begin.Line < 1 || location.Begin.Line > len(lines) {
+		return diagnostics.AddErrorMsg("the version of provider %s is not found at line %d of %s", requiredProviderBlock.Source, location.Begin.Line, location.Path)
+	}
+	newLine, ok := replaceYamlScalar(lines[location.Begin.Line-1], location.Begin.Column, location.End.Column, newVersion)
+	if !ok || location.End.Line != location.Begin.Line {
+		return diagnostics.AddErrorMsg("the version of provider %s is not found at line %d of %s", requiredProviderBlock.Source, location.Begin.Line, location.Path)
+	}
+	lines[location.Begin.Line-1] = newLine
+	if err := os.WriteFile(location.Path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return diagnostics.AddErrorMsg("write file %s error: %s", location.Path, err.Error())
+	}
+	requiredProviderBlock.Version = newVersion
+	return diagnostics.AddInfo("The version of provider %s required in %s is rewritten to %s", requiredProviderBlock.Source, utils.AbsPath(location.Path), newVersion)
+}
+
+// Replace the scalar between the columns of the line, the columns count from 1 and the end column is right after the value,
+// the scalar may be quoted, then the end column does not count the quotes, so the closing quote is looked for instead
+func replaceYamlScalar(line string, beginColumn, endColumn int, newValue string) (string, bool) {
+	runes := []rune(line)
+	begin := beginColumn - 1
+	if begin < 0 || begin >= len(runes) {
+		return "", false
+	}
+	if quote := runes[begin]; quote == '"' || quote == '\'' {
+		for end := begin + 1; end < len(runes); end++ {
+			if runes[end] == quote {
+				return string(runes[:begin+1]) + newValue + string(runes[end:]), true
+			}
+		}
+		return "", false
+	}
+	// The plain scalar may be followed by a comment, or by the rest of a flow mapping such as "version: v0.0.1}"
+	end := endColumn - 1
+	if end <= begin || end > len(runes) {
+		return "", false
+	}
+	return fmt.Sprintf("%s%s%s", string(runes[:begin]), newValue, string(runes[end:])), true
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
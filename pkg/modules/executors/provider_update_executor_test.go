@@ -0,0 +1,47 @@
+package executors
+
+import (
+	"github.com/selefra/selefra/pkg/modules/parser"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRewriteRequiredProviderVersion(t *testing.T) {
+	yamlFilePath := filepath.Join(t.TempDir(), "selefra.yaml")
+	yamlContent := `selefra:
+  name: test
+  providers:
+    - name: aws
+      source: aws
+      version: ">= v0.0.1" # keep the comment
+    - name: gcp
+      source: gcp
+      version: v0.0.1
+    - {name: k8s, source: k8s, version: v0.0.1}
+    - {name: azure, version: v0.0.1, source: azure} # the version is not the last
+`
+	assert.Nil(t, os.WriteFile(yamlFilePath, []byte(yamlContent), 0644))
+	rootModule, d := parser.NewYamlFileToModuleParser(yamlFilePath, nil).Parse()
+	assert.False(t, utils.HasError(d))
+
+	for _, requiredProviderBlock := range rootModule.SelefraBlock.RequireProvidersBlock {
+		assert.False(t, utils.HasError(rewriteRequiredProviderVersion(requiredProviderBlock, "v0.0.12")))
+		assert.Equal(t, "v0.0.12", requiredProviderBlock.Version)
+	}
+	fileBytes, err := os.ReadFile(yamlFilePath)
+	assert.Nil(t, err)
+	assert.Contains(t, string(fileBytes), `version: "v0.0.12" # keep the comment`)
+	assert.Contains(t, string(fileBytes), "version: v0.0.12\n")
+	assert.Contains(t, string(fileBytes), "- {name: k8s, source: k8s, version: v0.0.12}\n")
+	assert.Contains(t, string(fileBytes), "- {name: azure, version: v0.0.12, source: azure} # the version is not the last\n")
+
+	rootModule, d = parser.NewYamlFileToModuleParser(yamlFilePath, nil).Parse()
+	assert.False(t, utils.HasError(d))
+	assert.Len(t, rootModule.SelefraBlock.RequireProvidersBlock, 4)
+	for _, requiredProviderBlock := range rootModule.SelefraBlock.RequireProvidersBlock {
+		assert.Equal(t, "v0.0.12", requiredProviderBlock.Version)
+	}
+}
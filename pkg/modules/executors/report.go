@@ -0,0 +1,45 @@
+package executors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+const (
+
+	// ReportFormatJUnit A JUnit XML report, see JUnitReport
+	ReportFormatJUnit = "junit"
+
+	// ReportFormatSarif A SARIF report, see SarifReport
+	ReportFormatSarif = "sarif"
+)
+
+// ReportOption A report to write after the rules are executed, passed by apply --report <format>=<path>
+type ReportOption struct {
+
+	// ReportFormatJUnit or ReportFormatSarif
+	Format string
+
+	// Where to write the report
+	Path string
+}
+
+// ParseReportOption Parse a report in the form of <format>=<path>
+func ParseReportOption(value string) (*ReportOption, error) {
+	format, path, ok := strings.Cut(value, "=")
+	format = strings.ToLower(strings.TrimSpace(format))
+	path = strings.TrimSpace(path)
+	if !ok || format == "" || path == "" {
+		return nil, fmt.Errorf("report %s is not valid, it must be in the form of <format>=<path>, for example junit=report.xml", value)
+	}
+	switch format {
+	case ReportFormatJUnit, ReportFormatSarif:
+		return &ReportOption{Format: format, Path: path}, nil
+	default:
+		return nil, fmt.Errorf("report format %s is not supported, supported formats are %s and %s", format, ReportFormatJUnit, ReportFormatSarif)
+	}
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,187 @@
+package executors
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// JUnitTestSuites The root element of a JUnit XML report
+type JUnitTestSuites struct {
+	XMLName    xml.Name          `xml:"testsuites"`
+	Name       string            `xml:"name,attr"`
+	Tests      int               `xml:"tests,attr"`
+	Failures   int               `xml:"failures,attr"`
+	Errors     int               `xml:"errors,attr"`
+	Skipped    int               `xml:"skipped,attr"`
+	Time       string            `xml:"time,attr"`
+	TestSuites []*JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite The rules of a module
+type JUnitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Errors    int              `xml:"errors,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      string           `xml:"time,attr"`
+	Timestamp string           `xml:"timestamp,attr,omitempty"`
+	TestCases []*JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase One rule
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *JUnitMessage `xml:"failure,omitempty"`
+	Error     *JUnitMessage `xml:"error,omitempty"`
+	Skipped   *JUnitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// JUnitMessage The body of a failure, error or skipped element
+type JUnitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Content string `xml:",chardata"`
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// JUnitReport Collect the RulePlanResult of an apply and write them as a JUnit XML report, so CI systems can render each rule as a test case.
+// The rules are grouped into test suites by module, a rule that found something is a failure
+type JUnitReport struct {
+	startTime time.Time
+
+	// module name to its test suite
+	testSuiteMap map[string]*JUnitTestSuite
+
+	// module name to the time its rules took
+	durationMap map[string]time.Duration
+}
+
+// NewJUnitReport Create an empty report
+func NewJUnitReport() *JUnitReport {
+	return &JUnitReport{
+		startTime:    time.Now(),
+		testSuiteMap: make(map[string]*JUnitTestSuite),
+		durationMap:  make(map[string]time.Duration),
+	}
+}
+
+// Add Convert the result of a rule plan to a test case
+func (x *JUnitReport) Add(result *RulePlanResult) {
+	moduleName := ""
+	if result.RulePlan.Module != nil {
+		moduleName = result.RulePlan.Module.BuildFullName()
+	}
+	testSuite, exists := x.testSuiteMap[moduleName]
+	if !exists {
+		testSuite = &JUnitTestSuite{
+			Name:      moduleName,
+			Timestamp: x.startTime.Format("2006-01-02T15:04:05"),
+		}
+		x.testSuiteMap[moduleName] = testSuite
+	}
+
+	testCase := &JUnitTestCase{
+		Name:      result.RulePlan.RuleBlock.Name,
+		ClassName: moduleName,
+		Time:      formatJUnitDuration(result.Duration),
+	}
+	severity := ""
+	if result.RulePlan.MetadataBlock != nil {
+		severity = result.RulePlan.MetadataBlock.Severity
+	}
+	switch result.Status {
+	case RulePlanStatusFailed:
+		testCase.Failure = &JUnitMessage{
+			Message: fmt.Sprintf("%d findings", result.FindingsCount),
+			Type:    severity,
+			Content: result.Output,
+		}
+		testSuite.Failures++
+	case RulePlanStatusError:
+		testCase.Error = &JUnitMessage{
+			Message: result.Messages[0],
+			Content: strings.Join(result.Messages, "\n"),
+		}
+		testSuite.Errors++
+	case RulePlanStatusTimeout:
+		testCase.Error = &JUnitMessage{
+			Message: result.Messages[0],
+			Type:    string(RulePlanStatusTimeout),
+			Content: strings.Join(result.Messages, "\n"),
+		}
+		testSuite.Errors++
+	case RulePlanStatusSkipped:
+		testCase.Skipped = &JUnitMessage{
+			Message: strings.Join(result.Messages, "; "),
+		}
+		testSuite.Skipped++
+	}
+	// The findings suppressed by waivers do not fail the test case, but they are still listed
+	if len(result.Suppressed) != 0 {
+		suppressedLines := make([]string, 0, len(result.Suppressed))
+		for _, suppressed := range result.Suppressed {
+			suppressedLines = append(suppressedLines, fmt.Sprintf("suppressed: %s (reason: %s, owner: %s, expires: %s)",
+				suppressed.RuleBlock.Output, suppressed.Waiver.Reason, suppressed.Waiver.Owner, suppressed.Waiver.Expires))
+		}
+		testCase.SystemOut = strings.Join(suppressedLines, "\n")
+	}
+	testSuite.Tests++
+	x.durationMap[moduleName] += result.Duration
+	testSuite.TestCases = append(testSuite.TestCases, testCase)
+}
+
+// TestSuites The report as the root element, test suites and test cases are ordered by name so the report is stable
+func (x *JUnitReport) TestSuites() *JUnitTestSuites {
+	testSuites := &JUnitTestSuites{
+		Name: "selefra",
+		Time: formatJUnitDuration(time.Since(x.startTime)),
+	}
+	for moduleName, testSuite := range x.testSuiteMap {
+		sort.SliceStable(testSuite.TestCases, func(i, j int) bool {
+			return testSuite.TestCases[i].Name < testSuite.TestCases[j].Name
+		})
+		testSuite.Time = formatJUnitDuration(x.durationMap[moduleName])
+
+		testSuites.Tests += testSuite.Tests
+		testSuites.Failures += testSuite.Failures
+		testSuites.Errors += testSuite.Errors
+		testSuites.Skipped += testSuite.Skipped
+		testSuites.TestSuites = append(testSuites.TestSuites, testSuite)
+	}
+	sort.Slice(testSuites.TestSuites, func(i, j int) bool {
+		return testSuites.TestSuites[i].Name < testSuites.TestSuites[j].Name
+	})
+	return testSuites
+}
+
+// Write Save the report to the path as xml
+func (x *JUnitReport) Write(path string) error {
+	marshal, err := xml.MarshalIndent(x.TestSuites(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), marshal...), 0644)
+}
+
+func formatJUnitDuration(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,67 @@
+package executors
+
+import (
+	"encoding/xml"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/modules/planner"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJUnitReport(t *testing.T) {
+	rootModule := &module.Module{ModuleLocalDirectory: "./rules"}
+	newRulePlan := func(name string) *planner.RulePlan {
+		return &planner.RulePlan{
+			Module: rootModule,
+			RuleBlock: &module.RuleBlock{
+				Name:          name,
+				MetadataBlock: &module.RuleMetadataBlock{Severity: "High"},
+			},
+		}
+	}
+
+	report := NewJUnitReport()
+	report.Add(&RulePlanResult{RulePlan: newRulePlan("b_passed"), Status: RulePlanStatusPassed, Duration: time.Second})
+	report.Add(&RulePlanResult{RulePlan: newRulePlan("a_failed"), Status: RulePlanStatusFailed, FindingsCount: 2, Output: "bucket a is public\nbucket b is public\n"})
+	report.Add(&RulePlanResult{RulePlan: newRulePlan("c_error"), Status: RulePlanStatusError, Messages: []string{"relation does not exist"}})
+	report.Add(&RulePlanResult{RulePlan: newRulePlan("d_skipped"), Status: RulePlanStatusSkipped, Messages: []string{"filtered"}})
+	report.Add(&RulePlanResult{RulePlan: newRulePlan("e_timeout"), Status: RulePlanStatusTimeout, Messages: []string{"rule e_timeout timed out after 30s"}})
+
+	path := filepath.Join(t.TempDir(), "junit.xml")
+	assert.Nil(t, report.Write(path))
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	testSuites := &JUnitTestSuites{}
+	assert.Nil(t, xml.Unmarshal(content, testSuites))
+
+	assert.Equal(t, 5, testSuites.Tests)
+	assert.Equal(t, 1, testSuites.Failures)
+	assert.Equal(t, 2, testSuites.Errors)
+	assert.Equal(t, 1, testSuites.Skipped)
+	assert.Len(t, testSuites.TestSuites, 1)
+
+	testSuite := testSuites.TestSuites[0]
+	assert.Equal(t, "./rules", testSuite.Name)
+	assert.Equal(t, "a_failed", testSuite.TestCases[0].Name)
+	assert.Equal(t, "2 findings", testSuite.TestCases[0].Failure.Message)
+	assert.Equal(t, "bucket a is public\nbucket b is public\n", testSuite.TestCases[0].Failure.Content)
+	assert.Nil(t, testSuite.TestCases[1].Failure)
+	assert.Equal(t, "relation does not exist", testSuite.TestCases[2].Error.Message)
+	assert.NotNil(t, testSuite.TestCases[3].Skipped)
+	assert.Equal(t, string(RulePlanStatusTimeout), testSuite.TestCases[4].Error.Type)
+}
+
+func TestParseReportOption(t *testing.T) {
+	reportOption, err := ParseReportOption("JUnit=out/report.xml")
+	assert.Nil(t, err)
+	assert.Equal(t, ReportFormatJUnit, reportOption.Format)
+	assert.Equal(t, "out/report.xml", reportOption.Path)
+
+	for _, value := range []string{"junit", "junit=", "=report.xml", "html=report.html"} {
+		_, err := ParseReportOption(value)
+		assert.NotNil(t, err, value)
+	}
+}
@@ -0,0 +1,332 @@
+package executors
+
+import (
+	"encoding/json"
+	"github.com/selefra/selefra/pkg/grpc/pb/issue"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+const (
+
+	// OutputFormatSarif apply --output sarif writes the findings to SarifReportFileName in the --dir directory
+	OutputFormatSarif = "sarif"
+
+	// SarifReportFileName The name of the SARIF file written by apply
+	SarifReportFileName = "selefra.sarif"
+
+	// SarifVersion The version of the SARIF specification the report follows
+	SarifVersion = "2.1.0"
+
+	// SarifSchema The json schema of SARIF 2.1.0
+	SarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// SarifFingerprintKey The key of the fingerprint of a finding in the partialFingerprints of a result, see BuildFindingFingerprint
+	SarifFingerprintKey = "selefraFindingFingerprint/v1"
+)
+
+// SarifLog The root object of a SARIF file, only the properties selefra fills in are declared
+type SarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*SarifRun `json:"runs"`
+}
+
+// SarifRun The findings of one apply and the rules that produced them
+type SarifRun struct {
+	Tool    *SarifTool     `json:"tool"`
+	Results []*SarifResult `json:"results"`
+}
+
+// SarifTool Selefra itself
+type SarifTool struct {
+	Driver *SarifToolComponent `json:"driver"`
+}
+
+// SarifToolComponent The rules that were run
+type SarifToolComponent struct {
+	Name           string                      `json:"name"`
+	InformationUri string                      `json:"informationUri,omitempty"`
+	Rules          []*SarifReportingDescriptor `json:"rules"`
+}
+
+// SarifReportingDescriptor Describes a rule, it comes from the metadata of the rule block
+type SarifReportingDescriptor struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name,omitempty"`
+	ShortDescription     *SarifMessage          `json:"shortDescription,omitempty"`
+	FullDescription      *SarifMessage          `json:"fullDescription,omitempty"`
+	Help                 *SarifMessage          `json:"help,omitempty"`
+	DefaultConfiguration *SarifConfiguration    `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+// SarifConfiguration The default level of a rule
+type SarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+// SarifMessage A text shown to the user, markdown is optional
+type SarifMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// SarifResult One finding, that is one row matched by a rule
+type SarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	RuleIndex           int                    `json:"ruleIndex"`
+	Level               string                 `json:"level"`
+	Message             *SarifMessage          `json:"message"`
+	Locations           []*SarifLocation       `json:"locations,omitempty"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	BaselineState       string                 `json:"baselineState,omitempty"`
+	Suppressions        []*SarifSuppression    `json:"suppressions,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+// SarifSuppression Why a finding is not reported, it comes from the waiver that matches the finding
+type SarifSuppression struct {
+	Kind          string                 `json:"kind"`
+	Status        string                 `json:"status,omitempty"`
+	Justification string                 `json:"justification,omitempty"`
+	Properties    map[string]interface{} `json:"properties,omitempty"`
+}
+
+// SarifLocation Where the finding is, a cloud resource has no file so only logical locations are used
+type SarifLocation struct {
+	LogicalLocations []*SarifLogicalLocation `json:"logicalLocations"`
+}
+
+// SarifLogicalLocation The resource of a finding
+type SarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// SarifReport Collect the findings of an apply from the RuleQueryResultChannel and write them as a SARIF file,
+// so the results can be uploaded to code scanning dashboards
+type SarifReport struct {
+	run *SarifRun
+
+	// rule id to the index of the rule in the driver
+	ruleIndexMap map[string]int
+}
+
+// NewSarifReport Create an empty report
+func NewSarifReport() *SarifReport {
+	return &SarifReport{
+		run: &SarifRun{
+			Tool: &SarifTool{
+				Driver: &SarifToolComponent{
+					Name:           "selefra",
+					InformationUri: "https://github.com/selefra/selefra",
+					Rules:          make([]*SarifReportingDescriptor, 0),
+				},
+			},
+			Results: make([]*SarifResult, 0),
+		},
+		ruleIndexMap: make(map[string]int),
+	}
+}
+
+// Add Convert the query result to a SARIF result if it is a finding, passed resources are not reported
+func (x *SarifReport) Add(result *RuleQueryResult) {
+	if result.Status == issue.UploadIssueStream_Rule_SUCCESS || result.RuleBlock == nil {
+		return
+	}
+	finding := NewFindingFromRuleQueryResult(result)
+	ruleID := finding.RuleID
+	if ruleID == "" {
+		ruleID = finding.RuleName
+	}
+
+	sarifResult := &SarifResult{
+		RuleID:    ruleID,
+		RuleIndex: x.ruleIndex(ruleID, result),
+		Level:     SarifLevel(finding.Severity),
+		Message: &SarifMessage{
+			Text: finding.Output,
+		},
+		PartialFingerprints: map[string]string{
+			SarifFingerprintKey: finding.Fingerprint,
+		},
+		Properties: map[string]interface{}{
+			"severity": finding.Severity,
+			"provider": finding.ProviderName,
+			"schema":   finding.ProviderSchema,
+			"labels":   result.RuleBlock.Labels,
+		},
+	}
+	if finding.ResourceID != "" {
+		sarifResult.Locations = []*SarifLocation{
+			{
+				LogicalLocations: []*SarifLogicalLocation{
+					{
+						Name:               finding.ResourceID,
+						FullyQualifiedName: finding.ResourceID,
+						Kind:               "resource",
+					},
+				},
+			},
+		}
+	}
+	switch result.BaselineStatus {
+	case FindingBaselineStatusNew, FindingBaselineStatusUnchanged:
+		sarifResult.BaselineState = string(result.BaselineStatus)
+	}
+	// The waiver is declared in the project rather than in the source, so the suppression is external
+	if result.Waiver != nil {
+		sarifResult.Suppressions = []*SarifSuppression{
+			{
+				Kind:          "external",
+				Status:        "accepted",
+				Justification: result.Waiver.Reason,
+				Properties: map[string]interface{}{
+					"owner":   result.Waiver.Owner,
+					"expires": result.Waiver.Expires,
+				},
+			},
+		}
+	}
+	x.run.Results = append(x.run.Results, sarifResult)
+}
+
+// The rule is described by the metadata of its first finding, the later findings refer to it by index
+func (x *SarifReport) ruleIndex(ruleID string, result *RuleQueryResult) int {
+	if index, exists := x.ruleIndexMap[ruleID]; exists {
+		return index
+	}
+	descriptor := &SarifReportingDescriptor{
+		ID:   ruleID,
+		Name: result.RuleBlock.Name,
+	}
+	if metadata := result.RuleBlock.MetadataBlock; metadata != nil {
+		if metadata.Title != "" {
+			descriptor.ShortDescription = &SarifMessage{Text: metadata.Title}
+		}
+		if metadata.Description != "" {
+			descriptor.FullDescription = &SarifMessage{Text: metadata.Description}
+		}
+		// The remediation has been replaced with the content of its markdown file when the rule is rendered
+		if metadata.Remediation != "" {
+			descriptor.Help = &SarifMessage{Text: metadata.Remediation, Markdown: metadata.Remediation}
+		}
+		descriptor.DefaultConfiguration = &SarifConfiguration{Level: SarifLevel(metadata.Severity)}
+		descriptor.Properties = map[string]interface{}{
+			"severity":          metadata.Severity,
+			"security-severity": SarifSecuritySeverity(metadata.Severity),
+		}
+		if len(metadata.Tags) != 0 {
+			descriptor.Properties["tags"] = metadata.Tags
+		}
+	}
+	x.run.Tool.Driver.Rules = append(x.run.Tool.Driver.Rules, descriptor)
+	index := len(x.run.Tool.Driver.Rules) - 1
+	x.ruleIndexMap[ruleID] = index
+	return index
+}
+
+// ResultCount How many findings are in the report
+func (x *SarifReport) ResultCount() int {
+	return len(x.run.Results)
+}
+
+// Log The SARIF document of the report, the rules and results are sorted
+// because the rules are executed in parallel and their findings are added in any order
+func (x *SarifReport) Log() *SarifLog {
+	rules := make([]*SarifReportingDescriptor, len(x.run.Tool.Driver.Rules))
+	copy(rules, x.run.Tool.Driver.Rules)
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+	ruleIndexMap := make(map[string]int, len(rules))
+	for index, rule := range rules {
+		ruleIndexMap[rule.ID] = index
+	}
+
+	results := make([]*SarifResult, 0, len(x.run.Results))
+	for _, result := range x.run.Results {
+		sortedResult := *result
+		sortedResult.RuleIndex = ruleIndexMap[result.RuleID]
+		results = append(results, &sortedResult)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].RuleID != results[j].RuleID {
+			return results[i].RuleID < results[j].RuleID
+		}
+		if results[i].Message.Text != results[j].Message.Text {
+			return results[i].Message.Text < results[j].Message.Text
+		}
+		return results[i].PartialFingerprints[SarifFingerprintKey] < results[j].PartialFingerprints[SarifFingerprintKey]
+	})
+
+	return &SarifLog{
+		Schema:  SarifSchema,
+		Version: SarifVersion,
+		Runs: []*SarifRun{
+			{
+				Tool: &SarifTool{
+					Driver: &SarifToolComponent{
+						Name:           x.run.Tool.Driver.Name,
+						InformationUri: x.run.Tool.Driver.InformationUri,
+						Rules:          rules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+}
+
+// Write Save the report to the path as json
+func (x *SarifReport) Write(path string) error {
+	marshal, err := json.MarshalIndent(x.Log(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, marshal, 0644)
+}
+
+// SarifLevel Map the severity of a rule to the level of SARIF
+func SarifLevel(severity string) string {
+	switch severity {
+	case "Critical", "High":
+		return "error"
+	case "Medium":
+		return "warning"
+	case "Low", "Informational":
+		return "note"
+	default:
+		return "warning"
+	}
+}
+
+// SarifSecuritySeverity Map the severity of a rule to a score of 0.0 to 10.0, code scanning dashboards use it to rank the findings
+func SarifSecuritySeverity(severity string) string {
+	switch severity {
+	case "Critical":
+		return "9.5"
+	case "High":
+		return "8.0"
+	case "Medium":
+		return "5.5"
+	case "Low":
+		return "3.0"
+	default:
+		return "0.0"
+	}
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,61 @@
+package executors
+
+import (
+	"encoding/json"
+	"github.com/selefra/selefra/pkg/grpc/pb/issue"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSarifReport(t *testing.T) {
+	report := NewSarifReport()
+	newResult := func(resourceID string, status issue.UploadIssueStream_Rule_Status) *RuleQueryResult {
+		return &RuleQueryResult{
+			Status: status,
+			RuleBlock: &module.RuleBlock{
+				Name:   "bucket_is_public",
+				Output: "bucket " + resourceID + " is public",
+				Labels: map[string]interface{}{"resource_id": resourceID},
+				MetadataBlock: &module.RuleMetadataBlock{
+					Id:          "SF010101",
+					Severity:    "High",
+					Title:       "S3 bucket is public",
+					Description: "Anyone can read the bucket",
+					Remediation: "# Block public access",
+				},
+			},
+		}
+	}
+	report.Add(newResult("arn:aws:s3:::a", issue.UploadIssueStream_Rule_FAILED))
+	report.Add(newResult("arn:aws:s3:::b", issue.UploadIssueStream_Rule_FAILED))
+	report.Add(newResult("arn:aws:s3:::c", issue.UploadIssueStream_Rule_SUCCESS))
+	waived := newResult("arn:aws:s3:::d", issue.UploadIssueStream_Rule_FAILED)
+	waived.Waiver = &module.WaiverBlock{Reason: "public website", Owner: "web-team", Expires: "2099-01-01"}
+	report.Add(waived)
+	assert.Equal(t, 3, report.ResultCount())
+
+	path := filepath.Join(t.TempDir(), SarifReportFileName)
+	assert.Nil(t, report.Write(path))
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	sarifLog := &SarifLog{}
+	assert.Nil(t, json.Unmarshal(content, sarifLog))
+
+	assert.Equal(t, SarifVersion, sarifLog.Version)
+	run := sarifLog.Runs[0]
+	assert.Len(t, run.Tool.Driver.Rules, 1)
+	assert.Equal(t, "SF010101", run.Tool.Driver.Rules[0].ID)
+	assert.Equal(t, "S3 bucket is public", run.Tool.Driver.Rules[0].ShortDescription.Text)
+	assert.Equal(t, "# Block public access", run.Tool.Driver.Rules[0].Help.Markdown)
+	assert.Len(t, run.Results, 3)
+	assert.Empty(t, run.Results[0].Suppressions)
+	assert.Equal(t, "accepted", run.Results[2].Suppressions[0].Status)
+	assert.Equal(t, "public website", run.Results[2].Suppressions[0].Justification)
+	assert.Equal(t, "error", run.Results[1].Level)
+	assert.Equal(t, 0, run.Results[1].RuleIndex)
+	assert.Equal(t, "arn:aws:s3:::b", run.Results[1].Locations[0].LogicalLocations[0].FullyQualifiedName)
+	assert.NotEqual(t, run.Results[0].PartialFingerprints[SarifFingerprintKey], run.Results[1].PartialFingerprints[SarifFingerprintKey])
+}
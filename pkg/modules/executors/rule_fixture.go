@@ -0,0 +1,288 @@
+package executors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra-provider-sdk/storage"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/modules/module_loader"
+	"github.com/selefra/selefra/pkg/modules/planner"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// RuleFixtureDirectoryName The fixtures of the rules of a module are the yaml files in this directory of the module
+const RuleFixtureDirectoryName = "tests"
+
+// RuleFixtureFile The content of a fixture file, a file can hold the test cases of several rules
+type RuleFixtureFile struct {
+	Tests []*RuleFixture `yaml:"tests"`
+}
+
+// RuleFixture A test case of a rule: the rows of the tables the rule queries, and what the rule is expected to find in them
+type RuleFixture struct {
+
+	// The name of the test case, the rule name if empty
+	Name string `yaml:"name"`
+
+	// The name of the rule in the module of the fixture
+	Rule string `yaml:"rule"`
+
+	// Table name to its rows, the columns of a table are the keys of its rows, and their types are inferred from the values
+	Tables map[string][]map[string]interface{} `yaml:"tables"`
+
+	// What the rule should find, nothing if not set
+	Expect *RuleFixtureExpect `yaml:"expect"`
+
+	// The module the fixture belongs to
+	Module *module.Module `yaml:"-"`
+
+	// The file the fixture is read from
+	FilePath string `yaml:"-"`
+}
+
+// RuleFixtureExpect The findings a rule is expected to report
+type RuleFixtureExpect struct {
+
+	// How many rows the rule matches, it is the length of Outputs if not set
+	Count *int `yaml:"count"`
+
+	// The rendered outputs of the findings, in any order
+	Outputs []string `yaml:"outputs"`
+}
+
+// String The name of the fixture as shown in the test report
+func (x *RuleFixture) String() string {
+	name := x.Name
+	if name == "" {
+		name = x.Rule
+	}
+	return fmt.Sprintf("%s (%s)", name, x.FilePath)
+}
+
+// ExpectedCount How many findings the rule is expected to report
+func (x *RuleFixture) ExpectedCount() int {
+	if x.Expect == nil {
+		return 0
+	}
+	if x.Expect.Count != nil {
+		return *x.Expect.Count
+	}
+	return len(x.Expect.Outputs)
+}
+
+// Compare the findings of the rule with the expectation, returns what does not match, empty if the fixture passes
+func (x *RuleFixture) Compare(outputs []string) []string {
+	mismatches := make([]string, 0)
+	if len(outputs) != x.ExpectedCount() {
+		mismatches = append(mismatches, fmt.Sprintf("expected %d findings, but got %d", x.ExpectedCount(), len(outputs)))
+	}
+	if x.Expect == nil || len(x.Expect.Outputs) == 0 {
+		return mismatches
+	}
+
+	// The outputs are compared as a multiset, the order of the rows returned by the query is not stable
+	outputCountMap := make(map[string]int)
+	for _, output := range outputs {
+		outputCountMap[strings.TrimSpace(output)]++
+	}
+	for _, expected := range x.Expect.Outputs {
+		expected = strings.TrimSpace(expected)
+		if outputCountMap[expected] > 0 {
+			outputCountMap[expected]--
+		} else {
+			mismatches = append(mismatches, fmt.Sprintf("missing output: %s", expected))
+		}
+	}
+	unexpectedOutputs := make([]string, 0)
+	for output, count := range outputCountMap {
+		for i := 0; i < count; i++ {
+			unexpectedOutputs = append(unexpectedOutputs, fmt.Sprintf("unexpected output: %s", output))
+		}
+	}
+	sort.Strings(unexpectedOutputs)
+	return append(mismatches, unexpectedOutputs...)
+}
+
+// TableToProviderMap All tables of the fixture are bound to the same provider, so the rule query is executed on the fixture schema as is
+func (x *RuleFixture) TableToProviderMap(providerName string) map[string]string {
+	tableToProviderMap := make(map[string]string, len(x.Tables))
+	for tableName := range x.Tables {
+		tableToProviderMap[tableName] = providerName
+	}
+	return tableToProviderMap
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ReadRuleFixtureFile Read the fixtures in a fixture file
+func ReadRuleFixtureFile(path string) ([]*RuleFixture, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fixtureFile := &RuleFixtureFile{}
+	if err := yaml.Unmarshal(content, fixtureFile); err != nil {
+		return nil, fmt.Errorf("rule fixture file %s is not valid: %s", path, err.Error())
+	}
+	for index, fixture := range fixtureFile.Tests {
+		if fixture.Rule == "" {
+			return nil, fmt.Errorf("rule fixture file %s tests[%d] must have rule", path, index)
+		}
+		fixture.FilePath = path
+	}
+	return fixtureFile.Tests, nil
+}
+
+// LoadRuleFixtures Read the fixtures in the RuleFixtureDirectoryName directory of every module of the module tree
+func LoadRuleFixtures(ctx context.Context, rootModule *module.Module) ([]*RuleFixture, *schema.Diagnostics) {
+	diagnostics := schema.NewDiagnostics()
+	fixtures := make([]*RuleFixture, 0)
+	rootModule.Traversal(ctx, func(ctx context.Context, traversalContext *module.TraversalContext) bool {
+		fixtureDirectory := filepath.Join(traversalContext.Module.ModuleLocalDirectory, RuleFixtureDirectoryName)
+		entries, err := os.ReadDir(fixtureDirectory)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				diagnostics.AddErrorMsg("read rule fixture directory %s error: %s", fixtureDirectory, err.Error())
+			}
+			return true
+		}
+		for _, entry := range entries {
+			if !module_loader.IsYamlFile(entry) {
+				continue
+			}
+			moduleFixtures, err := ReadRuleFixtureFile(filepath.Join(fixtureDirectory, entry.Name()))
+			if err != nil {
+				diagnostics.AddErrorMsg(err.Error())
+				continue
+			}
+			for _, fixture := range moduleFixtures {
+				fixture.Module = traversalContext.Module
+				fixtures = append(fixtures, fixture)
+			}
+		}
+		return true
+	})
+	return fixtures, diagnostics
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// RuleFixtureTable A table of a fixture with the types of its columns
+type RuleFixtureTable struct {
+	Name string
+
+	// The columns in order of name
+	ColumnNames []string
+
+	// Column name to postgresql type
+	ColumnTypes map[string]string
+
+	Rows []map[string]interface{}
+}
+
+// NewRuleFixtureTable Infer the columns of the table from the values of its rows,
+// a column that has both integers and floats is a float, and a column that has no value is text
+func NewRuleFixtureTable(tableName string, rows []map[string]interface{}) (*RuleFixtureTable, error) {
+	table := &RuleFixtureTable{
+		Name:        tableName,
+		ColumnTypes: make(map[string]string),
+		Rows:        rows,
+	}
+	for _, row := range rows {
+		for columnName, value := range row {
+			columnType := ruleFixtureColumnType(value)
+			existsType, exists := table.ColumnTypes[columnName]
+			switch {
+			case !exists || existsType == "":
+				table.ColumnTypes[columnName] = columnType
+			case columnType == "" || columnType == existsType:
+			case isRuleFixtureNumberType(columnType) && isRuleFixtureNumberType(existsType):
+				table.ColumnTypes[columnName] = "double precision"
+			default:
+				return nil, fmt.Errorf("column %s of table %s has values of both %s and %s", columnName, tableName, existsType, columnType)
+			}
+		}
+	}
+	for columnName, columnType := range table.ColumnTypes {
+		if columnType == "" {
+			table.ColumnTypes[columnName] = "text"
+		}
+		table.ColumnNames = append(table.ColumnNames, columnName)
+	}
+	sort.Strings(table.ColumnNames)
+	return table, nil
+}
+
+// The postgresql type of a yaml value, empty for null
+func ruleFixtureColumnType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return ""
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "bigint"
+	case float64:
+		return "double precision"
+	case time.Time:
+		return "timestamp"
+	case map[string]interface{}, []interface{}:
+		return "jsonb"
+	default:
+		return "text"
+	}
+}
+
+func isRuleFixtureNumberType(columnType string) bool {
+	return columnType == "bigint" || columnType == "double precision"
+}
+
+// Create the table in the schema that the storage is on, and insert the rows
+func (x *RuleFixtureTable) Create(ctx context.Context, storage storage.Storage) *schema.Diagnostics {
+	columnDefinitions := make([]string, 0, len(x.ColumnNames))
+	quotedColumnNames := make([]string, 0, len(x.ColumnNames))
+	placeholders := make([]string, 0, len(x.ColumnNames))
+	for index, columnName := range x.ColumnNames {
+		columnDefinitions = append(columnDefinitions, planner.QuoteIdentifier(columnName)+" "+x.ColumnTypes[columnName])
+		quotedColumnNames = append(quotedColumnNames, planner.QuoteIdentifier(columnName))
+		placeholders = append(placeholders, fmt.Sprintf("$%d", index+1))
+	}
+	createSql := fmt.Sprintf("CREATE TABLE %s (%s)", planner.QuoteIdentifier(x.Name), strings.Join(columnDefinitions, ", "))
+	if d := storage.Exec(ctx, createSql); d != nil && d.HasError() {
+		return d
+	}
+	if len(x.ColumnNames) == 0 {
+		return nil
+	}
+
+	insertSql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", planner.QuoteIdentifier(x.Name), strings.Join(quotedColumnNames, ", "), strings.Join(placeholders, ", "))
+	for _, row := range x.Rows {
+		values := make([]any, 0, len(x.ColumnNames))
+		for _, columnName := range x.ColumnNames {
+			value := row[columnName]
+			if x.ColumnTypes[columnName] == "jsonb" && value != nil {
+				marshal, err := json.Marshal(value)
+				if err != nil {
+					return schema.NewDiagnostics().AddErrorMsg("column %s of table %s can not be converted to json: %s", columnName, x.Name, err.Error())
+				}
+				value = string(marshal)
+			}
+			values = append(values, value)
+		}
+		if d := storage.Exec(ctx, insertSql, values...); d != nil && d.HasError() {
+			return d
+		}
+	}
+	return nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
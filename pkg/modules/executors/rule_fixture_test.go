@@ -0,0 +1,46 @@
+package executors
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReadRuleFixtureFile(t *testing.T) {
+	fixtures, err := ReadRuleFixtureFile("./test_data/test_rule_fixture/tests/ebs.yaml")
+	assert.Nil(t, err)
+	assert.Len(t, fixtures, 2)
+	assert.Equal(t, "ebs_volume_unencrypted", fixtures[0].Rule)
+	assert.Equal(t, 1, fixtures[0].ExpectedCount())
+	assert.Equal(t, 0, fixtures[1].ExpectedCount())
+	assert.Equal(t, map[string]string{"aws_ec2_ebs_volumes": RuleFixtureProviderName}, fixtures[0].TableToProviderMap(RuleFixtureProviderName))
+
+	table, err := NewRuleFixtureTable("aws_ec2_ebs_volumes", fixtures[0].Tables["aws_ec2_ebs_volumes"])
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"encrypted", "size", "tags", "volume_id"}, table.ColumnNames)
+	assert.Equal(t, "boolean", table.ColumnTypes["encrypted"])
+	assert.Equal(t, "double precision", table.ColumnTypes["size"])
+	assert.Equal(t, "jsonb", table.ColumnTypes["tags"])
+	assert.Equal(t, "text", table.ColumnTypes["volume_id"])
+
+	_, err = NewRuleFixtureTable("t", []map[string]interface{}{{"a": true}, {"a": "yes"}})
+	assert.NotNil(t, err)
+}
+
+func TestRuleFixture_Compare(t *testing.T) {
+	fixture := &RuleFixture{
+		Rule: "ebs_volume_unencrypted",
+		Expect: &RuleFixtureExpect{
+			Outputs: []string{"volume vol-1 is not encrypted", "volume vol-2 is not encrypted"},
+		},
+	}
+	assert.Empty(t, fixture.Compare([]string{"volume vol-2 is not encrypted", "volume vol-1 is not encrypted\n"}))
+	assert.Equal(t, []string{
+		"missing output: volume vol-2 is not encrypted",
+		"unexpected output: volume vol-3 is not encrypted",
+	}, fixture.Compare([]string{"volume vol-1 is not encrypted", "volume vol-3 is not encrypted"}))
+	assert.Len(t, fixture.Compare(nil), 3)
+
+	count := 2
+	assert.Empty(t, (&RuleFixture{Expect: &RuleFixtureExpect{Count: &count}}).Compare([]string{"a", "b"}))
+	assert.Equal(t, []string{"expected 0 findings, but got 1"}, (&RuleFixture{}).Compare([]string{"a"}))
+}
@@ -0,0 +1,355 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"github.com/selefra/selefra-provider-sdk/env"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra-provider-sdk/storage/database_storage/postgresql_storage"
+	"github.com/selefra/selefra-provider-sdk/storage_factory"
+	"github.com/selefra/selefra-utils/pkg/id_util"
+	"github.com/selefra/selefra/pkg/grpc/pb/issue"
+	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/modules/module_loader"
+	"github.com/selefra/selefra/pkg/modules/planner"
+	"github.com/selefra/selefra/pkg/storage/pgstorage"
+	"github.com/selefra/selefra/pkg/utils"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+const (
+
+	// RuleFixtureProviderName The provider the tables of a fixture are bound to
+	RuleFixtureProviderName = "fixture"
+
+	// RuleFixtureSchemaPrefix The scratch schema of a fixture, it is dropped after the fixture is tested
+	RuleFixtureSchemaPrefix = "selefra_rule_test_"
+)
+
+// RuleTestExecutorOptions Options for testing the rules of a project with their fixtures
+type RuleTestExecutorOptions struct {
+
+	// The directory of the project
+	ProjectWorkspace string
+
+	// Where the submodules are downloaded to
+	DownloadWorkspace string
+
+	// The postgresql the scratch schemas are created in, the built-in postgresql is used if empty
+	DSN string
+
+	// Only test the fixtures of these rules, all fixtures if empty
+	Rules []string
+
+	// Receive the messages, the executor closes it when it is done
+	MessageChannel *message.Channel[*schema.Diagnostics]
+}
+
+// RuleTestResult The result of a fixture
+type RuleTestResult struct {
+	Fixture *RuleFixture
+
+	// The rendered outputs of the findings of the rule
+	Outputs []string
+
+	// Why the fixture failed, the fixture passed if it is empty
+	Mismatches []string
+}
+
+// IsPassed Whether the rule found exactly what the fixture expects
+func (x *RuleTestResult) IsPassed() bool {
+	return len(x.Mismatches) == 0
+}
+
+// RuleTestExecutor Load the rows of each fixture into a scratch schema, run the rule on it and compare the findings with the expectation,
+// so a rule can be tested without cloud credentials or a fetch
+type RuleTestExecutor struct {
+	options *RuleTestExecutorOptions
+
+	results []*RuleTestResult
+}
+
+var _ Executor = &RuleTestExecutor{}
+
+func NewRuleTestExecutor(options *RuleTestExecutorOptions) *RuleTestExecutor {
+	return &RuleTestExecutor{
+		options: options,
+		results: make([]*RuleTestResult, 0),
+	}
+}
+
+func (x *RuleTestExecutor) Name() string {
+	return "rule-test-executor"
+}
+
+// GetResults The results of the fixtures, in the order they are tested
+func (x *RuleTestExecutor) GetResults() []*RuleTestResult {
+	return x.results
+}
+
+// FailedCount How many fixtures failed
+func (x *RuleTestExecutor) FailedCount() int {
+	count := 0
+	for _, result := range x.results {
+		if !result.IsPassed() {
+			count++
+		}
+	}
+	return count
+}
+
+func (x *RuleTestExecutor) Execute(ctx context.Context) *schema.Diagnostics {
+	defer func() {
+		x.options.MessageChannel.SenderWaitAndClose()
+	}()
+
+	diagnostics := schema.NewDiagnostics()
+
+	rootModule, d := x.loadModule(ctx)
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+
+	fixtures, d := LoadRuleFixtures(ctx, rootModule)
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+	fixtures = x.filterFixtures(fixtures)
+	if len(fixtures) == 0 {
+		return diagnostics.AddErrorMsg("no rule fixture found, rule fixtures are yaml files in the %s directory of a module", RuleFixtureDirectoryName)
+	}
+
+	if !x.fixDsn() {
+		return diagnostics.AddErrorMsg("can not find a database to run the rule fixtures")
+	}
+
+	for _, fixture := range fixtures {
+		result := x.testFixture(ctx, rootModule, fixture)
+		x.results = append(x.results, result)
+		if result.IsPassed() {
+			x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("PASS %s", fixture.String()))
+		} else {
+			x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("FAIL %s\n\t%s", fixture.String(), strings.Join(result.Mismatches, "\n\t")))
+		}
+	}
+	x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("\n%d fixtures, %d passed, %d failed", len(x.results), len(x.results)-x.FailedCount(), x.FailedCount()))
+
+	return diagnostics
+}
+
+// A rule pack does not need to be a runnable project, so only the rules are checked
+func (x *RuleTestExecutor) loadModule(ctx context.Context) (*module.Module, *schema.Diagnostics) {
+	diagnostics := schema.NewDiagnostics()
+	loader, err := module_loader.NewLocalDirectoryModuleLoader(&module_loader.LocalDirectoryModuleLoaderOptions{
+		ModuleLoaderOptions: &module_loader.ModuleLoaderOptions{
+			Source:            x.options.ProjectWorkspace,
+			Version:           "",
+			DownloadDirectory: x.options.DownloadWorkspace,
+			ProgressTracker:   nil,
+			MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
+			DependenciesTree:  []string{x.options.ProjectWorkspace},
+		},
+		ModuleDirectory: x.options.ProjectWorkspace,
+	})
+	if err != nil {
+		return nil, diagnostics.AddErrorMsg("create local directory module loader from %s error: %s", x.options.ProjectWorkspace, err.Error())
+	}
+	rootModule, ok := loader.Load(ctx)
+	if !ok {
+		return nil, diagnostics.AddErrorMsg("local directory module loader load %s failed", x.options.ProjectWorkspace)
+	}
+	validatorContext := module.NewValidatorContext()
+	rootModule.Traversal(ctx, func(ctx context.Context, traversalContext *module.TraversalContext) bool {
+		diagnostics.AddDiagnostics(traversalContext.Module.RulesBlock.Check(traversalContext.Module, validatorContext))
+		return true
+	})
+	return rootModule, diagnostics
+}
+
+func (x *RuleTestExecutor) filterFixtures(fixtures []*RuleFixture) []*RuleFixture {
+	if len(x.options.Rules) == 0 {
+		return fixtures
+	}
+	ruleNameSet := make(map[string]struct{}, len(x.options.Rules))
+	for _, ruleName := range x.options.Rules {
+		ruleNameSet[ruleName] = struct{}{}
+	}
+	filteredFixtures := make([]*RuleFixture, 0)
+	for _, fixture := range fixtures {
+		if _, exists := ruleNameSet[fixture.Rule]; exists {
+			filteredFixtures = append(filteredFixtures, fixture)
+		}
+	}
+	return filteredFixtures
+}
+
+func (x *RuleTestExecutor) fixDsn() bool {
+	if x.options.DSN != "" {
+		return true
+	}
+	if os.Getenv(env.DatabaseDsn) != "" {
+		x.options.DSN = os.Getenv(env.DatabaseDsn)
+		return true
+	}
+	x.options.DSN = pgstorage.DefaultPostgreSQL(x.options.DownloadWorkspace, x.options.MessageChannel.MakeChildChannel())
+	return x.options.DSN != ""
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+func (x *RuleTestExecutor) testFixture(ctx context.Context, rootModule *module.Module, fixture *RuleFixture) *RuleTestResult {
+	result := &RuleTestResult{
+		Fixture: fixture,
+	}
+	fail := func(format string, args ...any) *RuleTestResult {
+		result.Mismatches = append(result.Mismatches, fmt.Sprintf(format, args...))
+		return result
+	}
+
+	// Each fixture has its own schema, so the rows of a fixture never leak into another
+	databaseSchema := RuleFixtureSchemaPrefix + strings.ReplaceAll(id_util.RandomId(), "-", "")
+	options := postgresql_storage.NewPostgresqlStorageOptions(x.options.DSN)
+	options.SearchPath = databaseSchema
+	databaseStorage, d := storage_factory.NewStorage(ctx, storage_factory.StorageTypePostgresql, options)
+	if utils.HasError(d) {
+		return fail("connect database error: %s", d.ToString())
+	}
+	defer func() {
+		databaseStorage.Close()
+	}()
+	if d := databaseStorage.Exec(ctx, "CREATE SCHEMA "+planner.QuoteIdentifier(databaseSchema)); utils.HasError(d) {
+		return fail("create schema %s error: %s", databaseSchema, d.ToString())
+	}
+	defer func() {
+		if d := databaseStorage.Exec(ctx, "DROP SCHEMA "+planner.QuoteIdentifier(databaseSchema)+" CASCADE"); utils.HasError(d) {
+			x.options.MessageChannel.Send(schema.NewDiagnostics().AddWarn("drop schema %s error: %s", databaseSchema, d.ToString()))
+		}
+	}()
+
+	tableNames := make([]string, 0, len(fixture.Tables))
+	for tableName := range fixture.Tables {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+	for _, tableName := range tableNames {
+		table, err := NewRuleFixtureTable(tableName, fixture.Tables[tableName])
+		if err != nil {
+			return fail(err.Error())
+		}
+		if d := table.Create(ctx, databaseStorage); utils.HasError(d) {
+			return fail("load table %s error: %s", tableName, d.ToString())
+		}
+	}
+
+	rulePlan, d := x.makeRulePlan(ctx, rootModule, fixture)
+	if utils.HasError(d) {
+		return fail("plan rule %s error: %s", fixture.Rule, d.ToString())
+	}
+	if rulePlan == nil {
+		return fail("rule %s not found in module %s", fixture.Rule, fixture.Module.BuildFullName())
+	}
+
+	outputs, messages := x.execRulePlan(ctx, rulePlan, &planner.ProviderContext{
+		ProviderName: RuleFixtureProviderName,
+		DSN:          x.options.DSN,
+		Schema:       databaseSchema,
+		Storage:      databaseStorage,
+	})
+	result.Outputs = outputs
+	if len(messages) != 0 {
+		result.Mismatches = append(result.Mismatches, messages...)
+		return result
+	}
+	result.Mismatches = append(result.Mismatches, fixture.Compare(outputs)...)
+	return result
+}
+
+// The rule is planned in the scope it has in the whole module tree, so the variables passed down by the parent modules are rendered
+func (x *RuleTestExecutor) makeRulePlan(ctx context.Context, rootModule *module.Module, fixture *RuleFixture) (*planner.RulePlan, *schema.Diagnostics) {
+	modulePlan, d := planner.MakeModuleQueryPlan(ctx, &planner.ModulePlannerOptions{
+		Instruction: map[string]interface{}{
+			planner.InstructionKeyRule: []string{fixture.Rule},
+		},
+		Module:             rootModule,
+		TableToProviderMap: fixture.TableToProviderMap(RuleFixtureProviderName),
+	})
+	if utils.HasError(d) {
+		return nil, d
+	}
+	return x.findRulePlan(modulePlan, fixture), nil
+}
+
+func (x *RuleTestExecutor) findRulePlan(modulePlan *planner.ModulePlan, fixture *RuleFixture) *planner.RulePlan {
+	for _, rulePlan := range modulePlan.RulesPlan {
+		if rulePlan.Module == fixture.Module && rulePlan.RuleBlock.Name == fixture.Rule {
+			return rulePlan
+		}
+	}
+	for _, subModulePlan := range modulePlan.SubModulesPlan {
+		if rulePlan := x.findRulePlan(subModulePlan, fixture); rulePlan != nil {
+			return rulePlan
+		}
+	}
+	return nil
+}
+
+// Run the rule plan with the query executor the same as apply, returns the outputs of the findings and the errors of the rule
+func (x *RuleTestExecutor) execRulePlan(ctx context.Context, rulePlan *planner.RulePlan, providerContext *planner.ProviderContext) ([]string, []string) {
+	lock := sync.Mutex{}
+	outputs := make([]string, 0)
+	messages := make([]string, 0)
+	addMessage := func(message string) {
+		lock.Lock()
+		defer lock.Unlock()
+		messages = append(messages, message)
+	}
+
+	messageChannel := message.NewChannel[*schema.Diagnostics](func(index int, message *schema.Diagnostics) {
+		if utils.HasError(message) {
+			addMessage(strings.TrimSpace(message.ToString()))
+		}
+	})
+	// The waivers of the module do not apply, a fixture tests the rule itself
+	ruleQueryResultChannel := message.NewChannel[*RuleQueryResult](func(index int, message *RuleQueryResult) {
+		if message.Status != issue.UploadIssueStream_Rule_SUCCESS {
+			lock.Lock()
+			defer lock.Unlock()
+			outputs = append(outputs, message.RuleBlock.Output)
+		}
+	})
+	rulePlanResultChannel := message.NewChannel[*RulePlanResult](func(index int, message *RulePlanResult) {
+		if message.Status == RulePlanStatusError || message.Status == RulePlanStatusTimeout || message.Status == RulePlanStatusSkipped {
+			for _, m := range message.Messages {
+				addMessage(m)
+			}
+		}
+	})
+	queryExecutor := NewModuleQueryExecutor(&ModuleQueryExecutorOptions{
+		Plan: &planner.ModulePlan{
+			Instruction: map[string]interface{}{},
+			Module:      rulePlan.Module,
+			ModuleScope: rulePlan.RuleScope,
+			RulesPlan:   []*planner.RulePlan{rulePlan},
+		},
+		MessageChannel:         messageChannel,
+		RuleQueryResultChannel: ruleQueryResultChannel,
+		RulePlanResultChannel:  rulePlanResultChannel,
+		ProviderExpandMap: map[string][]*planner.ProviderContext{
+			RuleFixtureProviderName: {providerContext},
+		},
+		WorkerNum: 1,
+	})
+	_ = queryExecutor.Execute(ctx)
+	messageChannel.ReceiverWait()
+	ruleQueryResultChannel.ReceiverWait()
+	rulePlanResultChannel.ReceiverWait()
+	return outputs, utils.RemoveRepeatedElement(messages)
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -1,12 +1,84 @@
 package local_modules_manager
 
-// LocalModuleManager Manage the cache of locally downloaded modules
+import (
+	"github.com/selefra/selefra/pkg/modules/module_loader"
+	"github.com/selefra/selefra/pkg/registry"
+	"path/filepath"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// LocalModuleManager Manage the cache of locally downloaded modules, the modules are downloaded to the same directories the module loaders use,
+// so a module got by the manager is not downloaded again when the project is applied
 type LocalModuleManager struct {
-	selefraHomeWorkspace string
-	projectWorkspace     string
-	downloadWorkspace    string
+
+	// selefra Specifies the storage path of the downloaded file
+	downloadWorkspace string
+
+	// The module registry is used to get the modules from the remote end
+	moduleRegistry registry.ModuleRegistry
 }
 
-func NewLocalModuleManager() *LocalModuleManager {
-	return &LocalModuleManager{}
+// NewLocalModuleManager The modules are got from the official registry if no registry repo is given
+func NewLocalModuleManager(downloadWorkspace string, registryRepoFullName ...string) (*LocalModuleManager, error) {
+
+	// init module registry
+	options := registry.NewModuleGithubRegistryOptions(downloadWorkspace, registryRepoFullName...)
+	moduleRegistry, err := registry.NewModuleGitHubRegistry(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalModuleManager{
+		downloadWorkspace: downloadWorkspace,
+		moduleRegistry:    moduleRegistry,
+	}, nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// LocalModule A module in the download cache
+type LocalModule struct {
+
+	// Which loader downloaded the module
+	LoaderType module_loader.ModuleLoaderType `json:"loader-type"`
+
+	// The name of the module in the registry, for the modules downloaded from a url it is the md5 of the url
+	Name string `json:"name"`
+
+	// Only the modules of the registry have a version
+	Version string `json:"version"`
+
+	// Where the module is downloaded to
+	Directory string `json:"directory"`
 }
+
+func (x *LocalModule) String() string {
+	if x.Version == "" {
+		return x.Name
+	}
+	return registry.NewModule(x.Name, x.Version).String()
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+func (x *LocalModuleManager) buildLocalModulesPath() string {
+	return filepath.Join(x.downloadWorkspace, module_loader.DownloadModulesDirectoryName)
+}
+
+// The modules of the registry are stored in a directory of their name, see module_loader.NewGitHubRegistryModuleLoader
+func (x *LocalModuleManager) buildLocalModulePath(moduleName string) string {
+	return filepath.Join(x.downloadWorkspace, module_loader.DownloadModulesDirectoryName, moduleName)
+}
+
+// Folder in which the module version is stored
+func (x *LocalModuleManager) buildLocalModuleVersionPath(moduleName, moduleVersion string) string {
+	return filepath.Join(x.downloadWorkspace, module_loader.DownloadModulesDirectoryName, moduleName, moduleVersion)
+}
+
+// The modules downloaded from a url are stored in a directory of the md5 of the url, see module_loader.NewURLModuleLoader
+func (x *LocalModuleManager) buildLocalURLModulePath(loaderType module_loader.ModuleLoaderType, directoryName string) string {
+	return filepath.Join(x.downloadWorkspace, module_loader.DownloadModulesDirectoryName, string(loaderType), directoryName)
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -1,7 +1,50 @@
 package local_modules_manager
 
-import "github.com/selefra/selefra/pkg/registry"
+import (
+	"context"
+	"github.com/hashicorp/go-getter"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra-utils/pkg/pointer"
+	"github.com/selefra/selefra/pkg/modules/module_loader"
+	"github.com/selefra/selefra/pkg/registry"
+	"github.com/selefra/selefra/pkg/utils"
+)
 
-func (x *LocalModuleManager) Get(module *registry.Module) {
+// Get Download the module from the registry to the download cache, the latest version is downloaded if the version is latest.
+// A version that is already in the cache is not downloaded again
+func (x *LocalModuleManager) Get(ctx context.Context, module *registry.Module, progressTracker getter.ProgressTracker) (*LocalModule, *schema.Diagnostics) {
 
+	diagnostics := schema.NewDiagnostics()
+
+	metadata, err := x.moduleRegistry.GetMetadata(ctx, module)
+	if err != nil {
+		return nil, diagnostics.AddErrorMsg("Get module %s metadata from registry failed: %s", module.String(), err.Error())
+	}
+	moduleVersion := module.Version
+	if module.IsLatestVersion() {
+		moduleVersion = metadata.LatestVersion
+	}
+	if !metadata.HasVersion(moduleVersion) {
+		return nil, diagnostics.AddErrorMsg("Module %s version %s not found in registry", module.Name, moduleVersion)
+	}
+
+	localModule := &LocalModule{
+		LoaderType: module_loader.ModuleLoaderTypeGitHubRegistry,
+		Name:       module.Name,
+		Version:    moduleVersion,
+		Directory:  x.buildLocalModuleVersionPath(module.Name, moduleVersion),
+	}
+	if utils.ExistsDirectory(localModule.Directory) {
+		return localModule, diagnostics.AddInfo("Module %s is already downloaded at %s", localModule.String(), localModule.Directory)
+	}
+
+	_, err = x.moduleRegistry.Download(ctx, registry.NewModule(module.Name, moduleVersion), &registry.ModuleRegistryDownloadOptions{
+		ModuleDownloadDirectoryPath: localModule.Directory,
+		SkipVerify:                  pointer.TruePointer(),
+		ProgressTracker:             progressTracker,
+	})
+	if err != nil {
+		return nil, diagnostics.AddErrorMsg("Download module %s failed: %s", localModule.String(), err.Error())
+	}
+	return localModule, diagnostics.AddInfo("Download module %s to %s success", localModule.String(), localModule.Directory)
 }
@@ -1,5 +1,122 @@
 package local_modules_manager
 
-func (x *LocalModuleManager) List() {
+import (
+	"errors"
+	goversion "github.com/hashicorp/go-version"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/modules/module_loader"
+	"os"
+	"sort"
+)
 
+// List All modules in the download cache, the modules of the registry first, ordered by name and version
+func (x *LocalModuleManager) List() ([]*LocalModule, *schema.Diagnostics) {
+
+	diagnostics := schema.NewDiagnostics()
+
+	path := x.buildLocalModulesPath()
+	entrySlice, err := os.ReadDir(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, diagnostics.AddInfo("You haven't downloaded any modules yet.")
+		} else {
+			return nil, diagnostics.AddErrorMsg("Can not exec list command, open directory %s error: %s", path, err.Error())
+		}
+	}
+
+	registryModuleSlice := make([]*LocalModule, 0)
+	urlModuleSlice := make([]*LocalModule, 0)
+	for _, entry := range entrySlice {
+		if !entry.IsDir() {
+			continue
+		}
+		switch loaderType := module_loader.ModuleLoaderType(entry.Name()); loaderType {
+		case module_loader.ModuleLoaderTypeURL, module_loader.ModuleLoaderTypeS3Bucket, module_loader.ModuleLoaderTypeGit, module_loader.ModuleLoaderTypeOCI:
+			localModules, d := x.listURLModules(loaderType)
+			if !diagnostics.AddDiagnostics(d).HasError() {
+				urlModuleSlice = append(urlModuleSlice, localModules...)
+			}
+		default:
+			localModules, d := x.ListModuleVersions(entry.Name())
+			if !diagnostics.AddDiagnostics(d).HasError() {
+				registryModuleSlice = append(registryModuleSlice, localModules...)
+			}
+		}
+	}
+
+	return append(registryModuleSlice, urlModuleSlice...), diagnostics
+}
+
+// ListModuleVersions Lists all the downloaded versions of this module of the registry, from the lowest version to the highest
+func (x *LocalModuleManager) ListModuleVersions(moduleName string) ([]*LocalModule, *schema.Diagnostics) {
+
+	diagnostics := schema.NewDiagnostics()
+
+	moduleDirectory := x.buildLocalModulePath(moduleName)
+	versionEntrySlice, err := os.ReadDir(moduleDirectory)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, diagnostics.AddErrorMsg("List module versions read directory %s error: %s", moduleDirectory, err.Error())
+	}
+
+	versions := make([]string, 0)
+	for _, versionEntry := range versionEntrySlice {
+		if versionEntry.IsDir() {
+			versions = append(versions, versionEntry.Name())
+		}
+	}
+	sortVersions(versions)
+
+	localModules := make([]*LocalModule, 0, len(versions))
+	for _, moduleVersion := range versions {
+		localModules = append(localModules, &LocalModule{
+			LoaderType: module_loader.ModuleLoaderTypeGitHubRegistry,
+			Name:       moduleName,
+			Version:    moduleVersion,
+			Directory:  x.buildLocalModuleVersionPath(moduleName, moduleVersion),
+		})
+	}
+	return localModules, diagnostics
+}
+
+// The modules downloaded from a url or a s3 bucket
+func (x *LocalModuleManager) listURLModules(loaderType module_loader.ModuleLoaderType) ([]*LocalModule, *schema.Diagnostics) {
+	path := x.buildLocalURLModulePath(loaderType, "")
+	entrySlice, err := os.ReadDir(path)
+	if err != nil {
+		return nil, schema.NewDiagnostics().AddErrorMsg("List modules read directory %s error: %s", path, err.Error())
+	}
+	localModules := make([]*LocalModule, 0)
+	for _, entry := range entrySlice {
+		if !entry.IsDir() {
+			continue
+		}
+		localModules = append(localModules, &LocalModule{
+			LoaderType: loaderType,
+			Name:       entry.Name(),
+			Directory:  x.buildLocalURLModulePath(loaderType, entry.Name()),
+		})
+	}
+	sort.Slice(localModules, func(i, j int) bool {
+		return localModules[i].Name < localModules[j].Name
+	})
+	return localModules, nil
+}
+
+// Sort the versions from the lowest to the highest, the directory names that are not versions are put first in dictionary order
+func sortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		versionI, errI := goversion.NewVersion(versions[i])
+		versionJ, errJ := goversion.NewVersion(versions[j])
+		switch {
+		case errI != nil && errJ != nil:
+			return versions[i] < versions[j]
+		case errI != nil || errJ != nil:
+			return errI != nil
+		default:
+			return versionI.LessThan(versionJ)
+		}
+	})
 }
@@ -1,5 +1,25 @@
 package local_modules_manager
 
-func (x *LocalModuleManager) Search() {
+import (
+	"context"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/registry"
+)
 
+// Search the module by keyword on the configured registry
+func (x *LocalModuleManager) Search(ctx context.Context, keyword string) ([]*registry.Module, *schema.Diagnostics) {
+	moduleSlice, err := x.moduleRegistry.Search(ctx, keyword)
+	if err != nil {
+		return nil, schema.NewDiagnostics().AddErrorMsg("Search module %s in registry failed: %s", keyword, err.Error())
+	}
+	return moduleSlice, nil
+}
+
+// GetMetadata Get the metadata of the module from the configured registry, such as its introduction and versions
+func (x *LocalModuleManager) GetMetadata(ctx context.Context, module *registry.Module) (*registry.ModuleMetadata, *schema.Diagnostics) {
+	metadata, err := x.moduleRegistry.GetMetadata(ctx, module)
+	if err != nil {
+		return nil, schema.NewDiagnostics().AddErrorMsg("Get module %s metadata from registry failed: %s", module.Name, err.Error())
+	}
+	return metadata, nil
 }
@@ -1,5 +1,206 @@
 package local_modules_manager
 
-func (x *LocalModuleManager) Tidy() {
+import (
+	"context"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra-utils/pkg/md5_util"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
+	"github.com/selefra/selefra/pkg/modules/module_loader"
+	"github.com/selefra/selefra/pkg/modules/parser"
+	"github.com/selefra/selefra/pkg/oci"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/selefra/selefra/pkg/version"
+	"os"
+	"path/filepath"
+	"strings"
+)
 
-}
\ No newline at end of file
+// Tidy Remove the downloaded versions of the registry modules the project uses, directly or by the modules it uses, that are
+// superseded by the versions it references now. The download directory is shared by all projects, so the modules the project
+// does not use at all are kept, unless all is true, then every downloaded module the project does not reference is removed.
+// The references are found from the files of the project and of the downloaded modules, nothing is downloaded, the latest version
+// of a registry module is the one in the lock file of the project if it is locked. If dryRun is true, the modules are only
+// returned but not removed
+func (x *LocalModuleManager) Tidy(ctx context.Context, projectWorkspace string, all, dryRun bool) ([]*LocalModule, *schema.Diagnostics) {
+
+	diagnostics := schema.NewDiagnostics()
+
+	lockFile, err := lock_file.Read(projectWorkspace)
+	if err != nil {
+		return nil, diagnostics.AddErrorMsg("read lock file %s failed: %s", lock_file.BuildLockFilePath(projectWorkspace), err.Error())
+	}
+
+	// If some references can not be found, no module is removed, it might be one of them
+	referencedDirectorySet := make(map[string]struct{})
+	if diagnostics.AddDiagnostics(x.collectReferencedModules(utils.AbsPath(projectWorkspace), lockFile, referencedDirectorySet, make(map[string]struct{}))).HasError() {
+		return nil, diagnostics
+	}
+
+	localModules, d := x.List()
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return nil, diagnostics
+	}
+
+	// The registry modules of which the project references a version
+	referencedModuleNameSet := make(map[string]struct{})
+	for _, localModule := range localModules {
+		if localModule.LoaderType == module_loader.ModuleLoaderTypeGitHubRegistry && isReferencedDirectory(utils.AbsPath(localModule.Directory), referencedDirectorySet) {
+			referencedModuleNameSet[localModule.Name] = struct{}{}
+		}
+	}
+
+	unusedModules := make([]*LocalModule, 0)
+	for _, localModule := range localModules {
+		if isReferencedDirectory(utils.AbsPath(localModule.Directory), referencedDirectorySet) {
+			continue
+		}
+		// It might be used by another project
+		if _, exists := referencedModuleNameSet[localModule.Name]; !all && (!exists || localModule.LoaderType != module_loader.ModuleLoaderTypeGitHubRegistry) {
+			continue
+		}
+		unusedModules = append(unusedModules, localModule)
+		if dryRun {
+			diagnostics.AddInfo("Module %s at %s is not used, it would be removed", localModule.String(), localModule.Directory)
+			continue
+		}
+		if err := os.RemoveAll(localModule.Directory); err != nil {
+			diagnostics.AddErrorMsg("Remove module %s at local directory %s failed: %s", localModule.String(), localModule.Directory, err.Error())
+			continue
+		}
+		diagnostics.AddInfo("Remove module %s at local directory %s success", localModule.String(), localModule.Directory)
+
+		// The directory of the module name is removed with its last version
+		if localModule.LoaderType == module_loader.ModuleLoaderTypeGitHubRegistry {
+			moduleDirectory := x.buildLocalModulePath(localModule.Name)
+			if entrySlice, err := os.ReadDir(moduleDirectory); err == nil && len(entrySlice) == 0 {
+				_ = os.Remove(moduleDirectory)
+			}
+		}
+	}
+	if len(unusedModules) == 0 {
+		if all {
+			diagnostics.AddInfo("All downloaded modules are used.")
+		} else {
+			diagnostics.AddInfo("No superseded version of the modules used is downloaded.")
+		}
+	}
+
+	return unusedModules, diagnostics
+}
+
+// Find the downloaded modules referenced by the uses of the module in the directory, and the modules they reference
+func (x *LocalModuleManager) collectReferencedModules(moduleDirectory string, lockFile *lock_file.LockFile, referencedDirectorySet, visitedDirectorySet map[string]struct{}) *schema.Diagnostics {
+
+	if _, exists := visitedDirectorySet[moduleDirectory]; exists {
+		return nil
+	}
+	visitedDirectorySet[moduleDirectory] = struct{}{}
+
+	diagnostics := schema.NewDiagnostics()
+
+	entrySlice, err := os.ReadDir(moduleDirectory)
+	if err != nil {
+		return diagnostics.AddErrorMsg("Module %s read error: %s", moduleDirectory, err.Error())
+	}
+	usesSlice := make([]string, 0)
+	for _, entry := range entrySlice {
+		if !module_loader.IsYamlFile(entry) {
+			continue
+		}
+		yamlFileModule, d := parser.NewYamlFileToModuleParser(filepath.Join(moduleDirectory, entry.Name()), nil).Parse()
+		if diagnostics.AddDiagnostics(d).HasError() {
+			continue
+		}
+		for _, moduleBlock := range yamlFileModule.ModulesBlock {
+			usesSlice = append(usesSlice, moduleBlock.Uses)
+		}
+	}
+	if diagnostics.HasError() {
+		return diagnostics
+	}
+
+	for _, uses := range usesSlice {
+		referencedDirectory, d := x.findReferencedModuleDirectory(moduleDirectory, uses, lockFile)
+		if diagnostics.AddDiagnostics(d).HasError() {
+			return diagnostics
+		}
+		// A module that is not downloaded yet references nothing
+		if referencedDirectory == "" || !utils.ExistsDirectory(referencedDirectory) {
+			continue
+		}
+		referencedDirectorySet[referencedDirectory] = struct{}{}
+		if diagnostics.AddDiagnostics(x.collectReferencedModules(referencedDirectory, lockFile, referencedDirectorySet, visitedDirectorySet)).HasError() {
+			return diagnostics
+		}
+	}
+
+	return diagnostics
+}
+
+// The directory the module of the uses is loaded from, the same directory the module loader downloads it to, the lock file
+// is nil if the project is not locked
+func (x *LocalModuleManager) findReferencedModuleDirectory(moduleDirectory, uses string, lockFile *lock_file.LockFile) (string, *schema.Diagnostics) {
+	switch loaderType := module_loader.NewModuleLoaderBySource(uses); loaderType {
+	case module_loader.ModuleLoaderTypeLocalDirectory:
+		return filepath.Join(moduleDirectory, uses), nil
+	case module_loader.ModuleLoaderTypeURL, module_loader.ModuleLoaderTypeS3Bucket:
+		directoryName, err := md5_util.Md5String(uses)
+		if err != nil {
+			return "", schema.NewDiagnostics().AddErrorMsg("Module uses %s in %s md5 error: %s", uses, moduleDirectory, err.Error())
+		}
+		return utils.AbsPath(x.buildLocalURLModulePath(loaderType, directoryName)), nil
+	case module_loader.ModuleLoaderTypeGit:
+		gitSource, err := module_loader.ParseGitModuleSource(uses)
+		if err != nil {
+			return "", schema.NewDiagnostics().AddErrorMsg("Module uses %s in %s is invalid: %s", uses, moduleDirectory, err.Error())
+		}
+		directoryName, err := gitSource.BuildDownloadDirectoryName()
+		if err != nil {
+			return "", schema.NewDiagnostics().AddErrorMsg("Module uses %s in %s md5 error: %s", uses, moduleDirectory, err.Error())
+		}
+		return utils.AbsPath(filepath.Join(x.buildLocalURLModulePath(loaderType, directoryName), filepath.FromSlash(gitSource.Subdirectory))), nil
+	case module_loader.ModuleLoaderTypeOCI:
+		reference, err := oci.ParseArtifactReference(uses)
+		if err != nil {
+			return "", schema.NewDiagnostics().AddErrorMsg("Module uses %s in %s is invalid: %s", uses, moduleDirectory, err.Error())
+		}
+		directoryName, err := md5_util.Md5String(reference.String())
+		if err != nil {
+			return "", schema.NewDiagnostics().AddErrorMsg("Module uses %s in %s md5 error: %s", uses, moduleDirectory, err.Error())
+		}
+		return utils.AbsPath(filepath.Join(x.buildLocalURLModulePath(loaderType, directoryName), oci.ModuleArtifactDirectoryName)), nil
+	case module_loader.ModuleLoaderTypeGitHubRegistry:
+		nameAndVersion := version.ParseNameAndVersion(uses)
+		if !nameAndVersion.IsLatestVersion() {
+			return utils.AbsPath(x.buildLocalModuleVersionPath(nameAndVersion.Name, nameAndVersion.Version)), nil
+		}
+		// The module loader uses the locked version for the latest version, as long as the project is locked
+		if lockFile != nil {
+			if lockedModule := lockFile.GetModule(uses); lockedModule != nil && lockedModule.Version != "" {
+				return utils.AbsPath(x.buildLocalModuleVersionPath(nameAndVersion.Name, lockedModule.Version)), nil
+			}
+		}
+		// Otherwise the latest version is the highest version downloaded, it can not be known without asking the registry
+		localModules, d := x.ListModuleVersions(nameAndVersion.Name)
+		if utils.HasError(d) || len(localModules) == 0 {
+			return "", d
+		}
+		return utils.AbsPath(localModules[len(localModules)-1].Directory), nil
+	default:
+		return "", schema.NewDiagnostics().AddErrorMsg("Module uses %s in %s is invalid, unsupported module loader", uses, moduleDirectory)
+	}
+}
+
+// A downloaded module is referenced if it or a directory in it is referenced, a git module may be used from a subdirectory
+// of the repository
+func isReferencedDirectory(directory string, referencedDirectorySet map[string]struct{}) bool {
+	if _, exists := referencedDirectorySet[directory]; exists {
+		return true
+	}
+	for referencedDirectory := range referencedDirectorySet {
+		if strings.HasPrefix(referencedDirectory, directory+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
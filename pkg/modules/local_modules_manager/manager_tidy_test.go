@@ -0,0 +1,103 @@
+package local_modules_manager
+
+import (
+	"context"
+	"github.com/selefra/selefra-utils/pkg/md5_util"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
+	"github.com/selefra/selefra/pkg/modules/module_loader"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalModuleManager_Tidy(t *testing.T) {
+	downloadWorkspace := t.TempDir()
+	projectWorkspace := t.TempDir()
+	manager, err := NewLocalModuleManager(downloadWorkspace)
+	assert.Nil(t, err)
+
+	moduleURL := "https://example.com/rules-c.zip"
+	urlDirectoryName, err := md5_util.Md5String(moduleURL)
+	assert.Nil(t, err)
+	writeModule := func(directory, uses string) {
+		assert.Nil(t, os.MkdirAll(directory, os.ModePerm))
+		if uses != "" {
+			content := "modules:\n  - name: sub\n    uses: " + uses + "\n"
+			assert.Nil(t, os.WriteFile(filepath.Join(directory, "modules.yaml"), []byte(content), 0644))
+		}
+	}
+
+	// The project uses the latest rules-a and a url module, the url module uses rules-b@v0.1.0
+	writeModule(projectWorkspace, "rules-a")
+	writeModule(filepath.Join(projectWorkspace, "sub"), moduleURL)
+	assert.Nil(t, os.WriteFile(filepath.Join(projectWorkspace, "selefra.yaml"), []byte("modules:\n  - name: local\n    uses: ./sub\n"), 0644))
+	writeModule(manager.buildLocalModuleVersionPath("rules-a", "v0.0.1"), "")
+	writeModule(manager.buildLocalModuleVersionPath("rules-a", "v0.0.10"), "")
+	writeModule(manager.buildLocalModuleVersionPath("rules-a", "v0.0.2"), "")
+	writeModule(manager.buildLocalModuleVersionPath("rules-b", "v0.1.0"), "")
+	writeModule(manager.buildLocalModuleVersionPath("rules-b", "v0.2.0"), "")
+	writeModule(manager.buildLocalModuleVersionPath("rules-unused", "v1.0.0"), "")
+	writeModule(manager.buildLocalURLModulePath(module_loader.ModuleLoaderTypeURL, urlDirectoryName), "rules-b@v0.1.0")
+	writeModule(manager.buildLocalURLModulePath(module_loader.ModuleLoaderTypeS3Bucket, "unused"), "")
+
+	localModules, d := manager.List()
+	assert.False(t, utils.HasError(d))
+	assert.Len(t, localModules, 8)
+	assert.Equal(t, "v0.0.10", localModules[2].Version)
+
+	// Only the superseded versions of the modules the project uses are removed by default
+	unusedModules, d := manager.Tidy(context.Background(), projectWorkspace, false, true)
+	if utils.HasError(d) {
+		t.Log(d.ToString())
+	}
+	assert.False(t, utils.HasError(d))
+	assert.Equal(t, []string{"rules-a@v0.0.1", "rules-a@v0.0.2", "rules-b@v0.2.0"}, localModuleNames(unusedModules))
+
+	unusedModules, d = manager.Tidy(context.Background(), projectWorkspace, true, true)
+	assert.False(t, utils.HasError(d))
+	assert.Equal(t, []string{"rules-a@v0.0.1", "rules-a@v0.0.2", "rules-b@v0.2.0", "rules-unused@v1.0.0", "unused"}, localModuleNames(unusedModules))
+	localModules, _ = manager.List()
+	assert.Len(t, localModules, 8)
+
+	_, d = manager.Tidy(context.Background(), projectWorkspace, false, false)
+	assert.False(t, utils.HasError(d))
+	localModules, _ = manager.List()
+	assert.Len(t, localModules, 5)
+	assert.True(t, utils.Exists(manager.buildLocalModulePath("rules-unused")))
+
+	_, d = manager.Tidy(context.Background(), projectWorkspace, true, false)
+	assert.False(t, utils.HasError(d))
+	localModules, _ = manager.List()
+	assert.Len(t, localModules, 3)
+	assert.False(t, utils.Exists(manager.buildLocalModulePath("rules-unused")))
+}
+
+func TestLocalModuleManager_TidyLocked(t *testing.T) {
+	downloadWorkspace := t.TempDir()
+	projectWorkspace := t.TempDir()
+	manager, err := NewLocalModuleManager(downloadWorkspace)
+	assert.Nil(t, err)
+
+	// The project uses the latest rules-a, but it is locked to v0.0.2, not the highest version downloaded
+	assert.Nil(t, os.WriteFile(filepath.Join(projectWorkspace, "selefra.yaml"), []byte("modules:\n  - name: a\n    uses: rules-a\n"), 0644))
+	lockFile := lock_file.NewLockFile()
+	lockFile.LockModule(&lock_file.LockedModule{Uses: "rules-a", Version: "v0.0.2"})
+	assert.Nil(t, lockFile.Save(projectWorkspace))
+	for _, moduleVersion := range []string{"v0.0.1", "v0.0.2", "v0.0.10"} {
+		assert.Nil(t, os.MkdirAll(manager.buildLocalModuleVersionPath("rules-a", moduleVersion), os.ModePerm))
+	}
+
+	unusedModules, d := manager.Tidy(context.Background(), projectWorkspace, false, true)
+	assert.False(t, utils.HasError(d))
+	assert.Equal(t, []string{"rules-a@v0.0.1", "rules-a@v0.0.10"}, localModuleNames(unusedModules))
+}
+
+func localModuleNames(localModules []*LocalModule) []string {
+	names := make([]string, 0)
+	for _, localModule := range localModules {
+		names = append(names, localModule.String())
+	}
+	return names
+}
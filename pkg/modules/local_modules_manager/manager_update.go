@@ -1,5 +1,66 @@
 package local_modules_manager
 
-func (x *LocalModuleManager) Update() {
+import (
+	"context"
+	"github.com/hashicorp/go-getter"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/modules/module_loader"
+	"github.com/selefra/selefra/pkg/registry"
+)
 
+// Update Download the latest version of the downloaded modules of the registry, all of them if no module name is given.
+// The old versions are kept, they may still be used by a project, use Tidy to remove the versions no longer used
+func (x *LocalModuleManager) Update(ctx context.Context, progressTracker getter.ProgressTracker, moduleNames ...string) *schema.Diagnostics {
+
+	diagnostics := schema.NewDiagnostics()
+
+	if len(moduleNames) == 0 {
+		localModules, d := x.List()
+		if diagnostics.AddDiagnostics(d).HasError() {
+			return diagnostics
+		}
+		moduleNameSet := make(map[string]struct{})
+		for _, localModule := range localModules {
+			if localModule.LoaderType != module_loader.ModuleLoaderTypeGitHubRegistry {
+				continue
+			}
+			if _, exists := moduleNameSet[localModule.Name]; !exists {
+				moduleNameSet[localModule.Name] = struct{}{}
+				moduleNames = append(moduleNames, localModule.Name)
+			}
+		}
+		if len(moduleNames) == 0 {
+			return diagnostics.AddInfo("There are no modules of the registry to update.")
+		}
+	}
+
+	for _, moduleName := range moduleNames {
+		localModules, d := x.ListModuleVersions(moduleName)
+		if diagnostics.AddDiagnostics(d).HasError() {
+			continue
+		}
+		if len(localModules) == 0 {
+			diagnostics.AddErrorMsg("Module %s is not downloaded, use selefra module get %s to download it", moduleName, moduleName)
+			continue
+		}
+		currentModule := localModules[len(localModules)-1]
+
+		latestModule, err := x.moduleRegistry.GetLatestVersion(ctx, registry.NewModule(moduleName, currentModule.Version))
+		if err != nil {
+			diagnostics.AddErrorMsg("Get module %s latest version from registry failed: %s", moduleName, err.Error())
+			continue
+		}
+		if latestModule.Version == currentModule.Version {
+			diagnostics.AddInfo("Module %s is already the latest version", currentModule.String())
+			continue
+		}
+
+		_, d = x.Get(ctx, registry.NewModule(moduleName, latestModule.Version), progressTracker)
+		if diagnostics.AddDiagnostics(d).HasError() {
+			continue
+		}
+		diagnostics.AddInfo("Module %s is updated from %s to %s", moduleName, currentModule.Version, latestModule.Version)
+	}
+
+	return diagnostics
 }
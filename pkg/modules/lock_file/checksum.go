@@ -0,0 +1,79 @@
+package lock_file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ChecksumPrefix Only sha256 is supported currently
+const ChecksumPrefix = "sha256:"
+
+// FileChecksum The sha256 of the file content
+func FileChecksum(filePath string) (string, error) {
+	sum, err := fileSha256(filePath)
+	if err != nil {
+		return "", err
+	}
+	return ChecksumPrefix + sum, nil
+}
+
+// DirectoryChecksum The sha256 of all files in the directory, a file is identified by its path relative to the directory,
+// so it does not matter where the directory is. The .git directory of a cloned module changes with every fetch, it is skipped
+func DirectoryChecksum(directory string) (string, error) {
+	lines := make([]string, 0)
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relativePath, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		sum, err := fileSha256(path)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s\n", sum, filepath.ToSlash(relativePath)))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(lines)
+
+	hash := sha256.New()
+	for _, line := range lines {
+		_, _ = hash.Write([]byte(line))
+	}
+	return ChecksumPrefix + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func fileSha256(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
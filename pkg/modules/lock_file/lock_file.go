@@ -0,0 +1,225 @@
+package lock_file
+
+import (
+	"errors"
+	"fmt"
+	"github.com/selefra/selefra/pkg/utils"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// FileName The lock file is placed in the root directory of the project
+const FileName = "selefra.lock.yaml"
+
+// FormatVersion The version of the lock file format
+const FormatVersion = 1
+
+// ErrChecksumMismatch What is downloaded is not what was locked
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+const fileHeader = "# This file is maintained by selefra lock, do not edit it by hand.\n" +
+	"# Run selefra lock --upgrade to resolve the providers and modules again.\n"
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// LockedProvider The provider version resolved for the project
+type LockedProvider struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+
+	// Where the provider is released
+	Source string `yaml:"source,omitempty"`
+
+	// The checksum of the provider executable file
+	Checksum string `yaml:"checksum"`
+}
+
+// LockedModule The module resolved for a uses of the project
+type LockedModule struct {
+	// The uses as it is written in the module
+	Uses string `yaml:"uses"`
+
+	// Only registry modules have a version
+	Version string `yaml:"version,omitempty"`
+
+	// Where the module is downloaded from
+	Source string `yaml:"source,omitempty"`
+
+	// The checksum of the module directory
+	Checksum string `yaml:"checksum"`
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// LockFile Records the providers and modules resolved for a project, so that every run uses the same ones
+type LockFile struct {
+	Version   int               `yaml:"version"`
+	Providers []*LockedProvider `yaml:"providers"`
+	Modules   []*LockedModule   `yaml:"modules"`
+
+	// The modules are locked by loaders running concurrently
+	lock sync.RWMutex
+
+	// The entries locked since the file is loaded, the others can be pruned
+	lockedProviders map[string]struct{}
+	lockedModules   map[string]struct{}
+}
+
+func NewLockFile() *LockFile {
+	return &LockFile{
+		Version:         FormatVersion,
+		Providers:       make([]*LockedProvider, 0),
+		Modules:         make([]*LockedModule, 0),
+		lockedProviders: make(map[string]struct{}),
+		lockedModules:   make(map[string]struct{}),
+	}
+}
+
+// BuildLockFilePath The path of the lock file of the project
+func BuildLockFilePath(projectWorkspace string) string {
+	return filepath.Join(projectWorkspace, FileName)
+}
+
+// Read the lock file of the project, nil if the project has not been locked
+func Read(projectWorkspace string) (*LockFile, error) {
+	lockFilePath := BuildLockFilePath(projectWorkspace)
+	if !utils.ExistsFile(lockFilePath) {
+		return nil, nil
+	}
+	lockFile, err := utils.ReadYamlFile[*LockFile](lockFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if lockFile == nil {
+		return NewLockFile(), nil
+	}
+	if lockFile.Version > FormatVersion {
+		return nil, fmt.Errorf("lock file %s version %d is not supported, please upgrade selefra", lockFilePath, lockFile.Version)
+	}
+	lockFile.lockedProviders = make(map[string]struct{})
+	lockFile.lockedModules = make(map[string]struct{})
+	return lockFile, nil
+}
+
+// Save the lock file to the project, the entries are sorted so that the file is stable
+func (x *LockFile) Save(projectWorkspace string) error {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	x.Version = FormatVersion
+	sort.Slice(x.Providers, func(i, j int) bool {
+		return x.Providers[i].Name < x.Providers[j].Name
+	})
+	sort.Slice(x.Modules, func(i, j int) bool {
+		return x.Modules[i].Uses < x.Modules[j].Uses
+	})
+	content, err := yaml.Marshal(x)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(BuildLockFilePath(projectWorkspace), append([]byte(fileHeader), content...), 0644)
+}
+
+// GetProvider Get the locked provider by name, nil if the provider is not locked
+func (x *LockFile) GetProvider(providerName string) *LockedProvider {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+
+	for _, lockedProvider := range x.Providers {
+		if lockedProvider.Name == providerName {
+			return lockedProvider
+		}
+	}
+	return nil
+}
+
+// GetModule Get the locked module by uses, nil if the module is not locked
+func (x *LockFile) GetModule(uses string) *LockedModule {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+
+	for _, lockedModule := range x.Modules {
+		if lockedModule.Uses == uses {
+			return lockedModule
+		}
+	}
+	return nil
+}
+
+// LockProvider Add the provider to the lock file, or replace the one with the same name
+func (x *LockFile) LockProvider(lockedProvider *LockedProvider) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	x.lockedProviders[lockedProvider.Name] = struct{}{}
+	for index, p := range x.Providers {
+		if p.Name == lockedProvider.Name {
+			x.Providers[index] = lockedProvider
+			return
+		}
+	}
+	x.Providers = append(x.Providers, lockedProvider)
+}
+
+// LockModule Add the module to the lock file, or replace the one with the same uses
+func (x *LockFile) LockModule(lockedModule *LockedModule) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	x.lockedModules[lockedModule.Uses] = struct{}{}
+	for index, m := range x.Modules {
+		if m.Uses == lockedModule.Uses {
+			x.Modules[index] = lockedModule
+			return
+		}
+	}
+	x.Modules = append(x.Modules, lockedModule)
+}
+
+// Prune Remove the providers and modules that have not been locked since the file is loaded, they are no longer used
+func (x *LockFile) Prune() {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	providers := make([]*LockedProvider, 0)
+	for _, lockedProvider := range x.Providers {
+		if _, exists := x.lockedProviders[lockedProvider.Name]; exists {
+			providers = append(providers, lockedProvider)
+		}
+	}
+	x.Providers = providers
+
+	modules := make([]*LockedModule, 0)
+	for _, lockedModule := range x.Modules {
+		if _, exists := x.lockedModules[lockedModule.Uses]; exists {
+			modules = append(modules, lockedModule)
+		}
+	}
+	x.Modules = modules
+}
+
+// VerifyProvider Check that the provider executable file is the one locked, the provider that is not locked or is locked
+// to another version is not checked
+func (x *LockFile) VerifyProvider(providerName, providerVersion, checksum string) error {
+	lockedProvider := x.GetProvider(providerName)
+	if lockedProvider == nil || lockedProvider.Version != providerVersion || lockedProvider.Checksum == checksum {
+		return nil
+	}
+	return fmt.Errorf("provider %s@%s %w, locked %s, got %s", providerName, providerVersion, ErrChecksumMismatch, lockedProvider.Checksum, checksum)
+}
+
+// VerifyModule Check that the module directory is the one locked, the module that is not locked is not checked
+func (x *LockFile) VerifyModule(uses, checksum string) error {
+	lockedModule := x.GetModule(uses)
+	if lockedModule == nil || lockedModule.Checksum == checksum {
+		return nil
+	}
+	return fmt.Errorf("module %s %w, locked %s, got %s", uses, ErrChecksumMismatch, lockedModule.Checksum, checksum)
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,85 @@
+package lock_file
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockFile_SaveAndRead(t *testing.T) {
+	projectWorkspace := t.TempDir()
+
+	lockFile, err := Read(projectWorkspace)
+	assert.Nil(t, err)
+	assert.Nil(t, lockFile)
+
+	lockFile = NewLockFile()
+	lockFile.LockProvider(&LockedProvider{Name: "gcp", Version: "v0.0.2", Source: "https://github.com/selefra/selefra-provider-gcp", Checksum: "sha256:b"})
+	lockFile.LockProvider(&LockedProvider{Name: "aws", Version: "v0.0.1", Checksum: "sha256:a"})
+	lockFile.LockModule(&LockedModule{Uses: "rules-aws-misconfigure-s3", Version: "v0.0.3", Checksum: "sha256:c"})
+	assert.Nil(t, lockFile.Save(projectWorkspace))
+
+	lockFile, err = Read(projectWorkspace)
+	assert.Nil(t, err)
+	assert.Equal(t, FormatVersion, lockFile.Version)
+	assert.Equal(t, "aws", lockFile.Providers[0].Name)
+	assert.Equal(t, "https://github.com/selefra/selefra-provider-gcp", lockFile.GetProvider("gcp").Source)
+	assert.Equal(t, "v0.0.3", lockFile.GetModule("rules-aws-misconfigure-s3").Version)
+	assert.Nil(t, lockFile.GetModule("rules-aws-misconfigure-ec2"))
+
+	// Only what is locked again is kept
+	lockFile.LockProvider(&LockedProvider{Name: "aws", Version: "v0.0.4", Checksum: "sha256:d"})
+	lockFile.Prune()
+	assert.Len(t, lockFile.Providers, 1)
+	assert.Equal(t, "v0.0.4", lockFile.GetProvider("aws").Version)
+	assert.Len(t, lockFile.Modules, 0)
+
+	assert.Nil(t, os.WriteFile(BuildLockFilePath(projectWorkspace), []byte("version: 100\n"), 0644))
+	_, err = Read(projectWorkspace)
+	assert.NotNil(t, err)
+}
+
+func TestLockFile_Verify(t *testing.T) {
+	lockFile := NewLockFile()
+	lockFile.LockProvider(&LockedProvider{Name: "aws", Version: "v0.0.1", Checksum: "sha256:a"})
+	lockFile.LockModule(&LockedModule{Uses: "https://example.com/rules.zip", Checksum: "sha256:b"})
+
+	assert.Nil(t, lockFile.VerifyProvider("aws", "v0.0.1", "sha256:a"))
+	assert.True(t, errors.Is(lockFile.VerifyProvider("aws", "v0.0.1", "sha256:x"), ErrChecksumMismatch))
+	// Another version or a provider not locked is not checked
+	assert.Nil(t, lockFile.VerifyProvider("aws", "v0.0.2", "sha256:x"))
+	assert.Nil(t, lockFile.VerifyProvider("gcp", "v0.0.1", "sha256:x"))
+
+	assert.Nil(t, lockFile.VerifyModule("https://example.com/rules.zip", "sha256:b"))
+	assert.True(t, errors.Is(lockFile.VerifyModule("https://example.com/rules.zip", "sha256:x"), ErrChecksumMismatch))
+	assert.Nil(t, lockFile.VerifyModule("rules-aws-misconfigure-s3", "sha256:x"))
+}
+
+func TestDirectoryChecksum(t *testing.T) {
+	writeModule := func(directory, rules string) {
+		assert.Nil(t, os.MkdirAll(filepath.Join(directory, "sub"), os.ModePerm))
+		assert.Nil(t, os.WriteFile(filepath.Join(directory, "modules.yaml"), []byte("modules: []\n"), 0644))
+		assert.Nil(t, os.WriteFile(filepath.Join(directory, "sub", "rules.yaml"), []byte(rules), 0644))
+	}
+	a, b := t.TempDir(), t.TempDir()
+	writeModule(a, "rules: []\n")
+	writeModule(b, "rules: []\n")
+
+	checksumA, err := DirectoryChecksum(a)
+	assert.Nil(t, err)
+	checksumB, err := DirectoryChecksum(b)
+	assert.Nil(t, err)
+	assert.Equal(t, checksumA, checksumB)
+	assert.Contains(t, checksumA, ChecksumPrefix)
+
+	writeModule(b, "rules:\n  - name: changed\n")
+	checksumB, err = DirectoryChecksum(b)
+	assert.Nil(t, err)
+	assert.NotEqual(t, checksumA, checksumB)
+
+	fileChecksum, err := FileChecksum(filepath.Join(a, "modules.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, "sha256:3494355b2571ed6db404c21753eaa6539ddd5f6a19a790a5c1d89a58e132406a", fileChecksum)
+}
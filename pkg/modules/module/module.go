@@ -6,6 +6,7 @@ import (
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
 	"github.com/selefra/selefra-utils/pkg/reflect_util"
 	"reflect"
+	"time"
 )
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -19,6 +20,7 @@ type Module struct {
 	ProvidersBlock ProvidersBlock
 	RulesBlock     RulesBlock
 	VariablesBlock VariablesBlock
+	WaiversBlock   WaiversBlock
 
 	// Parent of the current module
 	ParentModule *Module
@@ -73,6 +75,102 @@ func (x *Module) ListRequiredProvidersName() []string {
 	return requiredProviderNameSlice
 }
 
+// ProviderRegistry Where the provider of the given source is installed from. The registry of a required provider block wins,
+// looked up from the root module down, then the registry of the selefra block of the root module. Returns the location of
+// the setting too, both are empty if the official registry is used
+func (x *Module) ProviderRegistry(providerSource string) (string, *NodeLocation) {
+	rootModule := x
+	for rootModule.ParentModule != nil {
+		rootModule = rootModule.ParentModule
+	}
+	if registry, location := rootModule.requiredProviderRegistry(providerSource); registry != "" {
+		return registry, location
+	}
+	if rootModule.SelefraBlock != nil && rootModule.SelefraBlock.Registry != "" {
+		// The selefra block merged from several files has no location
+		if rootModule.SelefraBlock.LocatableImpl == nil {
+			return rootModule.SelefraBlock.Registry, nil
+		}
+		return rootModule.SelefraBlock.Registry, rootModule.SelefraBlock.GetNodeLocation("registry" + NodeLocationSelfValue)
+	}
+	return "", nil
+}
+
+func (x *Module) requiredProviderRegistry(providerSource string) (string, *NodeLocation) {
+	if x.SelefraBlock != nil {
+		for _, requiredProvider := range x.SelefraBlock.RequireProvidersBlock {
+			if requiredProvider.Source == providerSource && requiredProvider.Registry != "" {
+				return requiredProvider.Registry, requiredProvider.GetNodeLocation("registry" + NodeLocationSelfValue)
+			}
+		}
+	}
+	for _, subModule := range x.SubModules {
+		if registry, location := subModule.requiredProviderRegistry(providerSource); registry != "" {
+			return registry, location
+		}
+	}
+	return "", nil
+}
+
+// GetModuleBlock The block in the modules of the parent module that uses this module, nil for the root module
+func (x *Module) GetModuleBlock() *ModuleBlock {
+	if x.ParentModule == nil {
+		return nil
+	}
+	// The submodules are loaded in the order of the modules block
+	for index, subModule := range x.ParentModule.SubModules {
+		if subModule == x && index < len(x.ParentModule.ModulesBlock) {
+			return x.ParentModule.ModulesBlock[index]
+		}
+	}
+	return x.ParentModule.ModulesBlock.ModulesInputMap()[x.Source]
+}
+
+// RuleExcludedBy The filters of every module block from this module up to the root module apply to the rule of this module,
+// returns the first module block whose filters exclude the rule, nil if the rule is kept
+func (x *Module) RuleExcludedBy(rule *RuleBlock) *ModuleBlock {
+	for m := x; m != nil; m = m.ParentModule {
+		if moduleBlock := m.GetModuleBlock(); moduleBlock != nil && !moduleBlock.Keep(rule) {
+			return moduleBlock
+		}
+	}
+	return nil
+}
+
+// RuleTimeout How long the query of the rule may run on a storage, 0 means no limit. The timeout of the rule itself wins,
+// then the rule_timeout of the nearest module block up the module tree, then the rule_timeout of the selefra block
+func (x *Module) RuleTimeout(rule *RuleBlock) time.Duration {
+	timeouts := []string{rule.Timeout}
+	for m := x; m != nil; m = m.ParentModule {
+		if moduleBlock := m.GetModuleBlock(); moduleBlock != nil {
+			timeouts = append(timeouts, moduleBlock.RuleTimeout)
+		}
+		if m.ParentModule == nil && m.SelefraBlock != nil {
+			timeouts = append(timeouts, m.SelefraBlock.RuleTimeout)
+		}
+	}
+	for _, timeout := range timeouts {
+		if timeout == "" {
+			continue
+		}
+		if duration, err := ParseRuleTimeout(timeout); err == nil {
+			return duration
+		}
+	}
+	return 0
+}
+
+// FindWaiver The waivers of this module and of the modules up to the root module apply to the findings of this module,
+// returns the first waiver that suppresses the finding, nil if the finding is reported
+func (x *Module) FindWaiver(finding *RuleBlock, now time.Time) *WaiverBlock {
+	for m := x; m != nil; m = m.ParentModule {
+		if waiverBlock := m.WaiversBlock.Match(finding, now); waiverBlock != nil {
+			return waiverBlock
+		}
+	}
+	return nil
+}
+
 // ------------------------------------------------- --------------------------------------------------------------------
 
 // Merge the two modules into a new module
@@ -92,6 +190,7 @@ func (x *Module) Merge(other *Module) (*Module, *schema.Diagnostics) {
 	mergedModule.ProvidersBlock = MergeBlockWithDiagnostics(x.ProvidersBlock, other.ProvidersBlock, diagnostics)
 	mergedModule.RulesBlock = MergeBlockWithDiagnostics(x.RulesBlock, other.RulesBlock, diagnostics)
 	mergedModule.VariablesBlock = MergeBlockWithDiagnostics(x.VariablesBlock, other.VariablesBlock, diagnostics)
+	mergedModule.WaiversBlock = MergeBlockWithDiagnostics(x.WaiversBlock, other.WaiversBlock, diagnostics)
 
 	return mergedModule, diagnostics
 }
@@ -156,6 +255,10 @@ func (x *Module) Check(module *Module, validatorContext *ValidatorContext) *sche
 		diagnostics.AddDiagnostics(x.VariablesBlock.Check(x, validatorContext))
 	}
 
+	if x.WaiversBlock != nil {
+		diagnostics.AddDiagnostics(x.WaiversBlock.Check(x, validatorContext))
+	}
+
 	// check submodules
 	for _, subModule := range x.SubModules {
 		diagnostics.AddDiagnostics(subModule.Check(subModule, validatorContext))
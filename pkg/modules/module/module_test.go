@@ -0,0 +1,46 @@
+package module
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestModule_ProviderRegistry(t *testing.T) {
+	newRequireProviderBlock := func(source, registry string) *RequireProviderBlock {
+		requireProviderBlock := NewRequireProviderBlock()
+		requireProviderBlock.Name = source
+		requireProviderBlock.Source = source
+		requireProviderBlock.Registry = registry
+		return requireProviderBlock
+	}
+
+	rootModule := &Module{SelefraBlock: NewSelefraBlock()}
+	rootModule.SelefraBlock.RequireProvidersBlock = RequireProvidersBlock{newRequireProviderBlock("aws", "")}
+	subModule := &Module{
+		ParentModule: rootModule,
+		SelefraBlock: &SelefraBlock{
+			Registry: "http://sub.example.com",
+			RequireProvidersBlock: RequireProvidersBlock{
+				newRequireProviderBlock("aws", ""),
+				newRequireProviderBlock("gcp", "oci://registry.example.com/providers"),
+			},
+		},
+	}
+	rootModule.SubModules = []*Module{subModule}
+
+	// Nothing is set, the official registry is used
+	registry, _ := subModule.ProviderRegistry("aws")
+	assert.Equal(t, "", registry)
+
+	// The registry of the selefra block of a submodule is ignored, but the registry of a required provider is not
+	rootModule.SelefraBlock.Registry = "file:///data/mirror"
+	registry, _ = subModule.ProviderRegistry("aws")
+	assert.Equal(t, "file:///data/mirror", registry)
+	registry, _ = rootModule.ProviderRegistry("gcp")
+	assert.Equal(t, "oci://registry.example.com/providers", registry)
+
+	// The required provider of the root module wins
+	rootModule.SelefraBlock.RequireProvidersBlock = append(rootModule.SelefraBlock.RequireProvidersBlock, newRequireProviderBlock("gcp", "github://my-org/my-registry"))
+	registry, _ = subModule.ProviderRegistry("gcp")
+	assert.Equal(t, "github://my-org/my-registry", registry)
+}
@@ -3,6 +3,9 @@ package module
 import (
 	"fmt"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"path"
+	"sort"
+	"strings"
 )
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -76,10 +79,156 @@ func (x ModulesBlock) ModulesInputMap() map[string]*ModuleBlock {
 
 // ------------------------------------------------- --------------------------------------------------------------------
 
+const (
+
+	// FilterActionExclude The rules matched by the filter are not executed, it is the default action
+	FilterActionExclude = "exclude"
+
+	// FilterActionInclude Only the rules matched by at least one include filter of the module block are executed
+	FilterActionInclude = "include"
+)
+
+// Filter Select the rules of a module by their name and metadata, all the conditions that are set must match.
+// A filter without any condition matches nothing
 type Filter struct {
-	Name     string `yaml:"name" json:"name"`
+
+	// FilterActionExclude or FilterActionInclude, FilterActionExclude if empty
+	Action string `yaml:"action" json:"action"`
+
+	// The name of the rule, a glob pattern such as s3_*
+	Name string `yaml:"name" json:"name"`
+
+	// The severity of the rule, such as High, several severities separated by comma such as High,Critical,
+	// or a threshold such as >=High
 	Severity string `yaml:"severity" json:"severity"`
+
+	// The provider of the rule, several providers separated by comma
 	Provider string `yaml:"provider" json:"provider"`
+
+	// The rule has at least one of the tags
+	Tags []string `yaml:"tags" json:"tags"`
+
+	// The id of the rule, a glob pattern such as SF0101*
+	Id string `yaml:"id" json:"id"`
+}
+
+// IsEmpty Whether the filter has no condition
+func (x *Filter) IsEmpty() bool {
+	return x.Name == "" && x.Severity == "" && x.Provider == "" && len(x.Tags) == 0 && x.Id == ""
+}
+
+// IsInclude Whether the rules matched by the filter are kept rather than excluded
+func (x *Filter) IsInclude() bool {
+	return strings.EqualFold(x.Action, FilterActionInclude)
+}
+
+// Match Whether the rule meets all the conditions of the filter
+func (x *Filter) Match(rule *RuleBlock) bool {
+	if x.IsEmpty() {
+		return false
+	}
+	metadata := rule.MetadataBlock
+	if metadata == nil {
+		metadata = &RuleMetadataBlock{}
+	}
+
+	if x.Name != "" && !matchGlob(x.Name, rule.Name) {
+		return false
+	}
+	if x.Id != "" && !matchGlob(x.Id, metadata.Id) {
+		return false
+	}
+	if x.Severity != "" && !MatchSeverity(x.Severity, metadata.Severity) {
+		return false
+	}
+	if x.Provider != "" {
+		matched := false
+		for _, provider := range strings.Split(x.Provider, ",") {
+			if strings.EqualFold(strings.TrimSpace(provider), metadata.Provider) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(x.Tags) != 0 {
+		matched := false
+		for _, tag := range x.Tags {
+			for _, ruleTag := range metadata.Tags {
+				if strings.EqualFold(tag, ruleTag) {
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Check Whether the action, the severity and the glob patterns of the filter are valid
+func (x *Filter) Check() error {
+	if x.Action != "" && !strings.EqualFold(x.Action, FilterActionExclude) && !strings.EqualFold(x.Action, FilterActionInclude) {
+		return fmt.Errorf("filter action %s is not valid, it must be %s or %s", x.Action, FilterActionInclude, FilterActionExclude)
+	}
+	if _, err := path.Match(x.Name, ""); err != nil {
+		return fmt.Errorf("filter name %s is not a valid glob pattern: %s", x.Name, err.Error())
+	}
+	if _, err := path.Match(x.Id, ""); err != nil {
+		return fmt.Errorf("filter id %s is not a valid glob pattern: %s", x.Id, err.Error())
+	}
+	if x.Severity != "" {
+		for _, severity := range strings.Split(strings.TrimLeft(strings.TrimSpace(x.Severity), "<>="), ",") {
+			if SeverityLevel(severity) < 0 {
+				return fmt.Errorf("filter severity %s is not valid, it must be one of %s", severity, strings.Join(Severities, ", "))
+			}
+		}
+	}
+	if x.IsEmpty() {
+		return fmt.Errorf("filter has no condition, it matches nothing")
+	}
+	return nil
+}
+
+// MatchSeverity Whether the severity meets the expression, the expression is a severity, several severities separated by comma,
+// or a comparison with a severity using one of >=, >, <=, <
+func MatchSeverity(expression, severity string) bool {
+	expression = strings.TrimSpace(expression)
+	level := SeverityLevel(severity)
+	for _, operator := range []string{">=", "<=", ">", "<"} {
+		if !strings.HasPrefix(expression, operator) {
+			continue
+		}
+		threshold := SeverityLevel(strings.TrimPrefix(expression, operator))
+		if level < 0 || threshold < 0 {
+			return false
+		}
+		switch operator {
+		case ">=":
+			return level >= threshold
+		case "<=":
+			return level <= threshold
+		case ">":
+			return level > threshold
+		default:
+			return level < threshold
+		}
+	}
+	for _, s := range strings.Split(expression, ",") {
+		if strings.EqualFold(strings.TrimSpace(s), strings.TrimSpace(severity)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGlob(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
 }
 
 // ModuleBlock Used to represent a common element in the modules array
@@ -97,6 +246,9 @@ type ModuleBlock struct {
 	// The module supports specifying some variables
 	Input map[string]any `yaml:"input" json:"input"`
 
+	// The timeout of the rules of the module and its submodules that do not set their own timeout
+	RuleTimeout string `yaml:"rule_timeout" json:"rule_timeout"`
+
 	*LocatableImpl `yaml:"-"`
 }
 
@@ -124,16 +276,90 @@ func (x *ModuleBlock) Check(module *Module, validatorContext *ValidatorContext)
 		diagnostics.AddErrorMsg(report)
 	}
 
-	if len(x.Input) != 0 {
-		diagnostics.AddDiagnostics(x.checkInput(module, validatorContext))
+	diagnostics.AddDiagnostics(x.checkInput(module, validatorContext))
+
+	for index := range x.Filter {
+		if err := x.Filter[index].Check(); err != nil {
+			errorTips := fmt.Sprintf("Module %s filter %d error: %s", x.Name, index, err.Error())
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation("filter"))
+			diagnostics.AddErrorMsg(report)
+		}
+	}
+
+	if x.RuleTimeout != "" {
+		if _, err := ParseRuleTimeout(x.RuleTimeout); err != nil {
+			errorTips := fmt.Sprintf("Module %s rule_timeout %s is not valid: %s", x.Name, x.RuleTimeout, err.Error())
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation("rule_timeout"+NodeLocationSelfValue))
+			diagnostics.AddErrorMsg(report)
+		}
 	}
 
 	return diagnostics
 }
 
+// Keep Whether the rule is kept by the filters of the module block: when there are include filters the rule must match one of them,
+// and it must not match any exclude filter
+func (x *ModuleBlock) Keep(rule *RuleBlock) bool {
+	hasInclude := false
+	included := false
+	for index := range x.Filter {
+		filter := &x.Filter[index]
+		if filter.IsInclude() {
+			hasInclude = true
+			if filter.Match(rule) {
+				included = true
+			}
+		} else if filter.Match(rule) {
+			return false
+		}
+	}
+	return !hasInclude || included
+}
+
+// The input is checked against the variables the used module declares, so it is only checked after the submodules are loaded.
+// Whether the required variables are given is checked by the planner, because they may be inherited from the parent scope
 func (x *ModuleBlock) checkInput(module *Module, validatorContext *ValidatorContext) *schema.Diagnostics {
-	// nothing to do now
-	return nil
+
+	diagnostics := schema.NewDiagnostics()
+
+	var subModule *Module
+	for _, m := range module.SubModules {
+		if m.GetModuleBlock() == x {
+			subModule = m
+			break
+		}
+	}
+	if subModule == nil {
+		return diagnostics
+	}
+
+	variableMap := make(map[string]*VariableBlock, len(subModule.VariablesBlock))
+	for _, variableBlock := range subModule.VariablesBlock {
+		variableMap[variableBlock.Key] = variableBlock
+	}
+
+	inputKeys := make([]string, 0, len(x.Input))
+	for key := range x.Input {
+		inputKeys = append(inputKeys, key)
+	}
+	sort.Strings(inputKeys)
+	for _, key := range inputKeys {
+		variableBlock, exists := variableMap[key]
+		if !exists {
+			// The input is still in the scope of the module, the templates may use it without declaring
+			errorTips := fmt.Sprintf("Module %s input %s is not declared in the variables of module %s", x.Name, key, x.Uses)
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation("input."+key+NodeLocationSelfKey))
+			diagnostics.AddWarn(report)
+			continue
+		}
+		if err := variableBlock.CheckValue(x.Input[key]); err != nil {
+			errorTips := fmt.Sprintf("Module %s input %s is not valid: %s", x.Name, key, err.Error())
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation("input."+key+NodeLocationSelfValue))
+			diagnostics.AddErrorMsg(report)
+		}
+	}
+
+	return diagnostics
 }
 
 func (x *ModuleBlock) IsEmpty() bool {
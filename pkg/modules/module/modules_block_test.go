@@ -0,0 +1,114 @@
+package module
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"testing"
+	"time"
+)
+
+func newFilterTestRule(name, id, severity, provider string, tags ...string) *RuleBlock {
+	return &RuleBlock{
+		Name: name,
+		MetadataBlock: &RuleMetadataBlock{
+			Id:       id,
+			Severity: severity,
+			Provider: provider,
+			Tags:     tags,
+		},
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	rule := newFilterTestRule("s3_bucket_public", "SF010101", "High", "AWS", "Security", "CIS")
+
+	assert.True(t, (&Filter{Name: "s3_bucket_public"}).Match(rule))
+	assert.True(t, (&Filter{Name: "s3_*"}).Match(rule))
+	assert.True(t, (&Filter{Id: "SF0101*"}).Match(rule))
+	assert.True(t, (&Filter{Severity: "High,Critical"}).Match(rule))
+	assert.True(t, (&Filter{Severity: ">=high"}).Match(rule))
+	assert.False(t, (&Filter{Severity: ">High"}).Match(rule))
+	assert.True(t, (&Filter{Provider: "gcp, aws"}).Match(rule))
+	assert.True(t, (&Filter{Tags: []string{"cis", "pci"}}).Match(rule))
+	assert.False(t, (&Filter{Tags: []string{"pci"}}).Match(rule))
+	assert.False(t, (&Filter{Provider: "aws", Severity: "Critical"}).Match(rule))
+	assert.False(t, (&Filter{}).Match(rule))
+}
+
+func TestModuleBlock_Keep(t *testing.T) {
+	moduleBlock := &ModuleBlock{}
+	assert.Nil(t, yaml.Unmarshal([]byte(`
+name: aws_pack
+uses: ./rules
+filter:
+  - action: include
+    provider: AWS
+    severity: ">=High"
+  - name: s3_bucket_logging
+`), moduleBlock))
+	for _, filter := range moduleBlock.Filter {
+		assert.Nil(t, filter.Check())
+	}
+
+	assert.True(t, moduleBlock.Keep(newFilterTestRule("s3_bucket_public", "SF010101", "Critical", "AWS")))
+	assert.False(t, moduleBlock.Keep(newFilterTestRule("s3_bucket_logging", "SF010102", "High", "AWS")))
+	assert.False(t, moduleBlock.Keep(newFilterTestRule("ec2_ebs_encryption", "SF010201", "Medium", "AWS")))
+	assert.False(t, moduleBlock.Keep(newFilterTestRule("gcs_bucket_public", "SF020101", "Critical", "GCP")))
+
+	assert.NotNil(t, (&Filter{Action: "keep", Name: "a"}).Check())
+	assert.NotNil(t, (&Filter{Severity: ">=Urgent"}).Check())
+	assert.NotNil(t, (&Filter{Name: "[a"}).Check())
+	assert.NotNil(t, (&Filter{}).Check())
+}
+
+func TestModule_RuleExcludedBy(t *testing.T) {
+	rootModule := &Module{
+		ModulesBlock: ModulesBlock{
+			{Name: "pack", Uses: "./pack", Filter: []Filter{{Action: FilterActionInclude, Severity: "Critical"}}},
+		},
+	}
+	packModule := &Module{
+		Source:       "./pack",
+		ParentModule: rootModule,
+		ModulesBlock: ModulesBlock{
+			{Name: "aws", Uses: "./aws", Filter: []Filter{{Tags: []string{"deprecated"}}}},
+		},
+	}
+	awsModule := &Module{Source: "./aws", ParentModule: packModule}
+	rootModule.SubModules = []*Module{packModule}
+	packModule.SubModules = []*Module{awsModule}
+
+	assert.Nil(t, awsModule.RuleExcludedBy(newFilterTestRule("a", "1", "Critical", "AWS")))
+	assert.Equal(t, "aws", awsModule.RuleExcludedBy(newFilterTestRule("b", "2", "Critical", "AWS", "deprecated")).Name)
+	assert.Equal(t, "pack", awsModule.RuleExcludedBy(newFilterTestRule("c", "3", "High", "AWS")).Name)
+	assert.Nil(t, rootModule.RuleExcludedBy(newFilterTestRule("d", "4", "Low", "AWS")))
+}
+
+func TestModule_RuleTimeout(t *testing.T) {
+	rootModule := &Module{
+		SelefraBlock: &SelefraBlock{RuleTimeout: "10m"},
+		ModulesBlock: ModulesBlock{
+			{Name: "pack", Uses: "./pack", RuleTimeout: "1m"},
+		},
+	}
+	packModule := &Module{
+		Source:       "./pack",
+		ParentModule: rootModule,
+		ModulesBlock: ModulesBlock{
+			{Name: "aws", Uses: "./aws"},
+		},
+	}
+	awsModule := &Module{Source: "./aws", ParentModule: packModule}
+	rootModule.SubModules = []*Module{packModule}
+	packModule.SubModules = []*Module{awsModule}
+
+	assert.Equal(t, 30*time.Second, awsModule.RuleTimeout(&RuleBlock{Timeout: "30s"}))
+	assert.Equal(t, time.Minute, awsModule.RuleTimeout(&RuleBlock{}))
+	assert.Equal(t, 10*time.Minute, rootModule.RuleTimeout(&RuleBlock{}))
+	assert.Equal(t, time.Duration(0), (&Module{}).RuleTimeout(&RuleBlock{}))
+
+	_, err := ParseRuleTimeout("0s")
+	assert.NotNil(t, err)
+	_, err = ParseRuleTimeout("ten minutes")
+	assert.NotNil(t, err)
+}
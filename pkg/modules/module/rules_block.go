@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
 	"strings"
+	"time"
 )
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -101,6 +102,9 @@ type RuleBlock struct {
 
 	MainTable string `yaml:"main_table" json:"main_table"`
 
+	// How long the query of the rule may run on a storage, such as 30s or 5m, the rule_timeout of its module if not set
+	Timeout string `yaml:"timeout" json:"timeout"`
+
 	*LocatableImpl `yaml:"-"`
 }
 
@@ -147,6 +151,15 @@ func (x *RuleBlock) Check(module *Module, validatorContext *ValidatorContext) *s
 		diagnostics.AddDiagnostics(x.MetadataBlock.Check(module, validatorContext))
 	}
 
+	// timeout
+	if x.Timeout != "" {
+		if _, err := ParseRuleTimeout(x.Timeout); err != nil {
+			errorTips := fmt.Sprintf("Rule timeout %s is not valid: %s", x.Timeout, err.Error())
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation("timeout"+NodeLocationSelfValue))
+			diagnostics.AddErrorMsg(report)
+		}
+	}
+
 	return diagnostics
 }
 
@@ -165,6 +178,7 @@ func (x *RuleBlock) Copy() *RuleBlock {
 		Labels:        x.Labels,
 		Output:        x.Output,
 		MainTable:     x.MainTable,
+		Timeout:       x.Timeout,
 		LocatableImpl: x.LocatableImpl,
 	}
 	if x.MetadataBlock != nil {
@@ -173,8 +187,34 @@ func (x *RuleBlock) Copy() *RuleBlock {
 	return ruleBlock
 }
 
+// ParseRuleTimeout Parse the timeout of a rule, it is a positive duration such as 30s, 5m or 1h30m
+func ParseRuleTimeout(timeout string) (time.Duration, error) {
+	duration, err := time.ParseDuration(strings.TrimSpace(timeout))
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("timeout must be greater than 0")
+	}
+	return duration, nil
+}
+
 // ------------------------------------------------- --------------------------------------------------------------------
 
+// Severities The severities of a rule, from the lowest to the highest
+var Severities = []string{"Informational", "Low", "Medium", "High", "Critical"}
+
+// SeverityLevel The position of the severity in Severities, the higher the more severe, case-insensitive.
+// Returns -1 if it is not one of Severities
+func SeverityLevel(severity string) int {
+	for index, s := range Severities {
+		if strings.EqualFold(s, strings.TrimSpace(severity)) {
+			return index
+		}
+	}
+	return -1
+}
+
 // RuleMetadataBlock Represents metadata information for a block
 type RuleMetadataBlock struct {
 
@@ -28,6 +28,13 @@ type SelefraBlock struct {
 	// Global log level. This level is used when the provider does not specify a log level
 	LogLevel string `yaml:"log_level,omitempty" mapstructure:"log_level,omitempty"`
 
+	// The timeout of the rules that neither set their own timeout nor are in a module with a rule_timeout
+	RuleTimeout string `yaml:"rule_timeout,omitempty" mapstructure:"rule_timeout,omitempty"`
+
+	// Where the providers are installed from, such as github, github://owner/repo, a local directory, a http url or an
+	// oci:// url. The official registry on GitHub is used if not set, a required provider can use its own registry
+	Registry string `yaml:"registry,omitempty" mapstructure:"registry,omitempty"`
+
 	//What are the providers required for operation
 	RequireProvidersBlock RequireProvidersBlock `yaml:"providers,omitempty" mapstructure:"providers,omitempty"`
 
@@ -123,6 +130,28 @@ func (x *SelefraBlock) Merge(other *SelefraBlock) (*SelefraBlock, *schema.Diagno
 		mergedSelefraBlock.LogLevel = other.LogLevel
 	}
 
+	// RuleTimeout
+	if x.RuleTimeout != "" && other.RuleTimeout != "" {
+		errorTips := fmt.Sprintf("selefra rule_timeout block can not duplicated")
+		report := RenderErrorTemplate(errorTips, x.GetNodeLocation("rule_timeout"))
+		diagnostics.AddErrorMsg(report)
+	} else if x.RuleTimeout != "" {
+		mergedSelefraBlock.RuleTimeout = x.RuleTimeout
+	} else {
+		mergedSelefraBlock.RuleTimeout = other.RuleTimeout
+	}
+
+	// Registry
+	if x.Registry != "" && other.Registry != "" {
+		errorTips := fmt.Sprintf("selefra registry block can not duplicated")
+		report := RenderErrorTemplate(errorTips, x.GetNodeLocation("registry"))
+		diagnostics.AddErrorMsg(report)
+	} else if x.Registry != "" {
+		mergedSelefraBlock.Registry = x.Registry
+	} else {
+		mergedSelefraBlock.Registry = other.Registry
+	}
+
 	// only RequireProvidersBlock can merge
 	if x.RequireProvidersBlock != nil && other.RequireProvidersBlock != nil {
 		merge, d := x.RequireProvidersBlock.Merge(other.RequireProvidersBlock)
@@ -170,6 +199,14 @@ func (x *SelefraBlock) Check(module *Module, validatorContext *ValidatorContext)
 		x.ConnectionBlock.Check(module, validatorContext)
 	}
 
+	if x.RuleTimeout != "" {
+		if _, err := ParseRuleTimeout(x.RuleTimeout); err != nil {
+			errorTips := fmt.Sprintf("selefra rule_timeout %s is not valid: %s", x.RuleTimeout, err.Error())
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation("rule_timeout"+NodeLocationSelfValue))
+			diagnostics.AddErrorMsg(report)
+		}
+	}
+
 	// TODO To be determined, after discussion to determine the logic
 	//if len(x.RequireProvidersBlock) == 0 {
 	//	diagnostics.AddErrorMsg("selefra.providers can not be empty")
@@ -185,6 +222,8 @@ func (x *SelefraBlock) IsEmpty() bool {
 		(x.CloudBlock == nil || x.CloudBlock.IsEmpty()) &&
 		x.CliVersion == "" &&
 		x.LogLevel == "" &&
+		x.RuleTimeout == "" &&
+		x.Registry == "" &&
 		len(x.RequireProvidersBlock) == 0 &&
 		x.ConnectionBlock == nil
 }
@@ -427,6 +466,9 @@ type RequireProviderBlock struct {
 	// The debug parameter, if configured, uses the given path instead of downloading
 	Path string `yaml:"path,omitempty" json:"path,omitempty"`
 
+	// Where this provider is installed from, it overrides the registry of the selefra block
+	Registry string `yaml:"registry,omitempty" json:"registry,omitempty"`
+
 	//runtime *RequireProviderBlockRuntime
 	*LocatableImpl `yaml:"-"`
 }
@@ -479,7 +521,7 @@ func (x *RequireProviderBlock) Check(module *Module, validatorContext *Validator
 }
 
 func (x *RequireProviderBlock) IsEmpty() bool {
-	return x.Name == "" && x.Source == "" && x.Version == "" && x.Path == ""
+	return x.Name == "" && x.Source == "" && x.Version == "" && x.Path == "" && x.Registry == ""
 }
 
 //func (x *RequireProviderBlock) Runtime() *RequireProviderBlockRuntime {
@@ -4,6 +4,11 @@ import (
 	"fmt"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
 	"github.com/selefra/selefra-utils/pkg/reflect_util"
+	"github.com/selefra/selefra/pkg/utils"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"regexp"
+	"strings"
 )
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -81,6 +86,18 @@ func (x VariablesBlock) SetNodeLocation(selector string, nodeLocation *NodeLocat
 
 // ------------------------------------------------- --------------------------------------------------------------------
 
+// The types a variable can declare, a variable without a type accepts any value
+const (
+	VariableTypeString = "string"
+	VariableTypeNumber = "number"
+	VariableTypeBool   = "bool"
+	VariableTypeList   = "list"
+	VariableTypeMap    = "map"
+)
+
+// VariableTypes All types a variable can declare
+var VariableTypes = []string{VariableTypeString, VariableTypeNumber, VariableTypeBool, VariableTypeList, VariableTypeMap}
+
 // VariableBlock Used to declare a variable
 type VariableBlock struct {
 
@@ -96,6 +113,21 @@ type VariableBlock struct {
 	// Who is the author of the variable? What the hell is this?
 	Author string `yaml:"author" json:"author"`
 
+	// The type of the value, one of VariableTypes, any value is accepted if empty
+	Type string `yaml:"type" json:"type"`
+
+	// The value must be given by the user of the module, so the variable can not have a default
+	Required bool `yaml:"required" json:"required"`
+
+	// The value must be one of them if not empty
+	AllowedValues []any `yaml:"allowed_values" json:"allowed_values"`
+
+	// A string value must match the regular expression if not empty
+	Pattern string `yaml:"pattern" json:"pattern"`
+
+	// The value is a secret such as a password, it is masked when the variables are shown
+	Sensitive bool `yaml:"sensitive" json:"sensitive"`
+
 	*LocatableImpl `yaml:"-"`
 }
 
@@ -117,20 +149,146 @@ func (x *VariableBlock) Check(module *Module, validatorContext *ValidatorContext
 		diagnostics.AddErrorMsg(report)
 	}
 
-	if reflect_util.IsNil(x.Default) {
-		errorTips := fmt.Sprintf("Variable default must not be empty")
+	if x.Type != "" && !utils.HasOne(VariableTypes, x.Type) {
+		errorTips := fmt.Sprintf("Variable %s type %s is not supported, must be one of %s", x.Key, x.Type, strings.Join(VariableTypes, ", "))
+		report := RenderErrorTemplate(errorTips, x.GetNodeLocation("type"+NodeLocationSelfValue))
+		diagnostics.AddErrorMsg(report)
+		return diagnostics
+	}
+
+	if x.Required && x.HasDefault() {
+		errorTips := fmt.Sprintf("Variable %s is required, it can not have a default", x.Key)
 		report := RenderErrorTemplate(errorTips, x.GetNodeLocation("default"))
 		diagnostics.AddErrorMsg(report)
 	}
 
+	if x.Pattern != "" {
+		if x.Type != "" && x.Type != VariableTypeString {
+			errorTips := fmt.Sprintf("Variable %s pattern can only be used with type %s", x.Key, VariableTypeString)
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation("pattern"))
+			diagnostics.AddErrorMsg(report)
+		} else if _, err := regexp.Compile(x.Pattern); err != nil {
+			errorTips := fmt.Sprintf("Variable %s pattern %s is not a valid regular expression: %s", x.Key, x.Pattern, err.Error())
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation("pattern"+NodeLocationSelfValue))
+			diagnostics.AddErrorMsg(report)
+			return diagnostics
+		}
+	}
+
+	for _, allowedValue := range x.AllowedValues {
+		if err := x.checkType(allowedValue); err != nil {
+			errorTips := fmt.Sprintf("Variable %s allowed value %v is not valid: %s", x.Key, allowedValue, err.Error())
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation("allowed_values"+NodeLocationSelfValue))
+			diagnostics.AddErrorMsg(report)
+		}
+	}
+
+	if x.HasDefault() {
+		if err := x.CheckValue(x.Default); err != nil {
+			errorTips := fmt.Sprintf("Variable %s default is not valid: %s", x.Key, err.Error())
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation("default"+NodeLocationSelfValue))
+			diagnostics.AddErrorMsg(report)
+		}
+	}
+
 	return diagnostics
 }
 
+// HasDefault Whether the variable declares a default value
+func (x *VariableBlock) HasDefault() bool {
+	return !reflect_util.IsNil(x.Default)
+}
+
+// IsRequired A variable without a default must be given a value just like the one declared required
+func (x *VariableBlock) IsRequired() bool {
+	return x.Required || !x.HasDefault()
+}
+
+// CheckValue Whether the value is of the type, is one of the allowed values and matches the pattern of the variable
+func (x *VariableBlock) CheckValue(value any) error {
+
+	if err := x.checkType(value); err != nil {
+		return err
+	}
+
+	if len(x.AllowedValues) != 0 {
+		allowed := false
+		for _, allowedValue := range x.AllowedValues {
+			// The numbers may be of different go types, so they are compared as they are written
+			if fmt.Sprintf("%v", allowedValue) == fmt.Sprintf("%v", value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("value %v is not one of the allowed values %v", value, x.AllowedValues)
+		}
+	}
+
+	if stringValue, ok := value.(string); ok && x.Pattern != "" {
+		matched, err := regexp.MatchString(x.Pattern, stringValue)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			return fmt.Errorf("value %s does not match the pattern %s", stringValue, x.Pattern)
+		}
+	}
+
+	return nil
+}
+
+// ParseValue Convert a value given as a string, such as from the command line, to the type of the variable. It is parsed as
+// yaml unless the variable is a string or has no type, so a list can be given as [a, b] and a map as {a: 1}
+func (x *VariableBlock) ParseValue(raw string) (any, error) {
+	if x.Type == "" || x.Type == VariableTypeString {
+		return raw, nil
+	}
+	var value any
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, fmt.Errorf("value %s is not a %s: %s", raw, x.Type, err.Error())
+	}
+	return value, nil
+}
+
+func (x *VariableBlock) checkType(value any) error {
+	if x.Type == "" {
+		return nil
+	}
+	if reflect_util.IsNil(value) {
+		return fmt.Errorf("value must be a %s, but it is empty", x.Type)
+	}
+	ok := false
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String:
+		ok = x.Type == VariableTypeString
+	case reflect.Bool:
+		ok = x.Type == VariableTypeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		ok = x.Type == VariableTypeNumber
+	case reflect.Slice, reflect.Array:
+		ok = x.Type == VariableTypeList
+	case reflect.Map:
+		ok = x.Type == VariableTypeMap
+	}
+	if !ok {
+		return fmt.Errorf("value %v must be a %s", value, x.Type)
+	}
+	return nil
+}
+
 func (x *VariableBlock) IsEmpty() bool {
 	return x.Key == "" &&
 		reflect_util.IsNil(x.Default) &&
 		x.Description == "" &&
-		x.Author == ""
+		x.Author == "" &&
+		x.Type == "" &&
+		!x.Required &&
+		len(x.AllowedValues) == 0 &&
+		x.Pattern == "" &&
+		!x.Sensitive
 }
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,82 @@
+package module
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVariableBlock_CheckValue(t *testing.T) {
+	variableBlock := &VariableBlock{Key: "region", Type: VariableTypeString, Pattern: "^[a-z]+-[a-z]+-[0-9]$", AllowedValues: []any{"us-east-1", "eu-west-1"}}
+	assert.Nil(t, variableBlock.CheckValue("us-east-1"))
+	assert.NotNil(t, variableBlock.CheckValue("ap-south-1"))
+	assert.NotNil(t, variableBlock.CheckValue(1))
+
+	variableBlock = &VariableBlock{Key: "threshold", Type: VariableTypeNumber, AllowedValues: []any{30, 90}}
+	assert.Nil(t, variableBlock.CheckValue(90))
+	assert.Nil(t, variableBlock.CheckValue(uint64(30)))
+	assert.NotNil(t, variableBlock.CheckValue(60))
+	assert.NotNil(t, variableBlock.CheckValue("90"))
+
+	assert.Nil(t, (&VariableBlock{Type: VariableTypeBool}).CheckValue(true))
+	assert.Nil(t, (&VariableBlock{Type: VariableTypeList}).CheckValue([]any{"a"}))
+	assert.Nil(t, (&VariableBlock{Type: VariableTypeMap}).CheckValue(map[string]any{"a": 1}))
+	assert.NotNil(t, (&VariableBlock{Type: VariableTypeMap}).CheckValue([]any{"a"}))
+	assert.NotNil(t, (&VariableBlock{Type: VariableTypeString}).CheckValue(nil))
+	assert.Nil(t, (&VariableBlock{}).CheckValue([]any{"a"}))
+}
+
+func TestVariableBlock_Check(t *testing.T) {
+	check := func(variableBlock *VariableBlock) bool {
+		variableBlock.LocatableImpl = NewLocatableImpl()
+		return variableBlock.Check(nil, NewValidatorContext()).HasError()
+	}
+
+	assert.False(t, check(&VariableBlock{Key: "region", Default: "us-east-1"}))
+	assert.False(t, check(&VariableBlock{Key: "region", Required: true}))
+	assert.False(t, check(&VariableBlock{Key: "region"}))
+	assert.True(t, check(&VariableBlock{Default: "us-east-1"}))
+	assert.True(t, check(&VariableBlock{Key: "region", Type: "text", Default: "us-east-1"}))
+	assert.True(t, check(&VariableBlock{Key: "region", Required: true, Default: "us-east-1"}))
+	assert.True(t, check(&VariableBlock{Key: "region", Type: VariableTypeNumber, Default: "us-east-1"}))
+	assert.True(t, check(&VariableBlock{Key: "region", Type: VariableTypeNumber, Pattern: "^[0-9]+$", Default: 1}))
+	assert.True(t, check(&VariableBlock{Key: "region", Pattern: "[a", Default: "us-east-1"}))
+	assert.True(t, check(&VariableBlock{Key: "region", AllowedValues: []any{"us-east-1"}, Default: "eu-west-1"}))
+	assert.True(t, check(&VariableBlock{Key: "threshold", Type: VariableTypeNumber, AllowedValues: []any{"30"}}))
+
+	variableBlock := &VariableBlock{Key: "region"}
+	assert.True(t, variableBlock.IsRequired())
+	variableBlock.Default = "us-east-1"
+	assert.False(t, variableBlock.IsRequired())
+}
+
+func TestModuleBlock_CheckInput(t *testing.T) {
+	moduleBlock := &ModuleBlock{
+		Name: "aws_pack",
+		Uses: "./rules",
+		Input: map[string]any{
+			"threshold": "90",
+			"unknown":   true,
+		},
+		LocatableImpl: NewLocatableImpl(),
+	}
+	parentModule := &Module{ModulesBlock: ModulesBlock{moduleBlock}}
+	subModule := &Module{
+		Source:       "./rules",
+		ParentModule: parentModule,
+		VariablesBlock: VariablesBlock{
+			{Key: "threshold", Type: VariableTypeNumber, Default: 30, LocatableImpl: NewLocatableImpl()},
+			{Key: "region", Required: true, LocatableImpl: NewLocatableImpl()},
+		},
+	}
+	parentModule.SubModules = []*Module{subModule}
+
+	diagnostics := moduleBlock.checkInput(parentModule, NewValidatorContext())
+	assert.True(t, diagnostics.HasError())
+	assert.Len(t, diagnostics.GetDiagnosticSlice(), 2)
+
+	moduleBlock.Input = map[string]any{"threshold": 90}
+	assert.False(t, moduleBlock.checkInput(parentModule, NewValidatorContext()).HasError())
+
+	// The module used is not loaded, there is nothing to check
+	assert.False(t, moduleBlock.checkInput(&Module{ModulesBlock: ModulesBlock{moduleBlock}}, NewValidatorContext()).HasError())
+}
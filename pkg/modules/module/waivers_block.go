@@ -0,0 +1,191 @@
+package module
+
+import (
+	"fmt"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/utils"
+	"path"
+	"regexp"
+	"time"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// WaiverExpiresLayout The layout of the expiry date of a waiver, a waiver is valid until the end of that day
+const WaiverExpiresLayout = "2006-01-02"
+
+// WaiversBlock The accepted risks of a module, the findings they match are suppressed rather than reported
+type WaiversBlock []*WaiverBlock
+
+var _ MergableBlock[WaiversBlock] = (*WaiversBlock)(nil)
+var _ Block = (*WaiversBlock)(nil)
+
+func (x WaiversBlock) Merge(other WaiversBlock) (WaiversBlock, *schema.Diagnostics) {
+	mergedWaivers := make(WaiversBlock, 0, len(x)+len(other))
+	mergedWaivers = append(mergedWaivers, x...)
+	mergedWaivers = append(mergedWaivers, other...)
+	return mergedWaivers, nil
+}
+
+func (x WaiversBlock) Check(module *Module, validatorContext *ValidatorContext) *schema.Diagnostics {
+	diagnostics := schema.NewDiagnostics()
+	for _, waiverBlock := range x {
+		diagnostics.AddDiagnostics(waiverBlock.Check(module, validatorContext))
+	}
+	return diagnostics
+}
+
+func (x WaiversBlock) IsEmpty() bool {
+	return len(x) == 0
+}
+
+func (x WaiversBlock) GetNodeLocation(selector string) *NodeLocation {
+	panic(ErrNotSupport)
+}
+
+func (x WaiversBlock) SetNodeLocation(selector string, nodeLocation *NodeLocation) error {
+	panic(ErrNotSupport)
+}
+
+// Match Find the first waiver that is not expired at the time and matches the finding, nil if the finding is not waived
+func (x WaiversBlock) Match(finding *RuleBlock, now time.Time) *WaiverBlock {
+	for _, waiverBlock := range x {
+		if !waiverBlock.IsExpired(now) && waiverBlock.Match(finding) {
+			return waiverBlock
+		}
+	}
+	return nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// WaiverBlock Suppress the findings of a rule, the findings are matched by the id of the rule plus the labels of the resource
+// or a regular expression over the rendered output. Every waiver must say why it is accepted, who owns it and when it expires
+type WaiverBlock struct {
+
+	// The id in the metadata of the rule, a glob pattern such as SF0101*
+	RuleId string `yaml:"rule_id" json:"rule_id"`
+
+	// The rendered labels of the finding must have all these values, such as resource_id
+	Labels map[string]interface{} `yaml:"labels" json:"labels"`
+
+	// A regular expression that the rendered output of the finding must match
+	Output string `yaml:"output" json:"output"`
+
+	// Why the risk is accepted
+	Reason string `yaml:"reason" json:"reason"`
+
+	// Who accepted the risk
+	Owner string `yaml:"owner" json:"owner"`
+
+	// The waiver is valid until the end of this day, in the WaiverExpiresLayout layout
+	Expires string `yaml:"expires" json:"expires"`
+
+	*LocatableImpl `yaml:"-"`
+}
+
+var _ Block = &WaiverBlock{}
+
+func NewWaiverBlock() *WaiverBlock {
+	return &WaiverBlock{
+		LocatableImpl: NewLocatableImpl(),
+	}
+}
+
+func (x *WaiverBlock) Check(module *Module, validatorContext *ValidatorContext) *schema.Diagnostics {
+
+	diagnostics := schema.NewDiagnostics()
+
+	if x.RuleId == "" {
+		report := RenderErrorTemplate("Waiver rule_id must not be empty", x.GetNodeLocation(""))
+		diagnostics.AddErrorMsg(report)
+	} else if _, err := path.Match(x.RuleId, ""); err != nil {
+		errorTips := fmt.Sprintf("Waiver rule_id %s is not a valid glob pattern: %s", x.RuleId, err.Error())
+		report := RenderErrorTemplate(errorTips, x.GetNodeLocation("rule_id"+NodeLocationSelfValue))
+		diagnostics.AddErrorMsg(report)
+	}
+
+	if len(x.Labels) == 0 && x.Output == "" {
+		errorTips := fmt.Sprintf("Waiver of rule %s must match the findings by labels or output", x.RuleId)
+		report := RenderErrorTemplate(errorTips, x.GetNodeLocation(""))
+		diagnostics.AddErrorMsg(report)
+	}
+
+	if x.Output != "" {
+		if _, err := regexp.Compile(x.Output); err != nil {
+			errorTips := fmt.Sprintf("Waiver output %s is not a valid regular expression: %s", x.Output, err.Error())
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation("output"+NodeLocationSelfValue))
+			diagnostics.AddErrorMsg(report)
+		}
+	}
+
+	for _, field := range []struct{ name, value string }{{"reason", x.Reason}, {"owner", x.Owner}, {"expires", x.Expires}} {
+		if field.value == "" {
+			errorTips := fmt.Sprintf("Waiver of rule %s must have %s", x.RuleId, field.name)
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation(""))
+			diagnostics.AddErrorMsg(report)
+		}
+	}
+
+	if x.Expires != "" {
+		if _, err := x.ExpiresTime(); err != nil {
+			errorTips := fmt.Sprintf("Waiver expires %s is not a date like %s", x.Expires, WaiverExpiresLayout)
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation("expires"+NodeLocationSelfValue))
+			diagnostics.AddErrorMsg(report)
+		} else if x.IsExpired(time.Now()) {
+			// An expired waiver suppresses nothing, the findings are reported again
+			errorTips := fmt.Sprintf("Waiver of rule %s owned by %s expired on %s, the findings it matches are reported again", x.RuleId, x.Owner, x.Expires)
+			report := RenderErrorTemplate(errorTips, x.GetNodeLocation("expires"+NodeLocationSelfValue))
+			diagnostics.AddWarn(report)
+		}
+	}
+
+	return diagnostics
+}
+
+func (x *WaiverBlock) IsEmpty() bool {
+	if x == nil {
+		return true
+	}
+	return x.RuleId == "" && len(x.Labels) == 0 && x.Output == "" && x.Reason == "" && x.Owner == "" && x.Expires == ""
+}
+
+// ExpiresTime The end of the expiry day of the waiver, in local time
+func (x *WaiverBlock) ExpiresTime() (time.Time, error) {
+	expires, err := time.ParseInLocation(WaiverExpiresLayout, x.Expires, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return expires.AddDate(0, 0, 1), nil
+}
+
+// IsExpired Whether the waiver has expired at the time, a waiver whose expiry date can not be parsed is expired
+func (x *WaiverBlock) IsExpired(now time.Time) bool {
+	expires, err := x.ExpiresTime()
+	return err != nil || !now.Before(expires)
+}
+
+// Match Whether the finding, that is the rule block rendered with a row, is matched by the waiver
+func (x *WaiverBlock) Match(finding *RuleBlock) bool {
+	if finding == nil || finding.MetadataBlock == nil {
+		return false
+	}
+	if matched, err := path.Match(x.RuleId, finding.MetadataBlock.Id); err != nil || !matched {
+		return false
+	}
+	for key, value := range x.Labels {
+		findingValue, exists := finding.Labels[key]
+		if !exists || utils.Strava(findingValue) != utils.Strava(value) {
+			return false
+		}
+	}
+	if x.Output != "" {
+		outputRegexp, err := regexp.Compile(x.Output)
+		if err != nil || !outputRegexp.MatchString(finding.Output) {
+			return false
+		}
+	}
+	return true
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,62 @@
+package module
+
+import (
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newWaiverTestFinding(resourceID, output string) *RuleBlock {
+	return &RuleBlock{
+		Name:   "ebs_volume_unencrypted",
+		Output: output,
+		Labels: map[string]interface{}{"resource_id": resourceID, "region": "us-east-1"},
+		MetadataBlock: &RuleMetadataBlock{
+			Id:       "SF010302",
+			Severity: "High",
+		},
+	}
+}
+
+func TestWaiverBlock_Match(t *testing.T) {
+	finding := newWaiverTestFinding("vol-1", "volume vol-1 is not encrypted")
+
+	assert.True(t, (&WaiverBlock{RuleId: "SF010302", Labels: map[string]interface{}{"resource_id": "vol-1"}}).Match(finding))
+	assert.True(t, (&WaiverBlock{RuleId: "SF0103*", Output: `vol-\d+ is not`}).Match(finding))
+	assert.False(t, (&WaiverBlock{RuleId: "SF010302", Labels: map[string]interface{}{"resource_id": "vol-2"}}).Match(finding))
+	assert.False(t, (&WaiverBlock{RuleId: "SF010101", Labels: map[string]interface{}{"resource_id": "vol-1"}}).Match(finding))
+	assert.False(t, (&WaiverBlock{RuleId: "SF010302", Output: "snapshot"}).Match(finding))
+}
+
+func TestWaiversBlock_Match(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+	finding := newWaiverTestFinding("vol-1", "volume vol-1 is not encrypted")
+	expired := &WaiverBlock{RuleId: "SF010302", Output: "vol-1", Expires: "2024-05-31"}
+	lastDay := &WaiverBlock{RuleId: "SF010302", Output: "vol-1", Expires: "2024-06-01"}
+
+	assert.True(t, expired.IsExpired(now))
+	assert.False(t, lastDay.IsExpired(now))
+	assert.Nil(t, WaiversBlock{expired}.Match(finding, now))
+	assert.Equal(t, lastDay, WaiversBlock{expired, lastDay}.Match(finding, now))
+
+	module := &Module{WaiversBlock: WaiversBlock{lastDay}}
+	subModule := &Module{ParentModule: module}
+	assert.Equal(t, lastDay, subModule.FindWaiver(finding, now))
+}
+
+func TestWaiverBlock_Check(t *testing.T) {
+	waiver := NewWaiverBlock()
+	waiver.RuleId = "SF010302"
+	waiver.Output = "vol-1"
+	waiver.Reason = "test volume"
+	waiver.Owner = "infra"
+	waiver.Expires = "2000-01-01"
+	diagnostics := waiver.Check(nil, nil)
+	assert.False(t, utils.HasError(diagnostics))
+	assert.True(t, utils.IsNotEmpty(diagnostics))
+
+	waiver.Expires = "next week"
+	waiver.Owner = ""
+	assert.True(t, utils.HasError(waiver.Check(nil, nil)))
+}
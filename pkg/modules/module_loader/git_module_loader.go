@@ -0,0 +1,229 @@
+package module_loader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/hashicorp/go-getter"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra-utils/pkg/md5_util"
+	"github.com/selefra/selefra/pkg/http_client"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/utils"
+	"net/url"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// GitModuleSourcePrefix Force the source to be loaded from git, for example git::https://github.com/org/rules.git
+const GitModuleSourcePrefix = "git::"
+
+// GitModuleSource The parts of a git module source such as git::https://github.com/org/rules.git//aws/s3?ref=v0.0.1
+// or git@github.com:org/rules.git//aws/s3?ref=3fa1b2c
+type GitModuleSource struct {
+
+	// The repository to clone, a url or a scp-like address such as git@github.com:org/rules.git
+	Repository string `json:"repository" yaml:"repository"`
+
+	// The branch, tag or commit to check out, the default branch of the repository if empty
+	Ref string `json:"ref" yaml:"ref"`
+
+	// The directory of the module in the repository, the root directory of the repository if empty
+	Subdirectory string `json:"subdirectory" yaml:"subdirectory"`
+}
+
+// ParseGitModuleSource Parse the source of a git module
+func ParseGitModuleSource(source string) (*GitModuleSource, error) {
+	repository := source
+	if strings.HasPrefix(strings.ToLower(repository), GitModuleSourcePrefix) {
+		repository = repository[len(GitModuleSourcePrefix):]
+	}
+
+	repository, subdirectory := getter.SourceDirSubdir(repository)
+	if subdirectory != "" {
+		subdirectory = path.Clean(subdirectory)
+		if path.IsAbs(subdirectory) || subdirectory == ".." || strings.HasPrefix(subdirectory, "../") {
+			return nil, fmt.Errorf("git module source %s subdirectory %s must be in the repository", source, subdirectory)
+		}
+		if subdirectory == "." {
+			subdirectory = ""
+		}
+	}
+
+	ref := ""
+	if index := strings.Index(repository, "?"); index != -1 {
+		query, err := url.ParseQuery(repository[index+1:])
+		if err != nil {
+			return nil, fmt.Errorf("git module source %s query parse error: %s", source, err.Error())
+		}
+		ref = query.Get("ref")
+		query.Del("ref")
+		// The other parameters such as depth are passed to git as they are
+		repository = repository[:index]
+		if len(query) > 0 {
+			repository += "?" + query.Encode()
+		}
+	}
+	if repository == "" {
+		return nil, fmt.Errorf("git module source %s has no repository", source)
+	}
+
+	return &GitModuleSource{
+		Repository:   repository,
+		Ref:          ref,
+		Subdirectory: subdirectory,
+	}, nil
+}
+
+// BuildDownloadURL The url go-getter clones the repository at the ref from
+func (x *GitModuleSource) BuildDownloadURL(ref string) string {
+	downloadURL := GitModuleSourcePrefix + x.Repository
+	if ref == "" {
+		return downloadURL
+	}
+	if strings.Contains(x.Repository, "?") {
+		return downloadURL + "&ref=" + url.QueryEscape(ref)
+	}
+	return downloadURL + "?ref=" + url.QueryEscape(ref)
+}
+
+// BuildDownloadDirectoryName Every ref of the repository is cloned into its own directory, the subdirectories of the same
+// ref share the clone
+func (x *GitModuleSource) BuildDownloadDirectoryName() (string, error) {
+	return md5_util.Md5String(x.Repository + "?ref=" + x.Ref)
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// GitModuleLoaderOptions Parameter options when creating the git module loader
+type GitModuleLoaderOptions struct {
+	*ModuleLoaderOptions
+
+	// Where the module is in git
+	GitSource *GitModuleSource `json:"git-source" yaml:"git-source"`
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// GitModuleLoader Load the module from a git repository, the repository is cloned into the download directory or fetched
+// if it has been cloned before
+type GitModuleLoader struct {
+	options *GitModuleLoaderOptions
+
+	// Which path the repository is cloned to
+	repositoryDownloadDirectoryPath string
+}
+
+var _ ModuleLoader[*GitModuleLoaderOptions] = &GitModuleLoader{}
+
+func NewGitModuleLoader(options *GitModuleLoaderOptions) (*GitModuleLoader, error) {
+
+	if options.GitSource == nil {
+		gitSource, err := ParseGitModuleSource(options.Source)
+		if err != nil {
+			return nil, err
+		}
+		options.GitSource = gitSource
+	}
+
+	directoryName, err := options.GitSource.BuildDownloadDirectoryName()
+	if err != nil {
+		return nil, err
+	}
+	repositoryDownloadDirectoryPath := filepath.Join(options.DownloadDirectory, DownloadModulesDirectoryName, string(ModuleLoaderTypeGit), directoryName)
+
+	return &GitModuleLoader{
+		options:                         options,
+		repositoryDownloadDirectoryPath: repositoryDownloadDirectoryPath,
+	}, nil
+}
+
+func (x *GitModuleLoader) Name() ModuleLoaderType {
+	return ModuleLoaderTypeGit
+}
+
+func (x *GitModuleLoader) Load(ctx context.Context) (*module.Module, bool) {
+
+	defer func() {
+		x.options.MessageChannel.SenderWaitAndClose()
+	}()
+
+	// step 01. Clone or fetch the repository, the commit locked is checked out instead of the ref if any
+	ref := x.options.GitSource.Ref
+	if lockedCommit := x.options.lockedModuleVersion(); lockedCommit != "" {
+		ref = lockedCommit
+	}
+	err := http_client.DownloadToDirectory(ctx, x.repositoryDownloadDirectoryPath, x.options.GitSource.BuildDownloadURL(ref), x.options.ProgressTracker)
+	if err != nil {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("module load from git %s failed, error = %s", x.options.Source, err.Error()))
+		return nil, false
+	}
+	commit, err := x.headCommit(ctx)
+	if err != nil {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("module load from git %s failed, can not get the commit checked out: %s", x.options.Source, err.Error()))
+		return nil, false
+	}
+
+	// send tips
+	x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("clone git module %s at commit %s to local directory %s", x.options.Source, commit, x.repositoryDownloadDirectoryPath))
+
+	moduleDirectory := x.repositoryDownloadDirectoryPath
+	if x.options.GitSource.Subdirectory != "" {
+		moduleDirectory = filepath.Join(moduleDirectory, filepath.FromSlash(x.options.GitSource.Subdirectory))
+	}
+
+	// Make sure it is the module locked
+	d := x.options.lockModule(commit, x.options.GitSource.Repository, moduleDirectory)
+	x.options.MessageChannel.Send(d)
+	if utils.HasError(d) {
+		return nil, false
+	}
+
+	// step 02. The module in the repository is loaded from the local path
+	localDirectoryModuleLoaderOptions := &LocalDirectoryModuleLoaderOptions{
+		ModuleLoaderOptions: &ModuleLoaderOptions{
+			Source:            x.options.Source,
+			Version:           commit,
+			DownloadDirectory: x.options.DownloadDirectory,
+			ProgressTracker:   x.options.ProgressTracker,
+			MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
+			// The dependency level does not increase
+			DependenciesTree: x.options.DependenciesTree,
+			LockFile:         x.options.LockFile,
+			UpgradeLock:      x.options.UpgradeLock,
+		},
+		ModuleDirectory: moduleDirectory,
+	}
+	loader, err := NewLocalDirectoryModuleLoader(localDirectoryModuleLoaderOptions)
+	if err != nil {
+		localDirectoryModuleLoaderOptions.MessageChannel.SenderWaitAndClose()
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("create local directory %s module loader error: %s", moduleDirectory, err.Error()))
+		return nil, false
+	}
+
+	return loader.Load(ctx)
+}
+
+// The commit the repository is checked out at
+func (x *GitModuleLoader) headCommit(ctx context.Context) (string, error) {
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd.Dir = x.repositoryDownloadDirectoryPath
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %s", err.Error(), strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func (x *GitModuleLoader) Options() *GitModuleLoaderOptions {
+	return x.options
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,121 @@
+package module_loader
+
+import (
+	"context"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseGitModuleSource(t *testing.T) {
+	testCases := []struct {
+		source string
+		want   *GitModuleSource
+	}{
+		{"git::https://github.com/org/rules.git", &GitModuleSource{Repository: "https://github.com/org/rules.git"}},
+		{"git::https://github.com/org/rules.git//aws/s3?ref=v0.0.1", &GitModuleSource{Repository: "https://github.com/org/rules.git", Ref: "v0.0.1", Subdirectory: "aws/s3"}},
+		{"git::ssh://git@github.com/org/rules.git?ref=3fa1b2c&depth=1", &GitModuleSource{Repository: "ssh://git@github.com/org/rules.git?depth=1", Ref: "3fa1b2c"}},
+		{"git@github.com:org/rules.git//aws/?ref=main", &GitModuleSource{Repository: "git@github.com:org/rules.git", Ref: "main", Subdirectory: "aws"}},
+	}
+	for _, testCase := range testCases {
+		gitSource, err := ParseGitModuleSource(testCase.source)
+		assert.Nil(t, err)
+		assert.Equal(t, testCase.want, gitSource, testCase.source)
+		assert.Equal(t, ModuleLoaderTypeGit, NewModuleLoaderBySource(testCase.source))
+	}
+
+	_, err := ParseGitModuleSource("git::https://github.com/org/rules.git//../other")
+	assert.NotNil(t, err)
+
+	gitSource, err := ParseGitModuleSource("git::ssh://git@github.com/org/rules.git?depth=1")
+	assert.Nil(t, err)
+	assert.Equal(t, "git::ssh://git@github.com/org/rules.git?depth=1&ref=v0.0.2", gitSource.BuildDownloadURL("v0.0.2"))
+}
+
+func TestGitModuleLoader_Load(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// A repository with the rules of s3 at tag v1, then changed and made to use itself on main
+	workDirectory := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=selefra", "-c", "user.email=selefra@example.com", "-c", "init.defaultBranch=main"}, args...)...)
+		cmd.Dir = workDirectory
+		output, err := cmd.CombinedOutput()
+		assert.Nil(t, err, string(output))
+		return strings.TrimSpace(string(output))
+	}
+	writeFile := func(name, content string) {
+		filePath := filepath.Join(workDirectory, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(filePath), os.ModePerm))
+		assert.Nil(t, os.WriteFile(filePath, []byte(content), 0644))
+	}
+	bareRepository := filepath.Join(t.TempDir(), "rules.git")
+	repositoryURL := "file://" + filepath.ToSlash(bareRepository)
+
+	git("init")
+	writeFile("aws/s3/rules.yaml", "rules:\n  - name: s3_rule_v1\n    query: SELECT 1\n    output: \"v1\"\n")
+	git("add", "-A")
+	git("commit", "-m", "v1")
+	git("tag", "v1")
+	tagCommit := git("rev-parse", "HEAD")
+	writeFile("aws/s3/rules.yaml", "rules:\n  - name: s3_rule_v2\n    query: SELECT 1\n    output: \"v2\"\n")
+	writeFile("modules.yaml", "modules:\n  - name: self\n    uses: git::"+repositoryURL+"?ref=main\n")
+	git("add", "-A")
+	git("commit", "-m", "v2")
+	git("clone", "--bare", workDirectory, bareRepository)
+
+	downloadDirectory := t.TempDir()
+	load := func(uses string, lockFile *lock_file.LockFile) (*module.Module, bool, string) {
+		projectDirectory := t.TempDir()
+		assert.Nil(t, os.WriteFile(filepath.Join(projectDirectory, "modules.yaml"), []byte("modules:\n  - name: s3\n    uses: "+uses+"\n"), 0644))
+		messages := strings.Builder{}
+		messageChannel := message.NewChannel[*schema.Diagnostics](func(index int, d *schema.Diagnostics) {
+			if utils.IsNotEmpty(d) {
+				messages.WriteString(d.ToString())
+			}
+		})
+		loader, err := NewLocalDirectoryModuleLoader(&LocalDirectoryModuleLoaderOptions{
+			ModuleLoaderOptions: &ModuleLoaderOptions{
+				Source:            projectDirectory,
+				DownloadDirectory: downloadDirectory,
+				MessageChannel:    messageChannel,
+				DependenciesTree:  []string{projectDirectory},
+				LockFile:          lockFile,
+			},
+			ModuleDirectory: projectDirectory,
+		})
+		assert.Nil(t, err)
+		rootModule, ok := loader.Load(context.Background())
+		messageChannel.ReceiverWait()
+		return rootModule, ok, messages.String()
+	}
+
+	// The module in the subdirectory at the tag
+	uses := "git::" + repositoryURL + "//aws/s3?ref=v1"
+	lockFile := lock_file.NewLockFile()
+	rootModule, ok, messages := load(uses, lockFile)
+	assert.True(t, ok, messages)
+	assert.Len(t, rootModule.SubModules, 1)
+	assert.Equal(t, "s3_rule_v1", rootModule.SubModules[0].RulesBlock[0].Name)
+	assert.Equal(t, tagCommit, lockFile.GetModule(uses).Version)
+	assert.Equal(t, repositoryURL, lockFile.GetModule(uses).Source)
+
+	// Loaded again from the clone, the checksum is the same
+	_, ok, messages = load(uses, lockFile)
+	assert.True(t, ok, messages)
+
+	// A module that uses itself
+	_, ok, messages = load("git::"+repositoryURL+"?ref=main", nil)
+	assert.False(t, ok)
+	assert.Contains(t, messages, "circular dependency")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
 	"github.com/selefra/selefra-utils/pkg/pointer"
+	"github.com/selefra/selefra/pkg/cli_env"
 	"github.com/selefra/selefra/pkg/modules/module"
 	"github.com/selefra/selefra/pkg/registry"
 	"github.com/selefra/selefra/pkg/utils"
@@ -26,7 +27,7 @@ type GitHubRegistryModuleLoaderOptions struct {
 
 // GitHubRegistryModuleLoader Load the module from GitHub's Registry
 type GitHubRegistryModuleLoader struct {
-	githubRegistry *registry.ModuleGitHubRegistry
+	moduleRegistry registry.ModuleRegistry
 	options        *GitHubRegistryModuleLoaderOptions
 
 	downloadModule          *registry.Module
@@ -37,22 +38,24 @@ var _ ModuleLoader[*GitHubRegistryModuleLoaderOptions] = &GitHubRegistryModuleLo
 
 func NewGitHubRegistryModuleLoader(options *GitHubRegistryModuleLoaderOptions) (*GitHubRegistryModuleLoader, error) {
 
-	registryOptions := registry.NewModuleGithubRegistryOptions(options.DownloadDirectory, options.RegistryRepoFullName)
-	githubRegistry, err := registry.NewModuleGitHubRegistry(registryOptions)
+	moduleRegistry, err := newModuleRegistry(options)
 	if err != nil {
 		return nil, err
 	}
 
 	// check params
 	moduleNameAndVersion := version.ParseNameAndVersion(options.Source)
-	metadata, err := githubRegistry.GetMetadata(context.Background(), registry.NewModule(moduleNameAndVersion.Name, moduleNameAndVersion.Version))
+	metadata, err := moduleRegistry.GetMetadata(context.Background(), registry.NewModule(moduleNameAndVersion.Name, moduleNameAndVersion.Version))
 	if err != nil {
 		return nil, err
 	}
 	moduleVersion := moduleNameAndVersion.Version
-	// If it is the latest version, change it to the type it should be
+	// If it is the latest version, change it to the type it should be, unless a version is locked
 	if moduleNameAndVersion.IsLatestVersion() {
 		moduleVersion = metadata.LatestVersion
+		if lockedVersion := options.lockedModuleVersion(); lockedVersion != "" {
+			moduleVersion = lockedVersion
+		}
 	}
 
 	if !metadata.HasVersion(moduleVersion) {
@@ -64,13 +67,22 @@ func NewGitHubRegistryModuleLoader(options *GitHubRegistryModuleLoaderOptions) (
 
 	options.Version = moduleVersion
 	return &GitHubRegistryModuleLoader{
-		githubRegistry:          githubRegistry,
+		moduleRegistry:          moduleRegistry,
 		options:                 options,
 		downloadModule:          registry.NewModule(moduleNameAndVersion.Name, moduleVersion),
 		moduleDownloadDirectory: moduleDownloadDirectory,
 	}, nil
 }
 
+// The modules are loaded from the mirror SELEFRA_MIRROR points to if it is set, otherwise from the registry on GitHub
+func newModuleRegistry(options *GitHubRegistryModuleLoaderOptions) (registry.ModuleRegistry, error) {
+	if mirrorDirectory := cli_env.GetMirrorDirectory(); mirrorDirectory != "" {
+		return registry.NewModuleMirrorRegistry(mirrorDirectory)
+	}
+	registryOptions := registry.NewModuleGithubRegistryOptions(options.DownloadDirectory, options.RegistryRepoFullName)
+	return registry.NewModuleGitHubRegistry(registryOptions)
+}
+
 func (x *GitHubRegistryModuleLoader) Name() ModuleLoaderType {
 	return ModuleLoaderTypeGitHubRegistry
 }
@@ -87,7 +99,7 @@ func (x *GitHubRegistryModuleLoader) Load(ctx context.Context) (*module.Module,
 		SkipVerify:                  pointer.TruePointer(),
 		ProgressTracker:             x.options.ProgressTracker,
 	}
-	moduleDownloadDirectory, err := x.githubRegistry.Download(ctx, x.downloadModule, downloadOptions)
+	moduleDownloadDirectory, err := x.moduleRegistry.Download(ctx, x.downloadModule, downloadOptions)
 	if err != nil {
 		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("from github registry download module %s failed: %s", x.downloadModule.String(), err.Error()))
 		return nil, false
@@ -96,6 +108,20 @@ func (x *GitHubRegistryModuleLoader) Load(ctx context.Context) (*module.Module,
 	// send tips
 	x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("download github module %s to local directory %s", x.downloadModule.String(), moduleDownloadDirectory))
 
+	// Make sure it is the module locked
+	if x.options.LockFile != nil {
+		supplement, err := x.moduleRegistry.GetSupplement(ctx, x.downloadModule)
+		if err != nil {
+			x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("get github module %s supplement failed: %s", x.downloadModule.String(), err.Error()))
+			return nil, false
+		}
+		d := x.options.lockModule(x.downloadModule.Version, supplement.Source, moduleDownloadDirectory)
+		x.options.MessageChannel.Send(d)
+		if utils.HasError(d) {
+			return nil, false
+		}
+	}
+
 	// Continue to load submodules, if any
 	localDirectoryModuleLoaderOptions := &LocalDirectoryModuleLoaderOptions{
 		ModuleLoaderOptions: &ModuleLoaderOptions{
@@ -107,6 +133,8 @@ func (x *GitHubRegistryModuleLoader) Load(ctx context.Context) (*module.Module,
 			MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
 			// The dependency level does not increase
 			DependenciesTree: x.options.DependenciesTree,
+			LockFile:         x.options.LockFile,
+			UpgradeLock:      x.options.UpgradeLock,
 		},
 		ModuleDirectory: moduleDownloadDirectory,
 	}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
 	"github.com/selefra/selefra/pkg/modules/module"
 	"github.com/selefra/selefra/pkg/modules/parser"
 	"github.com/selefra/selefra/pkg/registry"
@@ -153,7 +154,18 @@ func (x *LocalDirectoryModuleLoader) loadSubModules(ctx context.Context, modules
 		useLocation := moduleBlock.GetNodeLocation(fmt.Sprintf("uses"))
 		//moduleDirectoryPath := filepath.Dir(useLocation.Path)
 
-		switch NewModuleLoaderBySource(moduleBlock.Uses) {
+		loaderType := NewModuleLoaderBySource(moduleBlock.Uses)
+
+		// A remote module that uses itself directly or indirectly would be loaded forever
+		if loaderType != ModuleLoaderTypeLocalDirectory && loaderType != ModuleLoaderTypeInvalid {
+			if dependenciesCycle := x.findDependenciesCycle(useModuleSource); len(dependenciesCycle) > 0 {
+				errorReport := module.RenderErrorTemplate(fmt.Sprintf("module uses source %s is a circular dependency: %s", useModuleSource, strings.Join(dependenciesCycle, " -> ")), useLocation)
+				x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg(errorReport))
+				return nil, false
+			}
+		}
+
+		switch loaderType {
 
 		// Unsupported loading mode
 		case ModuleLoaderTypeInvalid:
@@ -190,6 +202,20 @@ func (x *LocalDirectoryModuleLoader) loadSubModules(ctx context.Context, modules
 			}
 			subModuleSlice = append(subModuleSlice, subModule)
 
+		case ModuleLoaderTypeGit:
+			subModule, ok := x.loadGitModule(ctx, useLocation, useModuleSource)
+			if !ok {
+				return nil, false
+			}
+			subModuleSlice = append(subModuleSlice, subModule)
+
+		case ModuleLoaderTypeOCI:
+			subModule, ok := x.loadOCIModule(ctx, useLocation, useModuleSource)
+			if !ok {
+				return nil, false
+			}
+			subModuleSlice = append(subModuleSlice, subModule)
+
 		default:
 			errorReport := module.RenderErrorTemplate(fmt.Sprintf("module source %s can cannot be assign loader", useModuleSource), useLocation)
 			x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg(errorReport))
@@ -199,6 +225,67 @@ func (x *LocalDirectoryModuleLoader) loadSubModules(ctx context.Context, modules
 	return subModuleSlice, true
 }
 
+// If the source is already on the way from the root module to this module, return the way from where it is used first
+// to where it is used again, otherwise return nil
+func (x *LocalDirectoryModuleLoader) findDependenciesCycle(useModuleSource string) []string {
+	// The dependencies tree starts with the current module and ends with the root module
+	for index, source := range x.options.DependenciesTree {
+		if source != useModuleSource {
+			continue
+		}
+		dependenciesCycle := make([]string, 0, index+2)
+		for i := index; i >= 0; i-- {
+			dependenciesCycle = append(dependenciesCycle, x.options.DependenciesTree[i])
+		}
+		return append(dependenciesCycle, useModuleSource)
+	}
+	return nil
+}
+
+func (x *LocalDirectoryModuleLoader) loadGitModule(ctx context.Context, useLocation *module.NodeLocation, useModuleSource string) (*module.Module, bool) {
+	gitOptions := &GitModuleLoaderOptions{
+		ModuleLoaderOptions: &ModuleLoaderOptions{
+			Source:            useModuleSource,
+			DownloadDirectory: x.options.DownloadDirectory,
+			ProgressTracker:   x.options.ProgressTracker,
+			MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
+			DependenciesTree:  x.options.DeepDependenciesTree(useModuleSource),
+			LockFile:          x.options.LockFile,
+			UpgradeLock:       x.options.UpgradeLock,
+		},
+	}
+	loader, err := NewGitModuleLoader(gitOptions)
+	if err != nil {
+		gitOptions.MessageChannel.SenderWaitAndClose()
+		errorReport := module.RenderErrorTemplate(fmt.Sprintf("create git module loader %s error: %s", useModuleSource, err.Error()), useLocation)
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg(errorReport))
+		return nil, false
+	}
+	return loader.Load(ctx)
+}
+
+func (x *LocalDirectoryModuleLoader) loadOCIModule(ctx context.Context, useLocation *module.NodeLocation, useModuleSource string) (*module.Module, bool) {
+	ociOptions := &OCIModuleLoaderOptions{
+		ModuleLoaderOptions: &ModuleLoaderOptions{
+			Source:            useModuleSource,
+			DownloadDirectory: x.options.DownloadDirectory,
+			ProgressTracker:   x.options.ProgressTracker,
+			MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
+			DependenciesTree:  x.options.DeepDependenciesTree(useModuleSource),
+			LockFile:          x.options.LockFile,
+			UpgradeLock:       x.options.UpgradeLock,
+		},
+	}
+	loader, err := NewOCIModuleLoader(ociOptions)
+	if err != nil {
+		ociOptions.MessageChannel.SenderWaitAndClose()
+		errorReport := module.RenderErrorTemplate(fmt.Sprintf("create oci module loader %s error: %s", useModuleSource, err.Error()), useLocation)
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg(errorReport))
+		return nil, false
+	}
+	return loader.Load(ctx)
+}
+
 func (x *LocalDirectoryModuleLoader) loadURLModule(ctx context.Context, useLocation *module.NodeLocation, useModuleSource string) (*module.Module, bool) {
 	urlModuleLoaderOptions := &URLModuleLoaderOptions{
 		ModuleLoaderOptions: &ModuleLoaderOptions{
@@ -210,6 +297,8 @@ func (x *LocalDirectoryModuleLoader) loadURLModule(ctx context.Context, useLocat
 			ProgressTracker:  x.options.ProgressTracker,
 			MessageChannel:   x.options.MessageChannel.MakeChildChannel(),
 			DependenciesTree: x.options.DeepDependenciesTree(useModuleSource),
+			LockFile:         x.options.LockFile,
+			UpgradeLock:      x.options.UpgradeLock,
 		},
 		ModuleURL: useModuleSource,
 	}
@@ -239,6 +328,8 @@ func (x *LocalDirectoryModuleLoader) loadLocalDirectoryModule(ctx context.Contex
 			ProgressTracker:  x.options.ProgressTracker,
 			MessageChannel:   x.options.MessageChannel.MakeChildChannel(),
 			DependenciesTree: x.options.DeepDependenciesTree(useModuleSource),
+			LockFile:         x.options.LockFile,
+			UpgradeLock:      x.options.UpgradeLock,
 		},
 		ModuleDirectory: subModuleDirectory,
 	}
@@ -265,6 +356,8 @@ func (x *LocalDirectoryModuleLoader) loadGitHubRegistryModule(ctx context.Contex
 			DownloadDirectory: x.options.DownloadDirectory,
 			MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
 			DependenciesTree:  x.options.DeepDependenciesTree(useModuleSource),
+			LockFile:          x.options.LockFile,
+			UpgradeLock:       x.options.UpgradeLock,
 		},
 		RegistryRepoFullName: registry.ModuleGithubRegistryDefaultRepoFullName,
 	}
@@ -292,6 +385,8 @@ func (x *LocalDirectoryModuleLoader) loadS3BucketModule(ctx context.Context, use
 			DownloadDirectory: x.options.DownloadDirectory,
 			MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
 			DependenciesTree:  x.options.DeepDependenciesTree(useModuleSource),
+			LockFile:          x.options.LockFile,
+			UpgradeLock:       x.options.UpgradeLock,
 		},
 		S3BucketURL: useModuleSource,
 	}
@@ -349,8 +444,9 @@ func (x *LocalDirectoryModuleLoader) listModuleDirectoryYamlFilePath() ([]string
 	return yamlFileSlice, nil
 }
 
+// IsYamlFile Whether the file is a yaml file of the module, the lock file of the project is not
 func IsYamlFile(entry os.DirEntry) bool {
-	if entry.IsDir() {
+	if entry.IsDir() || entry.Name() == lock_file.FileName {
 		return false
 	}
 	ext := strings.ToLower(path.Ext(entry.Name()))
@@ -5,6 +5,7 @@ import (
 	"github.com/hashicorp/go-getter"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
 	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
 	"github.com/selefra/selefra/pkg/modules/module"
 )
 
@@ -36,6 +37,13 @@ type ModuleLoaderOptions struct {
 
 	// How do I go from the root module to the current module
 	DependenciesTree []string `json:"dependencies-tree" yaml:"dependencies-tree"`
+
+	// If not nil, the remote modules are loaded at the locked versions and checked against the locked checksums,
+	// and the modules loaded are locked into it
+	LockFile *lock_file.LockFile `json:"-" yaml:"-"`
+
+	// Ignore what is locked in LockFile and lock the modules loaded again
+	UpgradeLock bool `json:"upgrade-lock" yaml:"upgrade-lock"`
 }
 
 // DeepDependenciesTree The dependence goes deeper
@@ -48,6 +56,41 @@ func (x *ModuleLoaderOptions) DeepDependenciesTree(source string) []string {
 	return dependenciesTree
 }
 
+// The version the module uses is locked to, empty if it is not locked or the lock is being upgraded
+func (x *ModuleLoaderOptions) lockedModuleVersion() string {
+	if x.LockFile == nil || x.UpgradeLock {
+		return ""
+	}
+	if lockedModule := x.LockFile.GetModule(x.Source); lockedModule != nil {
+		return lockedModule.Version
+	}
+	return ""
+}
+
+// Check the downloaded module against the lock file and lock it
+func (x *ModuleLoaderOptions) lockModule(moduleVersion, moduleSource, moduleDirectory string) *schema.Diagnostics {
+	diagnostics := schema.NewDiagnostics()
+	if x.LockFile == nil {
+		return diagnostics
+	}
+	checksum, err := lock_file.DirectoryChecksum(moduleDirectory)
+	if err != nil {
+		return diagnostics.AddErrorMsg("compute checksum of module %s in directory %s failed: %s", x.Source, moduleDirectory, err.Error())
+	}
+	if !x.UpgradeLock {
+		if err := x.LockFile.VerifyModule(x.Source, checksum); err != nil {
+			return diagnostics.AddErrorMsg("%s, run selefra lock --upgrade if the module is changed on purpose", err.Error())
+		}
+	}
+	x.LockFile.LockModule(&lock_file.LockedModule{
+		Uses:     x.Source,
+		Version:  moduleVersion,
+		Source:   moduleSource,
+		Checksum: checksum,
+	})
+	return diagnostics
+}
+
 //func (x *ModuleLoaderOptions) Copy() *ModuleLoaderOptions {
 //	return &ModuleLoaderOptions{
 //		Source:  x.Source,
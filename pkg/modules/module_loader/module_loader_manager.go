@@ -1,6 +1,7 @@
 package module_loader
 
 import (
+	"github.com/selefra/selefra/pkg/oci"
 	"regexp"
 	"strings"
 )
@@ -24,6 +25,12 @@ const (
 	ModuleLoaderTypeLocalDirectory ModuleLoaderType = "local-directory-module-loader"
 
 	ModuleLoaderTypeURL ModuleLoaderType = "url-module-loader"
+
+	// ModuleLoaderTypeGit Load the module from a git repository
+	ModuleLoaderTypeGit ModuleLoaderType = "git-module-loader"
+
+	// ModuleLoaderTypeOCI Load the module from an artifact in an OCI registry
+	ModuleLoaderTypeOCI ModuleLoaderType = "oci-module-loader"
 )
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -34,6 +41,10 @@ var Pattern = regexp.MustCompile("^[A-Za-z_-]?[\\w\\-_@.]+$")
 func NewModuleLoaderBySource(source string) ModuleLoaderType {
 	formatSource := strings.ToLower(source)
 	switch {
+	case strings.HasPrefix(formatSource, GitModuleSourcePrefix) || strings.HasPrefix(formatSource, "git@"):
+		return ModuleLoaderTypeGit
+	case oci.IsArtifactSource(formatSource):
+		return ModuleLoaderTypeOCI
 	case strings.HasPrefix(formatSource, "s3://"):
 		return ModuleLoaderTypeS3Bucket
 	case strings.HasPrefix(formatSource, "http://") || strings.HasPrefix(formatSource, "https://"):
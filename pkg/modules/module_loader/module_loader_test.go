@@ -0,0 +1,35 @@
+package module_loader
+
+import (
+	"github.com/selefra/selefra/pkg/modules/lock_file"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModuleLoaderOptions_lockModule(t *testing.T) {
+	moduleDirectory := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(moduleDirectory, "rules.yaml"), []byte("rules: []\n"), 0644))
+
+	lockFile := lock_file.NewLockFile()
+	options := &ModuleLoaderOptions{Source: "rules-aws-misconfigure-s3", LockFile: lockFile}
+	assert.Equal(t, "", options.lockedModuleVersion())
+	assert.False(t, utils.HasError(options.lockModule("v0.0.1", "https://github.com/selefra/rules-aws-misconfigure-s3", moduleDirectory)))
+	assert.Equal(t, "v0.0.1", options.lockedModuleVersion())
+	// The same module is locked again
+	assert.False(t, utils.HasError(options.lockModule("v0.0.1", "https://github.com/selefra/rules-aws-misconfigure-s3", moduleDirectory)))
+
+	// The module is changed after it is locked
+	assert.Nil(t, os.WriteFile(filepath.Join(moduleDirectory, "rules.yaml"), []byte("rules:\n  - name: changed\n"), 0644))
+	d := options.lockModule("v0.0.1", "https://github.com/selefra/rules-aws-misconfigure-s3", moduleDirectory)
+	assert.True(t, utils.HasError(d))
+	assert.Contains(t, d.ToString(), lock_file.ErrChecksumMismatch.Error())
+
+	// Unless the lock is upgraded
+	options.UpgradeLock = true
+	assert.Equal(t, "", options.lockedModuleVersion())
+	assert.False(t, utils.HasError(options.lockModule("v0.0.2", "https://github.com/selefra/rules-aws-misconfigure-s3", moduleDirectory)))
+	assert.Equal(t, "v0.0.2", lockFile.GetModule("rules-aws-misconfigure-s3").Version)
+}
@@ -0,0 +1,128 @@
+package module_loader
+
+import (
+	"context"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra-utils/pkg/md5_util"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/oci"
+	"github.com/selefra/selefra/pkg/utils"
+	"oras.land/oras-go/pkg/registry"
+	"path/filepath"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// OCIModuleLoaderOptions Parameter options when creating the OCI module loader
+type OCIModuleLoaderOptions struct {
+	*ModuleLoaderOptions
+
+	// Where the module artifact is, the store is the remote registry configured by the SELEFRA_OCI_* environment variables if not set
+	ArtifactStore oci.ArtifactStore `json:"-" yaml:"-"`
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// OCIModuleLoader Load the module from an artifact in an OCI registry, such as oci://registry.example.com/selefra/rules-aws:v0.0.1
+type OCIModuleLoader struct {
+	options *OCIModuleLoaderOptions
+
+	// The artifact of the module
+	reference registry.Reference
+
+	// Which path the artifact is pulled to
+	moduleDownloadDirectoryPath string
+}
+
+var _ ModuleLoader[*OCIModuleLoaderOptions] = &OCIModuleLoader{}
+
+func NewOCIModuleLoader(options *OCIModuleLoaderOptions) (*OCIModuleLoader, error) {
+
+	reference, err := oci.ParseArtifactReference(options.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.ArtifactStore == nil {
+		store, err := oci.NewRemoteArtifactStore(oci.NewRemoteArtifactStoreOptionsFromEnv())
+		if err != nil {
+			return nil, err
+		}
+		options.ArtifactStore = store
+	}
+
+	directoryName, err := md5_util.Md5String(reference.String())
+	if err != nil {
+		return nil, err
+	}
+	moduleDownloadDirectoryPath := filepath.Join(options.DownloadDirectory, DownloadModulesDirectoryName, string(ModuleLoaderTypeOCI), directoryName)
+
+	return &OCIModuleLoader{
+		options:                     options,
+		reference:                   reference,
+		moduleDownloadDirectoryPath: moduleDownloadDirectoryPath,
+	}, nil
+}
+
+func (x *OCIModuleLoader) Name() ModuleLoaderType {
+	return ModuleLoaderTypeOCI
+}
+
+func (x *OCIModuleLoader) Load(ctx context.Context) (*module.Module, bool) {
+
+	defer func() {
+		x.options.MessageChannel.SenderWaitAndClose()
+	}()
+
+	// step 01. Pull the artifact, the digest locked is pulled instead of the tag if any
+	pullReference := x.reference.String()
+	if lockedDigest := x.options.lockedModuleVersion(); lockedDigest != "" {
+		pullReference = x.reference.Registry + "/" + x.reference.Repository + "@" + lockedDigest
+	}
+	manifestDigest, err := oci.PullModule(ctx, x.options.ArtifactStore, pullReference, x.moduleDownloadDirectoryPath)
+	if err != nil {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("module load from oci %s failed, error = %s", x.options.Source, err.Error()))
+		return nil, false
+	}
+	moduleDirectory := filepath.Join(x.moduleDownloadDirectoryPath, oci.ModuleArtifactDirectoryName)
+
+	// send tips
+	x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("pull oci module %s at digest %s to local directory %s", x.options.Source, manifestDigest, moduleDirectory))
+
+	// Make sure it is the module locked
+	d := x.options.lockModule(manifestDigest, x.reference.String(), moduleDirectory)
+	x.options.MessageChannel.Send(d)
+	if utils.HasError(d) {
+		return nil, false
+	}
+
+	// step 02. The module pulled is loaded from the local path
+	localDirectoryModuleLoaderOptions := &LocalDirectoryModuleLoaderOptions{
+		ModuleLoaderOptions: &ModuleLoaderOptions{
+			Source:            x.options.Source,
+			Version:           x.reference.Reference,
+			DownloadDirectory: x.options.DownloadDirectory,
+			ProgressTracker:   x.options.ProgressTracker,
+			MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
+			// The dependency level does not increase
+			DependenciesTree: x.options.DependenciesTree,
+			LockFile:         x.options.LockFile,
+			UpgradeLock:      x.options.UpgradeLock,
+		},
+		ModuleDirectory: moduleDirectory,
+	}
+	loader, err := NewLocalDirectoryModuleLoader(localDirectoryModuleLoaderOptions)
+	if err != nil {
+		localDirectoryModuleLoaderOptions.MessageChannel.SenderWaitAndClose()
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("create local directory %s module loader error: %s", moduleDirectory, err.Error()))
+		return nil, false
+	}
+
+	return loader.Load(ctx)
+}
+
+func (x *OCIModuleLoader) Options() *OCIModuleLoaderOptions {
+	return x.options
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,74 @@
+package module_loader
+
+import (
+	"context"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/oci"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOCIModuleLoader_Load(t *testing.T) {
+	assert.Equal(t, ModuleLoaderTypeOCI, NewModuleLoaderBySource("oci://localhost:5000/selefra/rules-aws:v1"))
+
+	store, err := oci.NewLayoutArtifactStore(t.TempDir())
+	assert.Nil(t, err)
+	push := func(ruleName string) string {
+		moduleDirectory := t.TempDir()
+		assert.Nil(t, os.WriteFile(filepath.Join(moduleDirectory, "rules.yaml"), []byte("rules:\n  - name: "+ruleName+"\n    query: SELECT 1\n    output: \"ok\"\n"), 0644))
+		desc, err := oci.PushModule(context.Background(), store, "localhost:5000/selefra/rules-aws:v1", moduleDirectory)
+		assert.Nil(t, err)
+		return desc.Digest.String()
+	}
+
+	downloadDirectory := t.TempDir()
+	source := "oci://localhost:5000/selefra/rules-aws:v1"
+	load := func(lockFile *lock_file.LockFile) (*module.Module, bool, string) {
+		messages := strings.Builder{}
+		messageChannel := message.NewChannel[*schema.Diagnostics](func(index int, d *schema.Diagnostics) {
+			if utils.IsNotEmpty(d) {
+				messages.WriteString(d.ToString())
+			}
+		})
+		loader, err := NewOCIModuleLoader(&OCIModuleLoaderOptions{
+			ModuleLoaderOptions: &ModuleLoaderOptions{
+				Source:            source,
+				DownloadDirectory: downloadDirectory,
+				MessageChannel:    messageChannel,
+				DependenciesTree:  []string{source},
+				LockFile:          lockFile,
+			},
+			ArtifactStore: store,
+		})
+		assert.Nil(t, err)
+		loadModule, ok := loader.Load(context.Background())
+		messageChannel.ReceiverWait()
+		return loadModule, ok, messages.String()
+	}
+
+	v1Digest := push("s3_rule_v1")
+	lockFile := lock_file.NewLockFile()
+	loadModule, ok, messages := load(lockFile)
+	assert.True(t, ok, messages)
+	assert.Equal(t, "s3_rule_v1", loadModule.RulesBlock[0].Name)
+	assert.Equal(t, v1Digest, lockFile.GetModule(source).Version)
+	assert.Equal(t, "localhost:5000/selefra/rules-aws:v1", lockFile.GetModule(source).Source)
+
+	// The tag is pushed again, the digest locked is still loaded
+	assert.NotEqual(t, v1Digest, push("s3_rule_v2"))
+	loadModule, ok, messages = load(lockFile)
+	assert.True(t, ok, messages)
+	assert.Equal(t, "s3_rule_v1", loadModule.RulesBlock[0].Name)
+
+	// Without the lock the tag is loaded
+	loadModule, ok, messages = load(nil)
+	assert.True(t, ok, messages)
+	assert.Equal(t, "s3_rule_v2", loadModule.RulesBlock[0].Name)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/selefra/selefra-utils/pkg/md5_util"
 	"github.com/selefra/selefra/pkg/http_client"
 	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/utils"
 	"path/filepath"
 )
 
@@ -58,24 +59,37 @@ func (x *S3BucketModuleLoader) Load(ctx context.Context) (*module.Module, bool)
 	}()
 
 	// step 01. Download and decompress
-	err := http_client.DownloadToDirectory(ctx, x.options.S3BucketURL, x.moduleDownloadDirectoryPath, x.options.ProgressTracker)
+	err := http_client.DownloadToDirectory(ctx, x.moduleDownloadDirectoryPath, x.options.S3BucketURL, x.options.ProgressTracker)
 	if err != nil {
-		// TODO
-		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg(""))
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("module load from %s failed, error = %s", x.options.S3BucketURL, err.Error()))
+		return nil, false
+	}
+
+	// Make sure it is the module locked
+	d := x.options.lockModule("", x.options.S3BucketURL, x.moduleDownloadDirectoryPath)
+	x.options.MessageChannel.Send(d)
+	if utils.HasError(d) {
 		return nil, false
 	}
 
 	// step 02. The download is decompressed and converted to loading from the local path
 	localDirectoryModuleLoaderOptions := &LocalDirectoryModuleLoaderOptions{
-		// TODO
-		//ModuleLoaderOptions: x.options.ModuleLoaderOptions.Copy(),
+		ModuleLoaderOptions: &ModuleLoaderOptions{
+			Source:            x.options.Source,
+			Version:           x.options.Version,
+			DownloadDirectory: x.options.DownloadDirectory,
+			MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
+			// The dependency level does not increase
+			DependenciesTree: x.options.DependenciesTree,
+			LockFile:         x.options.LockFile,
+			UpgradeLock:      x.options.UpgradeLock,
+		},
 		ModuleDirectory: x.moduleDownloadDirectoryPath,
 	}
 	loader, err := NewLocalDirectoryModuleLoader(localDirectoryModuleLoaderOptions)
 	if err != nil {
-		// TODO
 		localDirectoryModuleLoaderOptions.MessageChannel.SenderWaitAndClose()
-		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg(""))
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("create local directory %s module loader error: %s", x.moduleDownloadDirectoryPath, err.Error()))
 		return nil, false
 	}
 
@@ -6,6 +6,7 @@ import (
 	"github.com/selefra/selefra-utils/pkg/md5_util"
 	"github.com/selefra/selefra/pkg/http_client"
 	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/utils"
 	"path/filepath"
 )
 
@@ -79,6 +80,13 @@ func (x *URLModuleLoader) Load(ctx context.Context) (*module.Module, bool) {
 	// send tips
 	x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("download url module %s to local directory %s", x.options.ModuleURL, x.moduleDownloadDirectoryPath))
 
+	// Make sure it is the module locked
+	d := x.options.lockModule("", x.options.ModuleURL, x.moduleDownloadDirectoryPath)
+	x.options.MessageChannel.Send(d)
+	if utils.HasError(d) {
+		return nil, false
+	}
+
 	// step 02. The download is decompressed and converted to loading from the local path
 	localDirectoryModuleLoaderOptions := &LocalDirectoryModuleLoaderOptions{
 		ModuleLoaderOptions: &ModuleLoaderOptions{
@@ -90,6 +98,8 @@ func (x *URLModuleLoader) Load(ctx context.Context) (*module.Module, bool) {
 			MessageChannel:    x.options.MessageChannel.MakeChildChannel(),
 			// The dependency level does not increase
 			DependenciesTree: x.options.DependenciesTree,
+			LockFile:         x.options.LockFile,
+			UpgradeLock:      x.options.UpgradeLock,
 		},
 		ModuleDirectory: x.moduleDownloadDirectoryPath,
 	}
@@ -39,10 +39,11 @@ func (x *YamlFileToModuleParser) parseModulesBlock(moduleBlockKeyNode, moduleBlo
 // ------------------------------------------------ ---------------------------------------------------------------------
 
 const (
-	ModuleBlockNameFieldName   = "name"
-	ModuleBlockUsesFieldName   = "uses"
-	ModuleBlockFilterFieldName = "filter"
-	ModuleBlockInputFieldName  = "input"
+	ModuleBlockNameFieldName        = "name"
+	ModuleBlockUsesFieldName        = "uses"
+	ModuleBlockFilterFieldName      = "filter"
+	ModuleBlockInputFieldName       = "input"
+	ModuleBlockRuleTimeoutFieldName = "rule_timeout"
 )
 
 // Parse module block
@@ -68,6 +69,9 @@ func (x *YamlFileToModuleParser) parseModuleBlock(moduleIndex int, moduleBlockNo
 		case ModuleBlockFilterFieldName:
 			moduleBlock.Filter = x.parseFilterValueWithDiagnosticsAndSetLocation(moduleBlock, ModuleBlockFilterFieldName, entry, blockPath, diagnostics)
 
+		case ModuleBlockRuleTimeoutFieldName:
+			moduleBlock.RuleTimeout = x.parseStringValueWithDiagnosticsAndSetLocation(moduleBlock, ModuleBlockRuleTimeoutFieldName, entry, blockPath, diagnostics)
+
 		case ModuleBlockInputFieldName:
 			inputMap := x.parseModuleInputBlock(moduleBlock, moduleIndex, entry.key, entry.value, diagnostics)
 			if len(inputMap) != 0 {
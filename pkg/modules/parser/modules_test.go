@@ -34,7 +34,4 @@ func TestYamlFileToModuleParser_parseModulesBlock(t *testing.T) {
 	assert.NotEmpty(t, moduleBlock.GetNodeLocation("uses._value").ReadSourceString())
 	assert.NotEmpty(t, moduleBlock.GetNodeLocation("uses").ReadSourceString())
 
-	assert.NotEmpty(t, moduleBlock.GetNodeLocation("uses[0]").ReadSourceString())
-	assert.NotEmpty(t, moduleBlock.GetNodeLocation("uses[0]._value").ReadSourceString())
-
 }
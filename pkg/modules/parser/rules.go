@@ -45,6 +45,7 @@ const (
 	RuleBlockMetadataFieldName  = "metadata"
 	RuleBlockMainTableFieldName = "main_table"
 	RuleBlockOutputFieldName    = "output"
+	RuleBlockTimeoutFieldName   = "timeout"
 )
 
 func (x *YamlFileToModuleParser) parseRuleBlock(index int, ruleBlockNode *yaml.Node, diagnostics *schema.Diagnostics) *module.RuleBlock {
@@ -79,6 +80,9 @@ func (x *YamlFileToModuleParser) parseRuleBlock(index int, ruleBlockNode *yaml.N
 		case RuleBlockOutputFieldName:
 			ruleBlock.Output = x.parseStringValueWithDiagnosticsAndSetLocation(ruleBlock, RuleBlockOutputFieldName, entry, blockPath, diagnostics)
 
+		case RuleBlockTimeoutFieldName:
+			ruleBlock.Timeout = x.parseStringValueWithDiagnosticsAndSetLocation(ruleBlock, RuleBlockTimeoutFieldName, entry, blockPath, diagnostics)
+
 		default:
 			diagnostics.AddDiagnostics(x.buildNodeErrorMsgForUnSupport(entry.key, entry.value, fmt.Sprintf("%s.%s", blockPath, key)))
 		}
@@ -18,6 +18,8 @@ const (
 	SelefraBlockNameFieldName         = "name"
 	SelefraBlockCLIVersionFieldName   = "cli_version"
 	SelefraBlockLogLevelFieldName     = "log_level"
+	SelefraBlockRuleTimeoutFieldName  = "rule_timeout"
+	SelefraBlockRegistryFieldName     = "registry"
 	SelefraRequiredProvidersBlockName = "providers"
 	SelefraConnectionsBlockName       = "connection"
 	SelefraCloudBlockName             = "cloud"
@@ -61,6 +63,12 @@ func (x *YamlFileToModuleParser) parseSelefraBlock(selefraBlockKeyNode, selefraB
 		case SelefraBlockLogLevelFieldName:
 			selefraBlock.LogLevel = x.parseStringValueWithDiagnosticsAndSetLocation(selefraBlock, SelefraBlockLogLevelFieldName, entry, blockPath, diagnostics)
 
+		case SelefraBlockRuleTimeoutFieldName:
+			selefraBlock.RuleTimeout = x.parseStringValueWithDiagnosticsAndSetLocation(selefraBlock, SelefraBlockRuleTimeoutFieldName, entry, blockPath, diagnostics)
+
+		case SelefraBlockRegistryFieldName:
+			selefraBlock.Registry = x.parseStringValueWithDiagnosticsAndSetLocation(selefraBlock, SelefraBlockRegistryFieldName, entry, blockPath, diagnostics)
+
 		case SelefraCloudBlockName:
 			selefraBlock.CloudBlock = x.parseCloudBlock(entry.key, entry.value, diagnostics)
 
@@ -135,10 +143,11 @@ func (x *YamlFileToModuleParser) parseCloudBlock(cloudBlockKeyNode, cloudBlockVa
 // ------------------------------------------------- --------------------------------------------------------------------
 
 const (
-	RequiredProviderBlockNameFieldName    = "name"
-	RequiredProviderBlockSourceFieldName  = "source"
-	RequiredProviderBlockVersionFieldName = "version"
-	RequiredProviderBlockPathFieldName    = "path"
+	RequiredProviderBlockNameFieldName     = "name"
+	RequiredProviderBlockSourceFieldName   = "source"
+	RequiredProviderBlockVersionFieldName  = "version"
+	RequiredProviderBlockPathFieldName     = "path"
+	RequiredProviderBlockRegistryFieldName = "registry"
 )
 
 func (x *YamlFileToModuleParser) parseRequiredProvidersBlock(requiredProviderBlockKeyNode, requiredProviderBlockValueNode *yaml.Node, diagnostics *schema.Diagnostics) module.RequireProvidersBlock {
@@ -190,6 +199,9 @@ func (x *YamlFileToModuleParser) parseRequiredProviderBlock(index int, node *yam
 		case RequiredProviderBlockPathFieldName:
 			requiredProviderBlock.Path = x.parseStringValueWithDiagnosticsAndSetLocation(requiredProviderBlock, RequiredProviderBlockPathFieldName, entry, blockPath, diagnostics)
 
+		case RequiredProviderBlockRegistryFieldName:
+			requiredProviderBlock.Registry = x.parseStringValueWithDiagnosticsAndSetLocation(requiredProviderBlock, RequiredProviderBlockRegistryFieldName, entry, blockPath, diagnostics)
+
 		default:
 			diagnostics.AddDiagnostics(x.buildNodeErrorMsgForUnSupport(entry.key, entry.value, fmt.Sprintf("%s.%s", blockPath, key)))
 
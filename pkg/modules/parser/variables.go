@@ -36,10 +36,15 @@ func (x *YamlFileToModuleParser) parseVariablesBlock(variablesBlockKeyNode, vari
 }
 
 const (
-	VariableBlockKeyFieldName         = "key"
-	VariableBlockDefaultFieldName     = "default"
-	VariableBlockDescriptionFieldName = "description"
-	VariableBlockAuthorFieldName      = "author"
+	VariableBlockKeyFieldName           = "key"
+	VariableBlockDefaultFieldName       = "default"
+	VariableBlockDescriptionFieldName   = "description"
+	VariableBlockAuthorFieldName        = "author"
+	VariableBlockTypeFieldName          = "type"
+	VariableBlockRequiredFieldName      = "required"
+	VariableBlockAllowedValuesFieldName = "allowed_values"
+	VariableBlockPatternFieldName       = "pattern"
+	VariableBlockSensitiveFieldName     = "sensitive"
 )
 
 func (x *YamlFileToModuleParser) parseVariableBlock(index int, node *yaml.Node, diagnostics *schema.Diagnostics) *module.VariableBlock {
@@ -75,6 +80,30 @@ func (x *YamlFileToModuleParser) parseVariableBlock(index int, node *yaml.Node,
 		case VariableBlockAuthorFieldName:
 			variableBlock.Author = x.parseStringValueWithDiagnosticsAndSetLocation(variableBlock, VariableBlockAuthorFieldName, entry, blockPath, diagnostics)
 
+		case VariableBlockTypeFieldName:
+			variableBlock.Type = x.parseStringValueWithDiagnosticsAndSetLocation(variableBlock, VariableBlockTypeFieldName, entry, blockPath, diagnostics)
+
+		case VariableBlockRequiredFieldName:
+			variableBlock.Required = x.parseBoolValueWithDiagnosticsAndSetLocation(variableBlock, VariableBlockRequiredFieldName, entry, blockPath, diagnostics)
+
+		case VariableBlockAllowedValuesFieldName:
+			fieldSelector := fmt.Sprintf("%s.%s", blockPath, VariableBlockAllowedValuesFieldName)
+			if entry.value.Kind != yaml.SequenceNode {
+				diagnostics.AddDiagnostics(x.buildNodeErrorMsgForArrayType(entry.value, fieldSelector))
+				continue
+			}
+			if allowedValues, ok := x.parseInterfaceWithDiagnostics(entry.value, fieldSelector, diagnostics).([]any); ok {
+				variableBlock.AllowedValues = allowedValues
+			}
+			// set location
+			x.setLocationKVWithDiagnostics(variableBlock, VariableBlockAllowedValuesFieldName, fieldSelector, entry, diagnostics)
+
+		case VariableBlockPatternFieldName:
+			variableBlock.Pattern = x.parseStringValueWithDiagnosticsAndSetLocation(variableBlock, VariableBlockPatternFieldName, entry, blockPath, diagnostics)
+
+		case VariableBlockSensitiveFieldName:
+			variableBlock.Sensitive = x.parseBoolValueWithDiagnosticsAndSetLocation(variableBlock, VariableBlockSensitiveFieldName, entry, blockPath, diagnostics)
+
 		default:
 			diagnostics.AddDiagnostics(x.buildNodeErrorMsgForUnSupport(entry.key, entry.value, fmt.Sprintf("%s.%s", blockPath, key)))
 		}
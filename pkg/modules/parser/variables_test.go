@@ -7,7 +7,7 @@ import (
 )
 
 func TestYamlFileToModuleParser_parseVariablesBlock(t *testing.T) {
-	module, diagnostics := NewYamlFileToModuleParser("./test_data/test_parse_variables/modules.yaml").Parse()
+	module, diagnostics := NewYamlFileToModuleParser("./test_data/test_parse_variables/modules.yaml", make(map[string]interface{})).Parse()
 	if utils.IsNotEmpty(diagnostics) {
 		t.Log(diagnostics.ToString())
 	}
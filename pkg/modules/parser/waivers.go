@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------------------------------ ---------------------------------------------------------------------
+
+const (
+	WaiversBlockName = "waivers"
+)
+
+// Parse waivers block
+func (x *YamlFileToModuleParser) parseWaiversBlock(waiversBlockKeyNode, waiversBlockValueNode *yaml.Node, diagnostics *schema.Diagnostics) module.WaiversBlock {
+
+	// waivers must be an array element
+	if waiversBlockValueNode.Kind != yaml.SequenceNode {
+		diagnostics.AddDiagnostics(x.buildNodeErrorMsgForArrayType(waiversBlockValueNode, WaiversBlockName))
+		return nil
+	}
+
+	// Parse each child element
+	waiversBlock := make(module.WaiversBlock, 0)
+	for index, waiverNode := range waiversBlockValueNode.Content {
+		block := x.parseWaiverBlock(index, waiverNode, diagnostics)
+		if block != nil {
+			waiversBlock = append(waiversBlock, block)
+		}
+	}
+
+	if len(waiversBlock) == 0 {
+		return nil
+	}
+	return waiversBlock
+}
+
+// ------------------------------------------------ ---------------------------------------------------------------------
+
+const (
+	WaiverBlockRuleIdFieldName  = "rule_id"
+	WaiverBlockLabelsFieldName  = "labels"
+	WaiverBlockOutputFieldName  = "output"
+	WaiverBlockReasonFieldName  = "reason"
+	WaiverBlockOwnerFieldName   = "owner"
+	WaiverBlockExpiresFieldName = "expires"
+)
+
+// Parse waiver block
+func (x *YamlFileToModuleParser) parseWaiverBlock(index int, waiverBlockNode *yaml.Node, diagnostics *schema.Diagnostics) *module.WaiverBlock {
+
+	blockPath := fmt.Sprintf("%s[%d]", WaiversBlockName, index)
+
+	toMap, d := x.toMap(waiverBlockNode, blockPath)
+	diagnostics.AddDiagnostics(d)
+	if utils.HasError(d) {
+		return nil
+	}
+
+	waiverBlock := module.NewWaiverBlock()
+	for key, entry := range toMap {
+		switch key {
+		case WaiverBlockRuleIdFieldName:
+			waiverBlock.RuleId = x.parseStringValueWithDiagnosticsAndSetLocation(waiverBlock, WaiverBlockRuleIdFieldName, entry, blockPath, diagnostics)
+
+		case WaiverBlockLabelsFieldName:
+			waiverBlock.Labels = x.parseStringMapAndSetLocation(waiverBlock, WaiverBlockLabelsFieldName, entry, blockPath, diagnostics)
+
+		case WaiverBlockOutputFieldName:
+			waiverBlock.Output = x.parseStringValueWithDiagnosticsAndSetLocation(waiverBlock, WaiverBlockOutputFieldName, entry, blockPath, diagnostics)
+
+		case WaiverBlockReasonFieldName:
+			waiverBlock.Reason = x.parseStringValueWithDiagnosticsAndSetLocation(waiverBlock, WaiverBlockReasonFieldName, entry, blockPath, diagnostics)
+
+		case WaiverBlockOwnerFieldName:
+			waiverBlock.Owner = x.parseStringValueWithDiagnosticsAndSetLocation(waiverBlock, WaiverBlockOwnerFieldName, entry, blockPath, diagnostics)
+
+		case WaiverBlockExpiresFieldName:
+			waiverBlock.Expires = x.parseStringValueWithDiagnosticsAndSetLocation(waiverBlock, WaiverBlockExpiresFieldName, entry, blockPath, diagnostics)
+
+		default:
+			diagnostics.AddDiagnostics(x.buildNodeErrorMsgForUnSupport(entry.key, entry.value, fmt.Sprintf("%s.%s", blockPath, key)))
+
+		}
+	}
+
+	if waiverBlock.IsEmpty() {
+		return nil
+	}
+
+	// set location
+	x.setLocationKVWithDiagnostics(waiverBlock, "", blockPath, newNodeEntry(nil, waiverBlockNode), diagnostics)
+
+	return waiverBlock
+}
+
+// ------------------------------------------------ ---------------------------------------------------------------------
@@ -0,0 +1,26 @@
+package parser
+
+import (
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestYamlFileToModuleParser_parseWaiversBlock(t *testing.T) {
+	module, diagnostics := NewYamlFileToModuleParser("./test_data/test_parse_waivers/modules.yaml", nil).Parse()
+	if utils.IsNotEmpty(diagnostics) {
+		t.Log(diagnostics.ToString())
+	}
+	assert.False(t, utils.HasError(diagnostics))
+	assert.Len(t, module.WaiversBlock, 2)
+
+	waiverBlock := module.WaiversBlock[0]
+	assert.Equal(t, "SF010302", waiverBlock.RuleId)
+	assert.Equal(t, "vol-0a1b2c3d", waiverBlock.Labels["resource_id"])
+	assert.Equal(t, "infra-team", waiverBlock.Owner)
+	assert.Equal(t, "2099-12-31", waiverBlock.Expires)
+	assert.NotEmpty(t, waiverBlock.GetNodeLocation("").ReadSourceString())
+	assert.NotEmpty(t, waiverBlock.GetNodeLocation("expires._value").ReadSourceString())
+
+	assert.Equal(t, "bucket static-.* is public", module.WaiversBlock[1].Output)
+}
@@ -70,6 +70,8 @@ func (x *YamlFileToModuleParser) Parse() (*module.Module, *schema.Diagnostics) {
 			yamlFileModule.ProvidersBlock = x.parseProvidersBlock(key, value, diagnostics)
 		case ModulesBlockName:
 			yamlFileModule.ModulesBlock = x.parseModulesBlock(key, value, diagnostics)
+		case WaiversBlockName:
+			yamlFileModule.WaiversBlock = x.parseWaiversBlock(key, value, diagnostics)
 		case RulesBlockName:
 			if x.instruction != nil {
 				if x.instruction["query"] != nil {
@@ -115,6 +117,12 @@ func (x *YamlFileToModuleParser) parseUintValueWithDiagnosticsAndSetLocation(blo
 func (x *YamlFileToModuleParser) parseFilterValueWithDiagnosticsAndSetLocation(block module.Block, fieldName string, entry *nodeEntry, blockBasePath string, diagnostics *schema.Diagnostics) []module.Filter {
 	filters := x.parseFilterWithDiagnostics(entry.value, blockBasePath+"."+fieldName, diagnostics)
 
+	if entry.key != nil {
+		x.setLocationWithDiagnostics(block, fieldName+module.NodeLocationSelfKey, blockBasePath, entry.key, diagnostics)
+	}
+
+	x.setLocationWithDiagnostics(block, fieldName+module.NodeLocationSelfValue, blockBasePath, entry.value, diagnostics)
+
 	return filters
 }
 
@@ -130,6 +138,18 @@ func (x *YamlFileToModuleParser) parseStringValueWithDiagnosticsAndSetLocation(b
 	return valueString
 }
 
+func (x *YamlFileToModuleParser) parseBoolValueWithDiagnosticsAndSetLocation(block module.Block, fieldName string, entry *nodeEntry, blockBasePath string, diagnostics *schema.Diagnostics) bool {
+	valueBool := x.parseBoolWithDiagnostics(entry.value, blockBasePath+"."+fieldName, diagnostics)
+
+	if entry.key != nil {
+		x.setLocationWithDiagnostics(block, fieldName+module.NodeLocationSelfKey, blockBasePath, entry.key, diagnostics)
+	}
+
+	x.setLocationWithDiagnostics(block, fieldName+module.NodeLocationSelfValue, blockBasePath, entry.value, diagnostics)
+
+	return valueBool
+}
+
 func (x *YamlFileToModuleParser) parseInterfaceValueWithDiagnosticsAndSetLocation(block module.Block, fieldName string, entry *nodeEntry, blockBasePath string, diagnostics *schema.Diagnostics) interface{} {
 	valueString := x.parseInterfaceWithDiagnostics(entry.value, blockBasePath+"."+fieldName, diagnostics)
 
@@ -283,6 +303,19 @@ func (x *YamlFileToModuleParser) parseUintWithDiagnostics(node *yaml.Node, block
 	return pointer.ToUint64Pointer(uint64(intValue))
 }
 
+func (x *YamlFileToModuleParser) parseBoolWithDiagnostics(node *yaml.Node, blockPath string, diagnostics *schema.Diagnostics) bool {
+	if node.Kind != yaml.ScalarNode {
+		diagnostics.AddDiagnostics(x.buildNodeErrorMsgForScalarType(node, blockPath, "bool"))
+		return false
+	}
+	boolValue, err := strconv.ParseBool(strings.TrimSpace(node.Value))
+	if err != nil {
+		diagnostics.AddDiagnostics(x.buildNodeErrorMsgForScalarType(node, blockPath, "bool"))
+		return false
+	}
+	return boolValue
+}
+
 func (x *YamlFileToModuleParser) parseStringWithDiagnostics(node *yaml.Node, blockPath string, diagnostics *schema.Diagnostics) string {
 	if node.Kind == yaml.ScalarNode {
 		return node.Value
@@ -333,6 +366,7 @@ func (x *YamlFileToModuleParser) parseFilterWithDiagnostics(node *yaml.Node, blo
 	}
 	err = yaml.Unmarshal(b, &filters)
 	if err != nil {
+		diagnostics.AddErrorMsg("file = %s, unmarshal filter %s error: %s", x.yamlFilePath, blockPath, err.Error())
 		return []module.Filter{}
 	}
 	return filters
@@ -8,7 +8,7 @@ import (
 )
 
 func TestYamlFileToModuleParser_Parse(t *testing.T) {
-	module, diagnostics := NewYamlFileToModuleParser("./test_data/test_modules.yaml").Parse()
+	module, diagnostics := NewYamlFileToModuleParser("./test_data/test_modules.yaml", make(map[string]interface{})).Parse()
 	if utils.IsNotEmpty(diagnostics) {
 		t.Log(diagnostics.ToString())
 	}
@@ -2,9 +2,11 @@ package planner
 
 import (
 	"context"
+	"fmt"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
 	"github.com/selefra/selefra/pkg/modules/module"
-	moduleBlock "github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/sql_parser"
+	"sort"
 )
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -56,6 +58,9 @@ type ModulePlannerOptions struct {
 
 	// Table to Provider mapping
 	TableToProviderMap map[string]string
+
+	// The values of the variables of the root module given from the command line, the environment and the var files
+	Variables []*RootVariable
 }
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -78,11 +83,33 @@ func (x *ModulePlanner) Name() string {
 }
 
 func (x *ModulePlanner) MakePlan(ctx context.Context) (*ModulePlan, *schema.Diagnostics) {
-	return x.buildModulePlanner(ctx, x.options.Module, NewScope())
+	selector := NewRuleSelector(x.options.Instruction)
+	if err := selector.Check(); err != nil {
+		return nil, schema.NewDiagnostics().AddErrorMsg(err.Error())
+	}
+	rootScope, diagnostics := newRootScope(x.options.Module, x.options.Variables)
+	if diagnostics.HasError() {
+		return nil, diagnostics
+	}
+	modulePlan, d := x.buildModulePlanner(ctx, x.options.Module, rootScope, selector)
+	diagnostics.AddDiagnostics(d)
+	if modulePlan != nil && selector != nil && modulePlan.RulesCount() == 0 {
+		return nil, diagnostics.AddErrorMsg("no rule matches %s", selector.String())
+	}
+	return modulePlan, diagnostics
+}
+
+// RulesCount The number of rule plans of the module and its submodules
+func (x *ModulePlan) RulesCount() int {
+	count := len(x.RulesPlan)
+	for _, subModulePlan := range x.SubModulesPlan {
+		count += subModulePlan.RulesCount()
+	}
+	return count
 }
 
 // Specify execution plans for modules and submodules
-func (x *ModulePlanner) buildModulePlanner(ctx context.Context, module *module.Module, moduleScope *Scope) (*ModulePlan, *schema.Diagnostics) {
+func (x *ModulePlanner) buildModulePlanner(ctx context.Context, module *module.Module, moduleScope *Scope, selector *RuleSelector) (*ModulePlan, *schema.Diagnostics) {
 
 	diagnostics := schema.NewDiagnostics()
 
@@ -95,8 +122,17 @@ func (x *ModulePlanner) buildModulePlanner(ctx context.Context, module *module.M
 		RulesPlan:      nil,
 	}
 
+	// The variables the module declares are checked before any rule uses them
+	if diagnostics.AddDiagnostics(applyModuleVariables(module, moduleScope)).HasError() {
+		return nil, diagnostics
+	}
+
 	// Generate an execution plan for the rules in the module
 	for _, ruleBlock := range module.RulesBlock {
+		// Rules not selected from the command line are not planned at all
+		if !selector.matchWithoutProviders(ruleBlock) {
+			continue
+		}
 		rulePlan, d := NewRulePlanner(&RulePlannerOptions{
 			ModulePlan:         modulePlan,
 			Module:             module,
@@ -107,33 +143,142 @@ func (x *ModulePlanner) buildModulePlanner(ctx context.Context, module *module.M
 		if diagnostics.Add(d).HasError() {
 			return nil, diagnostics
 		}
+		// The providers the rule binds to are known only after it is planned
+		if !selector.Match(ruleBlock, rulePlan.BindingProviders...) {
+			continue
+		}
 		modulePlan.RulesPlan = append(modulePlan.RulesPlan, rulePlan)
 	}
 
-	var subModuleInputMap map[string]*moduleBlock.ModuleBlock
-	if len(module.ModulesBlock) != 0 {
-		subModuleInputMap = module.ModulesBlock.ModulesInputMap()
-	}
 	// Generate an execution plan for the submodules
 	for _, subModule := range module.SubModules {
+		subModulePlan, d := x.buildModulePlanner(ctx, subModule, x.subModuleScope(module, subModule, modulePlan.ModuleScope), selector)
+		if diagnostics.AddDiagnostics(d).HasError() {
+			return nil, diagnostics
+		}
+		modulePlan.SubModulesPlan = append(modulePlan.SubModulesPlan, subModulePlan)
+	}
+
+	return modulePlan, diagnostics
+}
+
+// The submodule inherits the scope of the module, and the module may have some initialized variables for it
+func (x *ModulePlanner) subModuleScope(module *module.Module, subModule *module.Module, moduleScope *Scope) *Scope {
+	subModuleScope := ExtendScope(moduleScope)
+	if len(module.ModulesBlock) != 0 {
+		if subModuleBlock := module.ModulesBlock.ModulesInputMap()[subModule.Source]; subModuleBlock != nil && len(subModuleBlock.Input) != 0 {
+			subModuleScope.SetVariables(subModuleBlock.Input)
+		}
+	}
+	return subModuleScope
+}
+
+// The defaults of the variables the module declares are put into the scope if they are not given by the parent module, then
+// every variable must have a value that is valid for its declaration
+func applyModuleVariables(currentModule *module.Module, moduleScope *Scope) *schema.Diagnostics {
+
+	diagnostics := schema.NewDiagnostics()
 
-		subModuleScope := ExtendScope(modulePlan.ModuleScope)
+	moduleBlock := currentModule.GetModuleBlock()
+	for _, variableBlock := range currentModule.VariablesBlock {
 
-		// Also, the module may have some initialized variables
-		if subModuleInputMap != nil {
-			if subModuleBlock := subModuleInputMap[subModule.Source]; subModuleBlock != nil && len(subModuleBlock.Input) != 0 {
-				subModuleScope.SetVariables(subModuleBlock.Input)
+		if variableBlock.Sensitive {
+			moduleScope.SetSensitive(variableBlock.Key)
+		}
+
+		value, exists := moduleScope.GetVariable(variableBlock.Key)
+		if !exists {
+			if !variableBlock.IsRequired() {
+				moduleScope.SetVariable(variableBlock.Key, variableBlock.Default)
+				continue
 			}
+			errorTips := fmt.Sprintf("Variable %s of module %s is required, but no value is given", variableBlock.Key, currentModule.BuildFullName())
+			location := variableBlock.GetNodeLocation("key")
+			if moduleBlock != nil {
+				errorTips = fmt.Sprintf("Module %s input %s is required by module %s", moduleBlock.Name, variableBlock.Key, moduleBlock.Uses)
+				if location = moduleBlock.GetNodeLocation("input"); location == nil {
+					location = moduleBlock.GetNodeLocation("uses")
+				}
+			}
+			diagnostics.AddErrorMsg(module.RenderErrorTemplate(errorTips, location))
+			continue
 		}
 
-		subModulePlan, d := x.buildModulePlanner(ctx, subModule, subModuleScope)
-		if diagnostics.AddDiagnostics(d).HasError() {
-			return nil, diagnostics
+		if err := variableBlock.CheckValue(value); err != nil {
+			errorTips := fmt.Sprintf("Variable %s of module %s is not valid: %s", variableBlock.Key, currentModule.BuildFullName(), err.Error())
+			location := variableBlock.GetNodeLocation("")
+			if moduleBlock != nil {
+				if _, given := moduleBlock.Input[variableBlock.Key]; given {
+					location = moduleBlock.GetNodeLocation("input." + variableBlock.Key + module.NodeLocationSelfValue)
+				}
+			}
+			diagnostics.AddErrorMsg(module.RenderErrorTemplate(errorTips, location))
 		}
-		modulePlan.SubModulesPlan = append(modulePlan.SubModulesPlan, subModulePlan)
 	}
 
-	return modulePlan, diagnostics
+	return diagnostics
+}
+
+// ListSelectedTables The tables used by the rules selected from the command line, so that only these tables need to be fetched.
+// Returns nil when there is no selector, or when the tables of some selected rule can not be known, in which case all tables are needed.
+// Before the providers are asked for their tables, TableToProviderMap is nil and a rule may be selected by --provider through the
+// tables it queries, so every rule the other selectors allow is counted, the rules selected later are a part of them
+func (x *ModulePlanner) ListSelectedTables(ctx context.Context) []string {
+	selector := NewRuleSelector(x.options.Instruction)
+	if selector.IsEmpty() {
+		return nil
+	}
+	tableSet := make(map[string]struct{})
+	rootScope, d := newRootScope(x.options.Module, x.options.Variables)
+	if d.HasError() {
+		return nil
+	}
+	if !x.listSelectedTables(ctx, x.options.Module, rootScope, selector, tableSet) {
+		return nil
+	}
+	tables := make([]string, 0, len(tableSet))
+	for tableName := range tableSet {
+		tables = append(tables, tableName)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+func (x *ModulePlanner) listSelectedTables(ctx context.Context, module *module.Module, moduleScope *Scope, selector *RuleSelector, tableSet map[string]struct{}) bool {
+	if applyModuleVariables(module, moduleScope).HasError() {
+		return false
+	}
+	for _, ruleBlock := range module.RulesBlock {
+		if !selector.matchWithoutProviders(ruleBlock) {
+			continue
+		}
+		query, err := ExtendScope(moduleScope).RenderingTemplate(ruleBlock.Query, ruleBlock.Query)
+		if err != nil {
+			return false
+		}
+		statement, err := sql_parser.Parse(query)
+		if err != nil {
+			return false
+		}
+		bindingProviders := make([]string, 0)
+		for _, relation := range statement.Relations() {
+			if providerName, exists := x.options.TableToProviderMap[relation.Name]; exists {
+				bindingProviders = append(bindingProviders, providerName)
+			}
+		}
+		if x.options.TableToProviderMap != nil && !selector.Match(ruleBlock, bindingProviders...) {
+			continue
+		}
+		for _, relation := range statement.Relations() {
+			tableSet[relation.Name] = struct{}{}
+		}
+	}
+	for _, subModule := range module.SubModules {
+		if !x.listSelectedTables(ctx, subModule, x.subModuleScope(module, subModule, moduleScope), selector, tableSet) {
+			return false
+		}
+	}
+	return true
 }
 
 // ------------------------------------------------- --------------------------------------------------------------------
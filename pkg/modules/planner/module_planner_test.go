@@ -0,0 +1,86 @@
+package planner
+
+import (
+	"context"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestVariableBlock(variableBlock *module.VariableBlock) *module.VariableBlock {
+	variableBlock.LocatableImpl = module.NewLocatableImpl()
+	return variableBlock
+}
+
+func TestApplyModuleVariables(t *testing.T) {
+	rootModule := &module.Module{
+		VariablesBlock: module.VariablesBlock{
+			newTestVariableBlock(&module.VariableBlock{Key: "threshold", Type: module.VariableTypeNumber, Default: 30}),
+			newTestVariableBlock(&module.VariableBlock{Key: "password", Sensitive: true, Default: "s3cr3t"}),
+		},
+	}
+	scope := NewScope()
+	assert.False(t, applyModuleVariables(rootModule, scope).HasError())
+	threshold, _ := scope.GetVariable("threshold")
+	assert.Equal(t, 30, threshold)
+	assert.Equal(t, SensitiveValueMask, scope.MaskedVariables()["password"])
+
+	// The value given wins over the default, but it must be valid
+	scope = NewScope()
+	scope.SetVariable("threshold", "90")
+	assert.True(t, applyModuleVariables(rootModule, scope).HasError())
+
+	// The required variable of the submodule is given by the input of the module block, or inherited from the parent scope
+	moduleBlock := &module.ModuleBlock{Name: "aws_pack", Uses: "./rules", LocatableImpl: module.NewLocatableImpl()}
+	rootModule.ModulesBlock = module.ModulesBlock{moduleBlock}
+	subModule := &module.Module{
+		Source:       "./rules",
+		ParentModule: rootModule,
+		VariablesBlock: module.VariablesBlock{
+			newTestVariableBlock(&module.VariableBlock{Key: "region", Required: true}),
+		},
+	}
+	rootModule.SubModules = []*module.Module{subModule}
+	planner := NewModulePlanner(&ModulePlannerOptions{Module: rootModule})
+
+	assert.True(t, applyModuleVariables(subModule, planner.subModuleScope(rootModule, subModule, NewScope())).HasError())
+
+	moduleBlock.Input = map[string]any{"region": "us-east-1"}
+	subModuleScope := planner.subModuleScope(rootModule, subModule, scope)
+	assert.False(t, applyModuleVariables(subModule, subModuleScope).HasError())
+	assert.True(t, subModuleScope.IsSensitive("password"))
+
+	moduleBlock.Input = nil
+	scope = NewScope()
+	scope.SetVariable("region", "eu-west-1")
+	assert.False(t, applyModuleVariables(subModule, planner.subModuleScope(rootModule, subModule, scope)).HasError())
+}
+
+func TestModulePlanner_ListSelectedTables(t *testing.T) {
+	rootModule := module.NewModule()
+	rootModule.RulesBlock = module.RulesBlock{
+		{
+			Name:          "bucket_of_instance",
+			Query:         "SELECT * FROM aws_s3_buckets b JOIN gcp_compute_instances i ON b.name = i.name",
+			MetadataBlock: &module.RuleMetadataBlock{Provider: "gcp"},
+		},
+		{Name: "pod_privileged", Query: "SELECT * FROM k8s_core_pods WHERE privileged"},
+	}
+	instruction := map[string]interface{}{InstructionKeyProvider: []string{"aws"}}
+
+	// Without the tables of the providers, every rule may be selected by the tables it queries
+	tables := NewModulePlanner(&ModulePlannerOptions{Instruction: instruction, Module: rootModule}).ListSelectedTables(context.Background())
+	assert.Equal(t, []string{"aws_s3_buckets", "gcp_compute_instances", "k8s_core_pods"}, tables)
+
+	// With them, the join is selected by aws through aws_s3_buckets
+	tables = NewModulePlanner(&ModulePlannerOptions{
+		Instruction: instruction,
+		Module:      rootModule,
+		TableToProviderMap: map[string]string{
+			"aws_s3_buckets":        "aws",
+			"gcp_compute_instances": "gcp",
+			"k8s_core_pods":         "k8s",
+		},
+	}).ListSelectedTables(context.Background())
+	assert.Equal(t, []string{"aws_s3_buckets", "gcp_compute_instances"}, tables)
+}
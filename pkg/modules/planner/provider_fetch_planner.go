@@ -100,6 +100,10 @@ type ProviderFetchPlan struct {
 
 	// What is the MD5 of the configuration block if the provider configuration is used
 	ProviderConfigurationMD5 string
+
+	// The tables used by the rules selected from the command line, only the tables of this provider among them are pulled.
+	// nil means no rule is selected, the tables are decided by the provider configuration
+	SelectedTables []string
 }
 
 func NewProviderFetchPlan(providerName, providerVersion string, providerBlock *module.ProviderBlock) *ProviderFetchPlan {
@@ -156,6 +160,9 @@ type ProviderFetchPlannerOptions struct {
 
 	// A place to send messages to the outside world
 	MessageChannel *message.Channel[*schema.Diagnostics]
+
+	// The tables used by the selected rules, see ModulePlanner.ListSelectedTables
+	SelectedTables []string
 }
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -225,6 +232,7 @@ func (x *ProviderFetchPlanner) expandByConfiguration(ctx context.Context) ([]*Pr
 
 		fetchToDatabaseSchema := pgstorage.GetSchemaKey(requiredProviderBlock.Source, providerWinnerVersion, providerBlock)
 		providerFetchPlan.FetchToDatabaseSchema = fetchToDatabaseSchema
+		providerFetchPlan.SelectedTables = x.options.SelectedTables
 		providerFetchPlanSlice = append(providerFetchPlanSlice, providerFetchPlan)
 
 	}
@@ -249,6 +257,7 @@ func (x *ProviderFetchPlanner) expandByConfiguration(ctx context.Context) ([]*Pr
 			continue
 		}
 		providerFetchPlan.FetchToDatabaseSchema = fetchToDatabaseSchema
+		providerFetchPlan.SelectedTables = x.options.SelectedTables
 		providerFetchPlanSlice = append(providerFetchPlanSlice, providerFetchPlan)
 	}
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
 	"github.com/selefra/selefra/pkg/modules/module"
 	"github.com/selefra/selefra/pkg/registry"
 	"github.com/selefra/selefra/pkg/utils"
@@ -13,9 +14,9 @@ import (
 
 // ------------------------------------------------ ---------------------------------------------------------------------
 
-// MakeProviderInstallPlan Plan the provider installation for the module
-func MakeProviderInstallPlan(ctx context.Context, module *module.Module) (ProvidersInstallPlan, *schema.Diagnostics) {
-	return NewProviderInstallPlanner(module).MakePlan(ctx)
+// MakeProviderInstallPlan Plan the provider installation for the module, if a lock file is given the locked versions are used
+func MakeProviderInstallPlan(ctx context.Context, module *module.Module, lockFile ...*lock_file.LockFile) (ProvidersInstallPlan, *schema.Diagnostics) {
+	return NewProviderInstallPlanner(module, lockFile...).MakePlan(ctx)
 }
 
 // ------------------------------------------------ ---------------------------------------------------------------------
@@ -36,6 +37,9 @@ func (x ProvidersInstallPlan) ToMap() map[string]string {
 type ProviderInstallPlan struct {
 	// Which version of which provider is to be used to pull data
 	*registry.Provider
+
+	// The registry setting the provider is installed from, empty means the official registry
+	Registry string
 }
 
 // NewProviderInstallPlan Create an installation plan based on the provider name and version number
@@ -50,14 +54,21 @@ func NewProviderInstallPlan(providerName, providerVersion string) *ProviderInsta
 // ProviderInstallPlanner This command is used to plan the provider installation for Module
 type ProviderInstallPlanner struct {
 	module *module.Module
+
+	// If not nil, the provider uses the locked version instead of the latest one that wins the vote
+	lockFile *lock_file.LockFile
 }
 
 var _ Planner[ProvidersInstallPlan] = &ProviderInstallPlanner{}
 
-func NewProviderInstallPlanner(module *module.Module) *ProviderInstallPlanner {
-	return &ProviderInstallPlanner{
+func NewProviderInstallPlanner(module *module.Module, lockFile ...*lock_file.LockFile) *ProviderInstallPlanner {
+	planner := &ProviderInstallPlanner{
 		module: module,
 	}
+	if len(lockFile) > 0 {
+		planner.lockFile = lockFile[0]
+	}
+	return planner
 }
 
 func (x *ProviderInstallPlanner) Name() string {
@@ -72,7 +83,9 @@ func (x *ProviderInstallPlanner) MakePlan(ctx context.Context) (ProvidersInstall
 	}
 	providerInstallPlanSlice := make([]*ProviderInstallPlan, 0)
 	for providerName, providerVersion := range providerVersionVoteWinnerMap {
-		providerInstallPlanSlice = append(providerInstallPlanSlice, NewProviderInstallPlan(providerName, providerVersion))
+		plan := NewProviderInstallPlan(providerName, providerVersion)
+		plan.Registry, _ = x.module.ProviderRegistry(providerName)
+		providerInstallPlanSlice = append(providerInstallPlanSlice, plan)
 	}
 	return providerInstallPlanSlice, diagnostics
 }
@@ -101,11 +114,19 @@ func (x *ProviderInstallPlanner) providerVersionVote(ctx context.Context) (map[s
 
 		// The election was defeated, and no version received unanimous votes
 		if len(winnersVersions) < 1 {
-			errorReportSlice = append(errorReportSlice, x.buildVersionVoteFailedReport(voteInfo))
+			errorReportSlice = append(errorReportSlice, buildVersionVoteFailedReport(voteInfo))
 		} else {
 			// Select the latest version of the provider that supports all Modules
 			winnerVersionSlice := version.Sort(voteInfo.GetWinnersVersionSlice())
 			winnerVersion := winnerVersionSlice[len(winnerVersionSlice)-1]
+			// The locked version is used as long as all modules still support it
+			if lockedVersion := x.lockedProviderVersion(providerName); lockedVersion != "" {
+				if utils.FindFirstSameKeyInTwoStringArray(winnerVersionSlice, []string{lockedVersion}) == "" {
+					errorReportSlice = append(errorReportSlice, fmt.Sprintf("Provider %s is locked to version %s in %s, but it is not supported by all modules, the supported versions are %s, run selefra lock --upgrade to lock it again", providerName, lockedVersion, lock_file.FileName, strings.Join(winnerVersionSlice, ", ")))
+					continue
+				}
+				winnerVersion = lockedVersion
+			}
 			// TODO debug log
 			providerVersionVoteWinnerMap[providerName] = winnerVersion
 		}
@@ -123,8 +144,19 @@ func (x *ProviderInstallPlanner) providerVersionVote(ctx context.Context) (map[s
 	return providerVersionVoteWinnerMap, diagnostics
 }
 
+// The version the provider is locked to, empty if it is not locked
+func (x *ProviderInstallPlanner) lockedProviderVersion(providerName string) string {
+	if x.lockFile == nil {
+		return ""
+	}
+	if lockedProvider := x.lockFile.GetProvider(providerName); lockedProvider != nil {
+		return lockedProvider.Version
+	}
+	return ""
+}
+
 // When a vote fails, construct a general report so the user knows what went wrong
-func (x *ProviderInstallPlanner) buildVersionVoteFailedReport(providerVote *ProviderVote) string {
+func buildVersionVoteFailedReport(providerVote *ProviderVote) string {
 	report := strings.Builder{}
 	report.WriteString(fmt.Sprintf("Failed to vote version for provider %s: \n", providerVote.ProviderName))
 	for module, versionSlice := range providerVote.ToModuleAllowProviderVersionMap() {
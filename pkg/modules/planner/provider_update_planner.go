@@ -0,0 +1,266 @@
+package planner
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/go-version"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/registry"
+	"github.com/selefra/selefra/pkg/utils"
+	selefraVersion "github.com/selefra/selefra/pkg/version"
+	"sort"
+	"strings"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// MakeProviderUpdatePlan Plan the update of the providers the module tree requires
+func MakeProviderUpdatePlan(ctx context.Context, options *ProviderUpdatePlannerOptions) (ProvidersUpdatePlan, *schema.Diagnostics) {
+	return NewProviderUpdatePlanner(options).MakePlan(ctx)
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+type ProvidersUpdatePlan []*ProviderUpdatePlan
+
+// ToInstallPlan The providers that have a version to update to, to be installed beside the versions used now
+func (x ProvidersUpdatePlan) ToInstallPlan() ProvidersInstallPlan {
+	installPlan := make(ProvidersInstallPlan, 0)
+	for _, plan := range x {
+		if plan.HasUpdate() {
+			installPlan = append(installPlan, &ProviderInstallPlan{
+				Provider: registry.NewProvider(plan.Name, plan.UpdateVersion),
+				Registry: plan.Registry,
+			})
+		}
+	}
+	return installPlan
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ProviderUpdatePlan What an update changes for a provider
+type ProviderUpdatePlan struct {
+	Name string
+
+	// The registry setting the provider is installed from, empty means the official registry
+	Registry string
+
+	// The version used now, empty if the provider is not installed yet
+	CurrentVersion string
+
+	// The latest version in the registry
+	LatestVersion string
+
+	// The latest version all modules allow, it is what the provider is updated to
+	UpdateVersion string
+
+	// Why the provider is not updated to the latest version, such as the modules whose constraints do not allow it
+	Note string
+
+	// The required provider block of the root module, it is nil if only the submodules require the provider
+	RootRequiredProviderBlock *module.RequireProviderBlock
+}
+
+// HasUpdate Whether the version to update to is newer than the version used now
+func (x *ProviderUpdatePlan) HasUpdate() bool {
+	if x.UpdateVersion == "" {
+		return false
+	}
+	if x.CurrentVersion == "" {
+		return true
+	}
+	currentVersion, err := version.NewVersion(x.CurrentVersion)
+	if err != nil {
+		return x.CurrentVersion != x.UpdateVersion
+	}
+	updateVersion, err := version.NewVersion(x.UpdateVersion)
+	if err != nil {
+		return false
+	}
+	return updateVersion.GreaterThan(currentVersion)
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ProviderUpdatePlannerOptions What to plan the update for
+type ProviderUpdatePlannerOptions struct {
+
+	// The root module of the project, with its submodules loaded
+	Module *module.Module
+
+	// The sources of the providers to update, all of the providers the module tree requires if empty
+	ProviderNames []string
+
+	// The versions used now, <provider, version>, such as the versions locked or installed
+	CurrentVersions map[string]string
+
+	// The version constraints of the root module are not respected, because they are going to be rewritten to the version
+	// updated to, the constraints of the submodules still are
+	IgnoreRootConstraints bool
+}
+
+// ProviderUpdatePlanner Find the latest version of each provider that every module allows, the same vote as the installation
+type ProviderUpdatePlanner struct {
+	options *ProviderUpdatePlannerOptions
+}
+
+var _ Planner[ProvidersUpdatePlan] = &ProviderUpdatePlanner{}
+
+func NewProviderUpdatePlanner(options *ProviderUpdatePlannerOptions) *ProviderUpdatePlanner {
+	return &ProviderUpdatePlanner{
+		options: options,
+	}
+}
+
+func (x *ProviderUpdatePlanner) Name() string {
+	return "provider-update-planner"
+}
+
+func (x *ProviderUpdatePlanner) MakePlan(ctx context.Context) (ProvidersUpdatePlan, *schema.Diagnostics) {
+
+	diagnostics := schema.NewDiagnostics()
+
+	// step 01. Every module votes for the versions it allows, the root module sits out if its constraints are to be rewritten
+	providerVoteMap := make(map[string]*ProviderVote)
+	providerVoterMap := make(map[string][]*providerUpdateVoter)
+	rootRequiredProviderBlockMap := make(map[string]*module.RequireProviderBlock)
+	x.options.Module.Traversal(ctx, func(ctx context.Context, traversalContext *module.TraversalContext) bool {
+		voteModule := traversalContext.Module
+		if voteModule.SelefraBlock == nil {
+			return true
+		}
+		for _, requiredProviderBlock := range voteModule.SelefraBlock.RequireProvidersBlock {
+			if !x.isProviderToUpdate(requiredProviderBlock.Source) {
+				continue
+			}
+			providerVote, exists := providerVoteMap[requiredProviderBlock.Source]
+			if !exists {
+				var d *schema.Diagnostics
+				providerVote, d = NewProviderVote(ctx, x.options.Module, requiredProviderBlock)
+				if diagnostics.AddDiagnostics(d).HasError() {
+					return false
+				}
+				providerVoteMap[requiredProviderBlock.Source] = providerVote
+			}
+			isRoot := voteModule == x.options.Module
+			if isRoot {
+				rootRequiredProviderBlockMap[requiredProviderBlock.Source] = requiredProviderBlock
+				if x.options.IgnoreRootConstraints {
+					continue
+				}
+			}
+			if diagnostics.AddDiagnostics(providerVote.Vote(voteModule, requiredProviderBlock)).HasError() {
+				return false
+			}
+			providerVoterMap[requiredProviderBlock.Source] = append(providerVoterMap[requiredProviderBlock.Source], &providerUpdateVoter{
+				module:                voteModule,
+				requiredProviderBlock: requiredProviderBlock,
+			})
+		}
+		return true
+	})
+	if utils.HasError(diagnostics) {
+		return nil, diagnostics
+	}
+	for _, providerName := range x.options.ProviderNames {
+		if _, exists := providerVoteMap[providerName]; !exists {
+			diagnostics.AddErrorMsg("provider %s is not required by any module of the project", providerName)
+		}
+	}
+	if utils.HasError(diagnostics) {
+		return nil, diagnostics
+	}
+
+	// step 02. The provider is updated to the latest version all the modules allow
+	updatePlan := make(ProvidersUpdatePlan, 0)
+	for providerName, providerVote := range providerVoteMap {
+		winnerVersionSlice := selefraVersion.Sort(providerVote.GetWinnersVersionSlice())
+		if len(winnerVersionSlice) == 0 {
+			diagnostics.AddErrorMsg(buildVersionVoteFailedReport(providerVote))
+			continue
+		}
+		plan := &ProviderUpdatePlan{
+			Name:                      providerName,
+			Registry:                  providerVote.Registry,
+			CurrentVersion:            x.options.CurrentVersions[providerName],
+			UpdateVersion:             winnerVersionSlice[len(winnerVersionSlice)-1],
+			RootRequiredProviderBlock: rootRequiredProviderBlockMap[providerName],
+		}
+		latestVersion, d := x.checkLatestVersion(ctx, providerVote, plan.CurrentVersion)
+		if diagnostics.AddDiagnostics(d).HasError() {
+			continue
+		}
+		plan.LatestVersion = latestVersion
+		if plan.LatestVersion != plan.UpdateVersion {
+			plan.Note = x.buildNotAllowedNote(providerVote, providerVoterMap[providerName], plan.LatestVersion)
+		}
+		updatePlan = append(updatePlan, plan)
+	}
+	if utils.HasError(diagnostics) {
+		return nil, diagnostics
+	}
+	sort.Slice(updatePlan, func(i, j int) bool {
+		return updatePlan[i].Name < updatePlan[j].Name
+	})
+	return updatePlan, diagnostics
+}
+
+func (x *ProviderUpdatePlanner) isProviderToUpdate(providerName string) bool {
+	return len(x.options.ProviderNames) == 0 || utils.HasOne(x.options.ProviderNames, providerName)
+}
+
+// Ask the registry whether there is a newer version than the one used now
+func (x *ProviderUpdatePlanner) checkLatestVersion(ctx context.Context, providerVote *ProviderVote, currentVersion string) (string, *schema.Diagnostics) {
+	if currentVersion == "" {
+		return providerVote.providerMetadata.LatestVersion, nil
+	}
+	providerRegistry, err := registry.NewProviderRegistry("./", providerVote.Registry)
+	if err != nil {
+		return "", schema.NewDiagnostics().AddErrorMsg("create provider %s registry failed: %s", providerVote.ProviderName, err.Error())
+	}
+	newerProvider, err := providerRegistry.CheckUpdate(ctx, registry.NewProvider(providerVote.ProviderName, currentVersion))
+	if err != nil {
+		return "", schema.NewDiagnostics().AddErrorMsg("check provider %s update error: %s", providerVote.ProviderName, err.Error())
+	}
+	if newerProvider == nil {
+		return currentVersion, nil
+	}
+	return newerProvider.Version, nil
+}
+
+// The modules whose constraints do not allow the latest version
+func (x *ProviderUpdatePlanner) buildNotAllowedNote(providerVote *ProviderVote, voters []*providerUpdateVoter, latestVersion string) string {
+	voteSummary, exists := providerVote.VersionVoteCountMap[latestVersion]
+	if !exists {
+		return ""
+	}
+	notAllowedSlice := make([]string, 0)
+	for _, voter := range voters {
+		if _, allowed := voteSummary.VoteSet[voter.module]; !allowed {
+			notAllowedSlice = append(notAllowedSlice, fmt.Sprintf("%s requires %s", voter.moduleName(x.options.Module), voter.requiredProviderBlock.Version))
+		}
+	}
+	if len(notAllowedSlice) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s is not allowed, %s", latestVersion, strings.Join(notAllowedSlice, ", "))
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// A module that votes for the versions of a provider
+type providerUpdateVoter struct {
+	module                *module.Module
+	requiredProviderBlock *module.RequireProviderBlock
+}
+
+func (x *providerUpdateVoter) moduleName(rootModule *module.Module) string {
+	if x.module == rootModule {
+		return "the project"
+	}
+	return "module " + x.module.BuildFullName()
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
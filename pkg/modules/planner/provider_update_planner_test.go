@@ -0,0 +1,72 @@
+package planner
+
+import (
+	"context"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMakeProviderUpdatePlan(t *testing.T) {
+	mirrorDirectory := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(mirrorDirectory, "provider", "aws"), os.ModePerm))
+	assert.Nil(t, os.WriteFile(filepath.Join(mirrorDirectory, "provider", "aws", "metadata.yaml"), []byte("name: aws\nlatest-version: v0.0.3\nversions:\n  - v0.0.1\n  - v0.0.2\n  - v0.0.3\n"), 0644))
+	server := httptest.NewServer(http.FileServer(http.Dir(mirrorDirectory)))
+	defer server.Close()
+
+	rootModule := randomModule(">= v0.0.1")
+	rootModule.SelefraBlock.Registry = server.URL
+	subModule := randomModule("< v0.0.3")
+	subModule.ParentModule = rootModule
+	rootModule.SubModules = append(rootModule.SubModules, subModule)
+
+	// case 1: The constraints of the submodule keep the provider from the latest version
+	plans, d := MakeProviderUpdatePlan(context.Background(), &ProviderUpdatePlannerOptions{
+		Module:          rootModule,
+		CurrentVersions: map[string]string{"aws": "v0.0.1"},
+	})
+	assert.False(t, utils.HasError(d))
+	assert.Len(t, plans, 1)
+	assert.Equal(t, "v0.0.1", plans[0].CurrentVersion)
+	assert.Equal(t, "v0.0.3", plans[0].LatestVersion)
+	assert.Equal(t, "v0.0.2", plans[0].UpdateVersion)
+	assert.NotEmpty(t, plans[0].Note)
+	assert.True(t, plans[0].HasUpdate())
+	assert.Equal(t, rootModule.SelefraBlock.RequireProvidersBlock[0], plans[0].RootRequiredProviderBlock)
+	installPlan := plans.ToInstallPlan()
+	assert.Len(t, installPlan, 1)
+	assert.Equal(t, "v0.0.2", installPlan[0].Version)
+	assert.Equal(t, server.URL, installPlan[0].Registry)
+
+	// case 2: Nothing to update if the version used now is already the best one
+	plans, d = MakeProviderUpdatePlan(context.Background(), &ProviderUpdatePlannerOptions{
+		Module:          rootModule,
+		CurrentVersions: map[string]string{"aws": "v0.0.2"},
+	})
+	assert.False(t, utils.HasError(d))
+	assert.False(t, plans[0].HasUpdate())
+	assert.Len(t, plans.ToInstallPlan(), 0)
+
+	// case 3: The constraints of the root module are left out when they are to be rewritten
+	rootModule.SelefraBlock.RequireProvidersBlock[0].Version = "v0.0.1"
+	subModule.SelefraBlock.RequireProvidersBlock[0].Version = ">= v0.0.1"
+	plans, d = MakeProviderUpdatePlan(context.Background(), &ProviderUpdatePlannerOptions{
+		Module:                rootModule,
+		CurrentVersions:       map[string]string{"aws": "v0.0.1"},
+		IgnoreRootConstraints: true,
+	})
+	assert.False(t, utils.HasError(d))
+	assert.Equal(t, "v0.0.3", plans[0].UpdateVersion)
+	assert.Empty(t, plans[0].Note)
+
+	// case 4: Only the providers required by the project can be updated
+	_, d = MakeProviderUpdatePlan(context.Background(), &ProviderUpdatePlannerOptions{
+		Module:        rootModule,
+		ProviderNames: []string{"gcp"},
+	})
+	assert.True(t, utils.HasError(d))
+}
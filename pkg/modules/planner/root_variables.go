@@ -0,0 +1,160 @@
+package planner
+
+import (
+	"fmt"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/cli_env"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"gopkg.in/yaml.v3"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// VariableSource Where the value of a variable of the root module is given from outside the module
+type VariableSource string
+
+// The sources in the order of precedence, a later one wins over an earlier one, and all of them win over the default
+const (
+	VariableSourceEnv     VariableSource = "env"
+	VariableSourceVarFile VariableSource = "var-file"
+	VariableSourceVar     VariableSource = "var"
+)
+
+// RootVariable The value of a variable of the root module given from the command line, the environment or a var file
+type RootVariable struct {
+	Key string `json:"key"`
+
+	Value any `json:"value"`
+
+	Source VariableSource `json:"source"`
+
+	// Which one of the source, such as the name of the environment variable or the path of the var file
+	From string `json:"from"`
+
+	// The value from the command line or the environment is a string, it is converted to the type the variable declares
+	raw bool
+}
+
+// RootVariablesOptions Where the values of the variables of the root module are given
+type RootVariablesOptions struct {
+
+	// In the form of key=value, a later one wins over an earlier one
+	Vars []string
+
+	// The paths of the yaml files of key: value, a later one wins over an earlier one
+	VarFiles []string
+
+	// Whether to read the SELEFRA_VAR_<key> environment variables
+	ReadEnv bool
+}
+
+// LoadRootVariables Collect the values of the variables of the root module, the order of precedence from low to high is
+// the default of the variable, the SELEFRA_VAR_<key> environment variables, the var files in order, and the --var in order
+func LoadRootVariables(options *RootVariablesOptions) ([]*RootVariable, error) {
+
+	variableMap := make(map[string]*RootVariable)
+
+	if options.ReadEnv {
+		for key, value := range cli_env.GetVariables() {
+			variableMap[key] = &RootVariable{
+				Key:    key,
+				Value:  value,
+				Source: VariableSourceEnv,
+				From:   cli_env.SelefraVariablePrefix + key,
+				raw:    true,
+			}
+		}
+	}
+
+	for _, varFile := range options.VarFiles {
+		fileBytes, err := os.ReadFile(varFile)
+		if err != nil {
+			return nil, fmt.Errorf("read var file %s error: %s", varFile, err.Error())
+		}
+		fileVariables := make(map[string]any)
+		if err := yaml.Unmarshal(fileBytes, &fileVariables); err != nil {
+			return nil, fmt.Errorf("var file %s must be a yaml mapping of the variables: %s", varFile, err.Error())
+		}
+		for key, value := range fileVariables {
+			variableMap[key] = &RootVariable{
+				Key:    key,
+				Value:  value,
+				Source: VariableSourceVarFile,
+				From:   varFile,
+			}
+		}
+	}
+
+	for _, v := range options.Vars {
+		key, value, ok := strings.Cut(v, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("--var %s is not valid, it must be in the form of key=value", v)
+		}
+		variableMap[key] = &RootVariable{
+			Key:    key,
+			Value:  value,
+			Source: VariableSourceVar,
+			From:   "--var",
+			raw:    true,
+		}
+	}
+
+	variables := make([]*RootVariable, 0, len(variableMap))
+	for _, variable := range variableMap {
+		variables = append(variables, variable)
+	}
+	sort.Slice(variables, func(i, j int) bool {
+		return variables[i].Key < variables[j].Key
+	})
+	return variables, nil
+}
+
+// The scope of the root module starts with the variables given from outside. They are inherited by the submodules, so the
+// strings are converted to the types declared by the root module, or by the first submodule that declares them
+func newRootScope(rootModule *module.Module, variables []*RootVariable) (*Scope, *schema.Diagnostics) {
+
+	diagnostics := schema.NewDiagnostics()
+	scope := NewScope()
+
+	variableBlockMap := make(map[string]*module.VariableBlock)
+	collectVariableBlocks(rootModule, variableBlockMap)
+
+	for _, variable := range variables {
+		variableBlock, declared := variableBlockMap[variable.Key]
+		if !declared {
+			diagnostics.AddWarn("variable %s given by %s is not declared by any module", variable.Key, variable.From)
+		}
+		value := variable.Value
+		if rawValue, ok := value.(string); ok && variable.raw && declared {
+			parsedValue, err := variableBlock.ParseValue(rawValue)
+			if err != nil {
+				diagnostics.AddErrorMsg("variable %s given by %s is not valid: %s", variable.Key, variable.From, err.Error())
+				continue
+			}
+			value = parsedValue
+		}
+		scope.SetVariable(variable.Key, value)
+	}
+
+	return scope, diagnostics
+}
+
+func collectVariableBlocks(m *module.Module, variableBlockMap map[string]*module.VariableBlock) {
+	if m == nil {
+		return
+	}
+	for _, variableBlock := range m.VariablesBlock {
+		if _, exists := variableBlockMap[variableBlock.Key]; !exists {
+			variableBlockMap[variableBlock.Key] = variableBlock
+		}
+	}
+	for _, subModule := range m.SubModules {
+		collectVariableBlocks(subModule, variableBlockMap)
+	}
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
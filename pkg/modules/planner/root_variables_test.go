@@ -0,0 +1,81 @@
+package planner
+
+import (
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadRootVariables(t *testing.T) {
+	t.Setenv("SELEFRA_VAR_threshold", "30")
+	t.Setenv("SELEFRA_VAR_region", "us-east-1")
+	t.Setenv("SELEFRA_VAR_env", "dev")
+
+	varFile := filepath.Join(t.TempDir(), "prod.yaml")
+	assert.Nil(t, os.WriteFile(varFile, []byte("threshold: 90\nregions:\n  - us-east-1\n  - eu-west-1\nenv: prod\n"), 0644))
+
+	variables, err := LoadRootVariables(&RootVariablesOptions{
+		Vars:     []string{"env=staging", "env=prod-eu"},
+		VarFiles: []string{varFile},
+		ReadEnv:  true,
+	})
+	assert.Nil(t, err)
+	variableMap := make(map[string]*RootVariable)
+	for _, variable := range variables {
+		variableMap[variable.Key] = variable
+	}
+	assert.Equal(t, "us-east-1", variableMap["region"].Value)
+	assert.Equal(t, VariableSourceEnv, variableMap["region"].Source)
+	assert.Equal(t, 90, variableMap["threshold"].Value)
+	assert.Equal(t, varFile, variableMap["threshold"].From)
+	assert.Equal(t, []any{"us-east-1", "eu-west-1"}, variableMap["regions"].Value)
+	assert.Equal(t, "prod-eu", variableMap["env"].Value)
+	assert.Equal(t, VariableSourceVar, variableMap["env"].Source)
+
+	_, err = LoadRootVariables(&RootVariablesOptions{Vars: []string{"threshold"}})
+	assert.NotNil(t, err)
+	_, err = LoadRootVariables(&RootVariablesOptions{VarFiles: []string{filepath.Join(t.TempDir(), "not-exists.yaml")}})
+	assert.NotNil(t, err)
+}
+
+func TestNewRootScope(t *testing.T) {
+	rootModule := &module.Module{
+		VariablesBlock: module.VariablesBlock{
+			newTestVariableBlock(&module.VariableBlock{Key: "threshold", Type: module.VariableTypeNumber, Default: 30}),
+			newTestVariableBlock(&module.VariableBlock{Key: "name", Default: "selefra"}),
+		},
+	}
+	rootModule.SubModules = []*module.Module{
+		{
+			ParentModule: rootModule,
+			VariablesBlock: module.VariablesBlock{
+				newTestVariableBlock(&module.VariableBlock{Key: "regions", Type: module.VariableTypeList, Default: []any{}}),
+			},
+		},
+	}
+
+	scope, d := newRootScope(rootModule, []*RootVariable{
+		{Key: "threshold", Value: "90", Source: VariableSourceVar, raw: true},
+		{Key: "name", Value: "007", Source: VariableSourceEnv, raw: true},
+		{Key: "regions", Value: "[us-east-1, eu-west-1]", Source: VariableSourceVar, raw: true},
+		{Key: "unknown", Value: "1", Source: VariableSourceVar, raw: true},
+	})
+	assert.False(t, d.HasError())
+	assert.Len(t, d.GetDiagnosticSlice(), 1)
+	threshold, _ := scope.GetVariable("threshold")
+	assert.Equal(t, 90, threshold)
+	name, _ := scope.GetVariable("name")
+	assert.Equal(t, "007", name)
+	regions, _ := scope.GetVariable("regions")
+	assert.Equal(t, []any{"us-east-1", "eu-west-1"}, regions)
+
+	// The value given wins over the default
+	assert.False(t, applyModuleVariables(rootModule, scope).HasError())
+	threshold, _ = scope.GetVariable("threshold")
+	assert.Equal(t, 90, threshold)
+
+	_, d = newRootScope(rootModule, []*RootVariable{{Key: "regions", Value: "[us-east-1", Source: VariableSourceVar, raw: true}})
+	assert.True(t, d.HasError())
+}
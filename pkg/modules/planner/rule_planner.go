@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
 	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/selefra/selefra/pkg/sql_parser"
 	"sort"
+	"strings"
 )
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -20,8 +22,8 @@ type RulePlan struct {
 	// Is the execution plan for which block
 	*module.RuleBlock
 
-	// Which provider is the rule bound to? Currently, a rule can be bound to only one provider
-	BindingProviderName string
+	// Which providers is the rule bound to, a rule may join the tables of several providers, keep dictionary order
+	BindingProviders []string
 
 	// Render a good rule - bound Query
 	Query string
@@ -29,7 +31,32 @@ type RulePlan struct {
 	// Which tables are used in this Query
 	BindingTables []string
 
+	// Where the binding tables appear in the Query, used to rewrite them into the schema of their provider
+	BindingTableReferences []*BindingTableReference
+
+	// The parsed Query, nil if the Query could not be parsed and the bindings were found by matching words
+	Statement *sql_parser.Statement
+
 	RuleScope *Scope
+
+	// Where the values of the sensitive variables are in the Query, they are masked when the Query is shown
+	SensitiveSpans []*TextSpan
+}
+
+// BindingTableReference A reference to a provider table in the rendered rule query
+type BindingTableReference struct {
+
+	// The name of the referenced table
+	TableName string
+
+	// The provider to which the table belongs
+	ProviderName string
+
+	// The position of the reference in the query, [Start, End)
+	Start, End int
+
+	// The reference already specifies a schema, so it must not be rewritten
+	IsQualified bool
 }
 
 func (x *RulePlan) String() string {
@@ -40,6 +67,121 @@ func (x *RulePlan) String() string {
 	}
 }
 
+// IsMultipleProviders Whether the rule query joins the tables of more than one provider
+func (x *RulePlan) IsMultipleProviders() bool {
+	return len(x.BindingProviders) > 1
+}
+
+// ExpandProviderContexts Each provider may have multiple fetch tasks, so a rule has to be executed on every combination of the
+// contexts of the providers it is bound to. Each returned slice has one context per binding provider, in the order of BindingProviders
+func (x *RulePlan) ExpandProviderContexts(providerExpandMap map[string][]*ProviderContext) [][]*ProviderContext {
+	combinations := [][]*ProviderContext{{}}
+	for _, providerName := range x.BindingProviders {
+		providerContexts := providerExpandMap[providerName]
+		if len(providerContexts) == 0 {
+			// One of the providers has no data to query, so there is no combination can be executed
+			return nil
+		}
+		nextCombinations := make([][]*ProviderContext, 0, len(combinations)*len(providerContexts))
+		for _, combination := range combinations {
+			for _, providerContext := range providerContexts {
+				nextCombination := make([]*ProviderContext, 0, len(combination)+1)
+				nextCombination = append(nextCombination, combination...)
+				nextCombination = append(nextCombination, providerContext)
+				nextCombinations = append(nextCombinations, nextCombination)
+			}
+		}
+		combinations = nextCombinations
+	}
+	return combinations
+}
+
+// BuildQuery Build the query to execute on the given provider contexts.
+// A rule bound to a single provider is executed as is on the search path of that provider,
+// when it spans several providers, each table reference is rewritten into the schema of its provider
+func (x *RulePlan) BuildQuery(providerContexts []*ProviderContext) string {
+	return x.buildQuery(providerContexts, false)
+}
+
+// BuildMaskedQuery Like BuildQuery, but the values of the sensitive variables are masked so that the query can be shown
+func (x *RulePlan) BuildMaskedQuery(providerContexts []*ProviderContext) string {
+	return x.buildQuery(providerContexts, true)
+}
+
+// A piece of the query [start, end) replaced by the text, the schema of a table is inserted before it, start equals end
+type queryEdit struct {
+	start, end int
+	text       string
+}
+
+func (x *RulePlan) buildQuery(providerContexts []*ProviderContext, isMasked bool) string {
+	edits := make([]*queryEdit, 0)
+	if x.IsMultipleProviders() {
+		providerSchemaMap := make(map[string]string)
+		for _, providerContext := range providerContexts {
+			providerSchemaMap[providerContext.ProviderName] = providerContext.Schema
+		}
+		for _, reference := range x.BindingTableReferences {
+			databaseSchema, exists := providerSchemaMap[reference.ProviderName]
+			if reference.IsQualified || !exists {
+				continue
+			}
+			edits = append(edits, &queryEdit{start: reference.Start, end: reference.Start, text: QuoteIdentifier(databaseSchema) + "."})
+		}
+	}
+	if isMasked {
+		for _, span := range x.SensitiveSpans {
+			edits = append(edits, &queryEdit{start: span.Start, end: span.End, text: SensitiveValueMask})
+		}
+	}
+	if len(edits) == 0 {
+		return x.Query
+	}
+	// A masked value goes before the schema of a table inserted at the same place, so that the table stays masked
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start < edits[j].start
+		}
+		return edits[i].end > edits[j].end
+	})
+
+	builder := strings.Builder{}
+	lastIndex := 0
+	for _, edit := range edits {
+		if edit.start < lastIndex {
+			continue
+		}
+		builder.WriteString(x.Query[lastIndex:edit.start])
+		builder.WriteString(edit.text)
+		lastIndex = edit.end
+	}
+	builder.WriteString(x.Query[lastIndex:])
+	return builder.String()
+}
+
+// QualifyTable Prefix the table with the schema of its provider if the rule spans several providers
+func (x *RulePlan) QualifyTable(tableName string, providerContexts []*ProviderContext) string {
+	if !x.IsMultipleProviders() {
+		return tableName
+	}
+	for _, reference := range x.BindingTableReferences {
+		if reference.TableName != tableName {
+			continue
+		}
+		for _, providerContext := range providerContexts {
+			if providerContext.ProviderName == reference.ProviderName {
+				return QuoteIdentifier(providerContext.Schema) + "." + tableName
+			}
+		}
+	}
+	return tableName
+}
+
+// QuoteIdentifier Quote a postgresql identifier, such as a schema name
+func QuoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
 // ------------------------------------------------- --------------------------------------------------------------------
 
 // MakeRulePlan Plan the execution of the rule
@@ -103,14 +245,22 @@ func (x *RulePlanner) MakePlan(ctx context.Context) (*RulePlan, *schema.Diagnost
 	}
 
 	//Resolve the binding of the Rule to the Provider and table
-	bindingProviders, bindingTables := x.extractBinding(query, x.options.TableToProviderMap)
-	if len(bindingProviders) != 1 {
-		var errorTips string
-		if len(bindingProviders) == 0 {
-			errorTips = fmt.Sprintf("Your rule query should use at least one of the provider tables. Check that your sql is written correctly: %s", x.options.RuleBlock.Query)
-		} else {
-			errorTips = fmt.Sprintf("The tables used in your rule query span multiple providers; the current version of the rule query only allows several tables from one provider to be used: %s", x.options.RuleBlock.Query)
+	statement, err := sql_parser.Parse(query)
+	var bindingProviders, bindingTables []string
+	var bindingTableReferences []*BindingTableReference
+	if err != nil {
+		// The query of the gpt rule is not sql, there is nothing to warn about
+		if sql_parser.IsQuery(query) {
+			location := x.options.RuleBlock.GetNodeLocation("query" + module.NodeLocationSelfValue)
+			report := module.RenderErrorTemplate(fmt.Sprintf("parse rule %s query error, the tables it uses are guessed by name: %s", x.options.RuleBlock.Name, err.Error()), location)
+			diagnostics.AddWarn(report)
 		}
+		bindingProviders, bindingTables, bindingTableReferences = x.extractBindingByWord(query, x.options.TableToProviderMap)
+	} else {
+		bindingProviders, bindingTables, bindingTableReferences = x.extractBinding(statement, x.options.TableToProviderMap)
+	}
+	if len(bindingProviders) == 0 {
+		errorTips := fmt.Sprintf("Your rule query should use at least one of the provider tables. Check that your sql is written correctly: %s", x.options.RuleBlock.Query)
 		location := x.options.RuleBlock.GetNodeLocation("query" + module.NodeLocationSelfValue)
 		// TODO 2023-2-24 15:10:15 bug: Can't correct marks used in yaml | a line
 		report := module.RenderErrorTemplate(errorTips, location)
@@ -125,22 +275,48 @@ func (x *RulePlanner) MakePlan(ctx context.Context) (*RulePlan, *schema.Diagnost
 
 		RuleBlock: x.options.RuleBlock,
 
-		BindingProviderName: bindingProviders[0],
-		BindingTables:       bindingTables,
+		BindingProviders:       bindingProviders,
+		BindingTables:          bindingTables,
+		BindingTableReferences: bindingTableReferences,
+		Statement:              statement,
 
 		Query: query,
 
-		RuleScope: ruleScope,
+		RuleScope:      ruleScope,
+		SensitiveSpans: ruleScope.SensitiveSpans(x.options.RuleBlock.Query, x.options.RuleBlock.Query, query),
 	}, diagnostics
 }
 
-// Extract the names of the tables it uses from the rendered rule Query
-func (x *RulePlanner) extractBinding(query string, tableToProviderMap map[string]string) (bindingProviders []string, bindingTables []string) {
+// Extract the tables it uses from the parsed rule Query, common table expressions, aliases, strings and comments are not tables
+func (x *RulePlanner) extractBinding(statement *sql_parser.Statement, tableToProviderMap map[string]string) (bindingProviders []string, bindingTables []string, bindingTableReferences []*BindingTableReference) {
+	bindingProviderSet := make(map[string]struct{})
+	bindingTableSet := make(map[string]struct{})
+	for _, relation := range statement.Relations() {
+		providerName, exists := tableToProviderMap[relation.Name]
+		if !exists {
+			continue
+		}
+		bindingTableSet[relation.Name] = struct{}{}
+		bindingProviderSet[providerName] = struct{}{}
+		bindingTableReferences = append(bindingTableReferences, &BindingTableReference{
+			TableName:    relation.Name,
+			ProviderName: providerName,
+			Start:        relation.Start,
+			End:          relation.End,
+			IsQualified:  relation.IsQualified(),
+		})
+	}
+	return x.sortBinding(bindingProviderSet), x.sortBinding(bindingTableSet), bindingTableReferences
+}
+
+// Extract the names of the tables it uses from the rendered rule Query by matching words, used when the Query can not be parsed
+func (x *RulePlanner) extractBindingByWord(query string, tableToProviderMap map[string]string) (bindingProviders []string, bindingTables []string, bindingTableReferences []*BindingTableReference) {
 	bindingProviderSet := make(map[string]struct{})
 	bindingTableSet := make(map[string]struct{})
 	inWord := false
 	lastIndex := 0
-	for index, c := range query {
+	// a sentinel at the end so that the last word is also closed
+	for index, c := range query + " " {
 		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= '0' && c <= '9' {
 			if !inWord {
 				inWord = true
@@ -152,24 +328,30 @@ func (x *RulePlanner) extractBinding(query string, tableToProviderMap map[string
 				if providerName, exists := tableToProviderMap[word]; exists {
 					bindingTableSet[word] = struct{}{}
 					bindingProviderSet[providerName] = struct{}{}
+					bindingTableReferences = append(bindingTableReferences, &BindingTableReference{
+						TableName:    word,
+						ProviderName: providerName,
+						Start:        lastIndex,
+						End:          index,
+						IsQualified:  strings.HasSuffix(strings.TrimRight(query[:lastIndex], " \t\r\n"), "."),
+					})
 				}
 				inWord = false
 			}
 		}
 	}
 
-	for providerName := range bindingProviderSet {
-		bindingProviders = append(bindingProviders, providerName)
-	}
-	for tableName := range bindingTableSet {
-		bindingTables = append(bindingTables, tableName)
-	}
-
-	// keep dictionary order, show it to console need keep same
-	sort.Strings(bindingProviders)
-	sort.Strings(bindingTables)
+	return x.sortBinding(bindingProviderSet), x.sortBinding(bindingTableSet), bindingTableReferences
+}
 
-	return
+// keep dictionary order, show it to console need keep same
+func (x *RulePlanner) sortBinding(bindingSet map[string]struct{}) []string {
+	bindingSlice := make([]string, 0, len(bindingSet))
+	for name := range bindingSet {
+		bindingSlice = append(bindingSlice, name)
+	}
+	sort.Strings(bindingSlice)
+	return bindingSlice
 }
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
 	"github.com/selefra/selefra/pkg/message"
+	"github.com/selefra/selefra/pkg/modules/module"
 	"github.com/selefra/selefra/pkg/modules/module_loader"
 	"github.com/selefra/selefra/pkg/utils"
 	"github.com/stretchr/testify/assert"
@@ -52,3 +53,116 @@ func TestRulePlanner_MakePlan(t *testing.T) {
 	//assert.Len(t, plan.BindingTables, 2)
 
 }
+
+func TestRulePlanner_MakePlanMultipleProviders(t *testing.T) {
+
+	ruleBlock := module.NewRuleBlock()
+	ruleBlock.Name = "deploy_key_owner_is_not_iam_user"
+	ruleBlock.Query = `SELECT k.* FROM github_repository_deploy_keys AS k LEFT JOIN aws_iam_users AS u ON k.title = u.user_name WHERE u.user_name IS NULL`
+
+	options := &RulePlannerOptions{
+		Module:      module.NewModule(),
+		ModuleScope: NewScope(),
+		RuleBlock:   ruleBlock,
+		TableToProviderMap: map[string]string{
+			"aws_iam_users":                 "aws",
+			"github_repository_deploy_keys": "github",
+		},
+	}
+	plan, diagnostics := NewRulePlanner(options).MakePlan(context.Background())
+	assert.False(t, utils.HasError(diagnostics))
+	assert.Equal(t, []string{"aws", "github"}, plan.BindingProviders)
+	assert.Equal(t, []string{"aws_iam_users", "github_repository_deploy_keys"}, plan.BindingTables)
+	assert.True(t, plan.IsMultipleProviders())
+
+	providerExpandMap := map[string][]*ProviderContext{
+		"aws": {
+			{ProviderName: "aws", Schema: "aws_a"},
+			{ProviderName: "aws", Schema: "aws_b"},
+		},
+		"github": {
+			{ProviderName: "github", Schema: "github_a"},
+		},
+		"gcp": {
+			{ProviderName: "gcp", Schema: "gcp_a"},
+		},
+	}
+	combinations := plan.ExpandProviderContexts(providerExpandMap)
+	assert.Len(t, combinations, 2)
+	assert.Equal(t, "aws_b", combinations[1][0].Schema)
+	assert.Equal(t, "github_a", combinations[1][1].Schema)
+
+	query := plan.BuildQuery(combinations[0])
+	assert.Equal(t, `SELECT k.* FROM "github_a".github_repository_deploy_keys AS k LEFT JOIN "aws_a".aws_iam_users AS u ON k.title = u.user_name WHERE u.user_name IS NULL`, query)
+	assert.Equal(t, `"aws_a".aws_iam_users`, plan.QualifyTable("aws_iam_users", combinations[0]))
+
+	// a provider without any fetched data leaves nothing to execute
+	delete(providerExpandMap, "github")
+	assert.Len(t, plan.ExpandProviderContexts(providerExpandMap), 0)
+}
+
+func TestRulePlanner_MakePlanParseQuery(t *testing.T) {
+
+	ruleBlock := module.NewRuleBlock()
+	ruleBlock.Name = "bucket_without_logging"
+	ruleBlock.Query = `WITH aws_s3_buckets_logging AS (SELECT b.name FROM "aws_s3_buckets" AS b WHERE b.logging_target_bucket IS NOT NULL)
+SELECT b.name AS aws_iam_users, 'aws_ec2_instances' AS text -- github_repository_deploy_keys
+FROM public.aws_s3_buckets b
+WHERE b.name NOT IN (SELECT name FROM aws_s3_buckets_logging)`
+
+	options := &RulePlannerOptions{
+		Module:      module.NewModule(),
+		ModuleScope: NewScope(),
+		RuleBlock:   ruleBlock,
+		TableToProviderMap: map[string]string{
+			"aws_s3_buckets":                "aws",
+			"aws_s3_buckets_logging":        "aws",
+			"aws_iam_users":                 "aws",
+			"aws_ec2_instances":             "aws",
+			"github_repository_deploy_keys": "github",
+		},
+	}
+	plan, diagnostics := NewRulePlanner(options).MakePlan(context.Background())
+	assert.False(t, utils.HasError(diagnostics))
+	assert.NotNil(t, plan.Statement)
+	assert.Equal(t, []string{"aws"}, plan.BindingProviders)
+	assert.Equal(t, []string{"aws_s3_buckets"}, plan.BindingTables)
+	assert.Len(t, plan.BindingTableReferences, 2)
+	assert.False(t, plan.BindingTableReferences[0].IsQualified)
+	assert.True(t, plan.BindingTableReferences[1].IsQualified)
+	assert.Equal(t, []string{"aws_iam_users", "text"}, plan.Statement.OutputColumns())
+
+	// a query that can not be parsed falls back to matching words
+	ruleBlock.Query = `SELECT * FROM aws_s3_buckets WHERE (`
+	plan, diagnostics = NewRulePlanner(options).MakePlan(context.Background())
+	assert.False(t, utils.HasError(diagnostics))
+	assert.Nil(t, plan.Statement)
+	assert.Equal(t, []string{"aws_s3_buckets"}, plan.BindingTables)
+}
+
+func TestRulePlanner_MakePlanSensitive(t *testing.T) {
+	scope := NewScope()
+	scope.SetVariable("password", "1")
+	scope.SetSensitive("password")
+	ruleBlock := &module.RuleBlock{
+		Name:  "password_of_user",
+		Query: `SELECT 1 FROM aws_iam_users WHERE password = '{{.password}}' AND id > 10`,
+	}
+	options := &RulePlannerOptions{
+		Module:             module.NewModule(),
+		ModuleScope:        scope,
+		RuleBlock:          ruleBlock,
+		TableToProviderMap: map[string]string{"aws_iam_users": "aws"},
+	}
+	plan, diagnostics := NewRulePlanner(options).MakePlan(context.Background())
+	assert.False(t, utils.HasError(diagnostics))
+	assert.Equal(t, `SELECT 1 FROM aws_iam_users WHERE password = '1' AND id > 10`, plan.BuildQuery(nil))
+	// Only where the variable is rendered is masked, not every 1 in the query
+	assert.Equal(t, `SELECT 1 FROM aws_iam_users WHERE password = '(sensitive)' AND id > 10`, plan.BuildMaskedQuery(nil))
+
+	// The value used in a condition can not be found, the whole query is masked
+	ruleBlock.Query = `SELECT * FROM aws_iam_users{{if eq .password "1"}} WHERE 1 = 1{{end}}`
+	plan, diagnostics = NewRulePlanner(options).MakePlan(context.Background())
+	assert.False(t, utils.HasError(diagnostics))
+	assert.Equal(t, SensitiveValueMask, plan.BuildMaskedQuery(nil))
+}
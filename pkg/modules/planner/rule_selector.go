@@ -0,0 +1,202 @@
+package planner
+
+import (
+	"fmt"
+	"github.com/selefra/selefra/pkg/modules/module"
+	"path"
+	"strings"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// The keys of the rule selectors in the instruction of a plan, the values are []string
+const (
+
+	// InstructionKeyRule The names or ids of the rules, glob patterns are supported
+	InstructionKeyRule = "rule"
+
+	// InstructionKeyTag The tags of the rules
+	InstructionKeyTag = "tag"
+
+	// InstructionKeySeverity The severities of the rules, see module.MatchSeverity for the expressions supported
+	InstructionKeySeverity = "severity"
+
+	// InstructionKeyProvider The providers of the rules
+	InstructionKeyProvider = "provider"
+)
+
+// RuleSelector Select the rules to execute from the command line, the values of a selector are ORed, and the selectors are ANDed
+type RuleSelector struct {
+
+	// Matches the name or the id of the rule
+	Rules []string
+
+	Tags []string
+
+	Severities []string
+
+	// Matches the provider in the metadata of the rule, or a provider whose tables the rule queries
+	Providers []string
+}
+
+// NewRuleSelector Read the rule selectors from the instruction, returns nil if there is no selector, that is all rules are selected
+func NewRuleSelector(instruction map[string]interface{}) *RuleSelector {
+	selector := &RuleSelector{
+		Rules:      instructionStringSlice(instruction, InstructionKeyRule),
+		Tags:       instructionStringSlice(instruction, InstructionKeyTag),
+		Severities: instructionStringSlice(instruction, InstructionKeySeverity),
+		Providers:  instructionStringSlice(instruction, InstructionKeyProvider),
+	}
+	if selector.IsEmpty() {
+		return nil
+	}
+	return selector
+}
+
+func instructionStringSlice(instruction map[string]interface{}, key string) []string {
+	if instruction == nil {
+		return nil
+	}
+	switch value := instruction[key].(type) {
+	case []string:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			// allow --tag a,b as well as --tag a --tag b
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					values = append(values, s)
+				}
+			}
+		}
+		return values
+	case string:
+		return instructionStringSlice(map[string]interface{}{key: []string{value}}, key)
+	default:
+		return nil
+	}
+}
+
+// IsEmpty Whether no selector is given
+func (x *RuleSelector) IsEmpty() bool {
+	return x == nil || len(x.Rules) == 0 && len(x.Tags) == 0 && len(x.Severities) == 0 && len(x.Providers) == 0
+}
+
+// Match Whether the rule is selected, a nil selector selects all rules. The binding providers are the providers whose tables
+// the query of the rule uses, a rule is selected by --provider if its metadata or one of them matches
+func (x *RuleSelector) Match(rule *module.RuleBlock, bindingProviders ...string) bool {
+	if x.IsEmpty() {
+		return true
+	}
+	if !x.matchWithoutProviders(rule) {
+		return false
+	}
+	metadata := rule.MetadataBlock
+	if metadata == nil {
+		metadata = &module.RuleMetadataBlock{}
+	}
+	if len(x.Providers) != 0 && !x.matchAny(x.Providers, func(provider string) bool {
+		if strings.EqualFold(provider, metadata.Provider) {
+			return true
+		}
+		for _, bindingProvider := range bindingProviders {
+			if strings.EqualFold(provider, bindingProvider) {
+				return true
+			}
+		}
+		return false
+	}) {
+		return false
+	}
+	return true
+}
+
+// The selectors other than the providers, they are checked before the rule is planned and its binding providers are known
+func (x *RuleSelector) matchWithoutProviders(rule *module.RuleBlock) bool {
+	if x.IsEmpty() {
+		return true
+	}
+	metadata := rule.MetadataBlock
+	if metadata == nil {
+		metadata = &module.RuleMetadataBlock{}
+	}
+
+	if len(x.Rules) != 0 && !x.matchAny(x.Rules, func(pattern string) bool {
+		return matchRuleGlob(pattern, rule.Name) || matchRuleGlob(pattern, metadata.Id)
+	}) {
+		return false
+	}
+	if len(x.Tags) != 0 && !x.matchAny(x.Tags, func(tag string) bool {
+		for _, ruleTag := range metadata.Tags {
+			if strings.EqualFold(tag, ruleTag) {
+				return true
+			}
+		}
+		return false
+	}) {
+		return false
+	}
+	if len(x.Severities) != 0 && !x.matchAny(x.Severities, func(severity string) bool {
+		return module.MatchSeverity(severity, metadata.Severity)
+	}) {
+		return false
+	}
+	return true
+}
+
+func (x *RuleSelector) matchAny(values []string, matchFunc func(value string) bool) bool {
+	for _, value := range values {
+		if matchFunc(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRuleGlob(pattern, name string) bool {
+	if name == "" {
+		return false
+	}
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+// Check Whether the glob patterns and the severities of the selector are valid
+func (x *RuleSelector) Check() error {
+	if x.IsEmpty() {
+		return nil
+	}
+	for _, pattern := range x.Rules {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("rule %s is not a valid glob pattern: %s", pattern, err.Error())
+		}
+	}
+	for _, severity := range x.Severities {
+		if module.SeverityLevel(strings.TrimLeft(severity, "<>=")) < 0 {
+			return fmt.Errorf("severity %s is not valid, it must be one of %s", severity, strings.Join(module.Severities, ", "))
+		}
+	}
+	return nil
+}
+
+// String The selectors as the command line flags
+func (x *RuleSelector) String() string {
+	if x.IsEmpty() {
+		return ""
+	}
+	flags := make([]string, 0)
+	for _, rule := range x.Rules {
+		flags = append(flags, "--rule "+rule)
+	}
+	for _, tag := range x.Tags {
+		flags = append(flags, "--tag "+tag)
+	}
+	for _, severity := range x.Severities {
+		flags = append(flags, "--severity "+severity)
+	}
+	for _, provider := range x.Providers {
+		flags = append(flags, "--provider "+provider)
+	}
+	return strings.Join(flags, " ")
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,56 @@
+package planner
+
+import (
+	"github.com/selefra/selefra/pkg/modules/module"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRuleSelector_Match(t *testing.T) {
+	rule := &module.RuleBlock{
+		Name: "ebs_volume_unencrypted",
+		MetadataBlock: &module.RuleMetadataBlock{
+			Id:       "SF010302",
+			Severity: "High",
+			Provider: "AWS",
+			Tags:     []string{"Security", "CIS"},
+		},
+	}
+
+	assert.Nil(t, NewRuleSelector(nil))
+	assert.Nil(t, NewRuleSelector(map[string]interface{}{"output": "json", InstructionKeyRule: []string{}}))
+	assert.True(t, NewRuleSelector(nil).Match(rule))
+
+	match := func(key string, values ...string) bool {
+		return NewRuleSelector(map[string]interface{}{key: values}).Match(rule)
+	}
+	assert.True(t, match(InstructionKeyRule, "ebs_*"))
+	assert.True(t, match(InstructionKeyRule, "s3_*", "SF0103*"))
+	assert.False(t, match(InstructionKeyRule, "s3_*"))
+	assert.True(t, match(InstructionKeyTag, "pci,cis"))
+	assert.False(t, match(InstructionKeyTag, "pci"))
+	assert.True(t, match(InstructionKeySeverity, ">=Medium"))
+	assert.False(t, match(InstructionKeySeverity, "Critical"))
+	assert.True(t, match(InstructionKeyProvider, "aws"))
+	assert.False(t, match(InstructionKeyProvider, "gcp"))
+
+	// a rule without a provider in the metadata is selected by the providers of the tables it queries
+	bindingRule := &module.RuleBlock{Name: "s3_bucket_public"}
+	providerSelector := NewRuleSelector(map[string]interface{}{InstructionKeyProvider: []string{"aws"}})
+	assert.False(t, providerSelector.Match(bindingRule))
+	assert.True(t, providerSelector.Match(bindingRule, "aws"))
+	assert.False(t, providerSelector.Match(bindingRule, "gcp"))
+	assert.True(t, providerSelector.matchWithoutProviders(bindingRule))
+
+	// selectors of different kinds must all match
+	assert.False(t, NewRuleSelector(map[string]interface{}{
+		InstructionKeyProvider: []string{"aws"},
+		InstructionKeySeverity: []string{"Critical"},
+	}).Match(rule))
+}
+
+func TestRuleSelector_Check(t *testing.T) {
+	assert.Nil(t, NewRuleSelector(map[string]interface{}{InstructionKeySeverity: []string{">=high"}}).Check())
+	assert.NotNil(t, NewRuleSelector(map[string]interface{}{InstructionKeySeverity: []string{"urgent"}}).Check())
+	assert.NotNil(t, NewRuleSelector(map[string]interface{}{InstructionKeyRule: []string{"ebs_["}}).Check())
+}
@@ -1,8 +1,12 @@
 package planner
 
 import (
+	"fmt"
+	"github.com/selefra/selefra-utils/pkg/reflect_util"
 	"github.com/selefra/selefra/pkg/modules/module"
 	"github.com/selefra/selefra/pkg/utils"
+	"regexp"
+	"strings"
 )
 
 // Scope Used to represent the scope of a module, scope have some variables can use
@@ -11,6 +15,9 @@ type Scope struct {
 	// Variable in scope, may be is self declare, or extend from parent scope
 	variablesMap map[string]any
 
+	// The variables declared sensitive, their values are masked when they are shown
+	sensitiveVariableSet map[string]struct{}
+
 	// The provider configuration information in scope, now extend from parent module, do not support custom by self
 	providerConfigBlockSlice []*module.ProviderBlock
 }
@@ -25,7 +32,8 @@ func ExtendScope(scope *Scope) *Scope {
 // NewScope create new scope
 func NewScope() *Scope {
 	return &Scope{
-		variablesMap: make(map[string]any),
+		variablesMap:         make(map[string]any),
+		sensitiveVariableSet: make(map[string]struct{}),
 	}
 }
 
@@ -37,6 +45,9 @@ func (x *Scope) Extend(scope *Scope) {
 		}
 		x.variablesMap[key] = value
 	}
+	for key := range scope.sensitiveVariableSet {
+		x.sensitiveVariableSet[key] = struct{}{}
+	}
 }
 
 // Clone Make a copy of the current scope
@@ -47,8 +58,14 @@ func (x *Scope) Clone() *Scope {
 		newVariablesMap[key] = value
 	}
 
+	newSensitiveVariableSet := make(map[string]struct{})
+	for key := range x.sensitiveVariableSet {
+		newSensitiveVariableSet[key] = struct{}{}
+	}
+
 	return &Scope{
 		variablesMap:             newVariablesMap,
+		sensitiveVariableSet:     newSensitiveVariableSet,
 		providerConfigBlockSlice: x.providerConfigBlockSlice,
 	}
 }
@@ -59,6 +76,93 @@ func (x *Scope) GetVariable(variableName string) (any, bool) {
 	return value, exists
 }
 
+// Variables A copy of the variables in scope, including those extended from the parent scope
+func (x *Scope) Variables() map[string]any {
+	variablesMap := make(map[string]any, len(x.variablesMap))
+	for key, value := range x.variablesMap {
+		variablesMap[key] = value
+	}
+	return variablesMap
+}
+
+// SensitiveValueMask What the value of a sensitive variable is shown as
+const SensitiveValueMask = "(sensitive)"
+
+// SetSensitive Mark the variable as sensitive
+func (x *Scope) SetSensitive(variableName string) {
+	x.sensitiveVariableSet[variableName] = struct{}{}
+}
+
+// IsSensitive Whether the variable is marked as sensitive
+func (x *Scope) IsSensitive(variableName string) bool {
+	_, exists := x.sensitiveVariableSet[variableName]
+	return exists
+}
+
+// MaskedVariables Like Variables, but the values of the sensitive variables are masked so that they can be shown
+func (x *Scope) MaskedVariables() map[string]any {
+	variablesMap := x.Variables()
+	for key := range variablesMap {
+		if x.IsSensitive(key) {
+			variablesMap[key] = SensitiveValueMask
+		}
+	}
+	return variablesMap
+}
+
+// TextSpan A piece of a text, [Start, End)
+type TextSpan struct {
+	Start, End int
+}
+
+// The value of a sensitive variable is rendered as this first, so that it is known where the value is in the rendered text
+var sensitiveSentinelRegexp = regexp.MustCompile("\x00sensitive-[0-9]+\x00")
+
+// SensitiveSpans Where the values of the sensitive variables are in the text rendered from the template with this scope, only
+// there they are masked, the same value elsewhere is not the variable. If it can not be told, such as a sensitive value is used
+// in a condition of the template, the whole text is returned as a span
+func (x *Scope) SensitiveSpans(templateName, templateString, renderedText string) []*TextSpan {
+	variablesMap := x.Variables()
+	sensitiveValueMap := make(map[string]string)
+	for key := range x.sensitiveVariableSet {
+		value, exists := variablesMap[key]
+		if !exists || reflect_util.IsNil(value) {
+			continue
+		}
+		sentinel := fmt.Sprintf("\x00sensitive-%d\x00", len(sensitiveValueMap))
+		sensitiveValueMap[sentinel] = fmt.Sprintf("%v", value)
+		variablesMap[key] = sentinel
+	}
+	if len(sensitiveValueMap) == 0 {
+		return nil
+	}
+	wholeText := []*TextSpan{{Start: 0, End: len(renderedText)}}
+	sentinelText, err := utils.RenderingTemplate(templateName, templateString, variablesMap)
+	if err != nil {
+		return wholeText
+	}
+
+	// Put the values back, the text must be the same as the one rendered with the values
+	spans := make([]*TextSpan, 0)
+	builder := strings.Builder{}
+	lastIndex := 0
+	for _, match := range sensitiveSentinelRegexp.FindAllStringIndex(sentinelText, -1) {
+		builder.WriteString(sentinelText[lastIndex:match[0]])
+		span := &TextSpan{Start: builder.Len()}
+		builder.WriteString(sensitiveValueMap[sentinelText[match[0]:match[1]]])
+		span.End = builder.Len()
+		if span.End > span.Start {
+			spans = append(spans, span)
+		}
+		lastIndex = match[1]
+	}
+	builder.WriteString(sentinelText[lastIndex:])
+	if builder.String() != renderedText {
+		return wholeText
+	}
+	return spans
+}
+
 // SetVariable Declare a variable
 func (x *Scope) SetVariable(variableName string, variableValue any) any {
 	oldValue := x.variablesMap[variableName]
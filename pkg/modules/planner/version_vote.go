@@ -31,7 +31,7 @@ func (x *ProviderVersionVoteService) Vote(ctx context.Context, module *module.Mo
 	diagnostics := schema.NewDiagnostics()
 	for _, requiredProviderBlock := range module.SelefraBlock.RequireProvidersBlock {
 		if _, exists := x.providerVersionVoteMap[requiredProviderBlock.Source]; !exists {
-			providerVote, d := NewProviderVote(ctx, requiredProviderBlock)
+			providerVote, d := NewProviderVote(ctx, module, requiredProviderBlock)
 			if diagnostics.AddDiagnostics(d).HasError() {
 				return diagnostics
 			}
@@ -60,15 +60,24 @@ func (x *ProviderVersionVoteService) Vote(ctx context.Context, module *module.Mo
 // ------------------------------------------------- --------------------------------------------------------------------
 
 type ProviderVote struct {
-	TotalVoteTimes      int
-	ProviderName        string
+	TotalVoteTimes int
+	ProviderName   string
+
+	// Where the versions are obtained from, empty means the official registry
+	Registry string
+
 	VersionVoteCountMap map[string]*VersionVoteSummary
 	providerMetadata    *registry.ProviderMetadata
+
+	// Where the registry is set, nil if it is not set
+	registryLocation *module.NodeLocation
 }
 
-func NewProviderVote(ctx context.Context, requiredProviderBlock *module.RequireProviderBlock) (*ProviderVote, *schema.Diagnostics) {
+// NewProviderVote The registry of the provider is the one that the module tree of the voting module selects for it
+func NewProviderVote(ctx context.Context, voteModule *module.Module, requiredProviderBlock *module.RequireProviderBlock) (*ProviderVote, *schema.Diagnostics) {
 	x := &ProviderVote{}
 	x.ProviderName = requiredProviderBlock.Source
+	x.Registry, x.registryLocation = voteModule.ProviderRegistry(requiredProviderBlock.Source)
 	d := x.InitProviderVersionVoteCountMap(ctx, requiredProviderBlock)
 	return x, d
 }
@@ -116,15 +125,19 @@ func (x *ProviderVote) InitProviderVersionVoteCountMap(ctx context.Context, bloc
 
 	x.VersionVoteCountMap = make(map[string]*VersionVoteSummary)
 
+	location := x.registryLocation
+	if location == nil {
+		location = block.GetNodeLocation("source" + module.NodeLocationSelfValue)
+	}
+
 	// It's not actually going to download, so it doesn't matter what the path is here
-	options := registry.NewProviderGithubRegistryOptions("./")
-	provider, err := registry.NewProviderGithubRegistry(options)
+	providerRegistry, err := registry.NewProviderRegistry("./", x.Registry)
 	if err != nil {
-		return diagnostics.AddErrorMsg("create provider github registry failed: %s", err.Error())
+		report := module.RenderErrorTemplate(fmt.Sprintf("create provider %s registry failed: %s", x.ProviderName, err.Error()), location)
+		return diagnostics.AddErrorMsg(report)
 	}
-	metadata, err := provider.GetMetadata(ctx, registry.NewProvider(x.ProviderName, selefraVersion.VersionLatest))
+	metadata, err := providerRegistry.GetMetadata(ctx, registry.NewProvider(x.ProviderName, selefraVersion.VersionLatest))
 	if err != nil {
-		location := block.GetNodeLocation("source" + module.NodeLocationSelfValue)
 		report := module.RenderErrorTemplate(fmt.Sprintf("get provider %s meta information from registry error: %s", x.ProviderName, err.Error()), location)
 		return diagnostics.AddErrorMsg(report)
 	}
@@ -5,6 +5,10 @@ import (
 	"github.com/selefra/selefra/pkg/modules/module"
 	"github.com/selefra/selefra/pkg/utils"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 )
@@ -66,3 +70,29 @@ func randomModule(requiredVersion string) *module.Module {
 
 	return rootModule
 }
+
+func TestProviderVote_Registry(t *testing.T) {
+	mirrorDirectory := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(mirrorDirectory, "provider", "aws"), os.ModePerm))
+	assert.Nil(t, os.WriteFile(filepath.Join(mirrorDirectory, "provider", "aws", "metadata.yaml"), []byte("name: aws\nlatest-version: v0.0.3\nversions:\n  - v0.0.1\n  - v0.0.2\n  - v0.0.3\n"), 0644))
+	server := httptest.NewServer(http.FileServer(http.Dir(mirrorDirectory)))
+	defer server.Close()
+
+	rootModule := randomModule(">= v0.0.2")
+	rootModule.SelefraBlock.Registry = server.URL
+	service := NewProviderVersionVoteService()
+	assert.False(t, utils.HasError(service.Vote(context.Background(), rootModule)))
+	assert.Equal(t, server.URL, service.providerVersionVoteMap["aws"].Registry)
+	slice := service.providerVersionVoteMap["aws"].GetWinnersVersionSlice()
+	sort.Strings(slice)
+	assert.Equal(t, []string{"v0.0.2", "v0.0.3"}, slice)
+
+	plans, d := MakeProviderInstallPlan(context.Background(), rootModule)
+	assert.False(t, utils.HasError(d))
+	assert.Len(t, plans, 1)
+	assert.Equal(t, "v0.0.3", plans[0].Version)
+	assert.Equal(t, server.URL, plans[0].Registry)
+
+	rootModule.SelefraBlock.Registry = "s3://bucket/registry"
+	assert.True(t, utils.HasError(NewProviderVersionVoteService().Vote(context.Background(), rootModule)))
+}
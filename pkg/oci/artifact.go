@@ -0,0 +1,259 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/containerd/containerd/images"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/selefra/selefra/pkg/utils"
+	"io"
+	"oras.land/oras-go/pkg/content"
+	"oras.land/oras-go/pkg/oras"
+	"oras.land/oras-go/pkg/registry"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// SourcePrefix The module or provider is an artifact in an OCI registry, for example oci://registry.example.com/selefra/rules-aws:v0.0.1
+const SourcePrefix = "oci://"
+
+const (
+	ModuleArtifactConfigMediaType = "application/vnd.selefra.module.config.v1+json"
+	ModuleArtifactLayerMediaType  = "application/vnd.selefra.module.layer.v1.tar+gzip"
+
+	ProviderArtifactConfigMediaType = "application/vnd.selefra.provider.config.v1+json"
+	ProviderArtifactLayerMediaType  = "application/vnd.selefra.provider.layer.v1"
+)
+
+const (
+
+	// AnnotationPlatform Which platform the provider executable in the layer runs on, such as linux_amd64
+	AnnotationPlatform = "io.selefra.platform"
+
+	// AnnotationProviderName The name of the provider in the artifact
+	AnnotationProviderName = "io.selefra.provider.name"
+
+	// AnnotationProviderPackageName The name of the provider executable without the platform and suffix
+	AnnotationProviderPackageName = "io.selefra.provider.package-name"
+)
+
+// ModuleArtifactDirectoryName The module directory is packed into the artifact with this name, and is pulled to it
+const ModuleArtifactDirectoryName = "module"
+
+// IsArtifactSource Whether the source is an artifact in an OCI registry
+func IsArtifactSource(source string) bool {
+	return strings.HasPrefix(strings.ToLower(source), SourcePrefix)
+}
+
+// ParseArtifactReference Parse the source such as oci://registry.example.com/selefra/rules-aws:v0.0.1 into the reference
+// of the artifact, the tag is latest if omitted
+func ParseArtifactReference(source string) (registry.Reference, error) {
+	raw := source
+	if IsArtifactSource(raw) {
+		raw = raw[len(SourcePrefix):]
+	}
+	reference, err := registry.ParseReference(raw)
+	if err != nil {
+		return registry.Reference{}, fmt.Errorf("oci source %s is not valid: %s", source, err.Error())
+	}
+	reference.Reference = reference.ReferenceOrDefault()
+	return reference, nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// PushModule Pack the module directory into an artifact and push it to the reference of the store
+func PushModule(ctx context.Context, store ArtifactStore, reference string, moduleDirectory string) (ocispec.Descriptor, error) {
+
+	if !utils.ExistsDirectory(moduleDirectory) {
+		return ocispec.Descriptor{}, fmt.Errorf("module directory %s not exists", moduleDirectory)
+	}
+
+	fileStore := content.NewFile("")
+	// The same module is always the same artifact
+	fileStore.Reproducible = true
+	defer func() {
+		_ = fileStore.Close()
+	}()
+
+	layer, err := fileStore.Add(ModuleArtifactDirectoryName, ModuleArtifactLayerMediaType, moduleDirectory)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("pack module directory %s error: %s", moduleDirectory, err.Error())
+	}
+	return pushArtifact(ctx, fileStore, store, reference, ModuleArtifactConfigMediaType, nil, layer)
+}
+
+// PullModule Pull the module artifact of the reference into the directory, the module is in the ModuleArtifactDirectoryName
+// subdirectory, returns the digest of the manifest which is the same as long as the module is not changed
+func PullModule(ctx context.Context, store ArtifactStore, reference string, directory string) (string, error) {
+
+	if err := utils.EnsureDirectoryNotExists(directory); err != nil {
+		return "", err
+	}
+	if err := utils.EnsureDirectoryExists(directory); err != nil {
+		return "", err
+	}
+
+	fileStore := content.NewFile(directory)
+	defer func() {
+		_ = fileStore.Close()
+	}()
+
+	manifest, err := oras.Copy(ctx, store, reference, fileStore, "", oras.WithAllowedMediaType(ModuleArtifactConfigMediaType, ModuleArtifactLayerMediaType))
+	if err != nil {
+		return "", fmt.Errorf("pull module %s error: %s", reference, err.Error())
+	}
+	if !utils.ExistsDirectory(filepath.Join(directory, ModuleArtifactDirectoryName)) {
+		return "", fmt.Errorf("artifact %s is not a selefra module", reference)
+	}
+	return manifest.Digest.String(), nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ProviderArtifact The provider of a version packed into an artifact, there is an executable for each platform
+type ProviderArtifact struct {
+
+	// The name of the provider, such as aws
+	Name string
+
+	// The name of the executable without the platform and suffix, such as selefra-provider-aws
+	PackageName string
+
+	// The executable of each platform, the key is the platform such as linux_amd64
+	Executables map[string]string
+}
+
+// PushProvider Pack the executables of the provider into an artifact and push it to the reference of the store
+func PushProvider(ctx context.Context, store ArtifactStore, reference string, artifact *ProviderArtifact) (ocispec.Descriptor, error) {
+
+	if len(artifact.Executables) == 0 {
+		return ocispec.Descriptor{}, fmt.Errorf("provider %s has no executable to push", artifact.Name)
+	}
+
+	fileStore := content.NewFile("")
+	defer func() {
+		_ = fileStore.Close()
+	}()
+
+	layers := make([]ocispec.Descriptor, 0, len(artifact.Executables))
+	for platform, executablePath := range artifact.Executables {
+		if !utils.ExistsFile(executablePath) {
+			return ocispec.Descriptor{}, fmt.Errorf("provider %s executable %s not exists", artifact.Name, executablePath)
+		}
+		// Every platform is in its own directory so that the executables have the same name
+		layer, err := fileStore.Add(platform+"/"+filepath.Base(executablePath), ProviderArtifactLayerMediaType, executablePath)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		layer.Annotations[AnnotationPlatform] = platform
+		layers = append(layers, layer)
+	}
+
+	annotations := map[string]string{
+		AnnotationProviderName:        artifact.Name,
+		AnnotationProviderPackageName: artifact.PackageName,
+	}
+	return pushArtifact(ctx, fileStore, store, reference, ProviderArtifactConfigMediaType, annotations, layers...)
+}
+
+// PullProvider Pull the executable of the platform in the provider artifact into the directory, returns the path of the executable
+func PullProvider(ctx context.Context, store ArtifactStore, reference string, platform string, directory string) (string, error) {
+
+	if err := utils.EnsureDirectoryNotExists(directory); err != nil {
+		return "", err
+	}
+	if err := utils.EnsureDirectoryExists(directory); err != nil {
+		return "", err
+	}
+
+	fileStore := content.NewFile(directory)
+	defer func() {
+		_ = fileStore.Close()
+	}()
+
+	// Only the executable of the platform is downloaded
+	executableName := ""
+	platformFilter := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		if desc.MediaType != ProviderArtifactLayerMediaType {
+			return nil, nil
+		}
+		if desc.Annotations[AnnotationPlatform] != platform {
+			return nil, images.ErrStopHandler
+		}
+		executableName = desc.Annotations[ocispec.AnnotationTitle]
+		return nil, nil
+	})
+	_, err := oras.Copy(ctx, store, reference, fileStore, "", oras.WithAllowedMediaType(ProviderArtifactConfigMediaType, ProviderArtifactLayerMediaType), oras.WithPullBaseHandler(platformFilter))
+	if err != nil {
+		return "", fmt.Errorf("pull provider %s error: %s", reference, err.Error())
+	}
+	if executableName == "" {
+		return "", fmt.Errorf("provider %s has no executable for platform %s", reference, platform)
+	}
+
+	executablePath := filepath.Join(directory, filepath.FromSlash(executableName))
+	if err := os.Chmod(executablePath, 0755); err != nil {
+		return "", err
+	}
+	return executablePath, nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// FetchManifest Fetch the manifest of the artifact without pulling its layers
+func FetchManifest(ctx context.Context, store ArtifactStore, reference string) (ocispec.Descriptor, *ocispec.Manifest, error) {
+	_, desc, err := store.Resolve(ctx, reference)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	fetcher, err := store.Fetcher(ctx, reference)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	reader, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	manifestBytes, err := io.ReadAll(reader)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	manifest := &ocispec.Manifest{}
+	if err := json.Unmarshal(manifestBytes, manifest); err != nil {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("artifact %s manifest parse error: %s", reference, err.Error())
+	}
+	return desc, manifest, nil
+}
+
+// Push the layers in the file store as an artifact with an empty config of the media type
+func pushArtifact(ctx context.Context, fileStore *content.File, store ArtifactStore, reference string, configMediaType string, annotations map[string]string, layers ...ocispec.Descriptor) (ocispec.Descriptor, error) {
+
+	configBytes, config, err := content.GenerateConfig(nil)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	config.MediaType = configMediaType
+	if err := fileStore.Load(config, configBytes); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	manifestBytes, manifest, err := content.GenerateManifest(&config, annotations, layers...)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if err := fileStore.StoreManifest(reference, manifest, manifestBytes); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	return oras.Copy(ctx, fileStore, reference, store, reference)
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
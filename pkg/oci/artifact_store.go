@@ -0,0 +1,214 @@
+package oci
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/selefra/selefra/pkg/cli_env"
+	"net/http"
+	"oras.land/oras-go/pkg/auth/docker"
+	"oras.land/oras-go/pkg/content"
+	"oras.land/oras-go/pkg/registry"
+	"oras.land/oras-go/pkg/registry/remote"
+	"oras.land/oras-go/pkg/registry/remote/auth"
+	"oras.land/oras-go/pkg/target"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ArtifactStore Where the artifacts of modules and providers are pushed to and pulled from, the references are in the form
+// of registry/repository:tag or registry/repository@digest
+type ArtifactStore interface {
+	target.Target
+
+	// Tags Lists the tags of the repository such as registry.example.com/selefra/aws
+	Tags(ctx context.Context, repository string) ([]string, error)
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// RemoteArtifactStoreOptions The options to access a remote OCI registry
+type RemoteArtifactStoreOptions struct {
+
+	// The credentials of the registry, the credentials docker login saved are used if empty
+	Username string
+	Password string
+
+	// Skip the verification of the registry certificate
+	Insecure bool
+
+	// Access the registry over http instead of https
+	PlainHTTP bool
+}
+
+// NewRemoteArtifactStoreOptionsFromEnv The options are read from the SELEFRA_OCI_* environment variables
+func NewRemoteArtifactStoreOptionsFromEnv() *RemoteArtifactStoreOptions {
+	return &RemoteArtifactStoreOptions{
+		Username:  cli_env.GetOCIUsername(),
+		Password:  cli_env.GetOCIPassword(),
+		Insecure:  cli_env.IsOCIInsecure(),
+		PlainHTTP: cli_env.IsOCIPlainHttp(),
+	}
+}
+
+// RemoteArtifactStore The artifacts are in a remote registry that implements the OCI distribution spec
+type RemoteArtifactStore struct {
+	*content.Registry
+
+	options *RemoteArtifactStoreOptions
+}
+
+var _ ArtifactStore = &RemoteArtifactStore{}
+
+func NewRemoteArtifactStore(options *RemoteArtifactStoreOptions) (*RemoteArtifactStore, error) {
+	store, err := content.NewRegistry(content.RegistryOptions{
+		Username:  options.Username,
+		Password:  options.Password,
+		Insecure:  options.Insecure,
+		PlainHTTP: options.PlainHTTP,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteArtifactStore{
+		Registry: store,
+		options:  options,
+	}, nil
+}
+
+func (x *RemoteArtifactStore) Tags(ctx context.Context, repository string) ([]string, error) {
+	remoteRepository, err := remote.NewRepository(repository)
+	if err != nil {
+		return nil, err
+	}
+	remoteRepository.PlainHTTP = x.options.PlainHTTP
+	remoteRepository.Client = x.buildAuthClient()
+
+	tags := make([]string, 0)
+	err = remoteRepository.Tags(ctx, func(pageTags []string) error {
+		tags = append(tags, pageTags...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (x *RemoteArtifactStore) buildAuthClient() *auth.Client {
+	httpClient := &http.Client{}
+	if x.options.Insecure {
+		httpClient.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+	return &auth.Client{
+		Client: httpClient,
+		Cache:  auth.DefaultCache,
+		Credential: func(ctx context.Context, hostname string) (auth.Credential, error) {
+			if x.options.Username != "" || x.options.Password != "" {
+				return auth.Credential{Username: x.options.Username, Password: x.options.Password}, nil
+			}
+			// Fall back to the credentials of docker login, anonymous if there is not any
+			dockerClient, err := docker.NewClient()
+			if err != nil {
+				return auth.EmptyCredential, nil
+			}
+			username, secret, err := dockerClient.(*docker.Client).Credential(hostname)
+			if err != nil {
+				return auth.EmptyCredential, nil
+			}
+			if username == "" {
+				return auth.Credential{RefreshToken: secret}, nil
+			}
+			return auth.Credential{Username: username, Password: secret}, nil
+		},
+	}
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// LayoutArtifactStore The artifacts are in a local directory in the OCI image layout, it can be copied into a network without
+// a registry, and it is the registry stand-in of the tests
+type LayoutArtifactStore struct {
+	*content.OCI
+
+	lock sync.Mutex
+}
+
+var _ ArtifactStore = &LayoutArtifactStore{}
+
+func NewLayoutArtifactStore(directory string) (*LayoutArtifactStore, error) {
+	store, err := content.NewOCI(directory)
+	if err != nil {
+		return nil, err
+	}
+	return &LayoutArtifactStore{
+		OCI: store,
+	}, nil
+}
+
+// Resolve The layout only indexes the references by tag, a reference by digest is looked up in the manifests of the repository
+func (x *LayoutArtifactStore) Resolve(ctx context.Context, ref string) (string, ocispec.Descriptor, error) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	repository, manifestDigest, isDigestReference := strings.Cut(ref, "@")
+	if !isDigestReference {
+		return x.OCI.Resolve(ctx, ref)
+	}
+	if err := x.OCI.LoadIndex(); err != nil {
+		return "", ocispec.Descriptor{}, err
+	}
+	for name, desc := range x.OCI.ListReferences() {
+		if strings.HasPrefix(name, repository+":") && desc.Digest == digest.Digest(manifestDigest) {
+			return ref, desc, nil
+		}
+	}
+	// Like a registry, a manifest is still there after its tag is pushed again
+	info, err := x.OCI.Info(ctx, digest.Digest(manifestDigest))
+	if err != nil {
+		return "", ocispec.Descriptor{}, fmt.Errorf("reference %s not in store", ref)
+	}
+	return ref, ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    info.Digest,
+		Size:      info.Size,
+	}, nil
+}
+
+func (x *LayoutArtifactStore) Fetcher(ctx context.Context, ref string) (remotes.Fetcher, error) {
+	if _, _, err := x.Resolve(ctx, ref); err != nil {
+		return nil, err
+	}
+	return x.OCI, nil
+}
+
+func (x *LayoutArtifactStore) Tags(ctx context.Context, repository string) ([]string, error) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	if err := x.OCI.LoadIndex(); err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0)
+	for name := range x.OCI.ListReferences() {
+		reference, err := registry.ParseReference(name)
+		if err != nil {
+			continue
+		}
+		if reference.Registry+"/"+reference.Repository == repository {
+			tags = append(tags, reference.Reference)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,97 @@
+package oci
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseArtifactReference(t *testing.T) {
+	reference, err := ParseArtifactReference("oci://registry.example.com/selefra/rules-aws:v0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "registry.example.com", reference.Registry)
+	assert.Equal(t, "selefra/rules-aws", reference.Repository)
+	assert.Equal(t, "v0.0.1", reference.Reference)
+
+	reference, err = ParseArtifactReference("oci://localhost:5000/rules-aws")
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost:5000/rules-aws:latest", reference.String())
+
+	_, err = ParseArtifactReference("oci://registry.example.com")
+	assert.NotNil(t, err)
+}
+
+func TestPushAndPullModule(t *testing.T) {
+	store, err := NewLayoutArtifactStore(t.TempDir())
+	assert.Nil(t, err)
+
+	moduleDirectory := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(moduleDirectory, "s3"), os.ModePerm))
+	assert.Nil(t, os.WriteFile(filepath.Join(moduleDirectory, "s3", "rules.yaml"), []byte("rules: []\n"), 0644))
+
+	ctx := context.Background()
+	reference := "localhost:5000/selefra/rules-aws:v0.0.1"
+	pushed, err := PushModule(ctx, store, reference, moduleDirectory)
+	assert.Nil(t, err)
+	// Pushed again without change, it is the same artifact
+	pushedAgain, err := PushModule(ctx, store, reference, moduleDirectory)
+	assert.Nil(t, err)
+	assert.Equal(t, pushed.Digest, pushedAgain.Digest)
+
+	tags, err := store.Tags(ctx, "localhost:5000/selefra/rules-aws")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"v0.0.1"}, tags)
+
+	pullDirectory := filepath.Join(t.TempDir(), "pull")
+	manifestDigest, err := PullModule(ctx, store, reference, pullDirectory)
+	assert.Nil(t, err)
+	assert.Equal(t, pushed.Digest.String(), manifestDigest)
+	rulesBytes, err := os.ReadFile(filepath.Join(pullDirectory, ModuleArtifactDirectoryName, "s3", "rules.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, "rules: []\n", string(rulesBytes))
+
+	// By digest
+	_, err = PullModule(ctx, store, "localhost:5000/selefra/rules-aws@"+manifestDigest, pullDirectory)
+	assert.Nil(t, err)
+
+	_, err = PullModule(ctx, store, "localhost:5000/selefra/rules-aws:v0.0.2", pullDirectory)
+	assert.NotNil(t, err)
+}
+
+func TestPushAndPullProvider(t *testing.T) {
+	store, err := NewLayoutArtifactStore(t.TempDir())
+	assert.Nil(t, err)
+
+	executableDirectory := t.TempDir()
+	executables := make(map[string]string)
+	for _, platform := range []string{"linux_amd64", "darwin_arm64"} {
+		executablePath := filepath.Join(executableDirectory, platform, "selefra-provider-aws")
+		assert.Nil(t, os.MkdirAll(filepath.Dir(executablePath), os.ModePerm))
+		assert.Nil(t, os.WriteFile(executablePath, []byte(platform), 0755))
+		executables[platform] = executablePath
+	}
+
+	ctx := context.Background()
+	reference := "localhost:5000/selefra/providers/aws:v0.0.1"
+	_, err = PushProvider(ctx, store, reference, &ProviderArtifact{Name: "aws", PackageName: "selefra-provider-aws", Executables: executables})
+	assert.Nil(t, err)
+
+	_, manifest, err := FetchManifest(ctx, store, reference)
+	assert.Nil(t, err)
+	assert.Equal(t, "selefra-provider-aws", manifest.Annotations[AnnotationProviderPackageName])
+	assert.Len(t, manifest.Layers, 2)
+
+	pullDirectory := t.TempDir()
+	executablePath, err := PullProvider(ctx, store, reference, "darwin_arm64", pullDirectory)
+	assert.Nil(t, err)
+	executableBytes, err := os.ReadFile(executablePath)
+	assert.Nil(t, err)
+	assert.Equal(t, "darwin_arm64", string(executableBytes))
+	// The executables of the other platforms are not downloaded
+	assert.NoFileExists(t, filepath.Join(pullDirectory, "linux_amd64", "selefra-provider-aws"))
+
+	_, err = PullProvider(ctx, store, reference, "windows_amd64", pullDirectory)
+	assert.NotNil(t, err)
+}
@@ -5,12 +5,13 @@ import (
 	"context"
 	"github.com/containerd/containerd/remotes/docker"
 	"github.com/selefra/selefra-provider-sdk/provider/schema"
-	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/cli_env"
 	"github.com/selefra/selefra/pkg/message"
 	"github.com/selefra/selefra/pkg/utils"
 	"io"
 	"oras.land/oras-go/pkg/content"
 	"oras.land/oras-go/pkg/oras"
+	"oras.land/oras-go/pkg/registry"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -61,6 +62,9 @@ type PostgreSQLDownloaderOptions struct {
 	//// Used to receive notifications when downloading progress updates to track progress
 	//ProgressTracker ProgressTracker
 
+	// The image is copied from the mirror instead of ghcr.io when it is set, SELEFRA_MIRROR is used if empty
+	MirrorDirectory string
+
 	MessageChannel *message.Channel[*schema.Diagnostics]
 }
 
@@ -112,12 +116,16 @@ func (x *PostgreSQLInstaller) Run(ctx context.Context) bool {
 func (x *PostgreSQLInstaller) DownloadOCIImage(ctx context.Context) bool {
 
 	// postgresql oci file installation directory
-	imageDownloadURL := global.PkgBasePath + runtime.GOOS + global.PkgTag
+	imageDownloadURL := PostgreSQLImageReference(runtime.GOOS)
 
 	// ensure install directory exists
 	postgresqlDirectory := x.buildPgInstallDirectoryPath()
 	_ = os.MkdirAll(postgresqlDirectory, 0755)
 
+	if mirrorDirectory := x.getMirrorDirectory(); mirrorDirectory != "" {
+		return x.copyOCIImageFromMirror(ctx, mirrorDirectory, postgresqlDirectory)
+	}
+
 	x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("Download postgresql oci image from %s to %s ...", imageDownloadURL, postgresqlDirectory))
 
 	fileStore := content.NewFile(postgresqlDirectory)
@@ -133,6 +141,46 @@ func (x *PostgreSQLInstaller) DownloadOCIImage(ctx context.Context) bool {
 	return true
 }
 
+func (x *PostgreSQLInstaller) getMirrorDirectory() string {
+	if x.options.MirrorDirectory != "" {
+		return x.options.MirrorDirectory
+	}
+	return cli_env.GetMirrorDirectory()
+}
+
+// The image is pinned to the digest recorded in the metadata of the mirror, and every blob is checked against its digest
+// while it is copied, so a changed image in the mirror fails the installation
+func (x *PostgreSQLInstaller) copyOCIImageFromMirror(ctx context.Context, mirrorDirectory, postgresqlDirectory string) bool {
+
+	mirrorPostgreSQL, err := FindMirrorPostgreSQL(mirrorDirectory, runtime.GOOS)
+	if err != nil {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("OCI install postgresql failed: %s", err.Error()))
+		return false
+	}
+
+	x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("Copy postgresql oci image %s from mirror %s to %s ...", mirrorPostgreSQL.Reference, mirrorDirectory, postgresqlDirectory))
+
+	store, err := NewLayoutArtifactStore(filepath.Join(mirrorDirectory, PostgreSQLMirrorDirectoryName))
+	if err != nil {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("OCI install postgresql failed, open mirror %s error: %s", mirrorDirectory, err.Error()))
+		return false
+	}
+	reference, err := registry.ParseReference(mirrorPostgreSQL.Reference)
+	if err != nil {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("OCI install postgresql failed, image reference %s in mirror is not valid: %s", mirrorPostgreSQL.Reference, err.Error()))
+		return false
+	}
+	pinnedReference := reference.Registry + "/" + reference.Repository + "@" + mirrorPostgreSQL.Digest
+	if _, err := oras.Copy(ctx, store, pinnedReference, content.NewFile(postgresqlDirectory), postgresqlDirectory); err != nil {
+		x.options.MessageChannel.Send(schema.NewDiagnostics().AddErrorMsg("OCI install postgresql failed, copy OCI image %s from mirror error: %s", pinnedReference, err.Error()))
+		return false
+	}
+
+	x.options.MessageChannel.Send(schema.NewDiagnostics().AddInfo("Copy postgresql OCI image from mirror success"))
+
+	return true
+}
+
 // IsInstalled Check whether postgresql is installed
 func (x *PostgreSQLInstaller) IsInstalled() bool {
 	// If the executable exists, it is considered installed
@@ -0,0 +1,77 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/selefra/selefra/global"
+	"github.com/selefra/selefra/pkg/utils"
+	"oras.land/oras-go/pkg/oras"
+	"path/filepath"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+const (
+
+	// MirrorFileName The metadata of a mirror created by selefra mirror create, the images of PostgreSQL are pinned in it
+	MirrorFileName = "mirror.yaml"
+
+	// PostgreSQLMirrorDirectoryName The images of the built-in PostgreSQL are in the OCI image layout in this directory of the mirror
+	PostgreSQLMirrorDirectoryName = "postgresql"
+)
+
+// MirrorPostgreSQL The image of the built-in PostgreSQL of an operating system in the mirror
+type MirrorPostgreSQL struct {
+	OS string `json:"os" yaml:"os"`
+
+	// The reference of the image such as ghcr.io/selefra/postgre_linux:latest
+	Reference string `json:"reference" yaml:"reference"`
+
+	// The digest of the manifest of the image
+	Digest string `json:"digest" yaml:"digest"`
+}
+
+// PostgreSQLImageReference The reference of the image of the built-in PostgreSQL of the operating system
+func PostgreSQLImageReference(goos string) string {
+	return global.PkgBasePath + goos + global.PkgTag
+}
+
+// MirrorPostgreSQLImage Copy the image of the built-in PostgreSQL of the operating system from ghcr.io into the OCI image
+// layout of the mirror
+func MirrorPostgreSQLImage(ctx context.Context, mirrorDirectory, goos string) (*MirrorPostgreSQL, error) {
+	store, err := NewLayoutArtifactStore(filepath.Join(mirrorDirectory, PostgreSQLMirrorDirectoryName))
+	if err != nil {
+		return nil, err
+	}
+	reference := PostgreSQLImageReference(goos)
+	manifest, err := oras.Copy(ctx, docker.NewResolver(docker.ResolverOptions{}), reference, store, reference)
+	if err != nil {
+		return nil, fmt.Errorf("copy postgresql image %s to mirror %s error: %s", reference, mirrorDirectory, err.Error())
+	}
+	return &MirrorPostgreSQL{
+		OS:        goos,
+		Reference: reference,
+		Digest:    manifest.Digest.String(),
+	}, nil
+}
+
+// FindMirrorPostgreSQL The image of the operating system recorded in the metadata of the mirror, only the images are read here,
+// the rest of the metadata is up to the registry package
+func FindMirrorPostgreSQL(mirrorDirectory, goos string) (*MirrorPostgreSQL, error) {
+	type mirrorMetadata struct {
+		PostgreSQL []*MirrorPostgreSQL `yaml:"postgresql"`
+	}
+	metadata, err := utils.ReadYamlFile[*mirrorMetadata](filepath.Join(mirrorDirectory, MirrorFileName))
+	if err != nil {
+		return nil, fmt.Errorf("read mirror metadata in %s error: %s", mirrorDirectory, err.Error())
+	}
+	for _, postgresql := range metadata.PostgreSQL {
+		if postgresql.OS == goos {
+			return postgresql, nil
+		}
+	}
+	return nil, fmt.Errorf("postgresql image of %s is not in mirror %s", goos, mirrorDirectory)
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,56 @@
+package oci
+
+import (
+	"context"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/message"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPostgreSQLInstaller_DownloadOCIImageFromMirror(t *testing.T) {
+	mirrorDirectory := t.TempDir()
+	store, err := NewLayoutArtifactStore(filepath.Join(mirrorDirectory, PostgreSQLMirrorDirectoryName))
+	assert.Nil(t, err)
+
+	// Any artifact stands in for the image here
+	imageDirectory := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(imageDirectory, "pgsql", "bin"), os.ModePerm))
+	assert.Nil(t, os.WriteFile(filepath.Join(imageDirectory, "pgsql", "bin", "pg_ctl"), []byte("#!/bin/sh\n"), 0755))
+	ctx := context.Background()
+	reference := PostgreSQLImageReference(runtime.GOOS)
+	pushed, err := PushModule(ctx, store, reference, imageDirectory)
+	assert.Nil(t, err)
+
+	writeMirror := func(digest string) {
+		out, err := yaml.Marshal(map[string]any{
+			"postgresql": []*MirrorPostgreSQL{{OS: runtime.GOOS, Reference: reference, Digest: digest}},
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, os.WriteFile(filepath.Join(mirrorDirectory, MirrorFileName), out, 0644))
+	}
+	download := func() bool {
+		messageChannel := message.NewChannel[*schema.Diagnostics](func(index int, message *schema.Diagnostics) {})
+		defer func() {
+			messageChannel.SenderWaitAndClose()
+			messageChannel.ReceiverWait()
+		}()
+		return NewPostgreSQLDownloader(&PostgreSQLDownloaderOptions{
+			DownloadDirectory: t.TempDir(),
+			MirrorDirectory:   mirrorDirectory,
+			MessageChannel:    messageChannel,
+		}).DownloadOCIImage(ctx)
+	}
+
+	writeMirror(pushed.Digest.String())
+	assert.True(t, download())
+
+	// The image is pinned to the digest in mirror.yaml
+	writeMirror("sha256:" + strings.Repeat("0", 64))
+	assert.False(t, download())
+}
@@ -0,0 +1,146 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/go-plugin"
+	"github.com/selefra/selefra-provider-sdk/grpc/shard"
+	"github.com/selefra/selefra/pkg/cli_env"
+	"net"
+	"strings"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// DevOverride The provider is not started from the installed executable but attached to a running process or started from
+// a local executable, so that the author of the provider can debug it while running selefra fetch or apply. It is set by
+// SELEFRA_REATTACH_PROVIDERS, for example:
+//
+//	{
+//	  "aws": {"protocol": "grpc", "protocol_version": 1, "pid": 4242, "test": true, "addr": {"network": "unix", "string": "/tmp/plugin1234"}},
+//	  "gcp": "/home/dev/selefra-provider-gcp/selefra-provider-gcp"
+//	}
+type DevOverride struct {
+	ProviderName string
+
+	// Attach to the provider process of this config, it is printed by the provider when served in test mode
+	ReattachConfig *plugin.ReattachConfig
+
+	// Or start the provider from this executable
+	ExecutableFilePath string
+}
+
+// NewPlugin Start or attach to the provider in place of the installed one
+func (x *DevOverride) NewPlugin(providerVersion string) (Plugin, error) {
+	if x.ReattachConfig != nil {
+		return NewUnmanagedPlugin("", x.ProviderName, providerVersion, x.ReattachConfig)
+	}
+	return NewManagedPlugin(x.ExecutableFilePath, x.ProviderName, providerVersion, "", nil)
+}
+
+// String Where the provider is taken from, for messages
+func (x *DevOverride) String() string {
+	if x.ReattachConfig != nil {
+		return fmt.Sprintf("process %d at %s", x.ReattachConfig.Pid, x.ReattachConfig.Addr.String())
+	}
+	return x.ExecutableFilePath
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// GetDevOverride The override of the provider set by SELEFRA_REATTACH_PROVIDERS, nil if the provider is not overridden
+func GetDevOverride(providerName string) (*DevOverride, error) {
+	devOverrides, err := ParseDevOverrides(cli_env.GetReattachProviders())
+	if err != nil {
+		return nil, err
+	}
+	return devOverrides[providerName], nil
+}
+
+// The reattach config in JSON, the same as go-plugin prints when a plugin is served in test mode
+type reattachConfigJson struct {
+	Protocol        string `json:"protocol"`
+	ProtocolVersion int    `json:"protocol_version"`
+	Pid             int    `json:"pid"`
+	Test            bool   `json:"test"`
+	Addr            struct {
+		Network string `json:"network"`
+		String  string `json:"string"`
+	} `json:"addr"`
+}
+
+// ParseDevOverrides Parse the value of SELEFRA_REATTACH_PROVIDERS, <provider name, override>
+func ParseDevOverrides(s string) (map[string]*DevOverride, error) {
+	devOverrides := make(map[string]*DevOverride)
+	if strings.TrimSpace(s) == "" {
+		return devOverrides, nil
+	}
+
+	rawOverrides := make(map[string]json.RawMessage)
+	if err := json.Unmarshal([]byte(s), &rawOverrides); err != nil {
+		return nil, fmt.Errorf("parse %s error: %s", cli_env.SelefraReattachProviders, err.Error())
+	}
+	for providerName, rawOverride := range rawOverrides {
+		devOverride := &DevOverride{
+			ProviderName: providerName,
+		}
+
+		// A string is the path of the executable
+		if err := json.Unmarshal(rawOverride, &devOverride.ExecutableFilePath); err == nil {
+			if devOverride.ExecutableFilePath == "" {
+				return nil, fmt.Errorf("parse %s error: the executable of provider %s is empty", cli_env.SelefraReattachProviders, providerName)
+			}
+			devOverrides[providerName] = devOverride
+			continue
+		}
+
+		reattachConfig := &reattachConfigJson{}
+		if err := json.Unmarshal(rawOverride, reattachConfig); err != nil {
+			return nil, fmt.Errorf("parse %s error: provider %s must be an executable path or a reattach config: %s", cli_env.SelefraReattachProviders, providerName, err.Error())
+		}
+		config, err := reattachConfig.toReattachConfig()
+		if err != nil {
+			return nil, fmt.Errorf("parse %s error: provider %s %s", cli_env.SelefraReattachProviders, providerName, err.Error())
+		}
+		devOverride.ReattachConfig = config
+		devOverrides[providerName] = devOverride
+	}
+	return devOverrides, nil
+}
+
+func (x *reattachConfigJson) toReattachConfig() (*plugin.ReattachConfig, error) {
+	var addr net.Addr
+	var err error
+	switch x.Addr.Network {
+	case "unix":
+		addr, err = net.ResolveUnixAddr("unix", x.Addr.String)
+	case "tcp":
+		addr, err = net.ResolveTCPAddr("tcp", x.Addr.String)
+	default:
+		return nil, fmt.Errorf("unsupported address network %q, only unix and tcp are supported", x.Addr.Network)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolve address %s error: %s", x.Addr.String, err.Error())
+	}
+
+	protocol := plugin.Protocol(x.Protocol)
+	if protocol == "" {
+		protocol = plugin.ProtocolGRPC
+	}
+	if protocol != plugin.ProtocolGRPC {
+		return nil, fmt.Errorf("unsupported protocol %q, only grpc is supported", x.Protocol)
+	}
+	protocolVersion := x.ProtocolVersion
+	if protocolVersion == 0 {
+		protocolVersion = shard.V1
+	}
+	return &plugin.ReattachConfig{
+		Protocol:        protocol,
+		ProtocolVersion: protocolVersion,
+		Addr:            addr,
+		Pid:             x.Pid,
+		Test:            x.Test,
+	}, nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,57 @@
+package plugin
+
+import (
+	"github.com/hashicorp/go-plugin"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseDevOverrides(t *testing.T) {
+	devOverrides, err := ParseDevOverrides(`{
+		"aws": {"protocol": "grpc", "protocol_version": 1, "pid": 4242, "test": true, "addr": {"network": "unix", "string": "/tmp/plugin1234"}},
+		"k8s": {"pid": 4343, "addr": {"network": "tcp", "string": "127.0.0.1:10000"}},
+		"gcp": "/home/dev/selefra-provider-gcp/selefra-provider-gcp"
+	}`)
+	assert.Nil(t, err)
+	assert.Len(t, devOverrides, 3)
+
+	aws := devOverrides["aws"]
+	assert.Equal(t, plugin.ProtocolGRPC, aws.ReattachConfig.Protocol)
+	assert.Equal(t, 4242, aws.ReattachConfig.Pid)
+	assert.True(t, aws.ReattachConfig.Test)
+	assert.Equal(t, "unix", aws.ReattachConfig.Addr.Network())
+	assert.Equal(t, "/tmp/plugin1234", aws.ReattachConfig.Addr.String())
+	assert.Equal(t, "process 4242 at /tmp/plugin1234", aws.String())
+
+	k8s := devOverrides["k8s"]
+	assert.Equal(t, plugin.ProtocolGRPC, k8s.ReattachConfig.Protocol)
+	assert.Equal(t, 1, k8s.ReattachConfig.ProtocolVersion)
+	assert.Equal(t, "127.0.0.1:10000", k8s.ReattachConfig.Addr.String())
+
+	gcp := devOverrides["gcp"]
+	assert.Nil(t, gcp.ReattachConfig)
+	assert.Equal(t, "/home/dev/selefra-provider-gcp/selefra-provider-gcp", gcp.ExecutableFilePath)
+
+	devOverrides, err = ParseDevOverrides("")
+	assert.Nil(t, err)
+	assert.Len(t, devOverrides, 0)
+
+	_, err = ParseDevOverrides(`{"aws": {"protocol": "netrpc", "addr": {"network": "unix", "string": "/tmp/plugin1234"}}}`)
+	assert.NotNil(t, err)
+	_, err = ParseDevOverrides(`{"aws": {"addr": {"network": "udp", "string": "127.0.0.1:10000"}}}`)
+	assert.NotNil(t, err)
+	_, err = ParseDevOverrides(`{"aws": ""}`)
+	assert.NotNil(t, err)
+	_, err = ParseDevOverrides(`aws=/tmp/plugin`)
+	assert.NotNil(t, err)
+}
+
+func TestGetDevOverride(t *testing.T) {
+	t.Setenv("SELEFRA_REATTACH_PROVIDERS", `{"gcp": "/tmp/selefra-provider-gcp"}`)
+	devOverride, err := GetDevOverride("gcp")
+	assert.Nil(t, err)
+	assert.Equal(t, "/tmp/selefra-provider-gcp", devOverride.ExecutableFilePath)
+	devOverride, err = GetDevOverride("aws")
+	assert.Nil(t, err)
+	assert.Nil(t, devOverride)
+}
@@ -82,7 +82,8 @@ func (x *LocalProvidersManager) InstallProvider(ctx context.Context, options *In
 		ExecutableFilePath: providerExecuteFilePath,
 		Checksum:           "",
 		InstallTime:        time.Now(),
-		Source:             LocalProviderSourceGitHubRegistry,
+		Source:             x.localProviderSource(),
+		SourceContext:      x.registrySetting,
 	}
 	marshal, err := json.Marshal(localProvider)
 	if err != nil {
@@ -13,6 +13,9 @@ const (
 	LocalProviderSourceUnknown LocalProviderSource = iota
 	LocalProviderSourceGitHubRegistry
 	LocalProviderSourceLocalRegistry
+	LocalProviderSourceHttpRegistry
+	LocalProviderSourceOCIRegistry
+	LocalProviderSourceMirrorRegistry
 )
 
 // ------------------------------------------------- --------------------------------------------------------------------
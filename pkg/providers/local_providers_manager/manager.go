@@ -25,25 +25,52 @@ type LocalProvidersManager struct {
 	// selefra Specifies the storage path of the downloaded file
 	downloadWorkspace string
 
+	// The registry setting the providers are installed from, empty means the official registry
+	registrySetting string
+
 	// The provider registry is used to update the provider from the remote end
 	providerRegistry registry.ProviderRegistry
 }
 
-func NewLocalProvidersManager(downloadWorkspace string) (*LocalProvidersManager, error) {
+// NewLocalProvidersManager The providers are installed from the registry of the given setting, such as the registry of the
+// selefra block or of a required provider, the official registry on GitHub is used if it is not given
+func NewLocalProvidersManager(downloadWorkspace string, registrySetting ...string) (*LocalProvidersManager, error) {
+
+	if len(registrySetting) == 0 {
+		registrySetting = append(registrySetting, "")
+	}
 
 	// init provider registry
-	options := registry.NewProviderGithubRegistryOptions(downloadWorkspace)
-	providerRegistry, err := registry.NewProviderGithubRegistry(options)
+	providerRegistry, err := registry.NewProviderRegistry(downloadWorkspace, registrySetting[0])
 	if err != nil {
 		return nil, err
 	}
 
 	return &LocalProvidersManager{
 		downloadWorkspace: downloadWorkspace,
+		registrySetting:   registrySetting[0],
 		providerRegistry:  providerRegistry,
 	}, nil
 }
 
+// Where the providers installed by this manager are obtained from
+func (x *LocalProvidersManager) localProviderSource() LocalProviderSource {
+	switch x.providerRegistry.(type) {
+	case *registry.ProviderGithubRegistry:
+		return LocalProviderSourceGitHubRegistry
+	case *registry.ProviderLocalRegistry:
+		return LocalProviderSourceLocalRegistry
+	case *registry.ProviderHttpRegistry:
+		return LocalProviderSourceHttpRegistry
+	case *registry.ProviderOCIRegistry:
+		return LocalProviderSourceOCIRegistry
+	case *registry.ProviderMirrorRegistry:
+		return LocalProviderSourceMirrorRegistry
+	default:
+		return LocalProviderSourceUnknown
+	}
+}
+
 // ------------------------------------------------- --------------------------------------------------------------------
 
 func (x *LocalProvidersManager) buildLocalProvidersPath() string {
@@ -1,17 +1,42 @@
 package local_providers_manager
 
-//import (
-//	"github.com/selefra/selefra/global"
-//	"testing"
-//)
-//
-//func TestSync(t *testing.T) {
-//	*global.WORKSPACE = "../../tests/workspace/offline"
-//	errLogs, _, err := Sync()
-//	if err != nil {
-//		t.Error(err)
-//	}
-//	if len(errLogs) != 0 {
-//		t.Error(errLogs)
-//	}
-//}
+import (
+	"context"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestLocalProvidersManager_Sync(t *testing.T) {
+	manager, err := NewLocalProvidersManager(t.TempDir())
+	assert.Nil(t, err)
+	for _, nameAndVersion := range [][]string{{"aws", "v0.0.1"}, {"aws", "v0.0.2"}, {"gcp", "v0.0.1"}} {
+		assert.Nil(t, os.MkdirAll(manager.buildLocalProviderVersionPath(nameAndVersion[0], nameAndVersion[1]), os.ModePerm))
+		assert.Nil(t, utils.WriteJsonFile(manager.buildLocalProviderVersionMetaFilePath(nameAndVersion[0], nameAndVersion[1]), NewLocalProvider(nameAndVersion[0], nameAndVersion[1])))
+	}
+	options := &SyncProvidersOptions{
+		UsedProviders: []*LocalProvider{NewLocalProvider("aws", "v0.0.2")},
+	}
+
+	// case 1: The unused versions are only reported
+	unusedProviders, d := manager.Sync(context.Background(), options)
+	assert.False(t, utils.HasError(d))
+	assert.Len(t, unusedProviders, 2)
+	assert.Equal(t, "aws@v0.0.1", unusedProviders[0].String())
+	assert.Equal(t, "gcp@v0.0.1", unusedProviders[1].String())
+	assert.True(t, utils.Exists(manager.buildLocalProviderVersionPath("aws", "v0.0.1")))
+
+	// case 2: The unused versions are removed with prune
+	options.Prune = true
+	unusedProviders, d = manager.Sync(context.Background(), options)
+	assert.False(t, utils.HasError(d))
+	assert.Len(t, unusedProviders, 2)
+	assert.False(t, utils.Exists(manager.buildLocalProviderVersionPath("aws", "v0.0.1")))
+	assert.False(t, utils.Exists(manager.buildLocalProviderPath("gcp")))
+	assert.True(t, utils.Exists(manager.buildLocalProviderVersionPath("aws", "v0.0.2")))
+
+	unusedProviders, d = manager.Sync(context.Background(), options)
+	assert.False(t, utils.HasError(d))
+	assert.Len(t, unusedProviders, 0)
+}
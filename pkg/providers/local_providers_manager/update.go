@@ -1,77 +1,34 @@
 package local_providers_manager
 
-//import (
-//	"context"
-//	"github.com/selefra/selefra-provider-sdk/provider/schema"
-//)
-//
-//type UpgradeOptions struct {
-//}
-//
-//func (x *LocalProvidersManager) Upgrade(ctx context.Context, providerNameSlice []string, messageChannel chan *schema.Diagnostics) {
-//
-//	defer func() {
-//		close(messageChannel)
-//	}()
-//
-//}
+import (
+	"github.com/hashicorp/go-version"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra/pkg/utils"
+)
 
-//func Upgrade(ctx context.Context, providerNameSlice []string, messageChannel chan *schema.Diagnostics) {
-//
-//	defer func() {
-//		close(messageChannel)
-//	}()
-//
-//	diagnostics := schema.NewDiagnostics()
-//
-//	err := config.IsSelefraWorkspace()
-//	if err != nil {
-//		messageChannel <- diagnostics.AddErrorMsg(err.Error())
-//		return
-//	}
-//
-//	var cof = &config.SelefraBlock{}
-//	err = cof.Get()
-//	if err != nil {
-//		messageChannel <- diagnostics.AddErrorMsg(err.Error())
-//		return
-//	}
-//
-//	providerNameMap := make(map[string]struct{})
-//	for _, providerName := range providerNameSlice {
-//		providerNameMap[providerName] = struct{}{}
-//	}
-//
-//	provider := registry.NewProviderGithubRegistry(x.downloadWorkspace)
-//	for _, p := range cof.Selefra.Providers {
-//		prov := registry.ProviderBinary{
-//			Provider: &registry.Provider{
-//				Name:    p.Name,
-//				Version: p.Version,
-//				Source:  "",
-//			},
-//			FilePath: p.Path,
-//		}
-//		if len(providerNameSlice) != 0 && !providerNameMap[p.Name] {
-//			break
-//		}
-//
-//		pp, err := provider.CheckUpdate(ctx, prov)
-//		if err != nil {
-//			return err
-//		}
-//		p.Path = pp.Filepath
-//		p.Version = pp.Version
-//		confs, err := tools.GetProviders(cof, p.Name)
-//		if err != nil {
-//			return err
-//		}
-//		for _, c := range confs {
-//			err = fetch.Fetch(ctx, cof, p, c)
-//			if err != nil {
-//				return err
-//			}
-//		}
-//	}
-//	return nil
-//}
+// LatestInstalledVersion The newest installed version of the provider, nil if no version of the provider is installed, it is
+// what the provider is updated from when the project does not lock the provider
+func (x *LocalProvidersManager) LatestInstalledVersion(providerName string) (*LocalProvider, *schema.Diagnostics) {
+
+	if !utils.Exists(x.buildLocalProviderPath(providerName)) {
+		return nil, nil
+	}
+
+	versions, diagnostics := x.ListProviderVersions(providerName)
+	if utils.HasError(diagnostics) {
+		return nil, diagnostics
+	}
+
+	var latestProvider *LocalProvider
+	var latestVersion *version.Version
+	for providerVersion, localProvider := range versions.ProviderVersionMap {
+		v, err := version.NewVersion(providerVersion)
+		if err != nil {
+			continue
+		}
+		if latestVersion == nil || v.GreaterThan(latestVersion) {
+			latestProvider, latestVersion = localProvider, v
+		}
+	}
+	return latestProvider, diagnostics
+}
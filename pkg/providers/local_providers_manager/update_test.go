@@ -1,18 +1,25 @@
 package local_providers_manager
 
-//import (
-//	"context"
-//	"github.com/selefra/selefra/cmd/provider"
-//	"github.com/selefra/selefra/global"
-//	"testing"
-//)
-//
-//func TestUpdate(t *testing.T) {
-//	*global.WORKSPACE = "../../tests/workspace/offline"
-//	ctx := context.Background()
-//	arg := []string{"aws"}
-//	err := provider.Upgrade(ctx, arg)
-//	if err != nil {
-//		t.Error(err)
-//	}
-//}
+import (
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestLocalProvidersManager_LatestInstalledVersion(t *testing.T) {
+	manager, err := NewLocalProvidersManager(t.TempDir())
+	assert.Nil(t, err)
+
+	localProvider, d := manager.LatestInstalledVersion("aws")
+	assert.False(t, utils.HasError(d))
+	assert.Nil(t, localProvider)
+
+	for _, providerVersion := range []string{"v0.0.9", "v0.0.10", "v0.0.2"} {
+		assert.Nil(t, os.MkdirAll(manager.buildLocalProviderVersionPath("aws", providerVersion), os.ModePerm))
+		assert.Nil(t, utils.WriteJsonFile(manager.buildLocalProviderVersionMetaFilePath("aws", providerVersion), NewLocalProvider("aws", providerVersion)))
+	}
+	localProvider, d = manager.LatestInstalledVersion("aws")
+	assert.False(t, utils.HasError(d))
+	assert.Equal(t, "v0.0.10", localProvider.Version)
+}
@@ -0,0 +1,177 @@
+package registry
+
+import (
+	"fmt"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
+	"github.com/selefra/selefra/pkg/oci"
+	"github.com/selefra/selefra/pkg/utils"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+const (
+
+	// MirrorFileName Describes what is in the mirror and the checksums of its files, a directory with it is a mirror
+	MirrorFileName = oci.MirrorFileName
+
+	// MirrorPostgreSQLDirectoryName The images of the built-in PostgreSQL are in the OCI image layout in this directory
+	MirrorPostgreSQLDirectoryName = oci.PostgreSQLMirrorDirectoryName
+)
+
+// MirrorMetadata The content of mirror.yaml. A mirror has the layout of the registry repository, so the providers and the
+// modules are served by the mirror registries, and the packages are verified against the checksums recorded here:
+//
+//	mirror.yaml
+//	provider/index.yaml
+//	provider/aws/metadata.yaml
+//	provider/aws/v0.0.1/supplement.yaml
+//	provider/aws/v0.0.1/selefra-provider-aws_0.0.1_linux_amd64.tar.gz
+//	module/rules-aws/metadata.yaml
+//	module/rules-aws/v0.0.1/supplement.yaml
+//	module/rules-aws/v0.0.1/rules-aws.zip
+//	postgresql/index.json
+type MirrorMetadata struct {
+	UpdateTime time.Time `json:"update-time" yaml:"update-time"`
+
+	Providers []*MirrorArtifact `json:"providers,omitempty" yaml:"providers,omitempty"`
+
+	Modules []*MirrorArtifact `json:"modules,omitempty" yaml:"modules,omitempty"`
+
+	PostgreSQL []*oci.MirrorPostgreSQL `json:"postgresql,omitempty" yaml:"postgresql,omitempty"`
+}
+
+// MirrorArtifact A version of a provider or a module in the mirror
+type MirrorArtifact struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+
+	// Where the artifact is mirrored from, such as the release of the provider
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+
+	Files []*MirrorFile `json:"files" yaml:"files"`
+}
+
+// MirrorFile A package in the mirror
+type MirrorFile struct {
+
+	// The path relative to the mirror directory with slashes, such as provider/aws/v0.0.1/selefra-provider-aws_0.0.1_linux_amd64.tar.gz
+	Path string `json:"path" yaml:"path"`
+
+	// The platform of a provider package, such as linux_amd64
+	Platform string `json:"platform,omitempty" yaml:"platform,omitempty"`
+
+	Sha256 string `json:"sha256" yaml:"sha256"`
+}
+
+// IsMirrorDirectory Whether the directory is a mirror created by selefra mirror create
+func IsMirrorDirectory(directory string) bool {
+	return utils.ExistsFile(filepath.Join(directory, MirrorFileName))
+}
+
+// ReadMirrorMetadata Read the metadata of the mirror in the directory
+func ReadMirrorMetadata(mirrorDirectory string) (*MirrorMetadata, error) {
+	metadata, err := utils.ReadYamlFile[*MirrorMetadata](filepath.Join(mirrorDirectory, MirrorFileName))
+	if err != nil {
+		return nil, fmt.Errorf("read mirror metadata in %s error: %s", mirrorDirectory, err.Error())
+	}
+	return metadata, nil
+}
+
+// Save Write the metadata to mirror.yaml of the directory, the artifacts are sorted so the file is stable
+func (x *MirrorMetadata) Save(mirrorDirectory string) error {
+	for _, artifacts := range [][]*MirrorArtifact{x.Providers, x.Modules} {
+		sort.Slice(artifacts, func(i, j int) bool {
+			if artifacts[i].Name != artifacts[j].Name {
+				return artifacts[i].Name < artifacts[j].Name
+			}
+			return artifacts[i].Version < artifacts[j].Version
+		})
+	}
+	sort.Slice(x.PostgreSQL, func(i, j int) bool {
+		return x.PostgreSQL[i].OS < x.PostgreSQL[j].OS
+	})
+	out, err := yaml.Marshal(x)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(mirrorDirectory, MirrorFileName), out, 0644)
+}
+
+// GetProvider The provider version in the mirror, nil if it is not mirrored
+func (x *MirrorMetadata) GetProvider(name, version string) *MirrorArtifact {
+	return findMirrorArtifact(x.Providers, name, version)
+}
+
+// GetModule The module version in the mirror, nil if it is not mirrored
+func (x *MirrorMetadata) GetModule(name, version string) *MirrorArtifact {
+	return findMirrorArtifact(x.Modules, name, version)
+}
+
+// SetProvider Add the provider version to the mirror, replacing the one with the same name and version
+func (x *MirrorMetadata) SetProvider(artifact *MirrorArtifact) {
+	x.Providers = setMirrorArtifact(x.Providers, artifact)
+}
+
+// SetModule Add the module version to the mirror, replacing the one with the same name and version
+func (x *MirrorMetadata) SetModule(artifact *MirrorArtifact) {
+	x.Modules = setMirrorArtifact(x.Modules, artifact)
+}
+
+// SetPostgreSQL Add the image of the operating system to the mirror, replacing the one of the same operating system
+func (x *MirrorMetadata) SetPostgreSQL(postgresql *oci.MirrorPostgreSQL) {
+	for index, p := range x.PostgreSQL {
+		if p.OS == postgresql.OS {
+			x.PostgreSQL[index] = postgresql
+			return
+		}
+	}
+	x.PostgreSQL = append(x.PostgreSQL, postgresql)
+}
+
+func findMirrorArtifact(artifacts []*MirrorArtifact, name, version string) *MirrorArtifact {
+	for _, artifact := range artifacts {
+		if artifact.Name == name && artifact.Version == version {
+			return artifact
+		}
+	}
+	return nil
+}
+
+func setMirrorArtifact(artifacts []*MirrorArtifact, artifact *MirrorArtifact) []*MirrorArtifact {
+	for index, a := range artifacts {
+		if a.Name == artifact.Name && a.Version == artifact.Version {
+			artifacts[index] = artifact
+			return artifacts
+		}
+	}
+	return append(artifacts, artifact)
+}
+
+// GetFile The file of the artifact in the given path, nil if it is not in the artifact
+func (x *MirrorArtifact) GetFile(path string) *MirrorFile {
+	for _, file := range x.Files {
+		if file.Path == path {
+			return file
+		}
+	}
+	return nil
+}
+
+// VerifyMirrorFile Check the file in the mirror against the checksum recorded in the metadata of the mirror
+func VerifyMirrorFile(mirrorDirectory string, file *MirrorFile) error {
+	checksum, err := lock_file.FileChecksum(filepath.Join(mirrorDirectory, filepath.FromSlash(file.Path)))
+	if err != nil {
+		return err
+	}
+	if checksum != lock_file.ChecksumPrefix+file.Sha256 {
+		return fmt.Errorf("the checksum of %s in mirror %s is %s, but sha256:%s is recorded in %s", file.Path, mirrorDirectory, checksum, file.Sha256, MirrorFileName)
+	}
+	return nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,383 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"github.com/selefra/selefra/pkg/http_client"
+	"github.com/selefra/selefra/pkg/modules/lock_file"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/selefra/selefra/pkg/version"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// The registries whose provider packages can be copied into a mirror tell where the packages of a version are, it is the
+// url prefix or the directory the package name is appended to
+type providerPackageLocator interface {
+	providerPackageLocation(provider *Provider, supplement *ProviderSupplement) (string, error)
+}
+
+// The registries whose module packages can be copied into a mirror
+type modulePackageLocator interface {
+	modulePackageLocation(module *Module, supplement *ModuleSupplement) (string, error)
+}
+
+// DownloadProviderPackage Download the package of the provider for the platform such as linux_amd64 to the file as it is,
+// the package is verified against the checksum of the platform in the supplement
+func DownloadProviderPackage(ctx context.Context, registry ProviderRegistry, provider *Provider, supplement *ProviderSupplement, platform, saveFile string) error {
+	locator, ok := registry.(providerPackageLocator)
+	if !ok {
+		return fmt.Errorf("the packages of provider %s can not be downloaded from registry %T", provider.String(), registry)
+	}
+	checksum, exists := supplement.Checksums.platformChecksums()[platform]
+	if !exists {
+		return fmt.Errorf("provider %s has no package for platform %s", provider.String(), platform)
+	}
+	location, err := locator.providerPackageLocation(provider, supplement)
+	if err != nil {
+		return err
+	}
+	packageUrl := location + buildProviderPlatformAssetName(provider, supplement, platform) + "?archive=false&checksum=sha256:" + checksum
+	return http_client.DownloadToFile(ctx, saveFile, packageUrl, nil)
+}
+
+// DownloadModulePackage Download the zip package of the module to the file as it is
+func DownloadModulePackage(ctx context.Context, registry ModuleRegistry, module *Module, supplement *ModuleSupplement, saveFile string) error {
+	locator, ok := registry.(modulePackageLocator)
+	if !ok {
+		return fmt.Errorf("the package of module %s can not be downloaded from registry %T", module.String(), registry)
+	}
+	location, err := locator.modulePackageLocation(module, supplement)
+	if err != nil {
+		return err
+	}
+	return http_client.DownloadToFile(ctx, saveFile, location+buildModulePackageName(supplement)+"?archive=false", nil)
+}
+
+func buildModulePackageName(supplement *ModuleSupplement) string {
+	return supplement.PackageName + ".zip"
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ProviderMirrorRegistry The providers in a mirror created by selefra mirror create, nothing is downloaded from the internet,
+// and a package is verified against the checksum in mirror.yaml before it is installed
+type ProviderMirrorRegistry struct {
+	*ProviderLocalRegistry
+
+	mirrorDirectory string
+	metadata        *MirrorMetadata
+}
+
+var _ ProviderRegistry = &ProviderMirrorRegistry{}
+
+func NewProviderMirrorRegistry(mirrorDirectory string) (*ProviderMirrorRegistry, error) {
+	metadata, err := ReadMirrorMetadata(mirrorDirectory)
+	if err != nil {
+		return nil, err
+	}
+	localRegistry, err := NewProviderLocalRegistry(mirrorDirectory)
+	if err != nil {
+		return nil, err
+	}
+	return &ProviderMirrorRegistry{
+		ProviderLocalRegistry: localRegistry,
+		mirrorDirectory:       mirrorDirectory,
+		metadata:              metadata,
+	}, nil
+}
+
+func (x *ProviderMirrorRegistry) Download(ctx context.Context, provider *Provider, options *ProviderRegistryDownloadOptions) (string, error) {
+	if provider.IsLatestVersion() {
+		latestProvider, err := x.GetLatestVersion(ctx, provider)
+		if err != nil {
+			return "", err
+		}
+		provider.Version = latestProvider.Version
+	}
+	supplement, err := x.GetSupplement(ctx, provider)
+	if err != nil {
+		return "", err
+	}
+
+	artifact := x.metadata.GetProvider(provider.Name, provider.Version)
+	if artifact == nil {
+		return "", fmt.Errorf("provider %s is not in mirror %s", provider.String(), x.mirrorDirectory)
+	}
+	assetName := buildProviderAssetName(provider, supplement)
+	file := artifact.GetFile(path.Join(ProvidersListDirectoryName, provider.Name, provider.Version, assetName))
+	if file == nil {
+		return "", fmt.Errorf("package %s of provider %s is not in mirror %s", assetName, provider.String(), x.mirrorDirectory)
+	}
+	if err := VerifyMirrorFile(x.mirrorDirectory, file); err != nil {
+		return "", err
+	}
+
+	versionDirectory, err := x.buildProviderVersionDirectory(provider)
+	if err != nil {
+		return "", err
+	}
+	return downloadProviderAsset(ctx, filepath.ToSlash(versionDirectory)+"/", provider, supplement, options)
+}
+
+func (x *ProviderMirrorRegistry) providerPackageLocation(provider *Provider, supplement *ProviderSupplement) (string, error) {
+	versionDirectory, err := x.buildProviderVersionDirectory(provider)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(versionDirectory) + "/", nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ModuleMirrorRegistry The modules in a mirror created by selefra mirror create, the package is verified against the checksum
+// in mirror.yaml before it is unpacked
+type ModuleMirrorRegistry struct {
+	*ModuleLocalRegistry
+
+	mirrorDirectory string
+	metadata        *MirrorMetadata
+}
+
+var _ ModuleRegistry = &ModuleMirrorRegistry{}
+
+func NewModuleMirrorRegistry(mirrorDirectory string) (*ModuleMirrorRegistry, error) {
+	metadata, err := ReadMirrorMetadata(mirrorDirectory)
+	if err != nil {
+		return nil, err
+	}
+	localRegistry, err := NewModuleLocalRegistry(mirrorDirectory)
+	if err != nil {
+		return nil, err
+	}
+	return &ModuleMirrorRegistry{
+		ModuleLocalRegistry: localRegistry,
+		mirrorDirectory:     mirrorDirectory,
+		metadata:            metadata,
+	}, nil
+}
+
+func (x *ModuleMirrorRegistry) Download(ctx context.Context, module *Module, options *ModuleRegistryDownloadOptions) (string, error) {
+	if module.IsLatestVersion() {
+		latestModule, err := x.GetLatestVersion(ctx, module)
+		if err != nil {
+			return "", err
+		}
+		module.Version = latestModule.Version
+	}
+	supplement, err := x.GetSupplement(ctx, module)
+	if err != nil {
+		return "", err
+	}
+
+	artifact := x.metadata.GetModule(module.Name, module.Version)
+	if artifact == nil {
+		return "", fmt.Errorf("module %s is not in mirror %s", module.String(), x.mirrorDirectory)
+	}
+	file := artifact.GetFile(path.Join(ModulesListDirectoryName, module.Name, module.Version, buildModulePackageName(supplement)))
+	if file == nil {
+		return "", fmt.Errorf("package of module %s is not in mirror %s", module.String(), x.mirrorDirectory)
+	}
+	if err := VerifyMirrorFile(x.mirrorDirectory, file); err != nil {
+		return "", err
+	}
+
+	if err := utils.EnsureDirectoryNotExists(options.ModuleDownloadDirectoryPath); err != nil {
+		return "", err
+	}
+	packagePath, err := filepath.Abs(filepath.Join(x.mirrorDirectory, filepath.FromSlash(file.Path)))
+	if err != nil {
+		return "", err
+	}
+	if err := http_client.DownloadToDirectory(ctx, options.ModuleDownloadDirectoryPath, packagePath, options.ProgressTracker); err != nil {
+		return "", err
+	}
+	return options.ModuleDownloadDirectoryPath, nil
+}
+
+func (x *ModuleMirrorRegistry) modulePackageLocation(module *Module, supplement *ModuleSupplement) (string, error) {
+	versionDirectory, err := filepath.Abs(filepath.Join(x.mirrorDirectory, ModulesListDirectoryName, module.Name, module.Version))
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(versionDirectory) + "/", nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// MirrorProvider Copy the supplement and the packages of every platform of the provider version from the registry into the
+// mirror, and add the version to the metadata of the provider and the providers index of the mirror
+func MirrorProvider(ctx context.Context, registry ProviderRegistry, provider *Provider, mirrorDirectory string) (*MirrorArtifact, error) {
+	if provider.IsLatestVersion() {
+		latestProvider, err := registry.GetLatestVersion(ctx, provider)
+		if err != nil {
+			return nil, err
+		}
+		provider = NewProvider(provider.Name, latestProvider.Version)
+	}
+	metadata, err := registry.GetMetadata(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	supplement, err := registry.GetSupplement(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	versionDirectory := filepath.Join(mirrorDirectory, ProvidersListDirectoryName, provider.Name, provider.Version)
+	if err := utils.EnsureDirectoryExists(versionDirectory); err != nil {
+		return nil, err
+	}
+	artifact := &MirrorArtifact{
+		Name:    provider.Name,
+		Version: provider.Version,
+		Source:  supplement.Source,
+	}
+	platformChecksums := supplement.Checksums.platformChecksums()
+	platforms := make([]string, 0, len(platformChecksums))
+	for platform := range platformChecksums {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	for _, platform := range platforms {
+		assetName := buildProviderPlatformAssetName(provider, supplement, platform)
+		if err := DownloadProviderPackage(ctx, registry, provider, supplement, platform, filepath.Join(versionDirectory, assetName)); err != nil {
+			return nil, fmt.Errorf("mirror provider %s package %s error: %s", provider.String(), assetName, err.Error())
+		}
+		// The package is verified against the checksum of the supplement while it is downloaded
+		artifact.Files = append(artifact.Files, &MirrorFile{
+			Path:     path.Join(ProvidersListDirectoryName, provider.Name, provider.Version, assetName),
+			Platform: platform,
+			Sha256:   platformChecksums[platform],
+		})
+	}
+	if len(artifact.Files) == 0 {
+		return nil, fmt.Errorf("provider %s has no package to mirror", provider.String())
+	}
+	if err := writeYamlFile(filepath.Join(versionDirectory, SupplementFileName), supplement); err != nil {
+		return nil, err
+	}
+
+	metadataPath := filepath.Join(mirrorDirectory, ProvidersListDirectoryName, provider.Name, MetaDataFileName)
+	mirrorMetadata := &ProviderMetadata{
+		Name:         metadata.Name,
+		LatestUpdate: metadata.LatestUpdate,
+		Introduction: metadata.Introduction,
+	}
+	if utils.ExistsFile(metadataPath) {
+		if mirrorMetadata, err = utils.ReadYamlFile[*ProviderMetadata](metadataPath); err != nil {
+			return nil, err
+		}
+	}
+	mirrorMetadata.Versions, mirrorMetadata.LatestVersion = addMirrorVersion(mirrorMetadata.Versions, provider.Version)
+	if err := writeYamlFile(metadataPath, mirrorMetadata); err != nil {
+		return nil, err
+	}
+
+	indexPath := filepath.Join(mirrorDirectory, ProvidersListDirectoryName, ProvidersIndexFileName)
+	providerNameSlice := make([]string, 0)
+	if utils.ExistsFile(indexPath) {
+		if providerNameSlice, err = utils.ReadYamlFile[[]string](indexPath); err != nil {
+			return nil, err
+		}
+	}
+	if !utils.HasOne(providerNameSlice, provider.Name) {
+		providerNameSlice = append(providerNameSlice, provider.Name)
+		sort.Strings(providerNameSlice)
+	}
+	if err := writeYamlFile(indexPath, providerNameSlice); err != nil {
+		return nil, err
+	}
+
+	return artifact, nil
+}
+
+// MirrorModule Copy the supplement and the package of the module version from the registry into the mirror, and add the
+// version to the metadata of the module in the mirror
+func MirrorModule(ctx context.Context, registry ModuleRegistry, module *Module, mirrorDirectory string) (*MirrorArtifact, error) {
+	if module.IsLatestVersion() {
+		latestModule, err := registry.GetLatestVersion(ctx, module)
+		if err != nil {
+			return nil, err
+		}
+		module = NewModule(module.Name, latestModule.Version)
+	}
+	metadata, err := registry.GetMetadata(ctx, module)
+	if err != nil {
+		return nil, err
+	}
+	supplement, err := registry.GetSupplement(ctx, module)
+	if err != nil {
+		return nil, err
+	}
+
+	versionDirectory := filepath.Join(mirrorDirectory, ModulesListDirectoryName, module.Name, module.Version)
+	if err := utils.EnsureDirectoryExists(versionDirectory); err != nil {
+		return nil, err
+	}
+	packagePath := filepath.Join(versionDirectory, buildModulePackageName(supplement))
+	if err := DownloadModulePackage(ctx, registry, module, supplement, packagePath); err != nil {
+		return nil, fmt.Errorf("mirror module %s package error: %s", module.String(), err.Error())
+	}
+	// The module registry does not publish checksums, so the package is pinned to the one downloaded now
+	checksum, err := lock_file.FileChecksum(packagePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeYamlFile(filepath.Join(versionDirectory, SupplementFileName), supplement); err != nil {
+		return nil, err
+	}
+
+	metadataPath := filepath.Join(mirrorDirectory, ModulesListDirectoryName, module.Name, MetaDataFileName)
+	mirrorMetadata := &ModuleMetadata{
+		Name:         metadata.Name,
+		LatestUpdate: metadata.LatestUpdate,
+		Introduction: metadata.Introduction,
+	}
+	if utils.ExistsFile(metadataPath) {
+		if mirrorMetadata, err = utils.ReadYamlFile[*ModuleMetadata](metadataPath); err != nil {
+			return nil, err
+		}
+	}
+	mirrorMetadata.Versions, mirrorMetadata.LatestVersion = addMirrorVersion(mirrorMetadata.Versions, module.Version)
+	if err := writeYamlFile(metadataPath, mirrorMetadata); err != nil {
+		return nil, err
+	}
+
+	return &MirrorArtifact{
+		Name:    module.Name,
+		Version: module.Version,
+		Source:  supplement.Source,
+		Files: []*MirrorFile{
+			{
+				Path:   path.Join(ModulesListDirectoryName, module.Name, module.Version, buildModulePackageName(supplement)),
+				Sha256: strings.TrimPrefix(checksum, lock_file.ChecksumPrefix),
+			},
+		},
+	}, nil
+}
+
+// The versions in the mirror in order with the version added, and the latest one of them
+func addMirrorVersion(versions []string, addVersion string) ([]string, string) {
+	if !utils.HasOne(versions, addVersion) {
+		versions = append(versions, addVersion)
+	}
+	versions = version.Sort(versions)
+	return versions, versions[len(versions)-1]
+}
+
+func writeYamlFile(yamlFilePath string, v any) error {
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(yamlFilePath, out, 0644)
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
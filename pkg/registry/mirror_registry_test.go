@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"archive/zip"
+	"context"
+	"github.com/selefra/selefra-utils/pkg/pointer"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProviderMirrorRegistry(t *testing.T) {
+	sourceRegistry, err := NewProviderLocalRegistry(newTestProviderMirror(t))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	mirrorDirectory := t.TempDir()
+	artifact, err := MirrorProvider(ctx, sourceRegistry, NewProvider("aws", "latest"), mirrorDirectory)
+	assert.Nil(t, err)
+	assert.Equal(t, "v0.0.1", artifact.Version)
+	assert.Len(t, artifact.Files, 1)
+	metadata := &MirrorMetadata{}
+	metadata.SetProvider(artifact)
+	assert.Nil(t, metadata.Save(mirrorDirectory))
+
+	// A directory with mirror.yaml is served by the mirror registry, and so is SELEFRA_MIRROR
+	registry, err := NewProviderRegistry(t.TempDir(), mirrorDirectory)
+	assert.Nil(t, err)
+	assert.IsType(t, &ProviderMirrorRegistry{}, registry)
+	t.Setenv("SELEFRA_MIRROR", mirrorDirectory)
+	registry, err = NewProviderRegistry(t.TempDir(), "")
+	assert.Nil(t, err)
+	assert.IsType(t, &ProviderMirrorRegistry{}, registry)
+
+	latestProvider, err := registry.GetLatestVersion(ctx, NewProvider("aws", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, "v0.0.1", latestProvider.Version)
+	executePath, err := registry.Download(ctx, NewProvider("aws", "latest"), &ProviderRegistryDownloadOptions{
+		ProviderDownloadDirectoryPath: filepath.Join(t.TempDir(), "aws"),
+		SkipVerify:                    pointer.TruePointer(),
+	})
+	assert.Nil(t, err)
+	assert.FileExists(t, executePath)
+
+	// The package is checked against mirror.yaml even if the verification of the supplement is skipped
+	packagePath := filepath.Join(mirrorDirectory, filepath.FromSlash(artifact.Files[0].Path))
+	assert.Nil(t, os.WriteFile(packagePath, []byte("tampered"), 0644))
+	_, err = registry.Download(ctx, NewProvider("aws", "v0.0.1"), &ProviderRegistryDownloadOptions{
+		ProviderDownloadDirectoryPath: filepath.Join(t.TempDir(), "aws"),
+		SkipVerify:                    pointer.TruePointer(),
+	})
+	assert.NotNil(t, err)
+
+	_, err = registry.Download(ctx, NewProvider("aws", "v0.0.2"), &ProviderRegistryDownloadOptions{
+		ProviderDownloadDirectoryPath: filepath.Join(t.TempDir(), "aws"),
+	})
+	assert.NotNil(t, err)
+}
+
+func TestModuleMirrorRegistry(t *testing.T) {
+	// The release of the module is a local directory, so nothing is downloaded from the internet
+	sourceDirectory := t.TempDir()
+	releaseDirectory := filepath.Join(sourceDirectory, "release")
+	versionDirectory := filepath.Join(sourceDirectory, ModulesListDirectoryName, "rules-aws", "v0.0.1")
+	assert.Nil(t, os.MkdirAll(versionDirectory, os.ModePerm))
+	assert.Nil(t, os.MkdirAll(filepath.Join(releaseDirectory, "releases", "download", "v0.0.1"), os.ModePerm))
+	writeYaml := func(path string, v any) {
+		out, err := yaml.Marshal(v)
+		assert.Nil(t, err)
+		assert.Nil(t, os.WriteFile(path, out, 0644))
+	}
+	writeYaml(filepath.Join(sourceDirectory, ModulesListDirectoryName, "rules-aws", MetaDataFileName), &ModuleMetadata{
+		Name:          "rules-aws",
+		LatestVersion: "v0.0.1",
+		Versions:      []string{"v0.0.1"},
+	})
+	writeYaml(filepath.Join(versionDirectory, SupplementFileName), &ModuleSupplement{
+		PackageName: "rules-aws",
+		Source:      filepath.ToSlash(releaseDirectory),
+	})
+	zipFile, err := os.Create(filepath.Join(releaseDirectory, "releases", "download", "v0.0.1", "rules-aws.zip"))
+	assert.Nil(t, err)
+	zipWriter := zip.NewWriter(zipFile)
+	rulesWriter, err := zipWriter.Create("rules.yaml")
+	assert.Nil(t, err)
+	_, err = rulesWriter.Write([]byte("rules: []\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, zipWriter.Close())
+	assert.Nil(t, zipFile.Close())
+
+	sourceRegistry, err := NewModuleLocalRegistry(sourceDirectory)
+	assert.Nil(t, err)
+	ctx := context.Background()
+	mirrorDirectory := t.TempDir()
+	artifact, err := MirrorModule(ctx, sourceRegistry, NewModule("rules-aws", "v0.0.1"), mirrorDirectory)
+	assert.Nil(t, err)
+	assert.Len(t, artifact.Files, 1)
+	metadata := &MirrorMetadata{}
+	metadata.SetModule(artifact)
+	assert.Nil(t, metadata.Save(mirrorDirectory))
+
+	registry, err := NewModuleMirrorRegistry(mirrorDirectory)
+	assert.Nil(t, err)
+	moduleDirectory, err := registry.Download(ctx, NewModule("rules-aws", "v0.0.1"), &ModuleRegistryDownloadOptions{
+		ModuleDownloadDirectoryPath: filepath.Join(t.TempDir(), "rules-aws"),
+	})
+	assert.Nil(t, err)
+	assert.FileExists(t, filepath.Join(moduleDirectory, "rules.yaml"))
+
+	assert.Nil(t, os.WriteFile(filepath.Join(mirrorDirectory, filepath.FromSlash(artifact.Files[0].Path)), []byte("tampered"), 0644))
+	_, err = registry.Download(ctx, NewModule("rules-aws", "v0.0.1"), &ModuleRegistryDownloadOptions{
+		ModuleDownloadDirectoryPath: filepath.Join(t.TempDir(), "rules-aws"),
+	})
+	assert.NotNil(t, err)
+}
@@ -84,6 +84,10 @@ func (x *ModuleGitHubRegistry) Download(ctx context.Context, module *Module, opt
 	return downloadModule(ctx, x.buildRegistryUrl(), module, options)
 }
 
+func (x *ModuleGitHubRegistry) modulePackageLocation(module *Module, supplement *ModuleSupplement) (string, error) {
+	return buildModuleReleaseLocation(module, supplement), nil
+}
+
 func (x *ModuleGitHubRegistry) GetLatestVersion(ctx context.Context, module *Module) (*Module, error) {
 	metadata, err := x.GetMetadata(ctx, module)
 	if err != nil {
@@ -198,7 +202,7 @@ func downloadModule(ctx context.Context, registryUrl string, module *Module, opt
 	// TODO optimization, Improve compatibility
 	// example: https://github.com/selefra/rules-aws-misconfiguration-s3/releases/download/v0.0.1/rules-aws-misconfigure-s3.zip
 	// example: https://github.com/selefra/rules-aws-misconfiguration-s3/archive/refs/tags/v0.0.2.zip
-	githubReleaseAssertURL := supplement.Source + "/releases/download/" + module.Version + "/" + githubReleaseAssertName + ".zip"
+	githubReleaseAssertURL := buildModuleReleaseLocation(module, supplement) + githubReleaseAssertName + ".zip"
 
 	// TODO checksum
 	//if !pointer.FromBoolPointerOrDefault(options.SkipVerify, true) {
@@ -225,6 +229,11 @@ func downloadModule(ctx context.Context, registryUrl string, module *Module, opt
 	return "", fmt.Errorf("module %s download failed", supplement.PackageName)
 }
 
+// The url prefix of the package in the release of the module
+func buildModuleReleaseLocation(module *Module, supplement *ModuleSupplement) string {
+	return supplement.Source + "/releases/download/" + module.Version + "/"
+}
+
 func formatModuleVersion(ctx context.Context, registryUrl string, module *Module) error {
 	if !module.IsLatestVersion() {
 		return nil
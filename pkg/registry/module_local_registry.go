@@ -61,6 +61,10 @@ func (x *ModuleLocalRegistry) Download(ctx context.Context, module *Module, opti
 	return downloadModule(ctx, registry.buildRegistryUrl(), module, options)
 }
 
+func (x *ModuleLocalRegistry) modulePackageLocation(module *Module, supplement *ModuleSupplement) (string, error) {
+	return buildModuleReleaseLocation(module, supplement), nil
+}
+
 func (x *ModuleLocalRegistry) GetLatestVersion(ctx context.Context, module *Module) (*Module, error) {
 	metaPath := filepath.Join(x.registryDirectory, ModulesListDirectoryName, module.Name, MetaDataFileName)
 	meta, err := utils.ReadYamlFile[*ProviderMetadata](metaPath)
@@ -253,6 +253,10 @@ func (x *ProviderGithubRegistry) GetSupplement(ctx context.Context, provider *Pr
 	return getProviderSupplement(ctx, x.buildRegistryUrl(), provider)
 }
 
+func (x *ProviderGithubRegistry) providerPackageLocation(provider *Provider, supplement *ProviderSupplement) (string, error) {
+	return buildProviderReleaseLocation(provider, supplement), nil
+}
+
 // ------------------------------------------------- --------------------------------------------------------------------
 
 func downloadProvider(ctx context.Context, registryUrl string, provider *Provider, options *ProviderRegistryDownloadOptions) (string, error) {
@@ -266,26 +270,46 @@ func downloadProvider(ctx context.Context, registryUrl string, provider *Provide
 		return "", err
 	}
 
+	// TODO optimization, Improve compatibility
+	// The providerBinarySuffix depends on the provider repository's CI. If that CI changes the providerBinarySuffix, it must be changed accordingly
+	return downloadProviderAsset(ctx, buildProviderReleaseLocation(provider, supplement), provider, supplement, options)
+}
+
+// The url prefix of the packages in the release of the provider
+func buildProviderReleaseLocation(provider *Provider, supplement *ProviderSupplement) string {
+	return supplement.Source + "/releases/download/" + provider.Version + "/"
+}
+
+// The name of the package of the provider for the current platform, such as selefra-provider-aws_0.0.1_linux_amd64.tar.gz
+func buildProviderAssetName(provider *Provider, supplement *ProviderSupplement) string {
+	return buildProviderPlatformAssetName(provider, supplement, runtime.GOOS+"_"+runtime.GOARCH)
+}
+
+// The name of the package of the provider for the platform such as linux_amd64
+func buildProviderPlatformAssetName(provider *Provider, supplement *ProviderSupplement, platform string) string {
+	return supplement.PackageName + "_" + strings.Replace(provider.Version, "v", "", 1) + "_" + platform + ".tar.gz"
+}
+
+// Download the package of the provider for the current platform from the given location and unpack it, the location is
+// the url prefix of a GitHub release, a http mirror or the directory of a local mirror
+func downloadProviderAsset(ctx context.Context, assetLocation string, provider *Provider, supplement *ProviderSupplement, options *ProviderRegistryDownloadOptions) (string, error) {
+
 	if err := utils.EnsureDirectoryNotExists(options.ProviderDownloadDirectoryPath); err != nil {
 		return "", err
 	}
 
-	githubReleaseAssertName := supplement.PackageName + "_" + strings.Replace(provider.Version, "v", "", 1) + "_" + runtime.GOOS + "_" + runtime.GOARCH
-
-	// TODO optimization, Improve compatibility
-	// The providerBinarySuffix depends on the provider repository's CI. If that CI changes the providerBinarySuffix, it must be changed accordingly
-	githubReleaseAssertURL := supplement.Source + "/releases/download/" + provider.Version + "/" + githubReleaseAssertName + ".tar.gz"
+	assetURL := assetLocation + buildProviderAssetName(provider, supplement)
 
 	if !pointer.FromBoolPointerOrDefault(options.SkipVerify, true) {
 		checksum, err := supplement.Checksums.selectChecksums()
 		if err != nil {
 			return "", err
 		}
-		githubReleaseAssertURL += "?checksum=sha256:" + checksum
+		assetURL += "?checksum=sha256:" + checksum
 	}
 
 	event := telemetry.NewEvent("provider-install").
-		Add("url", githubReleaseAssertURL).
+		Add("url", assetURL).
 		Add("provider_name", provider.Name).
 		Add("provider_version", provider.Version)
 	d := telemetry.Submit(ctx, event)
@@ -293,8 +317,8 @@ func downloadProvider(ctx context.Context, registryUrl string, provider *Provide
 		logger.ErrorF("telemetry provider install, msg = %s", d.String())
 	}
 
-	//targetUrl := cli_env.GetSelefraCloudHttpHost() + "/diagnosis.tar.gz?url=" + base64.StdEncoding.EncodeToString([]byte(githubReleaseAssertURL))
-	err = http_client.DownloadToDirectory(ctx, options.ProviderDownloadDirectoryPath, githubReleaseAssertURL, options.ProgressTracker)
+	//targetUrl := cli_env.GetSelefraCloudHttpHost() + "/diagnosis.tar.gz?url=" + base64.StdEncoding.EncodeToString([]byte(assetURL))
+	err := http_client.DownloadToDirectory(ctx, options.ProviderDownloadDirectoryPath, assetURL, options.ProgressTracker)
 	//err = http_client.DownloadToDirectory(ctx, options.ProviderDownloadDirectoryPath, targetUrl, options.ProgressTracker)
 	if err != nil {
 		return "", err
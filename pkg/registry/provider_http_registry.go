@@ -0,0 +1,154 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"github.com/selefra/selefra/pkg/http_client"
+	"strings"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ProviderHttpRegistry A plain http index of providers, it can be served by any static file server and has the same layout
+// as the registry repository, with the names of all providers in provider/index.yaml and the packages of every version
+// next to its supplement:
+//
+//	provider/index.yaml
+//	provider/aws/metadata.yaml
+//	provider/aws/v0.0.1/supplement.yaml
+//	provider/aws/v0.0.1/selefra-provider-aws_0.0.1_linux_amd64.tar.gz
+type ProviderHttpRegistry struct {
+
+	// The url of the index without the trailing slash, such as http://mirror.example.com/selefra
+	registryUrl string
+}
+
+var _ ProviderRegistry = &ProviderHttpRegistry{}
+
+func NewProviderHttpRegistry(registryUrl string) (*ProviderHttpRegistry, error) {
+	lowerUrl := strings.ToLower(registryUrl)
+	if !strings.HasPrefix(lowerUrl, "http://") && !strings.HasPrefix(lowerUrl, "https://") {
+		return nil, fmt.Errorf("provider http registry %s must start with http:// or https://", registryUrl)
+	}
+	return &ProviderHttpRegistry{
+		registryUrl: strings.TrimRight(registryUrl, "/"),
+	}, nil
+}
+
+func (x *ProviderHttpRegistry) buildProvidersUrl() string {
+	return x.registryUrl + "/" + ProvidersListDirectoryName
+}
+
+func (x *ProviderHttpRegistry) buildProviderVersionUrl(provider *Provider) string {
+	return fmt.Sprintf("%s/%s/%s/", x.buildProvidersUrl(), provider.Name, provider.Version)
+}
+
+func (x *ProviderHttpRegistry) CheckUpdate(ctx context.Context, provider *Provider) (*Provider, error) {
+
+	if provider.IsLatestVersion() {
+		return nil, nil
+	}
+
+	metadata, err := x.GetMetadata(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	if provider.Version == metadata.LatestVersion {
+		return nil, nil
+	}
+
+	return NewProvider(provider.Name, metadata.LatestVersion), nil
+}
+
+func (x *ProviderHttpRegistry) GetLatestVersion(ctx context.Context, provider *Provider) (*Provider, error) {
+	metadata, err := x.GetMetadata(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	return NewProvider(provider.Name, metadata.LatestVersion), nil
+}
+
+func (x *ProviderHttpRegistry) GetAllVersion(ctx context.Context, provider *Provider) ([]*Provider, error) {
+	metadata, err := x.GetMetadata(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	providerSlice := make([]*Provider, 0, len(metadata.Versions))
+	for _, v := range metadata.Versions {
+		providerSlice = append(providerSlice, NewProvider(provider.Name, v))
+	}
+	return providerSlice, nil
+}
+
+func (x *ProviderHttpRegistry) GetMetadata(ctx context.Context, provider *Provider) (*ProviderMetadata, error) {
+	metadataUrl := fmt.Sprintf("%s/%s/%s", x.buildProvidersUrl(), provider.Name, MetaDataFileName)
+	metadata, err := http_client.GetYaml[*ProviderMetadata](ctx, metadataUrl)
+	if err != nil {
+		return nil, fmt.Errorf("get provider %s metadata from %s error: %s", provider.Name, metadataUrl, err.Error())
+	}
+	return metadata, nil
+}
+
+func (x *ProviderHttpRegistry) GetSupplement(ctx context.Context, provider *Provider) (*ProviderSupplement, error) {
+	supplementUrl := x.buildProviderVersionUrl(provider) + SupplementFileName
+	supplement, err := http_client.GetYaml[*ProviderSupplement](ctx, supplementUrl)
+	if err != nil {
+		return nil, fmt.Errorf("get provider %s supplement from %s error: %s", provider.String(), supplementUrl, err.Error())
+	}
+	return supplement, nil
+}
+
+// Download The package is always downloaded from the index, so nothing but the index has to be reachable
+func (x *ProviderHttpRegistry) Download(ctx context.Context, provider *Provider, options *ProviderRegistryDownloadOptions) (string, error) {
+	if provider.IsLatestVersion() {
+		latestProvider, err := x.GetLatestVersion(ctx, provider)
+		if err != nil {
+			return "", err
+		}
+		provider.Version = latestProvider.Version
+	}
+	supplement, err := x.GetSupplement(ctx, provider)
+	if err != nil {
+		return "", err
+	}
+	return downloadProviderAsset(ctx, x.buildProviderVersionUrl(provider), provider, supplement, options)
+}
+
+func (x *ProviderHttpRegistry) providerPackageLocation(provider *Provider, supplement *ProviderSupplement) (string, error) {
+	return x.buildProviderVersionUrl(provider), nil
+}
+
+func (x *ProviderHttpRegistry) Search(ctx context.Context, keyword string) ([]*Provider, error) {
+	allProviderSlice, err := x.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyword = strings.ToLower(keyword)
+	hitProviderSlice := make([]*Provider, 0)
+	for _, provider := range allProviderSlice {
+		if strings.Contains(strings.ToLower(provider.Name), keyword) {
+			hitProviderSlice = append(hitProviderSlice, provider)
+		}
+	}
+	return hitProviderSlice, nil
+}
+
+// List A static file server can not list a directory, so the providers are the ones in the index
+func (x *ProviderHttpRegistry) List(ctx context.Context) ([]*Provider, error) {
+	indexUrl := x.buildProvidersUrl() + "/" + ProvidersIndexFileName
+	providerNameSlice, err := http_client.GetYaml[[]string](ctx, indexUrl)
+	if err != nil {
+		return nil, fmt.Errorf("get providers index from %s error: %s", indexUrl, err.Error())
+	}
+	providerSlice := make([]*Provider, 0, len(providerNameSlice))
+	for _, providerName := range providerNameSlice {
+		metadata, err := x.GetMetadata(ctx, NewProvider(providerName, ""))
+		if err != nil {
+			return nil, err
+		}
+		providerSlice = append(providerSlice, NewProvider(metadata.Name, metadata.LatestVersion))
+	}
+	return providerSlice, nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,127 @@
+package registry
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/selefra/selefra-utils/pkg/pointer"
+	"github.com/selefra/selefra/pkg/telemetry"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// Build a mirror of the provider aws v0.0.1 in a temporary directory, with the package of the current platform
+func newTestProviderMirror(t *testing.T) string {
+	telemetry.TelemetryEnable = false
+
+	mirrorDirectory := t.TempDir()
+	provider := NewProvider("aws", "v0.0.1")
+	versionDirectory := filepath.Join(mirrorDirectory, ProvidersListDirectoryName, provider.Name, provider.Version)
+	assert.Nil(t, os.MkdirAll(versionDirectory, os.ModePerm))
+
+	writeYaml := func(path string, v any) {
+		out, err := yaml.Marshal(v)
+		assert.Nil(t, err)
+		assert.Nil(t, os.WriteFile(path, out, 0644))
+	}
+	writeYaml(filepath.Join(mirrorDirectory, ProvidersListDirectoryName, ProvidersIndexFileName), []string{provider.Name})
+	writeYaml(filepath.Join(mirrorDirectory, ProvidersListDirectoryName, provider.Name, MetaDataFileName), &ProviderMetadata{
+		Name:          provider.Name,
+		LatestVersion: provider.Version,
+		Versions:      []string{provider.Version},
+	})
+
+	supplement := &ProviderSupplement{
+		PackageName: "selefra-provider-aws",
+		Source:      "https://github.com/selefra/selefra-provider-aws",
+	}
+	assetPath := filepath.Join(versionDirectory, buildProviderAssetName(provider, supplement))
+	assetFile, err := os.Create(assetPath)
+	assert.Nil(t, err)
+	gzipWriter := gzip.NewWriter(assetFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+	executable := []byte("#!/bin/sh\necho aws\n")
+	assert.Nil(t, tarWriter.WriteHeader(&tar.Header{Name: supplement.PackageName + providerBinarySuffix, Mode: 0755, Size: int64(len(executable))}))
+	_, err = tarWriter.Write(executable)
+	assert.Nil(t, err)
+	assert.Nil(t, tarWriter.Close())
+	assert.Nil(t, gzipWriter.Close())
+	assert.Nil(t, assetFile.Close())
+
+	assetBytes, err := os.ReadFile(assetPath)
+	assert.Nil(t, err)
+	sum := sha256.Sum256(assetBytes)
+	supplement.Checksums.setChecksum(runtime.GOOS+"_"+runtime.GOARCH, hex.EncodeToString(sum[:]))
+	writeYaml(filepath.Join(versionDirectory, SupplementFileName), supplement)
+
+	return mirrorDirectory
+}
+
+func TestProviderHttpRegistry(t *testing.T) {
+	mirrorDirectory := newTestProviderMirror(t)
+	server := httptest.NewServer(http.FileServer(http.Dir(mirrorDirectory)))
+	defer server.Close()
+
+	registry, err := NewProviderHttpRegistry(server.URL + "/")
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	providerSlice, err := registry.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, providerSlice, 1)
+	assert.Equal(t, "v0.0.1", providerSlice[0].Version)
+
+	providerSlice, err = registry.Search(ctx, "AW")
+	assert.Nil(t, err)
+	assert.Len(t, providerSlice, 1)
+
+	latestProvider, err := registry.GetLatestVersion(ctx, NewProvider("aws", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, "v0.0.1", latestProvider.Version)
+
+	update, err := registry.CheckUpdate(ctx, NewProvider("aws", "v0.0.1"))
+	assert.Nil(t, err)
+	assert.Nil(t, update)
+
+	executePath, err := registry.Download(ctx, NewProvider("aws", "latest"), &ProviderRegistryDownloadOptions{
+		ProviderDownloadDirectoryPath: filepath.Join(t.TempDir(), "aws"),
+		SkipVerify:                    pointer.FalsePointer(),
+	})
+	assert.Nil(t, err)
+	assert.FileExists(t, executePath)
+
+	_, err = registry.GetMetadata(ctx, NewProvider("gcp", ""))
+	assert.NotNil(t, err)
+}
+
+func TestProviderHttpRegistry_DownloadChecksumMismatch(t *testing.T) {
+	mirrorDirectory := newTestProviderMirror(t)
+	supplementPath := filepath.Join(mirrorDirectory, ProvidersListDirectoryName, "aws", "v0.0.1", SupplementFileName)
+	supplementBytes, err := os.ReadFile(supplementPath)
+	assert.Nil(t, err)
+	supplement := &ProviderSupplement{}
+	assert.Nil(t, yaml.Unmarshal(supplementBytes, supplement))
+	supplement.Checksums.setChecksum(runtime.GOOS+"_"+runtime.GOARCH, hex.EncodeToString(make([]byte, sha256.Size)))
+	supplementBytes, err = yaml.Marshal(supplement)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(supplementPath, supplementBytes, 0644))
+
+	server := httptest.NewServer(http.FileServer(http.Dir(mirrorDirectory)))
+	defer server.Close()
+
+	registry, err := NewProviderHttpRegistry(server.URL)
+	assert.Nil(t, err)
+	_, err = registry.Download(context.Background(), NewProvider("aws", "v0.0.1"), &ProviderRegistryDownloadOptions{
+		ProviderDownloadDirectoryPath: filepath.Join(t.TempDir(), "aws"),
+		SkipVerify:                    pointer.FalsePointer(),
+	})
+	assert.NotNil(t, err)
+}
@@ -95,7 +95,28 @@ func (x *ProviderLocalRegistry) GetAllVersion(ctx context.Context, provider *Pro
 	return providerSlice, nil
 }
 
+// Download When the directory is a mirror with the packages next to the supplement, the package is installed from it and
+// verified against the checksums of the supplement, otherwise it is downloaded from the release the supplement points to
 func (x *ProviderLocalRegistry) Download(ctx context.Context, provider *Provider, options *ProviderRegistryDownloadOptions) (string, error) {
+	if provider.IsLatestVersion() {
+		latestProvider, err := x.GetLatestVersion(ctx, provider)
+		if err != nil {
+			return "", err
+		}
+		provider.Version = latestProvider.Version
+	}
+	supplement, err := x.GetSupplement(ctx, provider)
+	if err != nil {
+		return "", err
+	}
+	versionDirectory, err := x.buildProviderVersionDirectory(provider)
+	if err != nil {
+		return "", err
+	}
+	if utils.Exists(filepath.Join(versionDirectory, buildProviderAssetName(provider, supplement))) {
+		return downloadProviderAsset(ctx, filepath.ToSlash(versionDirectory)+"/", provider, supplement, options)
+	}
+
 	registry, err := NewProviderGithubRegistry(NewProviderGithubRegistryOptions(x.registryDirectory, x.registryGitHubRepoFullName))
 	if err != nil {
 		return "", err
@@ -103,6 +124,23 @@ func (x *ProviderLocalRegistry) Download(ctx context.Context, provider *Provider
 	return downloadProvider(ctx, registry.buildRegistryUrl(), provider, options)
 }
 
+// The absolute path of the directory of the supplement and the packages of the provider version
+func (x *ProviderLocalRegistry) buildProviderVersionDirectory(provider *Provider) (string, error) {
+	return filepath.Abs(filepath.Join(x.registryDirectory, ProvidersListDirectoryName, provider.Name, provider.Version))
+}
+
+// The packages are next to the supplement if the directory is a mirror of them, otherwise they are in the release
+func (x *ProviderLocalRegistry) providerPackageLocation(provider *Provider, supplement *ProviderSupplement) (string, error) {
+	versionDirectory, err := x.buildProviderVersionDirectory(provider)
+	if err != nil {
+		return "", err
+	}
+	if utils.Exists(filepath.Join(versionDirectory, buildProviderAssetName(provider, supplement))) {
+		return filepath.ToSlash(versionDirectory) + "/", nil
+	}
+	return buildProviderReleaseLocation(provider, supplement), nil
+}
+
 func (x *ProviderLocalRegistry) Search(ctx context.Context, keyword string) ([]*Provider, error) {
 	allProviderSlice, err := x.List(ctx)
 	if err != nil {
@@ -84,3 +84,19 @@ func TestProviderLocalRegistry_CheckUpdate(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, providerSupplement)
 }
+
+func TestProviderLocalRegistry_DownloadFromMirror(t *testing.T) {
+	registry, err := NewProviderLocalRegistry(newTestProviderMirror(t))
+	assert.Nil(t, err)
+
+	providerSlice, err := registry.List(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, providerSlice, 1)
+
+	executePath, err := registry.Download(context.Background(), NewProvider("aws", "latest"), &ProviderRegistryDownloadOptions{
+		ProviderDownloadDirectoryPath: filepath.Join(t.TempDir(), "aws"),
+		SkipVerify:                    pointer.FalsePointer(),
+	})
+	assert.Nil(t, err)
+	assert.FileExists(t, executePath)
+}
@@ -0,0 +1,190 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/go-version"
+	"github.com/selefra/selefra/pkg/oci"
+	selefraVersion "github.com/selefra/selefra/pkg/version"
+	"runtime"
+	"strings"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// ProviderOCIRegistryOptions Options for creating the OCI registry of providers
+type ProviderOCIRegistryOptions struct {
+
+	// The namespace the providers are pushed to, such as oci://registry.example.com/selefra/providers, the provider aws is
+	// in the repository registry.example.com/selefra/providers/aws and every version is a tag
+	Source string
+
+	// The store is the remote registry configured by the SELEFRA_OCI_* environment variables if not set
+	ArtifactStore oci.ArtifactStore
+}
+
+func NewProviderOCIRegistryOptions(source string) *ProviderOCIRegistryOptions {
+	return &ProviderOCIRegistryOptions{
+		Source: source,
+	}
+}
+
+// ProviderOCIRegistry The providers are artifacts in an OCI registry, which can be the container registry of the enterprise
+type ProviderOCIRegistry struct {
+
+	// The namespace of the repositories without the oci:// prefix
+	namespace string
+
+	options *ProviderOCIRegistryOptions
+}
+
+var _ ProviderRegistry = &ProviderOCIRegistry{}
+
+func NewProviderOCIRegistry(options *ProviderOCIRegistryOptions) (*ProviderOCIRegistry, error) {
+
+	namespace := strings.Trim(options.Source, "/")
+	if oci.IsArtifactSource(namespace) {
+		namespace = strings.Trim(namespace[len(oci.SourcePrefix):], "/")
+	}
+	// The repository of any provider must be valid
+	if _, err := oci.ParseArtifactReference(namespace + "/provider"); err != nil {
+		return nil, fmt.Errorf("provider oci registry %s is not valid: %s", options.Source, err.Error())
+	}
+
+	if options.ArtifactStore == nil {
+		store, err := oci.NewRemoteArtifactStore(oci.NewRemoteArtifactStoreOptionsFromEnv())
+		if err != nil {
+			return nil, err
+		}
+		options.ArtifactStore = store
+	}
+
+	return &ProviderOCIRegistry{
+		namespace: namespace,
+		options:   options,
+	}, nil
+}
+
+// BuildProviderRepository The repository of the provider such as registry.example.com/selefra/providers/aws
+func (x *ProviderOCIRegistry) BuildProviderRepository(providerName string) string {
+	return x.namespace + "/" + providerName
+}
+
+// BuildProviderReference The artifact of the provider version such as registry.example.com/selefra/providers/aws:v0.0.1
+func (x *ProviderOCIRegistry) BuildProviderReference(provider *Provider) string {
+	return x.BuildProviderRepository(provider.Name) + ":" + provider.Version
+}
+
+func (x *ProviderOCIRegistry) CheckUpdate(ctx context.Context, provider *Provider) (*Provider, error) {
+
+	if provider.IsLatestVersion() {
+		return nil, nil
+	}
+
+	metadata, err := x.GetMetadata(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	if provider.Version == metadata.LatestVersion {
+		return nil, nil
+	}
+
+	return NewProvider(provider.Name, metadata.LatestVersion), nil
+}
+
+func (x *ProviderOCIRegistry) GetLatestVersion(ctx context.Context, provider *Provider) (*Provider, error) {
+	metadata, err := x.GetMetadata(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	return NewProvider(provider.Name, metadata.LatestVersion), nil
+}
+
+func (x *ProviderOCIRegistry) GetAllVersion(ctx context.Context, provider *Provider) ([]*Provider, error) {
+	metadata, err := x.GetMetadata(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	providerSlice := make([]*Provider, 0, len(metadata.Versions))
+	for _, v := range metadata.Versions {
+		providerSlice = append(providerSlice, NewProvider(provider.Name, v))
+	}
+	return providerSlice, nil
+}
+
+// GetMetadata The versions are the tags of the provider repository such as v0.0.1, the other tags such as latest are ignored
+func (x *ProviderOCIRegistry) GetMetadata(ctx context.Context, provider *Provider) (*ProviderMetadata, error) {
+	tags, err := x.options.ArtifactStore.Tags(ctx, x.BuildProviderRepository(provider.Name))
+	if err != nil {
+		return nil, fmt.Errorf("list provider %s versions from oci registry error: %s", provider.Name, err.Error())
+	}
+	versions := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if _, err := version.NewVersion(tag); err == nil && strings.HasPrefix(tag, "v") {
+			versions = append(versions, tag)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("provider %s not found in oci registry %s", provider.Name, x.options.Source)
+	}
+	versions = selefraVersion.Sort(versions)
+
+	return &ProviderMetadata{
+		Name:          provider.Name,
+		LatestVersion: versions[len(versions)-1],
+		Versions:      versions,
+	}, nil
+}
+
+// GetSupplement The checksums are the digests of the executable of every platform in the artifact
+func (x *ProviderOCIRegistry) GetSupplement(ctx context.Context, provider *Provider) (*ProviderSupplement, error) {
+	if err := x.formatProviderVersion(ctx, provider); err != nil {
+		return nil, err
+	}
+	_, manifest, err := oci.FetchManifest(ctx, x.options.ArtifactStore, x.BuildProviderReference(provider))
+	if err != nil {
+		return nil, fmt.Errorf("get provider %s manifest from oci registry error: %s", provider.String(), err.Error())
+	}
+	supplement := &ProviderSupplement{
+		PackageName: manifest.Annotations[oci.AnnotationProviderPackageName],
+		Source:      oci.SourcePrefix + x.BuildProviderRepository(provider.Name),
+	}
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != oci.ProviderArtifactLayerMediaType {
+			continue
+		}
+		supplement.Checksums.setChecksum(layer.Annotations[oci.AnnotationPlatform], layer.Digest.Encoded())
+	}
+	return supplement, nil
+}
+
+// Download Only the executable of the current platform is pulled, its digest is always verified while pulling
+func (x *ProviderOCIRegistry) Download(ctx context.Context, provider *Provider, options *ProviderRegistryDownloadOptions) (string, error) {
+	if err := x.formatProviderVersion(ctx, provider); err != nil {
+		return "", err
+	}
+	return oci.PullProvider(ctx, x.options.ArtifactStore, x.BuildProviderReference(provider), runtime.GOOS+"_"+runtime.GOARCH, options.ProviderDownloadDirectoryPath)
+}
+
+// Search The registry can not list its repositories, so the providers can only be installed by name
+func (x *ProviderOCIRegistry) Search(ctx context.Context, keyword string) ([]*Provider, error) {
+	return nil, fmt.Errorf("provider oci registry %s does not support search", x.options.Source)
+}
+
+func (x *ProviderOCIRegistry) List(ctx context.Context) ([]*Provider, error) {
+	return nil, fmt.Errorf("provider oci registry %s does not support list", x.options.Source)
+}
+
+func (x *ProviderOCIRegistry) formatProviderVersion(ctx context.Context, provider *Provider) error {
+	if !provider.IsLatestVersion() {
+		return nil
+	}
+	metadata, err := x.GetMetadata(ctx, provider)
+	if err != nil {
+		return err
+	}
+	provider.Version = metadata.LatestVersion
+	return nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
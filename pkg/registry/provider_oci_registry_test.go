@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"context"
+	"github.com/selefra/selefra/pkg/oci"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func newTestProviderOCIRegistry(t *testing.T) *ProviderOCIRegistry {
+	store, err := oci.NewLayoutArtifactStore(t.TempDir())
+	assert.Nil(t, err)
+
+	executablePath := filepath.Join(t.TempDir(), "selefra-provider-aws")
+	assert.Nil(t, os.WriteFile(executablePath, []byte("aws"), 0755))
+	platform := runtime.GOOS + "_" + runtime.GOARCH
+	for _, tag := range []string{"v0.0.1", "v0.0.2", "latest"} {
+		_, err := oci.PushProvider(context.Background(), store, "localhost:5000/selefra/providers/aws:"+tag, &oci.ProviderArtifact{
+			Name:        "aws",
+			PackageName: "selefra-provider-aws",
+			Executables: map[string]string{platform: executablePath},
+		})
+		assert.Nil(t, err)
+	}
+
+	options := NewProviderOCIRegistryOptions("oci://localhost:5000/selefra/providers/")
+	options.ArtifactStore = store
+	registry, err := NewProviderOCIRegistry(options)
+	assert.Nil(t, err)
+	return registry
+}
+
+func TestProviderOCIRegistry_GetMetadata(t *testing.T) {
+	registry := newTestProviderOCIRegistry(t)
+
+	metadata, err := registry.GetMetadata(context.Background(), NewProvider("aws", "latest"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"v0.0.1", "v0.0.2"}, metadata.Versions)
+	assert.Equal(t, "v0.0.2", metadata.LatestVersion)
+
+	update, err := registry.CheckUpdate(context.Background(), NewProvider("aws", "v0.0.1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "v0.0.2", update.Version)
+
+	_, err = registry.GetMetadata(context.Background(), NewProvider("gcp", "latest"))
+	assert.NotNil(t, err)
+}
+
+func TestProviderOCIRegistry_Download(t *testing.T) {
+	registry := newTestProviderOCIRegistry(t)
+
+	supplement, err := registry.GetSupplement(context.Background(), NewProvider("aws", "v0.0.1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "selefra-provider-aws", supplement.PackageName)
+	assert.Equal(t, "oci://localhost:5000/selefra/providers/aws", supplement.Source)
+	checksum, err := supplement.Checksums.selectChecksums()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, checksum)
+
+	provider := NewProvider("aws", "latest")
+	executablePath, err := registry.Download(context.Background(), provider, &ProviderRegistryDownloadOptions{
+		ProviderDownloadDirectoryPath: t.TempDir(),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "v0.0.2", provider.Version)
+	assert.FileExists(t, executablePath)
+}
@@ -3,9 +3,14 @@ package registry
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/hashicorp/go-getter"
+	"github.com/selefra/selefra/pkg/cli_env"
+	"github.com/selefra/selefra/pkg/oci"
 	"github.com/selefra/selefra/pkg/version"
+	"path/filepath"
 	"runtime"
+	"strings"
 )
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -14,6 +19,9 @@ const (
 	ProvidersListDirectoryName = "provider"
 	MetaDataFileName           = "metadata.yaml"
 	SupplementFileName         = "supplement.yaml"
+
+	// ProvidersIndexFileName The names of all providers in a registry that can not list its directories, such as a http one
+	ProvidersIndexFileName = "index.yaml"
 )
 
 // ------------------------------------------------- --------------------------------------------------------------------
@@ -104,6 +112,42 @@ func (x *Checksums) selectChecksums() (string, error) {
 	}
 }
 
+// The platform is such as linux_amd64, the platforms not supported are ignored
+func (x *Checksums) setChecksum(platform, checksum string) {
+	switch platform {
+	case "linux_arm64":
+		x.LinuxArm64 = checksum
+	case "linux_amd64":
+		x.LinuxAmd64 = checksum
+	case "windows_arm64":
+		x.WindowsArm64 = checksum
+	case "windows_amd64":
+		x.WindowsAmd64 = checksum
+	case "darwin_arm64":
+		x.DarwinArm64 = checksum
+	case "darwin_amd64":
+		x.DarwinAmd64 = checksum
+	}
+}
+
+// The checksums of the platforms that have a package, the platform is such as linux_amd64
+func (x *Checksums) platformChecksums() map[string]string {
+	platformChecksums := make(map[string]string)
+	for platform, checksum := range map[string]string{
+		"linux_arm64":   x.LinuxArm64,
+		"linux_amd64":   x.LinuxAmd64,
+		"windows_arm64": x.WindowsArm64,
+		"windows_amd64": x.WindowsAmd64,
+		"darwin_arm64":  x.DarwinArm64,
+		"darwin_amd64":  x.DarwinAmd64,
+	} {
+		if checksum != "" {
+			platformChecksums[platform] = checksum
+		}
+	}
+	return platformChecksums
+}
+
 // ------------------------------------------------- --------------------------------------------------------------------
 
 // ProviderRegistryDownloadOptions Some options when downloading the provider
@@ -146,3 +190,50 @@ type ProviderRegistry interface {
 }
 
 // ------------------------------------------------- --------------------------------------------------------------------
+
+// ProviderRegistryGitHub The registry setting of the official registry, it is also used when the registry is not set
+const ProviderRegistryGitHub = "github"
+
+// ProviderRegistryGitHubPrefix The registry setting of another GitHub repository such as github://my-org/my-registry
+const ProviderRegistryGitHubPrefix = "github://"
+
+// ProviderRegistryFilePrefix The registry setting of a local directory such as file:///data/selefra-registry
+const ProviderRegistryFilePrefix = "file://"
+
+// NewProviderRegistry Create the provider registry by the registry setting of the selefra block or the required provider block:
+//
+//	""                             the official registry on GitHub, or the mirror SELEFRA_MIRROR points to
+//	github, github://owner/repo    a registry repository on GitHub
+//	file:///path, /path, ./path    a local directory mirror, or a mirror created by selefra mirror create
+//	http://host/path               a plain http index
+//	oci://host/namespace           an OCI registry
+func NewProviderRegistry(downloadWorkspace, registrySetting string) (ProviderRegistry, error) {
+	lowerSetting := strings.ToLower(registrySetting)
+	switch {
+	case registrySetting == "" && cli_env.GetMirrorDirectory() != "":
+		return NewProviderMirrorRegistry(cli_env.GetMirrorDirectory())
+	case registrySetting == "" || lowerSetting == ProviderRegistryGitHub:
+		return NewProviderGithubRegistry(NewProviderGithubRegistryOptions(downloadWorkspace))
+	case strings.HasPrefix(lowerSetting, ProviderRegistryGitHubPrefix):
+		return NewProviderGithubRegistry(NewProviderGithubRegistryOptions(downloadWorkspace, strings.Trim(registrySetting[len(ProviderRegistryGitHubPrefix):], "/")))
+	case oci.IsArtifactSource(registrySetting):
+		return NewProviderOCIRegistry(NewProviderOCIRegistryOptions(registrySetting))
+	case strings.HasPrefix(lowerSetting, "http://") || strings.HasPrefix(lowerSetting, "https://"):
+		return NewProviderHttpRegistry(registrySetting)
+	case strings.HasPrefix(lowerSetting, ProviderRegistryFilePrefix):
+		return newProviderDirectoryRegistry(registrySetting[len(ProviderRegistryFilePrefix):])
+	case filepath.IsAbs(registrySetting) || strings.HasPrefix(registrySetting, "./") || strings.HasPrefix(registrySetting, "../"):
+		return newProviderDirectoryRegistry(registrySetting)
+	default:
+		return nil, fmt.Errorf("provider registry %s is not supported, it must be github, github://owner/repo, a local directory, a http url or an oci:// url", registrySetting)
+	}
+}
+
+func newProviderDirectoryRegistry(registryDirectory string) (ProviderRegistry, error) {
+	if IsMirrorDirectory(registryDirectory) {
+		return NewProviderMirrorRegistry(registryDirectory)
+	}
+	return NewProviderLocalRegistry(registryDirectory)
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
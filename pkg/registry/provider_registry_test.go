@@ -0,0 +1,36 @@
+package registry
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewProviderRegistry(t *testing.T) {
+	mirrorDirectory := newTestProviderMirror(t)
+
+	testCases := []struct {
+		setting      string
+		registryType ProviderRegistry
+	}{
+		{"", &ProviderGithubRegistry{}},
+		{"GitHub", &ProviderGithubRegistry{}},
+		{"github://my-org/my-registry", &ProviderGithubRegistry{}},
+		{"http://127.0.0.1:8080/selefra", &ProviderHttpRegistry{}},
+		{"file://" + mirrorDirectory, &ProviderLocalRegistry{}},
+		{mirrorDirectory, &ProviderLocalRegistry{}},
+	}
+	for _, testCase := range testCases {
+		registry, err := NewProviderRegistry(t.TempDir(), testCase.setting)
+		assert.Nil(t, err, testCase.setting)
+		assert.IsType(t, testCase.registryType, registry, testCase.setting)
+	}
+
+	registry, err := NewProviderRegistry(t.TempDir(), "github://my-org/my-registry")
+	assert.Nil(t, err)
+	assert.Equal(t, "my-org/my-registry", registry.(*ProviderGithubRegistry).buildRegistryRepoFullName())
+
+	for _, setting := range []string{"s3://bucket/registry", "github://my-org", "file:///not/exists"} {
+		_, err := NewProviderRegistry(t.TempDir(), setting)
+		assert.NotNil(t, err, setting)
+	}
+}
@@ -0,0 +1,303 @@
+package sql_parser
+
+import (
+	"sort"
+	"strconv"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// Statement A parsed PostgreSQL query statement
+type Statement struct {
+
+	// The statement that was parsed
+	SQL string
+
+	// The top-level query
+	Query *Query
+}
+
+// Relations All relations referenced anywhere in the statement, including subqueries and common table expressions, in order of appearance.
+// References to common table expressions are not relations and are not included
+func (x *Statement) Relations() []*RelationReference {
+	relations := make([]*RelationReference, 0)
+	x.Query.walk(func(query *Query) {
+		for _, selectClause := range query.Selects {
+			for _, tableExpression := range selectClause.From {
+				tableExpression.walkRelations(func(relation *RelationReference) {
+					if !relation.IsCommonTableExpression {
+						relations = append(relations, relation)
+					}
+				})
+			}
+		}
+	})
+	sort.SliceStable(relations, func(i, j int) bool {
+		return relations[i].Start < relations[j].Start
+	})
+	return relations
+}
+
+// CommonTableExpressions All common table expressions declared anywhere in the statement, in order of appearance
+func (x *Statement) CommonTableExpressions() []*CommonTableExpression {
+	commonTableExpressions := make([]*CommonTableExpression, 0)
+	x.Query.walk(func(query *Query) {
+		commonTableExpressions = append(commonTableExpressions, query.With...)
+	})
+	sort.SliceStable(commonTableExpressions, func(i, j int) bool {
+		return commonTableExpressions[i].Start < commonTableExpressions[j].Start
+	})
+	return commonTableExpressions
+}
+
+// OutputColumns The names of the columns the statement returns, following the naming rules of PostgreSQL.
+// A star is returned as * or qualifier.*, because the actual columns depend on the table definition
+func (x *Statement) OutputColumns() []string {
+	return x.Query.OutputColumns()
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// Query A query expression, possibly combined by set operations and with common table expressions
+type Query struct {
+
+	// Common table expressions declared by the WITH clause of this query
+	With []*CommonTableExpression
+
+	// Whether WITH RECURSIVE is used
+	IsRecursive bool
+
+	// The operands of the set operation, a simple query has only one
+	Selects []*SelectClause
+
+	// The set operators between the operands, len(Operators) == len(Selects) - 1
+	Operators []string
+
+	// Queries that appear in the ORDER BY, LIMIT, OFFSET ... clauses
+	SubQueries []*Query
+
+	// The position of the query in the statement, [Start, End)
+	Start, End int
+}
+
+// OutputColumns The column names of a set operation are decided by its first operand
+func (x *Query) OutputColumns() []string {
+	if len(x.Selects) == 0 {
+		return nil
+	}
+	return x.Selects[0].OutputColumns()
+}
+
+// Visit the query and all queries nested in it
+func (x *Query) walk(visitor func(query *Query)) {
+	if x == nil {
+		return
+	}
+	visitor(x)
+	for _, commonTableExpression := range x.With {
+		commonTableExpression.Query.walk(visitor)
+	}
+	for _, selectClause := range x.Selects {
+		selectClause.walk(visitor)
+	}
+	for _, subQuery := range x.SubQueries {
+		subQuery.walk(visitor)
+	}
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// CommonTableExpression An element of the WITH clause
+type CommonTableExpression struct {
+	Name string
+
+	// The column names declared after the name
+	Columns []string
+
+	Query *Query
+
+	// The position of the name in the statement, [Start, End)
+	Start, End int
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// SelectClause A single SELECT, VALUES or TABLE operand
+type SelectClause struct {
+
+	// The select list, empty for VALUES
+	Targets []*Target
+
+	// The number of columns when it is a VALUES list
+	ValuesColumnCount int
+
+	// The parenthesized query, when the operand is ( query )
+	Parenthesized *Query
+
+	// The FROM clause, every element is a table expression
+	From []TableExpression
+
+	// Queries that appear in the select list, WHERE, GROUP BY, HAVING or VALUES
+	SubQueries []*Query
+}
+
+// OutputColumns The names of the columns returned by this select
+func (x *SelectClause) OutputColumns() []string {
+	if x.Parenthesized != nil {
+		return x.Parenthesized.OutputColumns()
+	}
+	if x.ValuesColumnCount > 0 {
+		columns := make([]string, x.ValuesColumnCount)
+		for index := range columns {
+			columns[index] = "column" + strconv.Itoa(index+1)
+		}
+		return columns
+	}
+	columns := make([]string, len(x.Targets))
+	for index, target := range x.Targets {
+		columns[index] = target.Name
+	}
+	return columns
+}
+
+func (x *SelectClause) walk(visitor func(query *Query)) {
+	x.Parenthesized.walk(visitor)
+	for _, tableExpression := range x.From {
+		tableExpression.walkQueries(visitor)
+	}
+	for _, subQuery := range x.SubQueries {
+		subQuery.walk(visitor)
+	}
+}
+
+// Target An element of the select list
+type Target struct {
+
+	// The output name of the column
+	Name string
+
+	// The name given by AS or a bare label
+	Alias string
+
+	// The text of the expression
+	Expression string
+
+	// The position of the expression in the statement, [Start, End)
+	Start, End int
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// TableExpression An element of the FROM clause
+type TableExpression interface {
+
+	// Visit the relations referenced by the table expression
+	walkRelations(visitor func(relation *RelationReference))
+
+	// Visit the queries nested in the table expression
+	walkQueries(visitor func(query *Query))
+}
+
+// RelationReference A table or view referenced by name
+type RelationReference struct {
+
+	// The schema, empty if the name is not qualified
+	Schema string
+
+	// The name of the table
+	Name string
+
+	Alias string
+
+	// The name refers to a common table expression in scope instead of a table
+	IsCommonTableExpression bool
+
+	// The position of the table name in the statement, without the schema, [Start, End)
+	Start, End int
+
+	// The position of the reference including the schema
+	QualifiedStart int
+}
+
+var _ TableExpression = &RelationReference{}
+
+// IsQualified Whether the reference specifies a schema
+func (x *RelationReference) IsQualified() bool {
+	return x.Schema != ""
+}
+
+func (x *RelationReference) walkRelations(visitor func(relation *RelationReference)) {
+	visitor(x)
+}
+
+func (x *RelationReference) walkQueries(visitor func(query *Query)) {
+}
+
+// SubQueryReference A query in the FROM clause
+type SubQueryReference struct {
+	Query *Query
+
+	Alias string
+
+	IsLateral bool
+}
+
+var _ TableExpression = &SubQueryReference{}
+
+func (x *SubQueryReference) walkRelations(visitor func(relation *RelationReference)) {
+}
+
+func (x *SubQueryReference) walkQueries(visitor func(query *Query)) {
+	x.Query.walk(visitor)
+}
+
+// FunctionReference A set returning function in the FROM clause, such as jsonb_array_elements(...)
+type FunctionReference struct {
+	Name string
+
+	Alias string
+
+	// Queries in the arguments
+	SubQueries []*Query
+}
+
+var _ TableExpression = &FunctionReference{}
+
+func (x *FunctionReference) walkRelations(visitor func(relation *RelationReference)) {
+}
+
+func (x *FunctionReference) walkQueries(visitor func(query *Query)) {
+	for _, subQuery := range x.SubQueries {
+		subQuery.walk(visitor)
+	}
+}
+
+// JoinExpression Two table expressions joined together
+type JoinExpression struct {
+	Left TableExpression
+
+	// INNER, LEFT, RIGHT, FULL or CROSS, with NATURAL prefix if it is a natural join
+	JoinType string
+
+	Right TableExpression
+
+	// Queries in the ON condition
+	SubQueries []*Query
+}
+
+var _ TableExpression = &JoinExpression{}
+
+func (x *JoinExpression) walkRelations(visitor func(relation *RelationReference)) {
+	x.Left.walkRelations(visitor)
+	x.Right.walkRelations(visitor)
+}
+
+func (x *JoinExpression) walkQueries(visitor func(query *Query)) {
+	x.Left.walkQueries(visitor)
+	x.Right.walkQueries(visitor)
+	for _, subQuery := range x.SubQueries {
+		subQuery.walk(visitor)
+	}
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,362 @@
+package sql_parser
+
+import (
+	"fmt"
+	"github.com/songzhibin97/gkit/ternary"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// TokenType The type of lexical unit in a PostgreSQL statement
+type TokenType int
+
+const (
+	TokenTypeEOF TokenType = iota
+
+	// TokenTypeKeyword An unquoted identifier that is a reserved word in the positions the parser cares about
+	TokenTypeKeyword
+
+	// TokenTypeIdentifier An unquoted identifier, its value is folded to lower case like PostgreSQL does
+	TokenTypeIdentifier
+
+	// TokenTypeQuotedIdentifier A double-quoted identifier, its value is case-sensitive
+	TokenTypeQuotedIdentifier
+
+	// TokenTypeString A string constant, including escape strings, bit strings and dollar-quoted strings
+	TokenTypeString
+
+	// TokenTypeNumber A numeric constant
+	TokenTypeNumber
+
+	// TokenTypeParameter A positional parameter, such as $1
+	TokenTypeParameter
+
+	// TokenTypeOperator An operator, such as = or ::
+	TokenTypeOperator
+
+	// TokenTypePunctuation One of ( ) [ ] , ; .
+	TokenTypePunctuation
+)
+
+// Token A lexical unit with its position in the statement
+type Token struct {
+	Type TokenType
+
+	// For identifiers and keywords it is the normalized name, for other tokens it is the raw text
+	Value string
+
+	// The position of the token in the statement, [Start, End)
+	Start, End int
+}
+
+func (x *Token) is(tokenType TokenType, value string) bool {
+	return x.Type == tokenType && x.Value == value
+}
+
+// IsKeyword Whether the token is the given keyword, keyword must be lower case
+func (x *Token) IsKeyword(keyword string) bool {
+	return x.is(TokenTypeKeyword, keyword)
+}
+
+// IsPunctuation Whether the token is the given punctuation
+func (x *Token) IsPunctuation(punctuation string) bool {
+	return x.is(TokenTypePunctuation, punctuation)
+}
+
+// IsName Whether the token can be used as a name
+func (x *Token) IsName() bool {
+	return x.Type == TokenTypeIdentifier || x.Type == TokenTypeQuotedIdentifier
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// The keywords that delimit clauses, they can not be used as an alias without AS
+var keywordSet = map[string]struct{}{
+	"all": {}, "and": {}, "any": {}, "array": {}, "as": {}, "asc": {}, "between": {}, "by": {}, "case": {}, "cross": {},
+	"desc": {}, "distinct": {}, "else": {}, "end": {}, "except": {}, "exists": {}, "fetch": {}, "for": {}, "from": {},
+	"full": {}, "group": {}, "having": {}, "ilike": {}, "in": {}, "inner": {}, "intersect": {}, "is": {}, "join": {},
+	"lateral": {}, "left": {}, "like": {}, "limit": {}, "natural": {}, "not": {}, "null": {}, "offset": {}, "on": {},
+	"only": {}, "or": {}, "order": {}, "outer": {}, "recursive": {}, "returning": {}, "right": {}, "select": {},
+	"similar": {}, "table": {}, "then": {}, "union": {}, "using": {}, "values": {}, "when": {}, "where": {},
+	"window": {}, "with": {}, "tablesample": {},
+}
+
+// ErrSyntax Syntax errors report where in the statement they occur
+type ErrSyntax struct {
+	Position int
+	Message  string
+}
+
+func (x *ErrSyntax) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", x.Position, x.Message)
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// Tokenize Split a PostgreSQL statement into tokens, comments and whitespace are dropped
+func Tokenize(sql string) ([]*Token, error) {
+	tokens := make([]*Token, 0)
+	index := 0
+	for index < len(sql) {
+		c := sql[index]
+		switch {
+		case isSpace(c):
+			index++
+
+		// -- line comment
+		case c == '-' && peekByte(sql, index+1) == '-':
+			end := strings.IndexByte(sql[index:], '\n')
+			if end < 0 {
+				index = len(sql)
+			} else {
+				index += end + 1
+			}
+
+		// /* block comment */, which can be nested
+		case c == '/' && peekByte(sql, index+1) == '*':
+			end, err := scanBlockComment(sql, index)
+			if err != nil {
+				return nil, err
+			}
+			index = end
+
+		case c == '\'':
+			end, err := scanQuoted(sql, index, '\'', false)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, &Token{Type: TokenTypeString, Value: sql[index:end], Start: index, End: end})
+			index = end
+
+		case c == '"':
+			end, err := scanQuoted(sql, index, '"', false)
+			if err != nil {
+				return nil, err
+			}
+			name := strings.ReplaceAll(sql[index+1:end-1], `""`, `"`)
+			tokens = append(tokens, &Token{Type: TokenTypeQuotedIdentifier, Value: name, Start: index, End: end})
+			index = end
+
+		// E'...', B'...', X'...', N'...' and U&'...'
+		case isStringPrefix(sql, index):
+			quoteIndex := strings.IndexByte(sql[index:], '\'') + index
+			end, err := scanQuoted(sql, quoteIndex, '\'', c == 'e' || c == 'E')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, &Token{Type: TokenTypeString, Value: sql[index:end], Start: index, End: end})
+			index = end
+
+		case c == '$':
+			end, tokenType, err := scanDollar(sql, index)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, &Token{Type: tokenType, Value: sql[index:end], Start: index, End: end})
+			index = end
+
+		case isDigit(c) || (c == '.' && isDigit(peekByte(sql, index+1))):
+			end := scanNumber(sql, index)
+			tokens = append(tokens, &Token{Type: TokenTypeNumber, Value: sql[index:end], Start: index, End: end})
+			index = end
+
+		case isIdentifierStart(sql, index):
+			end := scanIdentifier(sql, index)
+			word := strings.ToLower(sql[index:end])
+			tokenType := TokenTypeIdentifier
+			if _, exists := keywordSet[word]; exists {
+				tokenType = TokenTypeKeyword
+			}
+			tokens = append(tokens, &Token{Type: tokenType, Value: word, Start: index, End: end})
+			index = end
+
+		case strings.IndexByte("()[],;.", c) >= 0:
+			tokens = append(tokens, &Token{Type: TokenTypePunctuation, Value: string(c), Start: index, End: index + 1})
+			index++
+
+		case c == ':' && peekByte(sql, index+1) == ':':
+			tokens = append(tokens, &Token{Type: TokenTypeOperator, Value: "::", Start: index, End: index + 2})
+			index += 2
+
+		case isOperatorChar(c):
+			end := index
+			for end < len(sql) && isOperatorChar(sql[end]) {
+				// a comment starts inside the operator
+				if (sql[end] == '-' && peekByte(sql, end+1) == '-') || (sql[end] == '/' && peekByte(sql, end+1) == '*') {
+					break
+				}
+				end++
+			}
+			if end == index {
+				end++
+			}
+			tokens = append(tokens, &Token{Type: TokenTypeOperator, Value: sql[index:end], Start: index, End: end})
+			index = end
+
+		default:
+			return nil, &ErrSyntax{Position: index, Message: fmt.Sprintf("unexpected character %q", c)}
+		}
+	}
+	tokens = append(tokens, &Token{Type: TokenTypeEOF, Start: len(sql), End: len(sql)})
+	return tokens, nil
+}
+
+func peekByte(s string, index int) byte {
+	if index < len(s) {
+		return s[index]
+	}
+	return 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isOperatorChar(c byte) bool {
+	return strings.IndexByte("+-*/<>=~!@#%^&|`?:", c) >= 0
+}
+
+func isIdentifierStart(s string, index int) bool {
+	c := s[index]
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' {
+		return true
+	}
+	if c >= utf8.RuneSelf {
+		r, _ := utf8.DecodeRuneInString(s[index:])
+		return unicode.IsLetter(r)
+	}
+	return false
+}
+
+func scanIdentifier(s string, index int) int {
+	for index < len(s) {
+		c := s[index]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$' || isDigit(c) {
+			index++
+			continue
+		}
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[index:])
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				index += size
+				continue
+			}
+		}
+		break
+	}
+	return index
+}
+
+func isStringPrefix(s string, index int) bool {
+	switch s[index] {
+	case 'e', 'E', 'b', 'B', 'x', 'X', 'n', 'N':
+		return peekByte(s, index+1) == '\''
+	case 'u', 'U':
+		return peekByte(s, index+1) == '&' && peekByte(s, index+2) == '\''
+	}
+	return false
+}
+
+// Scan a quoted token starting at index, the quote is escaped by doubling it, return the end position after the closing quote
+func scanQuoted(s string, index int, quote byte, backslashEscape bool) (int, error) {
+	i := index + 1
+	for i < len(s) {
+		switch s[i] {
+		case '\\':
+			if backslashEscape {
+				i += 2
+				continue
+			}
+		case quote:
+			if peekByte(s, i+1) == quote {
+				i += 2
+				continue
+			}
+			return i + 1, nil
+		}
+		i++
+	}
+	return 0, &ErrSyntax{Position: index, Message: fmt.Sprintf("unterminated quoted %s", ternary.ReturnString(quote == '"', "identifier", "string"))}
+}
+
+func scanBlockComment(s string, index int) (int, error) {
+	depth := 0
+	i := index
+	for i < len(s) {
+		if s[i] == '/' && peekByte(s, i+1) == '*' {
+			depth++
+			i += 2
+		} else if s[i] == '*' && peekByte(s, i+1) == '/' {
+			depth--
+			i += 2
+			if depth == 0 {
+				return i, nil
+			}
+		} else {
+			i++
+		}
+	}
+	return 0, &ErrSyntax{Position: index, Message: "unterminated /* comment"}
+}
+
+// Either a positional parameter $1 or a dollar-quoted string $tag$...$tag$
+func scanDollar(s string, index int) (int, TokenType, error) {
+	i := index + 1
+	if isDigit(peekByte(s, i)) {
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+		return i, TokenTypeParameter, nil
+	}
+	for i < len(s) && s[i] != '$' {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || isDigit(c) || c >= utf8.RuneSelf) {
+			return 0, TokenTypeEOF, &ErrSyntax{Position: index, Message: "invalid dollar quote"}
+		}
+		i++
+	}
+	if i >= len(s) {
+		return 0, TokenTypeEOF, &ErrSyntax{Position: index, Message: "invalid dollar quote"}
+	}
+	tag := s[index : i+1]
+	closeIndex := strings.Index(s[i+1:], tag)
+	if closeIndex < 0 {
+		return 0, TokenTypeEOF, &ErrSyntax{Position: index, Message: "unterminated dollar-quoted string"}
+	}
+	return i + 1 + closeIndex + len(tag), TokenTypeString, nil
+}
+
+func scanNumber(s string, index int) int {
+	i := index
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	if peekByte(s, i) == '.' && peekByte(s, i+1) != '.' {
+		i++
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+	}
+	if c := peekByte(s, i); c == 'e' || c == 'E' {
+		j := i + 1
+		if c := peekByte(s, j); c == '+' || c == '-' {
+			j++
+		}
+		if isDigit(peekByte(s, j)) {
+			i = j
+			for i < len(s) && isDigit(s[i]) {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
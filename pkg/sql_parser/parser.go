@@ -0,0 +1,854 @@
+package sql_parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// Parse Parse a single PostgreSQL query statement (SELECT, WITH, VALUES or TABLE).
+// Expressions are not parsed into a tree, only the structure that decides which relations and columns a query uses is kept
+func Parse(sql string) (*Statement, error) {
+	tokens, err := Tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{sql: sql, tokens: tokens}
+	if !p.isQueryStart(p.index) {
+		return nil, p.errorf("only SELECT statements are supported, but got %s", p.describe(p.peek()))
+	}
+	query, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().IsPunctuation(";") {
+		p.next()
+	}
+	if p.peek().Type != TokenTypeEOF {
+		return nil, p.errorf("unexpected %s after the end of the statement", p.describe(p.peek()))
+	}
+	return &Statement{SQL: sql, Query: query}, nil
+}
+
+// IsQuery Whether the text looks like a query statement, that is, it starts with SELECT, WITH, VALUES or TABLE
+func IsQuery(sql string) bool {
+	tokens, err := Tokenize(sql)
+	if err != nil {
+		return false
+	}
+	p := &parser{sql: sql, tokens: tokens}
+	return p.isQueryStart(p.index)
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+type parser struct {
+	sql    string
+	tokens []*Token
+	index  int
+
+	// The names of the common table expressions visible at the current position, the innermost scope is the last one
+	commonTableExpressionScopes []map[string]struct{}
+}
+
+func (x *parser) peek() *Token {
+	return x.peekAt(x.index)
+}
+
+func (x *parser) peekAt(index int) *Token {
+	if index < len(x.tokens) {
+		return x.tokens[index]
+	}
+	return x.tokens[len(x.tokens)-1]
+}
+
+func (x *parser) next() *Token {
+	token := x.peek()
+	if x.index < len(x.tokens)-1 {
+		x.index++
+	}
+	return token
+}
+
+func (x *parser) acceptKeyword(keywords ...string) bool {
+	for _, keyword := range keywords {
+		if x.peek().IsKeyword(keyword) {
+			x.next()
+			return true
+		}
+	}
+	return false
+}
+
+func (x *parser) expectKeyword(keyword string) error {
+	if !x.acceptKeyword(keyword) {
+		return x.errorf("expected %s, but got %s", strings.ToUpper(keyword), x.describe(x.peek()))
+	}
+	return nil
+}
+
+func (x *parser) expectPunctuation(punctuation string) error {
+	if !x.peek().IsPunctuation(punctuation) {
+		return x.errorf("expected \"%s\", but got %s", punctuation, x.describe(x.peek()))
+	}
+	x.next()
+	return nil
+}
+
+func (x *parser) errorf(format string, args ...any) error {
+	return &ErrSyntax{Position: x.peek().Start, Message: fmt.Sprintf(format, args...)}
+}
+
+func (x *parser) describe(token *Token) string {
+	if token.Type == TokenTypeEOF {
+		return "end of statement"
+	}
+	return fmt.Sprintf("\"%s\"", x.sql[token.Start:token.End])
+}
+
+// Whether a query starts at the given token, parentheses around the query are allowed
+func (x *parser) isQueryStart(index int) bool {
+	for x.peekAt(index).IsPunctuation("(") {
+		index++
+	}
+	token := x.peekAt(index)
+	return token.IsKeyword("select") || token.IsKeyword("with") || token.IsKeyword("values") || token.IsKeyword("table")
+}
+
+func (x *parser) isCommonTableExpression(name string) bool {
+	for _, scope := range x.commonTableExpressionScopes {
+		if _, exists := scope[name]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// query := [ WITH [ RECURSIVE ] cte [, ...] ] operand [ { UNION | INTERSECT | EXCEPT } [ ALL | DISTINCT ] operand ... ] [ ORDER BY ... ] [ LIMIT ... ] ...
+func (x *parser) parseQuery() (*Query, error) {
+	query := &Query{Start: x.peek().Start}
+
+	if x.acceptKeyword("with") {
+		scope := make(map[string]struct{})
+		x.commonTableExpressionScopes = append(x.commonTableExpressionScopes, scope)
+		defer func() {
+			x.commonTableExpressionScopes = x.commonTableExpressionScopes[:len(x.commonTableExpressionScopes)-1]
+		}()
+		query.IsRecursive = x.acceptKeyword("recursive")
+		for {
+			commonTableExpression, err := x.parseCommonTableExpression(scope, query.IsRecursive)
+			if err != nil {
+				return nil, err
+			}
+			query.With = append(query.With, commonTableExpression)
+			if !x.peek().IsPunctuation(",") {
+				break
+			}
+			x.next()
+		}
+	}
+
+	for {
+		selectClause, err := x.parseSelectOperand()
+		if err != nil {
+			return nil, err
+		}
+		query.Selects = append(query.Selects, selectClause)
+		if !x.acceptKeyword("union", "intersect", "except") {
+			break
+		}
+		operator := strings.ToUpper(x.peekAt(x.index - 1).Value)
+		if x.acceptKeyword("all", "distinct") {
+			operator += " " + strings.ToUpper(x.peekAt(x.index-1).Value)
+		}
+		query.Operators = append(query.Operators, operator)
+	}
+
+	// ORDER BY, LIMIT, OFFSET, FETCH and FOR UPDATE run to the end of the query
+	subQueries, err := x.skipExpression(func(token *Token) bool {
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	query.SubQueries = subQueries
+
+	query.End = x.peekAt(x.index - 1).End
+	return query, nil
+}
+
+// cte := name [ ( column [, ...] ) ] AS [ [ NOT ] MATERIALIZED ] ( query )
+func (x *parser) parseCommonTableExpression(scope map[string]struct{}, isRecursive bool) (*CommonTableExpression, error) {
+	nameToken := x.peek()
+	if !nameToken.IsName() {
+		return nil, x.errorf("expected the name of a common table expression, but got %s", x.describe(nameToken))
+	}
+	x.next()
+	commonTableExpression := &CommonTableExpression{Name: nameToken.Value, Start: nameToken.Start, End: nameToken.End}
+	if x.peek().IsPunctuation("(") {
+		columns, err := x.parseNameList()
+		if err != nil {
+			return nil, err
+		}
+		commonTableExpression.Columns = columns
+	}
+	if err := x.expectKeyword("as"); err != nil {
+		return nil, err
+	}
+	x.acceptKeyword("not")
+	if x.peek().Type == TokenTypeIdentifier && x.peek().Value == "materialized" {
+		x.next()
+	}
+
+	// A recursive common table expression can reference itself
+	if isRecursive {
+		scope[commonTableExpression.Name] = struct{}{}
+	}
+	if err := x.expectPunctuation("("); err != nil {
+		return nil, err
+	}
+	query, err := x.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+	if err := x.expectPunctuation(")"); err != nil {
+		return nil, err
+	}
+	commonTableExpression.Query = query
+	scope[commonTableExpression.Name] = struct{}{}
+	return commonTableExpression, nil
+}
+
+// operand := ( query ) | SELECT ... | VALUES ( ... ) [, ...] | TABLE name
+func (x *parser) parseSelectOperand() (*SelectClause, error) {
+	switch {
+	case x.peek().IsPunctuation("("):
+		x.next()
+		query, err := x.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		if err := x.expectPunctuation(")"); err != nil {
+			return nil, err
+		}
+		return &SelectClause{Parenthesized: query}, nil
+	case x.acceptKeyword("select"):
+		return x.parseSelect()
+	case x.acceptKeyword("values"):
+		return x.parseValues()
+	case x.acceptKeyword("table"):
+		x.acceptKeyword("only")
+		relation, err := x.parseRelationName()
+		if err != nil {
+			return nil, err
+		}
+		if x.peek().Type == TokenTypeOperator && x.peek().Value == "*" {
+			x.next()
+		}
+		relation.IsCommonTableExpression = !relation.IsQualified() && x.isCommonTableExpression(relation.Name)
+		return &SelectClause{
+			Targets: []*Target{{Name: "*", Expression: "*", Start: relation.QualifiedStart, End: relation.End}},
+			From:    []TableExpression{relation},
+		}, nil
+	default:
+		return nil, x.errorf("expected SELECT, VALUES or TABLE, but got %s", x.describe(x.peek()))
+	}
+}
+
+// The keywords that end a select list or a condition of a select
+var selectClauseEndKeywords = []string{"from", "where", "group", "having", "window", "order", "limit", "offset", "fetch", "for", "union", "intersect", "except"}
+
+func isSelectClauseEnd(token *Token, keywords ...string) bool {
+	for _, keyword := range keywords {
+		if token.IsKeyword(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// SELECT [ ALL | DISTINCT [ ON ( ... ) ] ] target [, ...] [ FROM ... ] [ WHERE ... ] [ GROUP BY ... ] [ HAVING ... ] [ WINDOW ... ]
+func (x *parser) parseSelect() (*SelectClause, error) {
+	selectClause := &SelectClause{}
+
+	if x.acceptKeyword("distinct") {
+		if x.acceptKeyword("on") {
+			subQueries, err := x.skipParenthesized()
+			if err != nil {
+				return nil, err
+			}
+			selectClause.SubQueries = append(selectClause.SubQueries, subQueries...)
+		}
+	} else {
+		x.acceptKeyword("all")
+	}
+
+	// the select list can be empty
+	if !isSelectClauseEnd(x.peek(), selectClauseEndKeywords...) && !x.isExpressionEnd(x.peek()) {
+		for {
+			target, subQueries, err := x.parseTarget()
+			if err != nil {
+				return nil, err
+			}
+			selectClause.Targets = append(selectClause.Targets, target)
+			selectClause.SubQueries = append(selectClause.SubQueries, subQueries...)
+			if !x.peek().IsPunctuation(",") {
+				break
+			}
+			x.next()
+		}
+	}
+
+	if x.acceptKeyword("from") {
+		for {
+			tableExpression, err := x.parseTableExpression()
+			if err != nil {
+				return nil, err
+			}
+			selectClause.From = append(selectClause.From, tableExpression)
+			if !x.peek().IsPunctuation(",") {
+				break
+			}
+			x.next()
+		}
+	}
+
+	// WHERE, GROUP BY, HAVING and WINDOW are only searched for subqueries
+	for _, keyword := range []string{"where", "group", "having", "window"} {
+		if !x.acceptKeyword(keyword) {
+			continue
+		}
+		subQueries, err := x.skipExpression(func(token *Token) bool {
+			return isSelectClauseEnd(token, selectClauseEndKeywords...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		selectClause.SubQueries = append(selectClause.SubQueries, subQueries...)
+	}
+
+	return selectClause, nil
+}
+
+// VALUES ( expression [, ...] ) [, ...]
+func (x *parser) parseValues() (*SelectClause, error) {
+	selectClause := &SelectClause{}
+	for {
+		if err := x.expectPunctuation("("); err != nil {
+			return nil, err
+		}
+		columnCount := 0
+		for {
+			subQueries, err := x.skipExpression(func(token *Token) bool {
+				return token.IsPunctuation(",")
+			})
+			if err != nil {
+				return nil, err
+			}
+			selectClause.SubQueries = append(selectClause.SubQueries, subQueries...)
+			columnCount++
+			if !x.peek().IsPunctuation(",") {
+				break
+			}
+			x.next()
+		}
+		if err := x.expectPunctuation(")"); err != nil {
+			return nil, err
+		}
+		if selectClause.ValuesColumnCount == 0 {
+			selectClause.ValuesColumnCount = columnCount
+		}
+		if !x.peek().IsPunctuation(",") {
+			break
+		}
+		x.next()
+	}
+	return selectClause, nil
+}
+
+// target := * | expression [ [ AS ] label ]
+func (x *parser) parseTarget() (*Target, []*Query, error) {
+	startIndex := x.index
+	subQueries, err := x.skipExpression(func(token *Token) bool {
+		return token.IsPunctuation(",") || token.IsKeyword("as") || isSelectClauseEnd(token, selectClauseEndKeywords...)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	endIndex := x.index
+	if endIndex == startIndex {
+		return nil, nil, x.errorf("expected an expression, but got %s", x.describe(x.peek()))
+	}
+
+	alias := ""
+	if x.acceptKeyword("as") {
+		token := x.peek()
+		// AS can be followed by any word, even a reserved one
+		if !token.IsName() && token.Type != TokenTypeKeyword {
+			return nil, nil, x.errorf("expected a column label, but got %s", x.describe(token))
+		}
+		x.next()
+		alias = token.Value
+	} else if endIndex-startIndex >= 2 && x.isBareLabel(endIndex-1) {
+		// expression label, without AS
+		alias = x.tokens[endIndex-1].Value
+		endIndex--
+	}
+
+	expressionTokens := x.tokens[startIndex:endIndex]
+	target := &Target{
+		Alias:      alias,
+		Start:      expressionTokens[0].Start,
+		End:        expressionTokens[len(expressionTokens)-1].End,
+		Expression: x.sql[expressionTokens[0].Start:expressionTokens[len(expressionTokens)-1].End],
+	}
+	if alias != "" {
+		target.Name = alias
+	} else {
+		target.Name = x.columnName(expressionTokens)
+	}
+	return target, subQueries, nil
+}
+
+// Whether the token at index is a column label written without AS, the token before it must be able to end an expression
+func (x *parser) isBareLabel(index int) bool {
+	token := x.tokens[index]
+	if !token.IsName() {
+		return false
+	}
+	previous := x.tokens[index-1]
+	switch previous.Type {
+	case TokenTypeIdentifier, TokenTypeQuotedIdentifier, TokenTypeString, TokenTypeNumber, TokenTypeParameter:
+		return true
+	case TokenTypePunctuation:
+		return previous.Value == ")" || previous.Value == "]"
+	case TokenTypeKeyword:
+		return previous.Value == "end" || previous.Value == "null"
+	}
+	return false
+}
+
+// The name PostgreSQL gives to a column without a label
+func (x *parser) columnName(tokens []*Token) string {
+	if len(tokens) == 0 {
+		return "?column?"
+	}
+
+	// qualifier.* is kept as it is
+	if last := tokens[len(tokens)-1]; len(tokens) >= 3 && last.Type == TokenTypeOperator && last.Value == "*" && tokens[len(tokens)-2].IsPunctuation(".") {
+		return x.sql[tokens[0].Start:last.End]
+	}
+
+	// a::type is named after a
+	depth := 0
+	for index := len(tokens) - 1; index > 0; index-- {
+		token := tokens[index]
+		switch {
+		case token.IsPunctuation(")") || token.IsPunctuation("]"):
+			depth++
+		case token.IsPunctuation("(") || token.IsPunctuation("["):
+			depth--
+		case depth == 0 && token.Type == TokenTypeOperator:
+			if token.Value == "::" {
+				return x.columnName(tokens[:index])
+			}
+			// any other operator at the top level makes it an anonymous expression
+			return "?column?"
+		}
+	}
+
+	first := tokens[0]
+	switch {
+	case first.IsKeyword("case"):
+		return "case"
+	case first.IsKeyword("exists"):
+		return "exists"
+	case first.IsKeyword("array"):
+		return "array"
+	case first.IsPunctuation("("):
+		if last := tokens[len(tokens)-1]; last.IsPunctuation(")") && x.matchingParenthesis(tokens, 0) == len(tokens)-1 {
+			return x.columnName(tokens[1 : len(tokens)-1])
+		}
+		return "?column?"
+	case first.Type == TokenTypeOperator && first.Value == "*" && len(tokens) == 1:
+		return "*"
+	case !first.IsName():
+		return "?column?"
+	}
+
+	// name [. name ...] [. *] [ ( ... ) ] [ [ ... ] ]
+	index := 0
+	name := first.Value
+	for index+2 < len(tokens) && tokens[index+1].IsPunctuation(".") {
+		next := tokens[index+2]
+		if !next.IsName() {
+			break
+		}
+		name = next.Value
+		index += 2
+	}
+	if index+1 == len(tokens) {
+		return name
+	}
+	// a function call, which may be followed by FILTER or OVER
+	if tokens[index+1].IsPunctuation("(") {
+		return name
+	}
+	// array subscript
+	if tokens[index+1].IsPunctuation("[") {
+		return name
+	}
+	return "?column?"
+}
+
+func (x *parser) matchingParenthesis(tokens []*Token, open int) int {
+	depth := 0
+	for index := open; index < len(tokens); index++ {
+		if tokens[index].IsPunctuation("(") {
+			depth++
+		} else if tokens[index].IsPunctuation(")") {
+			depth--
+			if depth == 0 {
+				return index
+			}
+		}
+	}
+	return -1
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// table_expression := primary { [ NATURAL ] [ INNER | { LEFT | RIGHT | FULL } [ OUTER ] | CROSS ] JOIN primary [ ON ... | USING ( ... ) ] }
+func (x *parser) parseTableExpression() (TableExpression, error) {
+	left, err := x.parseTablePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		joinType := ""
+		if x.acceptKeyword("natural") {
+			joinType = "NATURAL "
+		}
+		switch {
+		case x.acceptKeyword("cross"):
+			joinType += "CROSS"
+		case x.acceptKeyword("inner"):
+			joinType += "INNER"
+		case x.acceptKeyword("left", "right", "full"):
+			joinType += strings.ToUpper(x.peekAt(x.index - 1).Value)
+			x.acceptKeyword("outer")
+		default:
+			if x.peek().IsKeyword("join") {
+				joinType += "INNER"
+			} else if joinType != "" {
+				return nil, x.errorf("expected JOIN, but got %s", x.describe(x.peek()))
+			} else {
+				return left, nil
+			}
+		}
+		if err := x.expectKeyword("join"); err != nil {
+			return nil, err
+		}
+		right, err := x.parseTablePrimary()
+		if err != nil {
+			return nil, err
+		}
+		join := &JoinExpression{Left: left, JoinType: joinType, Right: right}
+		if x.acceptKeyword("on") {
+			subQueries, err := x.skipExpression(func(token *Token) bool {
+				return token.IsPunctuation(",") || isJoinStart(token) || isSelectClauseEnd(token, selectClauseEndKeywords...)
+			})
+			if err != nil {
+				return nil, err
+			}
+			join.SubQueries = subQueries
+		} else if x.acceptKeyword("using") {
+			if _, err := x.parseNameList(); err != nil {
+				return nil, err
+			}
+			x.parseAlias()
+		}
+		left = join
+	}
+}
+
+func isJoinStart(token *Token) bool {
+	return isSelectClauseEnd(token, "natural", "cross", "inner", "left", "right", "full", "join")
+}
+
+// primary := [ ONLY ] relation [ * ] [ TABLESAMPLE ... ] [ alias ] | [ LATERAL ] ( query ) [ alias ] | [ LATERAL ] function ( ... ) [ alias ] | [ LATERAL ] ROWS FROM ( function ( ... ) [, ...] ) [ alias ] | ( table_expression ) [ alias ]
+func (x *parser) parseTablePrimary() (TableExpression, error) {
+	isLateral := x.acceptKeyword("lateral")
+
+	if x.peek().IsPunctuation("(") {
+		startIndex := x.index
+		// ( ( ... ) ... ) can be either a join or a query, try the join first
+		if !x.isQueryStart(startIndex+1) || x.peekAt(startIndex+1).IsPunctuation("(") {
+			if tableExpression, err := x.parseParenthesizedJoin(); err == nil {
+				return tableExpression, nil
+			} else if !x.isQueryStart(startIndex + 1) {
+				return nil, err
+			}
+			x.index = startIndex
+		}
+		x.next()
+		query, err := x.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		if err := x.expectPunctuation(")"); err != nil {
+			return nil, err
+		}
+		return &SubQueryReference{Query: query, Alias: x.parseAlias(), IsLateral: isLateral}, nil
+	}
+
+	// ROWS is not reserved, it is a table name unless FROM ( follows
+	if token := x.peek(); token.Type == TokenTypeIdentifier && token.Value == "rows" && x.peekAt(x.index+1).IsKeyword("from") && x.peekAt(x.index+2).IsPunctuation("(") {
+		x.next()
+		x.next()
+		subQueries, err := x.skipParenthesized()
+		if err != nil {
+			return nil, err
+		}
+		if err := x.parseWithOrdinality(); err != nil {
+			return nil, err
+		}
+		return &FunctionReference{Name: "rows from", Alias: x.parseAlias(), SubQueries: subQueries}, nil
+	}
+
+	x.acceptKeyword("only")
+	relation, err := x.parseRelationName()
+	if err != nil {
+		return nil, err
+	}
+
+	// set returning function
+	if x.peek().IsPunctuation("(") {
+		subQueries, err := x.skipParenthesized()
+		if err != nil {
+			return nil, err
+		}
+		if err := x.parseWithOrdinality(); err != nil {
+			return nil, err
+		}
+		return &FunctionReference{Name: relation.Name, Alias: x.parseAlias(), SubQueries: subQueries}, nil
+	}
+
+	if x.peek().Type == TokenTypeOperator && x.peek().Value == "*" {
+		x.next()
+	}
+	if x.acceptKeyword("tablesample") {
+		// method ( arguments ) [ REPEATABLE ( seed ) ]
+		x.next()
+		if _, err := x.skipParenthesized(); err != nil {
+			return nil, err
+		}
+		if x.peek().Type == TokenTypeIdentifier && x.peek().Value == "repeatable" {
+			x.next()
+			if _, err := x.skipParenthesized(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	relation.Alias = x.parseAlias()
+	relation.IsCommonTableExpression = !relation.IsQualified() && x.isCommonTableExpression(relation.Name)
+	return relation, nil
+}
+
+// ( table_expression ) [ alias ]
+func (x *parser) parseParenthesizedJoin() (TableExpression, error) {
+	if err := x.expectPunctuation("("); err != nil {
+		return nil, err
+	}
+	tableExpression, err := x.parseTableExpression()
+	if err != nil {
+		return nil, err
+	}
+	if err := x.expectPunctuation(")"); err != nil {
+		return nil, err
+	}
+	if alias := x.parseAlias(); alias != "" {
+		if join, ok := tableExpression.(*JoinExpression); ok {
+			return &SubQueryReference{Query: &Query{Selects: []*SelectClause{{From: []TableExpression{join}}}}, Alias: alias}, nil
+		}
+	}
+	return tableExpression, nil
+}
+
+// [ WITH ORDINALITY ] after a set returning function
+func (x *parser) parseWithOrdinality() error {
+	if !x.acceptKeyword("with") {
+		return nil
+	}
+	if x.peek().Type != TokenTypeIdentifier || x.peek().Value != "ordinality" {
+		return x.errorf("expected ORDINALITY, but got %s", x.describe(x.peek()))
+	}
+	x.next()
+	return nil
+}
+
+// [ catalog . ] [ schema . ] name
+func (x *parser) parseRelationName() (*RelationReference, error) {
+	names := make([]*Token, 0)
+	for {
+		token := x.peek()
+		if !token.IsName() {
+			return nil, x.errorf("expected a table name, but got %s", x.describe(token))
+		}
+		x.next()
+		names = append(names, token)
+		if !x.peek().IsPunctuation(".") {
+			break
+		}
+		x.next()
+	}
+	if len(names) > 3 {
+		return nil, &ErrSyntax{Position: names[0].Start, Message: "improper qualified name (too many dotted names)"}
+	}
+	last := names[len(names)-1]
+	relation := &RelationReference{Name: last.Value, Start: last.Start, End: last.End, QualifiedStart: names[0].Start}
+	if len(names) > 1 {
+		relation.Schema = names[len(names)-2].Value
+	}
+	return relation, nil
+}
+
+// alias := [ AS ] name [ ( column [, ...] ) ]
+func (x *parser) parseAlias() string {
+	hasAs := x.acceptKeyword("as")
+	token := x.peek()
+	if !token.IsName() && !(hasAs && token.Type == TokenTypeKeyword) {
+		return ""
+	}
+	x.next()
+	if x.peek().IsPunctuation("(") {
+		// the column aliases are not needed, a malformed list is reported by the caller
+		_, _ = x.parseNameList()
+	}
+	return token.Value
+}
+
+// ( name [, ...] )
+func (x *parser) parseNameList() ([]string, error) {
+	if err := x.expectPunctuation("("); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for {
+		token := x.peek()
+		if !token.IsName() {
+			return nil, x.errorf("expected a column name, but got %s", x.describe(token))
+		}
+		x.next()
+		names = append(names, token.Value)
+		// column definition list of a function, such as (key text, value jsonb)
+		for !x.peek().IsPunctuation(",") && !x.peek().IsPunctuation(")") && x.peek().Type != TokenTypeEOF {
+			if x.peek().IsPunctuation("(") {
+				if _, err := x.skipParenthesized(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			x.next()
+		}
+		if !x.peek().IsPunctuation(",") {
+			break
+		}
+		x.next()
+	}
+	if err := x.expectPunctuation(")"); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// Whether the token ends any expression no matter where it is
+func (x *parser) isExpressionEnd(token *Token) bool {
+	return token.Type == TokenTypeEOF || token.IsPunctuation(")") || token.IsPunctuation(";")
+}
+
+// Skip an expression until isEnd returns true at the top level, the queries nested in the expression are parsed and returned
+func (x *parser) skipExpression(isEnd func(token *Token) bool) ([]*Query, error) {
+	subQueries := make([]*Query, 0)
+	startIndex := x.index
+	for {
+		token := x.peek()
+		if x.isExpressionEnd(token) {
+			return subQueries, nil
+		}
+		// the GROUP in WITHIN GROUP ( ORDER BY ... ) does not start a GROUP BY clause
+		isWithinGroup := token.IsKeyword("group") && x.index > startIndex && x.peekAt(x.index-1).Type == TokenTypeIdentifier && x.peekAt(x.index-1).Value == "within"
+		if !isWithinGroup && isEnd(token) {
+			return subQueries, nil
+		}
+		switch {
+		case token.IsPunctuation("("):
+			queries, err := x.skipParenthesized()
+			if err != nil {
+				return nil, err
+			}
+			subQueries = append(subQueries, queries...)
+		case token.IsPunctuation("["):
+			queries, err := x.skipBracketed()
+			if err != nil {
+				return nil, err
+			}
+			subQueries = append(subQueries, queries...)
+		case token.IsPunctuation("]"):
+			return nil, x.errorf("unexpected \"]\"")
+		default:
+			x.next()
+		}
+	}
+}
+
+// Skip ( ... ), which is either a query or a list of expressions
+func (x *parser) skipParenthesized() ([]*Query, error) {
+	if err := x.expectPunctuation("("); err != nil {
+		return nil, err
+	}
+	if x.isQueryStart(x.index) {
+		query, err := x.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		if err := x.expectPunctuation(")"); err != nil {
+			return nil, err
+		}
+		return []*Query{query}, nil
+	}
+	subQueries, err := x.skipExpression(func(token *Token) bool {
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := x.expectPunctuation(")"); err != nil {
+		return nil, err
+	}
+	return subQueries, nil
+}
+
+// Skip [ ... ]
+func (x *parser) skipBracketed() ([]*Query, error) {
+	if err := x.expectPunctuation("["); err != nil {
+		return nil, err
+	}
+	subQueries, err := x.skipExpression(func(token *Token) bool {
+		return token.IsPunctuation("]")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := x.expectPunctuation("]"); err != nil {
+		return nil, err
+	}
+	return subQueries, nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
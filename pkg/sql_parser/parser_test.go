@@ -0,0 +1,118 @@
+package sql_parser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func relationNames(statement *Statement) []string {
+	names := make([]string, 0)
+	for _, relation := range statement.Relations() {
+		if relation.IsQualified() {
+			names = append(names, relation.Schema+"."+relation.Name)
+		} else {
+			names = append(names, relation.Name)
+		}
+	}
+	return names
+}
+
+func TestParse(t *testing.T) {
+	sql := `SELECT d.id, d.title AS "Key Title", count(*) total, u.arn::text
+FROM github_repository_deploy_keys AS d
+	LEFT JOIN aws_iam_users u ON u.user_name = d.title
+WHERE d.read_only = false -- aws_s3_buckets in a comment
+	AND d.title <> 'aws_ec2_instances'
+	AND d.id IN (SELECT id FROM "GitHub_Keys" WHERE key LIKE '%' || $1)
+GROUP BY d.id`
+	statement, err := Parse(sql)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"github_repository_deploy_keys", "aws_iam_users", "GitHub_Keys"}, relationNames(statement))
+	assert.Equal(t, []string{"id", "Key Title", "total", "arn"}, statement.OutputColumns())
+
+	relations := statement.Relations()
+	assert.Equal(t, "d", relations[0].Alias)
+	assert.Equal(t, "u", relations[1].Alias)
+	assert.Equal(t, "github_repository_deploy_keys", sql[relations[0].Start:relations[0].End])
+	assert.Equal(t, `"GitHub_Keys"`, sql[relations[2].Start:relations[2].End])
+}
+
+func TestParse_CommonTableExpression(t *testing.T) {
+	statement, err := Parse(`WITH RECURSIVE tree(id, parent) AS (
+	SELECT id, parent FROM aws_organizations_accounts WHERE parent IS NULL
+	UNION ALL
+	SELECT a.id, a.parent FROM aws_organizations_accounts a JOIN tree t ON a.parent = t.id
+), users AS MATERIALIZED (SELECT * FROM public.aws_iam_users)
+SELECT tree.id, users.* FROM tree, users, LATERAL jsonb_array_elements(users.tags) AS tag(value)
+ORDER BY (SELECT max(id) FROM aws_s3_buckets) LIMIT 10;`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"aws_organizations_accounts", "aws_organizations_accounts", "public.aws_iam_users", "aws_s3_buckets"}, relationNames(statement))
+	assert.Equal(t, []string{"id", "users.*"}, statement.OutputColumns())
+
+	commonTableExpressions := statement.CommonTableExpressions()
+	assert.Equal(t, 2, len(commonTableExpressions))
+	assert.Equal(t, "tree", commonTableExpressions[0].Name)
+	assert.Equal(t, []string{"id", "parent"}, commonTableExpressions[0].Columns)
+	assert.Equal(t, "users", commonTableExpressions[1].Name)
+}
+
+func TestParse_CommonTableExpressionScope(t *testing.T) {
+	// the name of a common table expression is only hidden inside the query that declares it
+	statement, err := Parse(`SELECT * FROM (WITH t AS (SELECT 1) SELECT * FROM t) s, t`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"t"}, relationNames(statement))
+}
+
+func TestParse_Joins(t *testing.T) {
+	statement, err := Parse(`select * from (a natural join b) cross join c
+	full outer join ((select 1 as x) union (select 2)) v on true
+	inner join d using (id), e tablesample system (10), only f *`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, relationNames(statement))
+}
+
+func TestParse_RowsFrom(t *testing.T) {
+	statement, err := Parse(`select * from rows from (jsonb_array_elements(x.tags), unnest((select array_agg(id) from aws_s3_buckets))) with ordinality as t(tag, id, n), aws_iam_users, rows`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"aws_s3_buckets", "aws_iam_users", "rows"}, relationNames(statement))
+}
+
+func TestParse_OutputColumns(t *testing.T) {
+	testCases := map[string][]string{
+		`SELECT 1, 'a' b, a + b, (c), case when x then 1 end, exists(select 1), t.x[1], "Mixed"`: {"?column?", "b", "?column?", "c", "case", "exists", "x", "Mixed"},
+		`VALUES (1, 2), (3, 4)`:                                        {"column1", "column2"},
+		`SELECT a FROM t UNION SELECT b FROM t`:                        {"a"},
+		`SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY a) FROM t`: {"percentile_cont"},
+		`TABLE aws_s3_buckets`:                                         {"*"},
+	}
+	for sql, columns := range testCases {
+		statement, err := Parse(sql)
+		assert.Nil(t, err, sql)
+		assert.Equal(t, columns, statement.OutputColumns(), sql)
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	for _, sql := range []string{
+		`DELETE FROM aws_s3_buckets`,
+		`SELECT * FROM aws_s3_buckets WHERE name = 'unterminated`,
+		`SELECT * FROM (SELECT 1`,
+		`SELECT * FROM a JOIN`,
+		`SELECT 1; SELECT 2`,
+	} {
+		_, err := Parse(sql)
+		assert.NotNil(t, err, sql)
+		_, ok := err.(*ErrSyntax)
+		assert.True(t, ok, sql)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tokens, err := Tokenize(`SELECT E'it\'s', $tag$ a ' b $tag$, "A""B", 1.5e3, x::int /* a /* nested */ comment */ FROM t`)
+	assert.Nil(t, err)
+	values := make([]string, 0)
+	for _, token := range tokens {
+		values = append(values, token.Value)
+	}
+	assert.Equal(t, []string{"select", `E'it\'s'`, ",", "$tag$ a ' b $tag$", ",", `A"B`, ",", "1.5e3", ",", "x", "::", "int", "from", "t", ""}, values)
+}
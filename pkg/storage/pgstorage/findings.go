@@ -0,0 +1,261 @@
+package pgstorage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra-provider-sdk/storage"
+	"github.com/selefra/selefra/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+const (
+
+	// RunsTableName Each apply is a run, the table keeps the history of runs
+	RunsTableName = "selefra_runs"
+
+	// FindingsTableName The findings of the rules of every run
+	FindingsTableName = "selefra_findings"
+
+	// FindingsSchemaVersionKey The version of the tables above, used to upgrade them when the layout changes
+	FindingsSchemaVersionKey = "findings-schema-version"
+)
+
+// The statements to create or upgrade the findings tables, the version of the tables is the number of statements executed.
+// Only append to it, never modify the statements that have been released
+var findingsSchemaMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS ` + RunsTableName + ` (
+		run_id         TEXT PRIMARY KEY,
+		project_name   TEXT        NOT NULL DEFAULT '',
+		hostname       TEXT        NOT NULL DEFAULT '',
+		status         TEXT        NOT NULL,
+		findings_count BIGINT      NOT NULL DEFAULT 0,
+		started_at     TIMESTAMPTZ NOT NULL,
+		finished_at    TIMESTAMPTZ
+	)`,
+	`CREATE TABLE IF NOT EXISTS ` + FindingsTableName + ` (
+		id               BIGSERIAL PRIMARY KEY,
+		run_id           TEXT        NOT NULL REFERENCES ` + RunsTableName + `(run_id) ON DELETE CASCADE,
+		fingerprint      TEXT        NOT NULL,
+		rule_name        TEXT        NOT NULL,
+		rule_id          TEXT        NOT NULL DEFAULT '',
+		severity         TEXT        NOT NULL DEFAULT '',
+		title            TEXT        NOT NULL DEFAULT '',
+		output           TEXT        NOT NULL DEFAULT '',
+		module_name      TEXT        NOT NULL DEFAULT '',
+		provider_name    TEXT        NOT NULL DEFAULT '',
+		provider_version TEXT        NOT NULL DEFAULT '',
+		provider_schema  TEXT        NOT NULL DEFAULT '',
+		resource_id      TEXT        NOT NULL DEFAULT '',
+		labels           JSONB       NOT NULL DEFAULT '{}',
+		created_at       TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS ` + FindingsTableName + `_run_id_idx ON ` + FindingsTableName + ` (run_id)`,
+	`CREATE INDEX IF NOT EXISTS ` + FindingsTableName + `_fingerprint_idx ON ` + FindingsTableName + ` (fingerprint)`,
+}
+
+// Run status
+const (
+	RunStatusRunning = "running"
+	RunStatusSuccess = "success"
+	RunStatusFailed  = "failed"
+)
+
+// RunInformation One row of the selefra_runs table
+type RunInformation struct {
+	RunID         string
+	ProjectName   string
+	Hostname      string
+	Status        string
+	FindingsCount int64
+	StartedAt     time.Time
+	FinishedAt    time.Time
+}
+
+// Finding One row of the selefra_findings table, it is also the item of a baseline file
+type Finding struct {
+	RunID string `json:"run_id,omitempty"`
+
+	// Identifies the same finding across runs, see BuildFindingFingerprint
+	Fingerprint string `json:"fingerprint"`
+
+	RuleName string `json:"rule_name"`
+	RuleID   string `json:"rule_id,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Title    string `json:"title,omitempty"`
+
+	// The rendered output of the rule
+	Output string `json:"output,omitempty"`
+
+	ModuleName string `json:"module_name,omitempty"`
+
+	// The primary provider the rule query was executed on
+	ProviderName    string `json:"provider_name,omitempty"`
+	ProviderVersion string `json:"provider_version,omitempty"`
+	ProviderSchema  string `json:"provider_schema,omitempty"`
+
+	// The resource_id label of the rule, it may be empty
+	ResourceID string `json:"resource_id,omitempty"`
+
+	// The rendered labels of the rule as json
+	Labels string `json:"labels,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FindingIdentityLabels The labels of a rule that identify the resource of a finding
+var FindingIdentityLabels = []string{"resource_account_id", "resource_id", "resource_region", "resource_type"}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// InitFindingsSchema Create the findings tables if they do not exist, and upgrade them if they are older than this version of selefra
+func InitFindingsSchema(ctx context.Context, storage storage.Storage) *schema.Diagnostics {
+	diagnostics := schema.NewDiagnostics()
+
+	value, d := storage.GetValue(ctx, FindingsSchemaVersionKey)
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return diagnostics
+	}
+	version := 0
+	if value != "" {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return diagnostics.AddErrorMsg("findings schema version %s is not a number: %s", value, err.Error())
+		}
+		version = v
+	}
+	if version > len(findingsSchemaMigrations) {
+		return diagnostics.AddErrorMsg("findings schema version %d is newer than this selefra supports (%d), please upgrade selefra", version, len(findingsSchemaMigrations))
+	}
+
+	for ; version < len(findingsSchemaMigrations); version++ {
+		if diagnostics.AddDiagnostics(storage.Exec(ctx, findingsSchemaMigrations[version])).HasError() {
+			return diagnostics
+		}
+		if diagnostics.AddDiagnostics(storage.SetKey(ctx, FindingsSchemaVersionKey, strconv.Itoa(version+1))).HasError() {
+			return diagnostics
+		}
+	}
+	return diagnostics
+}
+
+// SaveRun Insert the run, or update its status if it already exists
+func SaveRun(ctx context.Context, storage storage.Storage, run *RunInformation) *schema.Diagnostics {
+	var finishedAt any
+	if !run.FinishedAt.IsZero() {
+		finishedAt = run.FinishedAt
+	}
+	sql := fmt.Sprintf(`INSERT INTO %s (run_id, project_name, hostname, status, findings_count, started_at, finished_at) VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (run_id) DO UPDATE SET status = excluded.status, findings_count = excluded.findings_count, finished_at = excluded.finished_at`, RunsTableName)
+	return storage.Exec(ctx, sql, run.RunID, run.ProjectName, run.Hostname, run.Status, run.FindingsCount, run.StartedAt, finishedAt)
+}
+
+// SaveFinding Insert a finding of a run
+func SaveFinding(ctx context.Context, storage storage.Storage, finding *Finding) *schema.Diagnostics {
+	labels := finding.Labels
+	if labels == "" {
+		labels = "{}"
+	}
+	sql := fmt.Sprintf(`INSERT INTO %s (run_id, fingerprint, rule_name, rule_id, severity, title, output, module_name, provider_name, provider_version, provider_schema, resource_id, labels, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`, FindingsTableName)
+	return storage.Exec(ctx, sql, finding.RunID, finding.Fingerprint, finding.RuleName, finding.RuleID, finding.Severity, finding.Title, finding.Output,
+		finding.ModuleName, finding.ProviderName, finding.ProviderVersion, finding.ProviderSchema, finding.ResourceID, labels, finding.CreatedAt)
+}
+
+// ListFindings All findings of a run
+func ListFindings(ctx context.Context, storage storage.Storage, runID string) ([]*Finding, *schema.Diagnostics) {
+	sql := fmt.Sprintf(`SELECT run_id, fingerprint, rule_name, rule_id, severity, title, output, module_name, provider_name, provider_version, provider_schema, resource_id, labels::TEXT AS labels
+FROM %s WHERE run_id = $1 ORDER BY id`, FindingsTableName)
+	queryResult, diagnostics := storage.Query(ctx, sql, runID)
+	if utils.HasError(diagnostics) {
+		return nil, diagnostics
+	}
+	defer func() {
+		_ = queryResult.Close()
+	}()
+	rows, d := queryResult.ReadRows(-1)
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return nil, diagnostics
+	}
+	findings := make([]*Finding, 0)
+	if rows == nil {
+		return findings, diagnostics
+	}
+	for _, row := range rows.SplitRowByRow() {
+		findings = append(findings, &Finding{
+			RunID:           row.GetStringOrDefault("run_id", ""),
+			Fingerprint:     row.GetStringOrDefault("fingerprint", ""),
+			RuleName:        row.GetStringOrDefault("rule_name", ""),
+			RuleID:          row.GetStringOrDefault("rule_id", ""),
+			Severity:        row.GetStringOrDefault("severity", ""),
+			Title:           row.GetStringOrDefault("title", ""),
+			Output:          row.GetStringOrDefault("output", ""),
+			ModuleName:      row.GetStringOrDefault("module_name", ""),
+			ProviderName:    row.GetStringOrDefault("provider_name", ""),
+			ProviderVersion: row.GetStringOrDefault("provider_version", ""),
+			ProviderSchema:  row.GetStringOrDefault("provider_schema", ""),
+			ResourceID:      row.GetStringOrDefault("resource_id", ""),
+			Labels:          row.GetStringOrDefault("labels", ""),
+		})
+	}
+	return findings, diagnostics
+}
+
+// GetLatestRunID The id of the last run that finished successfully, empty if there is none
+func GetLatestRunID(ctx context.Context, storage storage.Storage) (string, *schema.Diagnostics) {
+	sql := fmt.Sprintf(`SELECT run_id FROM %s WHERE status = $1 ORDER BY finished_at DESC LIMIT 1`, RunsTableName)
+	queryResult, diagnostics := storage.Query(ctx, sql, RunStatusSuccess)
+	if utils.HasError(diagnostics) {
+		return "", diagnostics
+	}
+	defer func() {
+		_ = queryResult.Close()
+	}()
+	rows, d := queryResult.ReadRows(-1)
+	if diagnostics.AddDiagnostics(d).HasError() || rows == nil || rows.RowCount() == 0 {
+		return "", diagnostics
+	}
+	return rows.GetCellStringValueOrDefault(0, 0, ""), diagnostics
+}
+
+// ExistsRun Whether the run is saved in the database
+func ExistsRun(ctx context.Context, storage storage.Storage, runID string) (bool, *schema.Diagnostics) {
+	sql := fmt.Sprintf(`SELECT run_id FROM %s WHERE run_id = $1`, RunsTableName)
+	queryResult, diagnostics := storage.Query(ctx, sql, runID)
+	if utils.HasError(diagnostics) {
+		return false, diagnostics
+	}
+	defer func() {
+		_ = queryResult.Close()
+	}()
+	rows, d := queryResult.ReadRows(-1)
+	if diagnostics.AddDiagnostics(d).HasError() {
+		return false, diagnostics
+	}
+	return rows != nil && rows.RowCount() > 0, diagnostics
+}
+
+// BuildFindingFingerprint The fingerprint is the rule id plus the identity labels of the resource, see FindingIdentityLabels,
+// so it does not change between runs as long as the same rule finds the same resource.
+// A rule without identity labels falls back to its rendered output
+func BuildFindingFingerprint(ruleID string, identityLabels map[string]string, output string) string {
+	parts := []string{ruleID}
+	for _, label := range FindingIdentityLabels {
+		if value := identityLabels[label]; value != "" {
+			parts = append(parts, label+"="+value)
+		}
+	}
+	if len(parts) == 1 {
+		parts = append(parts, output)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,84 @@
+package pgstorage
+
+import (
+	"context"
+	"github.com/selefra/selefra-utils/pkg/id_util"
+	"github.com/selefra/selefra/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSaveFinding(t *testing.T) {
+	testStorage := getTestStorage(t)
+
+	d := InitFindingsSchema(context.Background(), testStorage)
+	if utils.HasError(d) {
+		t.Log(d.String())
+	}
+	assert.False(t, utils.HasError(d))
+
+	// twice is fine
+	d = InitFindingsSchema(context.Background(), testStorage)
+	assert.False(t, utils.HasError(d))
+
+	run := &RunInformation{
+		RunID:     id_util.RandomId(),
+		Status:    RunStatusRunning,
+		StartedAt: time.Now(),
+	}
+	d = SaveRun(context.Background(), testStorage, run)
+	if utils.HasError(d) {
+		t.Log(d.String())
+	}
+	assert.False(t, utils.HasError(d))
+
+	finding := &Finding{
+		RunID:      run.RunID,
+		RuleName:   "bucket_is_public",
+		Severity:   "High",
+		ResourceID: "arn:aws:s3:::test-bucket",
+		Labels:     `{"resource_id": "arn:aws:s3:::test-bucket"}`,
+		CreatedAt:  time.Now(),
+	}
+	finding.Fingerprint = BuildFindingFingerprint(finding.RuleName, map[string]string{"resource_id": finding.ResourceID}, finding.Output)
+	d = SaveFinding(context.Background(), testStorage, finding)
+	if utils.HasError(d) {
+		t.Log(d.String())
+	}
+	assert.False(t, utils.HasError(d))
+
+	run.Status = RunStatusSuccess
+	run.FindingsCount = 1
+	run.FinishedAt = time.Now()
+	d = SaveRun(context.Background(), testStorage, run)
+	assert.False(t, utils.HasError(d))
+
+	findings, d := ListFindings(context.Background(), testStorage, run.RunID)
+	assert.False(t, utils.HasError(d))
+	assert.Len(t, findings, 1)
+	assert.Equal(t, finding.Fingerprint, findings[0].Fingerprint)
+
+	latestRunID, d := GetLatestRunID(context.Background(), testStorage)
+	assert.False(t, utils.HasError(d))
+	assert.Equal(t, run.RunID, latestRunID)
+
+	exists, d := ExistsRun(context.Background(), testStorage, run.RunID)
+	assert.False(t, utils.HasError(d))
+	assert.True(t, exists)
+}
+
+func TestBuildFindingFingerprint(t *testing.T) {
+	labels := map[string]string{"resource_id": "arn:aws:s3:::a", "resource_region": "us-east-1", "owner": "ops"}
+	fingerprint := BuildFindingFingerprint("s3_bucket_public", labels, "bucket a is public")
+
+	// the output and other labels may change, the resource is the same
+	labels["owner"] = "dev"
+	assert.Equal(t, fingerprint, BuildFindingFingerprint("s3_bucket_public", labels, "bucket a is public to everyone"))
+
+	labels["resource_id"] = "arn:aws:s3:::b"
+	assert.NotEqual(t, fingerprint, BuildFindingFingerprint("s3_bucket_public", labels, "bucket a is public"))
+
+	// without identity labels the output is used
+	assert.NotEqual(t, BuildFindingFingerprint("s3_bucket_public", nil, "a"), BuildFindingFingerprint("s3_bucket_public", nil, "b"))
+}
@@ -53,3 +53,21 @@ func SaveSchemaOwner(ctx context.Context, storage storage.Storage, owner *Schema
 }
 
 // ------------------------------------------------- --------------------------------------------------------------------
+
+// ReleaseSchemaOwner Remove the owner record of the schema if it is held by the holder, so the schema can be held by others,
+// the record held by someone else is kept. Whether the record is removed is returned
+func ReleaseSchemaOwner(ctx context.Context, storage storage.Storage, holderID string) (bool, *schema.Diagnostics) {
+	owner, diagnostics := GetSchemaOwner(ctx, storage)
+	if utils.HasError(diagnostics) {
+		return false, diagnostics
+	}
+	if owner == nil || owner.HolderID != holderID {
+		return false, diagnostics
+	}
+	if d := storage.DeleteKey(ctx, SchemaOwnerKey); utils.HasError(d) {
+		return false, d
+	}
+	return true, nil
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -0,0 +1,147 @@
+package pgstorage
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/selefra/selefra-provider-sdk/provider/schema"
+	"github.com/selefra/selefra-provider-sdk/storage"
+	"time"
+)
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// QueryWithStatementTimeout Run the query with the statement_timeout of postgresql, so the database itself stops a query that runs too long.
+// The query runs on a connection taken from the pool of the storage, the statement_timeout is reset before the connection is put back.
+// If the timeout is not positive or the storage is not postgresql, it is the same as Storage.Query
+func QueryWithStatementTimeout(ctx context.Context, s storage.Storage, query string, timeout time.Duration) (storage.QueryResult, *schema.Diagnostics) {
+	pool, ok := s.GetStorageConnection().(*pgxpool.Pool)
+	if !ok || timeout <= 0 {
+		return s.Query(ctx, query)
+	}
+
+	diagnostics := schema.NewDiagnostics()
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, diagnostics.AddErrorMsg("Postgresql acquire connection error: %s", err.Error())
+	}
+	// statement_timeout is in milliseconds and 0 disables it, so a timeout shorter than a millisecond is rounded up
+	timeoutMilliseconds := timeout.Milliseconds()
+	if timeoutMilliseconds < 1 {
+		timeoutMilliseconds = 1
+	}
+	if _, err := conn.Exec(ctx, fmt.Sprintf("SET statement_timeout = %d", timeoutMilliseconds)); err != nil {
+		releaseStatementTimeoutConn(conn)
+		return nil, diagnostics.AddErrorMsg("Postgresql set statement_timeout error: %s", err.Error())
+	}
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		releaseStatementTimeoutConn(conn)
+		return nil, diagnostics.AddErrorMsg("Postgresql sql query %s exec error: %s", query, err.Error())
+	}
+	return &statementTimeoutQueryResult{
+		rows: rows,
+		conn: conn,
+	}, nil
+}
+
+// Reset the statement_timeout and put the connection back to the pool, a connection that can not be reset is closed,
+// for example the connection of a query cancelled by its context
+func releaseStatementTimeoutConn(conn *pgxpool.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	if _, err := conn.Exec(ctx, "RESET statement_timeout"); err != nil {
+		_ = conn.Conn().Close(ctx)
+	}
+	conn.Release()
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
+
+// statementTimeoutQueryResult The result of QueryWithStatementTimeout, the connection is released when the result is closed.
+// Unlike the query result of the storage, an error while reading the rows, such as the statement timeout, is returned by ReadRows
+type statementTimeoutQueryResult struct {
+	rows pgx.Rows
+	conn *pgxpool.Conn
+
+	closed bool
+}
+
+var _ storage.QueryResult = &statementTimeoutQueryResult{}
+
+func (x *statementTimeoutQueryResult) Next() bool {
+	return x.rows.Next()
+}
+
+func (x *statementTimeoutQueryResult) Decode(item any) *schema.Diagnostics {
+	if err := x.rows.Scan(item); err != nil {
+		return schema.NewDiagnostics().AddErrorMsg("Postgresql query result decode error: %s", err.Error())
+	}
+	return nil
+}
+
+func (x *statementTimeoutQueryResult) Values() ([]any, *schema.Diagnostics) {
+	values, err := x.rows.Values()
+	if err != nil {
+		return nil, schema.NewDiagnostics().AddErrorMsg("Postgresql query result values error: %s", err.Error())
+	}
+	return values, nil
+}
+
+func (x *statementTimeoutQueryResult) ValuesMap() (map[string]any, *schema.Diagnostics) {
+	values, d := x.Values()
+	if d != nil && d.HasError() {
+		return nil, d
+	}
+	columnNames := x.GetColumnNames()
+	if len(columnNames) != len(values) {
+		return nil, schema.NewDiagnostics().AddErrorMsg("Postgresql query result values map error: column length mismatch")
+	}
+	valuesMap := make(map[string]any, len(values))
+	for index, columnName := range columnNames {
+		valuesMap[columnName] = values[index]
+	}
+	return valuesMap, nil
+}
+
+func (x *statementTimeoutQueryResult) ReadRows(rowLimit int) (*schema.Rows, *schema.Diagnostics) {
+	rows := schema.NewRows().SetColumnNames(x.GetColumnNames())
+	for (rowLimit < 0 || rows.RowCount() < rowLimit) && x.rows.Next() {
+		values, err := x.rows.Values()
+		if err != nil {
+			return rows, schema.NewDiagnostics().AddErrorMsg("Postgresql query result read rows error: %s", err.Error())
+		}
+		if err := rows.AppendRowValues(values); err != nil {
+			return nil, schema.NewDiagnostics().AddErrorMsg("Postgresql query result read rows error: %s", err.Error())
+		}
+	}
+	if err := x.rows.Err(); err != nil {
+		return rows, schema.NewDiagnostics().AddErrorMsg("Postgresql query result read rows error: %s", err.Error())
+	}
+	return rows, nil
+}
+
+func (x *statementTimeoutQueryResult) GetColumnNames() []string {
+	columnNames := make([]string, 0)
+	for _, column := range x.rows.FieldDescriptions() {
+		columnNames = append(columnNames, string(column.Name))
+	}
+	return columnNames
+}
+
+func (x *statementTimeoutQueryResult) Close() *schema.Diagnostics {
+	if x.closed {
+		return nil
+	}
+	x.closed = true
+	x.rows.Close()
+	releaseStatementTimeoutConn(x.conn)
+	return nil
+}
+
+func (x *statementTimeoutQueryResult) GetRawQueryResult() any {
+	return x.rows
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------